@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// UndisclosedRecipients is the name of the empty address group that is commonly used as To
+// address header field, if all recipients of a Msg are Bcc recipients
+const UndisclosedRecipients = "undisclosed-recipients"
+
+// addrGroup is an RFC 5322 address group (e.g. "Team: a@example.com, b@example.com;")
+type addrGroup struct {
+	name  string
+	addrs []*mail.Address
+}
+
+// ToGroup takes and validates a group name and a list of mail addresses and adds them as
+// RFC 5322 address group to the To address header field of the Msg. The group may be empty
+// (e.g. "undisclosed-recipients:;"). The addresses of the group are recipients of the Msg
+func (m *Msg) ToGroup(n string, a ...string) error {
+	return m.addAddrGroup(HeaderTo, n, a...)
+}
+
+// CcGroup takes and validates a group name and a list of mail addresses and adds them as
+// RFC 5322 address group to the Cc address header field of the Msg. The addresses of the
+// group are recipients of the Msg
+func (m *Msg) CcGroup(n string, a ...string) error {
+	return m.addAddrGroup(HeaderCc, n, a...)
+}
+
+// SetUndisclosedRecipients sets the To address header field of the Msg to the empty
+// "undisclosed-recipients:;" address group, which keeps the To header of a Msg that is only
+// sent to Bcc recipients RFC 5322 compliant
+func (m *Msg) SetUndisclosedRecipients() {
+	if m.addrHeader != nil {
+		delete(m.addrHeader, HeaderTo)
+	}
+	if m.addrGroups != nil {
+		delete(m.addrGroups, HeaderTo)
+	}
+	_ = m.addAddrGroup(HeaderTo, UndisclosedRecipients)
+}
+
+// addAddrGroup adds an address group with the given name and addresses to the given address
+// header field of the Msg
+func (m *Msg) addAddrGroup(h AddrHeader, n string, a ...string) error {
+	n = strings.TrimSpace(n)
+	if n == "" {
+		return fmt.Errorf("address group name must not be empty")
+	}
+	if strings.ContainsAny(n, "\r\n") {
+		return fmt.Errorf("address group name must not contain line breaks")
+	}
+	g := addrGroup{name: n}
+	for _, av := range a {
+		pa, err := mail.ParseAddress(av)
+		if err != nil {
+			return fmt.Errorf(errParseMailAddr, av, err)
+		}
+		g.addrs = append(g.addrs, pa)
+	}
+	if m.addrGroups == nil {
+		m.addrGroups = make(map[AddrHeader][]addrGroup)
+	}
+	m.addrGroups[h] = append(m.addrGroups[h], g)
+	return nil
+}
+
+// String returns the RFC 5322 representation of the address group. Group names that are not
+// a plain phrase of atoms are quoted or, if they contain non-ASCII characters, encoded
+func (g addrGroup) String() string {
+	if len(g.addrs) == 0 {
+		return groupName(g.name) + ":;"
+	}
+	al := make([]string, 0, len(g.addrs))
+	for _, a := range g.addrs {
+		al = append(al, a.String())
+	}
+	return groupName(g.name) + ": " + strings.Join(al, ", ") + ";"
+}
+
+// groupName returns the given address group name as RFC 5322 phrase
+func groupName(n string) string {
+	atom := true
+	for _, r := range n {
+		if r != ' ' && !isAtext(r) {
+			atom = false
+			break
+		}
+	}
+	if atom {
+		return n
+	}
+	// mail.Address quotes and encodes the display name as needed
+	return strings.TrimSuffix((&mail.Address{Name: n}).String(), " <@>")
+}
+
+// isAtext returns true if the given rune is an RFC 5322 atext character
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+}
+
+// cloneAddrGroups returns a deep copy of the given address groups
+func cloneAddrGroups(gl []addrGroup) []addrGroup {
+	cl := make([]addrGroup, len(gl))
+	for i, g := range gl {
+		cl[i] = addrGroup{name: g.name, addrs: make([]*mail.Address, len(g.addrs))}
+		for j, a := range g.addrs {
+			ca := *a
+			cl[i].addrs[j] = &ca
+		}
+	}
+	return cl
+}
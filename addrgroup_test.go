@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestMsg_ToGroup tests the rendering of RFC 5322 address groups and their recipients
+func TestMsg_ToGroup(t *testing.T) {
+	m := NewMsg()
+	if err := m.To("single@example.com"); err != nil {
+		t.Fatalf("To() failed: %s", err)
+	}
+	if err := m.ToGroup("Team", "a@example.com", "Bob <b@example.com>"); err != nil {
+		t.Fatalf("ToGroup() failed: %s", err)
+	}
+	if err := m.CcGroup("Müller & Co", "c@example.com"); err != nil {
+		t.Fatalf("CcGroup() failed: %s", err)
+	}
+	if err := m.CcGroup("Empty"); err != nil {
+		t.Fatalf("CcGroup() failed: %s", err)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() failed: %s", err)
+	}
+	for _, h := range []string{
+		"To: <single@example.com>, Team: <a@example.com>, \"Bob\" <b@example.com>;\r\n",
+		"Cc: =?utf-8?b?TcO8bGxlciAmIENv?=: <c@example.com>;, Empty:;\r\n",
+	} {
+		if !strings.Contains(buf.String(), h) {
+			t.Errorf("address group header %q not found in message: %s", h, buf.String())
+		}
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		t.Fatalf("GetRecipients() failed: %s", err)
+	}
+	want := "single@example.com,a@example.com,b@example.com,c@example.com"
+	if strings.Join(rl, ",") != want {
+		t.Errorf("GetRecipients() failed. Expected: %s, got: %v", want, rl)
+	}
+
+	c := m.Clone()
+	if err := c.ToGroup("Other", "d@example.com"); err != nil {
+		t.Fatalf("ToGroup() on clone failed: %s", err)
+	}
+	if len(m.addrGroups[HeaderTo]) != 1 {
+		t.Errorf("ToGroup() on clone changed the original message")
+	}
+
+	if err := m.ToGroup(""); err == nil {
+		t.Errorf("ToGroup() with empty name was expected to fail")
+	}
+	if err := m.ToGroup("Team", "invalid"); err == nil {
+		t.Errorf("ToGroup() with invalid address was expected to fail")
+	}
+}
+
+// TestMsg_SetUndisclosedRecipients tests the empty undisclosed-recipients address group
+func TestMsg_SetUndisclosedRecipients(t *testing.T) {
+	m := NewMsg()
+	if err := m.To("to@example.com"); err != nil {
+		t.Fatalf("To() failed: %s", err)
+	}
+	if err := m.Bcc("bcc@example.com"); err != nil {
+		t.Fatalf("Bcc() failed: %s", err)
+	}
+	m.SetUndisclosedRecipients()
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() failed: %s", err)
+	}
+	if !strings.Contains(buf.String(), "To: undisclosed-recipients:;\r\n") {
+		t.Errorf("undisclosed-recipients group not found in message: %s", buf.String())
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		t.Fatalf("GetRecipients() failed: %s", err)
+	}
+	if len(rl) != 1 || rl[0] != "bcc@example.com" {
+		t.Errorf("GetRecipients() failed. Expected: [bcc@example.com], got: %v", rl)
+	}
+}
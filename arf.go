@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeedbackType is the type of feedback of a feedback report as described in RFC 5965
+type FeedbackType string
+
+// List of feedback types
+const (
+	// FeedbackAbuse indicates unsolicited mail or some other kind of email abuse
+	FeedbackAbuse FeedbackType = "abuse"
+
+	// FeedbackAuthFailure indicates an email authentication failure report
+	FeedbackAuthFailure FeedbackType = "auth-failure"
+
+	// FeedbackFraud indicates some kind of fraud or phishing activity
+	FeedbackFraud FeedbackType = "fraud"
+
+	// FeedbackNotSpam indicates that the entity providing the report does not consider the
+	// message to be spam
+	FeedbackNotSpam FeedbackType = "not-spam"
+
+	// FeedbackOther indicates any other feedback that does not fit into the other types
+	FeedbackOther FeedbackType = "other"
+
+	// FeedbackVirus indicates that a virus was found in the message
+	FeedbackVirus FeedbackType = "virus"
+)
+
+// ErrNoFeedbackReport is returned if a message is not a feedback report
+var ErrNoFeedbackReport = errors.New("message is not a feedback report")
+
+// FeedbackReport is a feedback report in the Abuse Reporting Format (ARF) as described in
+// RFC 5965, as it is sent by mailbox providers to senders that are enrolled in their
+// feedback loops
+type FeedbackReport struct {
+	// FeedbackType is the type of the feedback
+	FeedbackType FeedbackType
+	// UserAgent is the name and version of the software that generated the report
+	UserAgent string
+	// Version is the version of the report format (defaults to 1)
+	Version string
+	// OriginalEnvelopeID is the envelope ID of the reported message
+	OriginalEnvelopeID string
+	// OriginalMailFrom is the envelope sender of the reported message
+	OriginalMailFrom string
+	// OriginalRcptTo is the list of envelope recipients of the reported message
+	OriginalRcptTo []string
+	// ArrivalDate is the time at which the reported message was received
+	ArrivalDate time.Time
+	// ReportingMTA is the MTA that generated the report
+	ReportingMTA string
+	// SourceIP is the IP address the reported message was received from
+	SourceIP string
+	// Incidents is the number of incidents the report represents
+	Incidents int
+	// AuthenticationResults is the list of the Authentication-Results of the reported
+	// message
+	AuthenticationResults []string
+	// ReportedDomain is the list of domains that the report is about
+	ReportedDomain []string
+	// ReportedURI is the list of URIs that the report is about
+	ReportedURI []string
+	// Original is the reported message or its header section. It is attached to generated
+	// reports and set by ParseFeedbackReport
+	Original []byte
+}
+
+// NewMsgFromFeedbackReport returns a new multipart/report Msg for the given FeedbackReport.
+// The Msg consists of the given human-readable description, the machine-readable
+// message/feedback-report part and the reported message. If the reported message of the
+// FeedbackReport has no body, it is attached as text/rfc822-headers. The sender, recipient
+// and subject of the Msg have to be set by the caller
+func NewMsgFromFeedbackReport(fr *FeedbackReport, desc string, o ...MsgOption) (*Msg, error) {
+	if fr == nil {
+		return nil, fmt.Errorf("feedback report must not be nil")
+	}
+	if fr.FeedbackType == "" {
+		return nil, fmt.Errorf("feedback type must not be empty")
+	}
+	if len(fr.Original) == 0 {
+		return nil, fmt.Errorf("reported message must not be empty")
+	}
+
+	m := NewMsg(o...)
+	m.reportType = "feedback-report"
+	m.SetBodyString(TypeTextPlain, desc)
+	m.attachments = append(m.attachments, newReportFile("message/feedback-report", Encoding7bit,
+		fr.fields()))
+	ct := TypeMessageRFC822
+	if _, bb, err := splitMsg(fr.Original); err != nil || len(bytes.TrimSpace(bb)) == 0 {
+		ct = "text/rfc822-headers"
+	}
+	m.attachments = append(m.attachments, newReportFile(ct, NoEncoding, fr.Original))
+	return m, nil
+}
+
+// ParseFeedbackReport parses the given message as a feedback report. The message must be a
+// "multipart/report" message with the "feedback-report" report type and a
+// "message/feedback-report" part. Otherwise ErrNoFeedbackReport is returned
+func ParseFeedbackReport(r io.Reader) (*FeedbackReport, error) {
+	var fr *FeedbackReport
+	var orig []byte
+	err := readReport(r, "feedback-report", func(mt string, pr io.Reader) error {
+		switch mt {
+		case "message/feedback-report":
+			if fr != nil {
+				return nil
+			}
+			h, err := textproto.NewReader(bufio.NewReader(pr)).ReadMIMEHeader()
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("failed to parse feedback report: %w", err)
+			}
+			fr = parseFeedbackReportFields(h)
+		case "message/rfc822", "text/rfc822-headers":
+			if orig != nil {
+				return nil
+			}
+			b, err := io.ReadAll(pr)
+			if err != nil {
+				return fmt.Errorf("failed to read reported message: %w", err)
+			}
+			orig = b
+		}
+		return nil
+	})
+	if errors.Is(err, errNoReport) || (err == nil && fr == nil) {
+		return nil, ErrNoFeedbackReport
+	}
+	if err != nil {
+		return nil, err
+	}
+	fr.Original = orig
+	return fr, nil
+}
+
+// fields returns the rendered fields of the message/feedback-report part
+func (fr *FeedbackReport) fields() []byte {
+	buf := bytes.Buffer{}
+	af := func(f, v string) {
+		if v != "" {
+			buf.WriteString(fmt.Sprintf("%s: %s%s", f, v, SingleNewLine))
+		}
+	}
+	ua := fr.UserAgent
+	if ua == "" {
+		ua = fmt.Sprintf("go-mail/%s", VERSION)
+	}
+	v := fr.Version
+	if v == "" {
+		v = "1"
+	}
+	af("Feedback-Type", string(fr.FeedbackType))
+	af("User-Agent", ua)
+	af("Version", v)
+	af("Original-Envelope-Id", fr.OriginalEnvelopeID)
+	if fr.OriginalMailFrom != "" {
+		af("Original-Mail-From", fmt.Sprintf("<%s>", fr.OriginalMailFrom))
+	}
+	for _, r := range fr.OriginalRcptTo {
+		af("Original-Rcpt-To", fmt.Sprintf("<%s>", r))
+	}
+	if !fr.ArrivalDate.IsZero() {
+		af("Arrival-Date", fr.ArrivalDate.Format(time.RFC1123Z))
+	}
+	if fr.ReportingMTA != "" {
+		af("Reporting-MTA", "dns; "+fr.ReportingMTA)
+	}
+	af("Source-IP", fr.SourceIP)
+	if fr.Incidents > 0 {
+		af("Incidents", strconv.Itoa(fr.Incidents))
+	}
+	for _, a := range fr.AuthenticationResults {
+		af("Authentication-Results", a)
+	}
+	for _, d := range fr.ReportedDomain {
+		af("Reported-Domain", d)
+	}
+	for _, u := range fr.ReportedURI {
+		af("Reported-URI", u)
+	}
+	return buf.Bytes()
+}
+
+// parseFeedbackReportFields returns the FeedbackReport for the given fields of a
+// message/feedback-report part
+func parseFeedbackReportFields(h textproto.MIMEHeader) *FeedbackReport {
+	fr := &FeedbackReport{
+		FeedbackType:          FeedbackType(strings.ToLower(strings.TrimSpace(h.Get("Feedback-Type")))),
+		UserAgent:             h.Get("User-Agent"),
+		Version:               h.Get("Version"),
+		OriginalEnvelopeID:    h.Get("Original-Envelope-Id"),
+		OriginalMailFrom:      trimAngleAddr(h.Get("Original-Mail-From")),
+		ReportingMTA:          dsnValue(h.Get("Reporting-MTA")),
+		SourceIP:              h.Get("Source-IP"),
+		AuthenticationResults: h.Values("Authentication-Results"),
+		ReportedDomain:        h.Values("Reported-Domain"),
+		ReportedURI:           h.Values("Reported-URI"),
+	}
+	for _, r := range h.Values("Original-Rcpt-To") {
+		fr.OriginalRcptTo = append(fr.OriginalRcptTo, trimAngleAddr(r))
+	}
+	if d := h.Get("Arrival-Date"); d != "" {
+		if t, err := mail.ParseDate(d); err == nil {
+			fr.ArrivalDate = t
+		}
+	}
+	if i, err := strconv.Atoi(h.Get("Incidents")); err == nil {
+		fr.Incidents = i
+	}
+	return fr
+}
+
+// trimAngleAddr returns the given address without surrounding whitespace and angle brackets
+func trimAngleAddr(a string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(a), "<"), ">")
+}
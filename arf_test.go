@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewMsgFromFeedbackReport tests that generated feedback reports can be parsed again
+func TestNewMsgFromFeedbackReport(t *testing.T) {
+	ad := time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC)
+	fr := &FeedbackReport{
+		FeedbackType:     FeedbackAbuse,
+		UserAgent:        "SomeGenerator/1.0",
+		OriginalMailFrom: "sender@example.com",
+		OriginalRcptTo:   []string{"rcpt@example.com", "other@example.com"},
+		ArrivalDate:      ad,
+		ReportingMTA:     "mx.example.com",
+		SourceIP:         "192.0.2.1",
+		Incidents:        3,
+		ReportedDomain:   []string{"example.com"},
+		Original:         []byte("From: sender@example.com\r\nSubject: Spam\r\n\r\nBuy now!\r\n"),
+	}
+	m, err := NewMsgFromFeedbackReport(fr, "This is an email abuse report.")
+	if err != nil {
+		t.Fatalf("NewMsgFromFeedbackReport failed: %s", err)
+	}
+	if err := m.From("abuse@example.net"); err != nil {
+		t.Fatalf("failed to set From address: %s", err)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write feedback report: %s", err)
+	}
+	if !strings.Contains(buf.String(), "Content-Type: multipart/report; report-type=feedback-report;") {
+		t.Errorf("NewMsgFromFeedbackReport failed. Expected multipart/report content type, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Content-Type: message/rfc822") {
+		t.Errorf("NewMsgFromFeedbackReport failed. Expected message/rfc822 part, got: %s", buf.String())
+	}
+
+	pr, err := ParseFeedbackReport(&buf)
+	if err != nil {
+		t.Fatalf("ParseFeedbackReport failed: %s", err)
+	}
+	if pr.FeedbackType != FeedbackAbuse || pr.UserAgent != "SomeGenerator/1.0" || pr.Version != "1" ||
+		pr.OriginalMailFrom != "sender@example.com" || len(pr.OriginalRcptTo) != 2 ||
+		pr.OriginalRcptTo[1] != "other@example.com" || !pr.ArrivalDate.Equal(ad) ||
+		pr.ReportingMTA != "mx.example.com" || pr.SourceIP != "192.0.2.1" || pr.Incidents != 3 ||
+		len(pr.ReportedDomain) != 1 || pr.ReportedDomain[0] != "example.com" {
+		t.Errorf("ParseFeedbackReport failed. Unexpected report: %+v", pr)
+	}
+	if !bytes.Contains(pr.Original, []byte("Buy now!")) {
+		t.Errorf("ParseFeedbackReport failed. Expected reported message, got: %q", pr.Original)
+	}
+
+	fr.Original = []byte("From: sender@example.com\r\nSubject: Spam\r\n\r\n")
+	m, err = NewMsgFromFeedbackReport(fr, "This is an email abuse report.")
+	if err != nil {
+		t.Fatalf("NewMsgFromFeedbackReport failed: %s", err)
+	}
+	buf.Reset()
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write feedback report: %s", err)
+	}
+	if !strings.Contains(buf.String(), "Content-Type: text/rfc822-headers") {
+		t.Errorf("NewMsgFromFeedbackReport failed. Expected text/rfc822-headers part for headers only")
+	}
+
+	if _, err := NewMsgFromFeedbackReport(&FeedbackReport{Original: fr.Original}, ""); err == nil {
+		t.Errorf("NewMsgFromFeedbackReport without feedback type was expected to fail")
+	}
+	if _, err := NewMsgFromFeedbackReport(&FeedbackReport{FeedbackType: FeedbackAbuse}, ""); err == nil {
+		t.Errorf("NewMsgFromFeedbackReport without reported message was expected to fail")
+	}
+	if _, err := ParseFeedbackReport(strings.NewReader(testDSN)); !errors.Is(err, ErrNoFeedbackReport) {
+		t.Errorf("ParseFeedbackReport of a DSN was expected to fail, got: %v", err)
+	}
+}
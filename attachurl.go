@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// DefaultURLAttachmentLimit is the default maximum size in bytes of an attachment that is
+// fetched via AttachFromURL
+const DefaultURLAttachmentLimit int64 = 25 << 20
+
+// ErrURLAttachmentTooLarge is returned by AttachFromURL if the remote resource exceeds the
+// size limit of the Msg
+var ErrURLAttachmentTooLarge = errors.New("remote resource exceeds the attachment size limit")
+
+// WithHTTPClient overrides the default http.Client that AttachFromURL uses to fetch remote
+// resources
+func WithHTTPClient(hc *http.Client) MsgOption {
+	return func(m *Msg) {
+		m.httpClient = hc
+	}
+}
+
+// WithURLAttachmentLimit overrides the maximum size in bytes of an attachment that is fetched
+// via AttachFromURL (see DefaultURLAttachmentLimit)
+func WithURLAttachmentLimit(l int64) MsgOption {
+	return func(m *Msg) {
+		if l > 0 {
+			m.urlAttachLimit = l
+		}
+	}
+}
+
+// AttachFromURL fetches the remote resource of the given HTTP(S) URL and adds it as attachment
+// File to the Msg. The file name is taken from the Content-Disposition header of the response
+// or from the URL path, the content type from the Content-Type header of the response. Both
+// can be overridden with the FileOption functions. The resource is fetched at the time of the
+// call and must not exceed the size limit of the Msg (see WithURLAttachmentLimit)
+func (m *Msg) AttachFromURL(ctx context.Context, u string, o ...FileOption) error {
+	f, err := m.fileFromURL(ctx, u)
+	if err != nil {
+		return fmt.Errorf("failed to attach %q: %w", u, err)
+	}
+	m.attachments = m.appendFile(m.attachments, f, o...)
+	return nil
+}
+
+// fileFromURL returns a File pointer with the content of the remote resource of the given URL
+func (m *Msg) fileFromURL(ctx context.Context, u string) (*File, error) {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if pu.Scheme != "http" && pu.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", pu.Scheme)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pu.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := m.httpClient
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP response: %s", res.Status)
+	}
+
+	l := m.urlAttachLimit
+	if l <= 0 {
+		l = DefaultURLAttachmentLimit
+	}
+	if res.ContentLength > l {
+		return nil, ErrURLAttachmentTooLarge
+	}
+	d, err := io.ReadAll(io.LimitReader(res.Body, l+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(d)) > l {
+		return nil, ErrURLAttachmentTooLarge
+	}
+
+	f := &File{
+		Name:   path.Base(pu.Path),
+		Header: make(map[string][]string),
+		Writer: func(w io.Writer) (int64, error) {
+			return io.Copy(w, bytes.NewReader(d))
+		},
+	}
+	if f.Name == "/" || f.Name == "." {
+		f.Name = pu.Hostname()
+	}
+	if _, p, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil &&
+		p["filename"] != "" {
+		f.Name = path.Base(p["filename"])
+	}
+	if mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		f.ContentType = ContentType(mt)
+	}
+	return f, nil
+}
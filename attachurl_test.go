@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestMsg_AttachFromURL tests the Msg.AttachFromURL method
+func TestMsg_AttachFromURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reports/daily.csv", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		_, _ = w.Write([]byte("a,b\n1,2\n"))
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="report.pdf"`)
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4"))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 1024))
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	tests := []struct {
+		name  string
+		path  string
+		opts  []FileOption
+		fname string
+		ctype ContentType
+		data  string
+	}{
+		{"name from path", "/reports/daily.csv", nil, "daily.csv", "text/csv", "a,b\n1,2\n"},
+		{"name from disposition", "/download", nil, "report.pdf", "application/pdf", "%PDF-1.4"},
+		{"file options", "/download", []FileOption{WithFileName("q1.pdf")}, "q1.pdf", "application/pdf", "%PDF-1.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg(WithHTTPClient(s.Client()))
+			if err := m.AttachFromURL(context.Background(), s.URL+tt.path, tt.opts...); err != nil {
+				t.Fatalf("AttachFromURL failed: %s", err)
+			}
+			al := m.GetAttachments()
+			if len(al) != 1 {
+				t.Fatalf("AttachFromURL failed. Expected 1 attachment, got: %d", len(al))
+			}
+			if al[0].Name != tt.fname {
+				t.Errorf("AttachFromURL failed. Expected name %q, got: %q", tt.fname, al[0].Name)
+			}
+			if al[0].ContentType != tt.ctype {
+				t.Errorf("AttachFromURL failed. Expected content type %q, got: %q", tt.ctype, al[0].ContentType)
+			}
+			buf := bytes.Buffer{}
+			if _, err := al[0].Writer(&buf); err != nil {
+				t.Fatalf("failed to write attachment: %s", err)
+			}
+			if buf.String() != tt.data {
+				t.Errorf("AttachFromURL failed. Expected content %q, got: %q", tt.data, buf.String())
+			}
+		})
+	}
+
+	m := NewMsg(WithHTTPClient(s.Client()), WithURLAttachmentLimit(512))
+	if err := m.AttachFromURL(context.Background(), s.URL+"/large"); !errors.Is(err, ErrURLAttachmentTooLarge) {
+		t.Errorf("AttachFromURL was expected to fail with ErrURLAttachmentTooLarge, got: %v", err)
+	}
+	if err := m.AttachFromURL(context.Background(), s.URL+"/missing"); err == nil {
+		t.Errorf("AttachFromURL with missing resource was expected to fail")
+	}
+	if err := m.AttachFromURL(context.Background(), "ftp://example.com/file.txt"); err == nil {
+		t.Errorf("AttachFromURL with unsupported scheme was expected to fail")
+	}
+	if len(m.GetAttachments()) != 0 {
+		t.Errorf("AttachFromURL failures were not expected to add attachments")
+	}
+}
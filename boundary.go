@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// maxBoundaryAttempts is the number of alternative boundaries that are tried if the boundary
+// of the Msg occurs in its content, before a random boundary is used
+const maxBoundaryAttempts = 10
+
+// boundaryScanner is an io.Writer that detects whether the content written to it contains
+// the given delimiter, also across multiple writes
+type boundaryScanner struct {
+	d     []byte
+	tail  []byte
+	found bool
+}
+
+// Write satisfies the io.Writer interface for the boundaryScanner
+func (bs *boundaryScanner) Write(p []byte) (int, error) {
+	if bs.found {
+		return len(p), nil
+	}
+	buf := append(bs.tail, p...)
+	if bytes.Contains(buf, bs.d) {
+		bs.found = true
+		return len(p), nil
+	}
+	if k := len(bs.d) - 1; len(buf) > k {
+		buf = buf[len(buf)-k:]
+	}
+	bs.tail = append(bs.tail[:0], buf...)
+	return len(p), nil
+}
+
+// safeBoundary returns the boundary that is used to write the Msg. Random boundaries of the
+// mime/multipart package (if no boundary is set) are long enough to not collide with the
+// content. A boundary that was set explicitly (e.g. via WithFixedBoundary) is checked against
+// the content that is not Base64 encoded and, if it occurs in the content, replaced with a
+// numbered variant, so that the MIME structure of the Msg is not corrupted
+func (m *Msg) safeBoundary() string {
+	if m.boundary == "" {
+		return ""
+	}
+	b := m.boundary
+	for i := 1; m.boundaryCollides(b); i++ {
+		if i > maxBoundaryAttempts {
+			return ""
+		}
+		b = fmt.Sprintf("%s.%d", m.boundary, i)
+	}
+	return b
+}
+
+// boundaryCollides returns true if the delimiter of the given boundary occurs in the content
+// of the body parts, the digest or the files of the Msg that are not Base64 encoded
+func (m *Msg) boundaryCollides(b string) bool {
+	wl := make([]func(io.Writer) (int64, error), 0, len(m.parts)+len(m.digest))
+	for _, p := range m.parts {
+		if !p.del && p.w != nil {
+			wl = append(wl, p.w)
+		}
+	}
+	for _, f := range append(append(append([]*File{}, m.embeds...), m.attachments...), m.digest...) {
+		if f.Writer != nil && (f.ContentType == TypeMessageRFC822 || (f.Enc != "" && f.Enc != EncodingB64)) {
+			wl = append(wl, f.Writer)
+		}
+	}
+	for _, w := range wl {
+		bs := &boundaryScanner{d: []byte("--" + b)}
+		_, _ = w(bs)
+		if bs.found {
+			return true
+		}
+	}
+	return false
+}
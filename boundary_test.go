@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// TestBoundaryScanner tests that the boundaryScanner detects the delimiter across writes
+func TestBoundaryScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		found  bool
+	}{
+		{"single write", []string{"foo\r\n--boundary\r\n"}, true},
+		{"split write", []string{"foo\r\n--boun", "dary\r\n"}, true},
+		{"byte writes", strings.Split("foo--boundary", ""), true},
+		{"delimiter across writes", []string{"foo\r\n-boundary", "--bound", "ary-"}, true},
+		{"different boundary", []string{"--other", "boundary"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := &boundaryScanner{d: []byte("--boundary")}
+			for _, w := range tt.writes {
+				if n, err := bs.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("Write failed: %d, %v", n, err)
+				}
+			}
+			if bs.found != tt.found {
+				t.Errorf("boundaryScanner failed. Expected found: %t, got: %t", tt.found, bs.found)
+			}
+		})
+	}
+}
+
+// TestMsg_safeBoundary tests that a boundary that occurs in the content is replaced
+func TestMsg_safeBoundary(t *testing.T) {
+	m := NewMsg(WithFixedBoundary())
+	m.SetBodyString(TypeTextPlain, "Plain")
+	m.AttachReader("data.bin", strings.NewReader("--"+FixedBoundary))
+	if b := m.safeBoundary(); b != FixedBoundary {
+		t.Errorf("Base64 encoded content was not expected to collide. Got boundary: %s", b)
+	}
+	m.AddAlternativeString(TypeTextHTML, "<pre>\r\n--"+FixedBoundary+"\r\n</pre>")
+	if b := m.safeBoundary(); b != FixedBoundary+".1" {
+		t.Errorf("safeBoundary failed. Expected: %s.1, got: %s", FixedBoundary, b)
+	}
+	if b := NewMsg().safeBoundary(); b != "" {
+		t.Errorf("safeBoundary without boundary was expected to be empty, got: %s", b)
+	}
+}
+
+// TestMsg_WriteTo_boundaryCollision tests that a digest of messages with the same fixed
+// boundary is written as valid MIME structure
+func TestMsg_WriteTo_boundaryCollision(t *testing.T) {
+	m := NewMsg(WithFixedBoundary())
+	for _, s := range []string{"First", "Second"} {
+		om := NewMsg(WithFixedBoundary())
+		om.SetBodyString(TypeTextPlain, "Plain "+s)
+		om.AddAlternativeString(TypeTextHTML, "<p>HTML "+s+"</p>")
+		if err := m.AddToDigest(om); err != nil {
+			t.Fatalf("AddToDigest failed: %s", err)
+		}
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	pm, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	_, p, err := mime.ParseMediaType(pm.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse content type: %s", err)
+	}
+	if p["boundary"] == FixedBoundary {
+		t.Errorf("colliding boundary was expected to be replaced")
+	}
+	mr := multipart.NewReader(pm.Body, p["boundary"])
+	n := 0
+	for {
+		_, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read digest part: %s", err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 digest parts, got: %d", n)
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/wneessen/go-mail/log"
@@ -84,6 +86,16 @@ type Client struct {
 	// Timeout for the SMTP server connection
 	cto time.Duration
 
+	// dialto is the timeout for establishing the connection (cto if not set)
+	dialto time.Duration
+
+	// cmdto is the timeout for the response to a single SMTP command (cto if not set)
+	cmdto time.Duration
+
+	// datato is the timeout for every write of the mail data and for the final response to
+	// the mail data (cto if not set)
+	datato time.Duration
+
 	// dsn indicates that we want to use DSN for the Client
 	dsn bool
 
@@ -93,15 +105,33 @@ type Client struct {
 	// dsnrntype defines the DSNRcptNotifyOption in case DSN is enabled
 	dsnrntype []string
 
+	// requireTLS indicates that messages are sent with the REQUIRETLS parameter (RFC 8689)
+	requireTLS bool
+
 	// enc indicates if a Client connection is encrypted or not
 	enc bool
 
 	// noNoop indicates the Noop is to be skipped
 	noNoop bool
 
+	// no8bitDowngrade indicates that 8bit messages should not be converted to a 7bit
+	// compatible encoding if the server does not support 8BITMIME
+	no8bitDowngrade bool
+
+	// chunking indicates that the message content should be transferred via BDAT
+	// if the server supports the CHUNKING extension
+	chunking bool
+
+	// chunksize is the size of a single BDAT chunk in bytes
+	chunksize int
+
 	// HELO/EHLO string for the greeting the target SMTP server
 	helo string
 
+	// heloLiteral indicates that the address literal of the local address of the connection
+	// is used as HELO/EHLO greeting, since no FQDN of the local host could be detected
+	heloLiteral bool
+
 	// Hostname of the target SMTP server cto connect cto
 	host string
 
@@ -137,11 +167,83 @@ type Client struct {
 
 	// l is a logger that implements the log.Logger interface
 	l log.Logger
+
+	// keepalive is the interval in which NOOP commands are sent to keep an idle connection
+	// open. If set, the Client re-establishes broken connections before sending
+	keepalive time.Duration
+
+	// retries is the maximum number of delivery attempts for a single message
+	retries int
+
+	// retrybackoff is the delay before the first retry. It is doubled for every further retry
+	retrybackoff time.Duration
+
+	// dialContextFunc is a custom function to establish the server connection
+	dialContextFunc DialContextFunc
+
+	// ratelimit limits the number of messages the Client sends per period of time
+	ratelimit *rateLimiter
+
+	// throttle limits the concurrency and rate of deliveries per recipient domain
+	throttle *DomainThrottle
+
+	// suppression is consulted before a recipient is added to the envelope
+	suppression SuppressionChecker
+
+	// onSend is called before every delivery attempt of a Msg
+	onSend MsgHookFunc
+
+	// onDelivered is called after a Msg has been delivered successfully
+	onDelivered MsgHookFunc
+
+	// onError is called after the delivery of a Msg failed finally
+	onError MsgErrorHookFunc
+
+	// maxrcpts is the maximum number of recipients per SMTP transaction
+	maxrcpts int
+
+	// relays is the ordered list of fallback relays for the failover
+	relays []string
+
+	// relaycooldown is the time a relay is avoided after it failed
+	relaycooldown time.Duration
+
+	// failover tracks the health of the host and the fallback relays, if relays are set
+	failover *failover
+
+	// connHost is the host of the current server connection, which differs from host if the
+	// Client failed over to a fallback relay
+	connHost string
+
+	// sahost is the host the built-in smtp.Auth was created for
+	sahost string
+
+	// kastop is closed to stop the keep-alive goroutine
+	kastop chan struct{}
+
+	// mu protects the server connection from concurrent use
+	mu sync.Mutex
 }
 
 // Option returns a function that can be used for grouping Client options
 type Option func(*Client) error
 
+// defaultHelo is the detected FQDN of the local host, which is looked up only once
+var (
+	defaultHelo     string
+	defaultHeloOnce sync.Once
+)
+
+// DialContextFunc is a function to establish the network connection to the SMTP server.
+// It has the same signature as net.Dialer.DialContext
+type DialContextFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// MsgHookFunc is a callback that is called by the Client for a Msg during the delivery
+type MsgHookFunc func(m *Msg)
+
+// MsgErrorHookFunc is a callback that is called by the Client if the delivery of a Msg failed
+type MsgErrorHookFunc func(m *Msg, err error)
+
 var (
 	// ErrInvalidPort should be used if a port is specified that is not valid
 	ErrInvalidPort = errors.New("invalid port number")
@@ -169,6 +271,43 @@ var (
 	// the server does not offer 8BITMIME mode
 	ErrServerNoUnencoded = errors.New("message is 8bit unencoded, but server does not support 8BITMIME")
 
+	// ErrServerNoSMTPUTF8 should be used when the envelope of a message contains internationalized
+	// addresses that cannot be converted into ASCII, but the server does not offer SMTPUTF8
+	ErrServerNoSMTPUTF8 = errors.New("message requires SMTPUTF8, but server does not support SMTPUTF8")
+
+	// ErrServerNoRequireTLS should be used when REQUIRETLS is requested for a message, but the
+	// server does not offer REQUIRETLS or the connection is not encrypted
+	ErrServerNoRequireTLS = errors.New("message requires REQUIRETLS, but server does not support REQUIRETLS via TLS")
+
+	// ErrInvalidKeepAlive should be used if a keep-alive interval is set that is zero or negative
+	ErrInvalidKeepAlive = errors.New("keep-alive interval cannot be zero or negative")
+
+	// ErrInvalidRetryPolicy should be used if a retry policy with less than one attempt or a
+	// negative backoff is set
+	ErrInvalidRetryPolicy = errors.New("retry attempts must be positive and backoff cannot be negative")
+
+	// ErrInvalidRateLimit should be used if a rate limit with a zero or negative number of
+	// messages or period is set
+	ErrInvalidRateLimit = errors.New("rate limit messages and period must be positive")
+
+	// ErrVRFYNotSupported should be used if the server does not implement the VRFY command
+	// or refuses to verify addresses
+	ErrVRFYNotSupported = errors.New("server does not support address verification via VRFY")
+
+	// ErrEXPNNotSupported should be used if the server does not implement the EXPN command
+	ErrEXPNNotSupported = errors.New("server does not support mailing list expansion via EXPN")
+
+	// ErrInvalidMaxRcpts should be used if a maximum number of recipients per transaction is
+	// set that is zero or negative
+	ErrInvalidMaxRcpts = errors.New("maximum number of recipients cannot be zero or negative")
+
+	// ErrInvalidFailoverCooldown should be used if a failover cooldown is set that is zero or
+	// negative
+	ErrInvalidFailoverCooldown = errors.New("failover cooldown cannot be zero or negative")
+
+	// ErrInvalidChunkSize should be used if a BDAT chunk size is set that is zero or negative
+	ErrInvalidChunkSize = errors.New("chunk size cannot be zero or negative")
+
 	// ErrInvalidDSNMailReturnOption should be used when an invalid option is provided for the
 	// DSNMailReturnOption in WithDSN
 	ErrInvalidDSNMailReturnOption = errors.New("DSN mail return option can only be HDRS or FULL")
@@ -192,6 +331,8 @@ func NewClient(h string, o ...Option) (*Client, error) {
 		port:      DefaultPort,
 		tlsconfig: &tls.Config{ServerName: h, MinVersion: DefaultTLSMinVersion},
 		tlspolicy: DefaultTLSPolicy,
+
+		relaycooldown: DefaultFailoverCooldown,
 	}
 
 	// Set default HELO/EHLO hostname
@@ -213,6 +354,9 @@ func NewClient(h string, o ...Option) (*Client, error) {
 	if c.host == "" {
 		return c, ErrNoHostname
 	}
+	if len(c.relays) > 0 {
+		c.failover = newFailover(append([]string{c.host}, c.relays...), c.relaycooldown)
+	}
 
 	return c, nil
 }
@@ -228,7 +372,9 @@ func WithPort(p int) Option {
 	}
 }
 
-// WithTimeout overrides the default connection timeout
+// WithTimeout overrides the default connection timeout. It applies to all phases of the
+// connection, unless a more specific timeout is set via WithDialTimeout, WithCommandTimeout
+// or WithDataTimeout
 func WithTimeout(t time.Duration) Option {
 	return func(c *Client) error {
 		if t <= 0 {
@@ -239,6 +385,43 @@ func WithTimeout(t time.Duration) Option {
 	}
 }
 
+// WithDialTimeout overrides the connection timeout for establishing the connection to the
+// SMTP server
+func WithDialTimeout(t time.Duration) Option {
+	return func(c *Client) error {
+		if t <= 0 {
+			return ErrInvalidTimeout
+		}
+		c.dialto = t
+		return nil
+	}
+}
+
+// WithCommandTimeout overrides the connection timeout for the response of the SMTP server to
+// a single command (e.g. EHLO, MAIL FROM or RCPT TO)
+func WithCommandTimeout(t time.Duration) Option {
+	return func(c *Client) error {
+		if t <= 0 {
+			return ErrInvalidTimeout
+		}
+		c.cmdto = t
+		return nil
+	}
+}
+
+// WithDataTimeout overrides the connection timeout for the transfer of the mail data. The
+// timeout applies to every single write of the mail data and to the final response of the
+// server, so that large messages do not require a long command timeout
+func WithDataTimeout(t time.Duration) Option {
+	return func(c *Client) error {
+		if t <= 0 {
+			return ErrInvalidTimeout
+		}
+		c.datato = t
+		return nil
+	}
+}
+
 // WithSSL tells the client to use a SSL/TLS connection
 func WithSSL() Option {
 	return func(c *Client) error {
@@ -264,13 +447,15 @@ func WithLogger(l log.Logger) Option {
 	}
 }
 
-// WithHELO tells the client to use the provided string as HELO/EHLO greeting host
+// WithHELO tells the client to use the provided string as HELO/EHLO greeting host instead
+// of the detected FQDN of the local host
 func WithHELO(h string) Option {
 	return func(c *Client) error {
 		if h == "" {
 			return ErrInvalidHELO
 		}
 		c.helo = h
+		c.heloLiteral = false
 		return nil
 	}
 }
@@ -363,27 +548,9 @@ func WithDSNMailReturnType(mro DSNMailReturnOption) Option {
 // See: https://www.rfc-editor.org/rfc/rfc1891
 func WithDSNRcptNotifyType(rno ...DSNRcptNotifyOption) Option {
 	return func(c *Client) error {
-		var rnol []string
-		var ns, nns bool
-		if len(rno) > 0 {
-			for _, crno := range rno {
-				switch crno {
-				case DSNRcptNotifyNever:
-					ns = true
-				case DSNRcptNotifySuccess:
-					nns = true
-				case DSNRcptNotifyFailure:
-					nns = true
-				case DSNRcptNotifyDelay:
-					nns = true
-				default:
-					return ErrInvalidDSNRcptNotifyOption
-				}
-				rnol = append(rnol, string(crno))
-			}
-		}
-		if ns && nns {
-			return ErrInvalidDSNRcptNotifyCombination
+		rnol, err := dsnRcptNotify(rno)
+		if err != nil {
+			return err
 		}
 
 		c.dsn = true
@@ -392,6 +559,45 @@ func WithDSNRcptNotifyType(rno ...DSNRcptNotifyOption) Option {
 	}
 }
 
+// dsnRcptNotify validates the given list of DSNRcptNotifyOption and returns it as the
+// list of values of the NOTIFY parameter
+func dsnRcptNotify(rno []DSNRcptNotifyOption) ([]string, error) {
+	var rnol []string
+	var ns, nns bool
+	for _, crno := range rno {
+		switch crno {
+		case DSNRcptNotifyNever:
+			ns = true
+		case DSNRcptNotifySuccess:
+			nns = true
+		case DSNRcptNotifyFailure:
+			nns = true
+		case DSNRcptNotifyDelay:
+			nns = true
+		default:
+			return nil, ErrInvalidDSNRcptNotifyOption
+		}
+		rnol = append(rnol, string(crno))
+	}
+	if ns && nns {
+		return nil, ErrInvalidDSNRcptNotifyCombination
+	}
+	return rnol, nil
+}
+
+// WithRequireTLS enables the Client to send messages with the REQUIRETLS parameter as
+// described in RFC 8689, which requires that all MTAs on the delivery path relay the message
+// via TLS. The delivery of a message fails with ErrNoRequireTLS if the server does not
+// support REQUIRETLS or the connection is not encrypted. Messages with the
+// "TLS-Required: No" header (see Msg.SetTLSRequiredNo) are sent without REQUIRETLS
+// See: https://www.rfc-editor.org/rfc/rfc8689
+func WithRequireTLS() Option {
+	return func(c *Client) error {
+		c.requireTLS = true
+		return nil
+	}
+}
+
 // WithoutNoop disables the Client Noop check during connections. This is primarily for servers which delay responses
 // to SMTP commands that are not the AUTH command. For example Microsoft Exchange's Tarpit.
 func WithoutNoop() Option {
@@ -401,6 +607,42 @@ func WithoutNoop() Option {
 	}
 }
 
+// Without8BitDowngrade disables the conversion of messages that use the 8bit NoEncoding
+// into a 7bit compatible encoding if the server does not support the 8BITMIME extension.
+// By default, the Client sends a copy of such a Msg with quoted-printable text parts and
+// Base64 attachments/embeds, while the Msg itself is left unchanged. With this option, the
+// delivery of such messages fails with ErrNoUnencoded instead
+func Without8BitDowngrade() Option {
+	return func(c *Client) error {
+		c.no8bitDowngrade = true
+		return nil
+	}
+}
+
+// WithChunking tells the Client to transfer the message content via the BDAT command
+// if the server supports the CHUNKING extension (RFC 3030). BDAT avoids the dot-stuffing
+// overhead of the DATA command, which is beneficial for large messages. If the server
+// does not support CHUNKING, the Client will fall back to DATA
+func WithChunking() Option {
+	return func(c *Client) error {
+		c.chunking = true
+		return nil
+	}
+}
+
+// WithChunkSize enables CHUNKING (see WithChunking) and overrides the default size of
+// a single BDAT chunk
+func WithChunkSize(s int) Option {
+	return func(c *Client) error {
+		if s <= 0 {
+			return ErrInvalidChunkSize
+		}
+		c.chunking = true
+		c.chunksize = s
+		return nil
+	}
+}
+
 // TLSPolicy returns the currently set TLSPolicy as string
 func (c *Client) TLSPolicy() string {
 	return c.tlspolicy.String()
@@ -464,42 +706,303 @@ func (c *Client) SetSMTPAuth(a SMTPAuthType) {
 // SetSMTPAuthCustom overrides the current SMTP AUTH setting with the given custom smtp.Auth
 func (c *Client) SetSMTPAuthCustom(sa smtp.Auth) {
 	c.sa = sa
+	c.sahost = ""
 }
 
-// setDefaultHelo retrieves the current hostname and sets it as HELO/EHLO hostname
+// WithDialContextFunc overrides the function that is used to establish the network
+// connection to the SMTP server. This allows to connect through SOCKS5 or HTTP CONNECT
+// proxies or custom network stacks. If SSL is enabled, the TLS handshake is performed
+// on top of the returned connection
+func WithDialContextFunc(f DialContextFunc) Option {
+	return func(c *Client) error {
+		c.dialContextFunc = f
+		return nil
+	}
+}
+
+// WithKeepAlive tells the Client to keep the connection to the SMTP server open across
+// multiple Send calls. While the connection is idle, a NOOP command is sent in the given
+// interval, so that the server does not close the connection. If the connection broke
+// or timed out nevertheless, it is transparently re-established on the next Send. The
+// connection is kept open until Close is called
+func WithKeepAlive(i time.Duration) Option {
+	return func(c *Client) error {
+		if i <= 0 {
+			return ErrInvalidKeepAlive
+		}
+		c.keepalive = i
+		return nil
+	}
+}
+
+// WithRetry tells the Client to retry the delivery of a message up to the given number of
+// attempts in total, if it failed with a temporary error (4xx reply code) or because the
+// connection broke, which is common with greylisting. The Client waits for the given
+// backoff before the first retry and doubles it for every further retry. Broken
+// connections are re-established before the retry. Deliveries that failed after the
+// server accepted the message are never retried
+func WithRetry(a int, b time.Duration) Option {
+	return func(c *Client) error {
+		if a < 1 || b < 0 {
+			return ErrInvalidRetryPolicy
+		}
+		c.retries = a
+		c.retrybackoff = b
+		return nil
+	}
+}
+
+// WithRateLimit limits the Client to send at most n messages within any period of time
+// p, so that bulk sends stay below the throttling limits of the provider. Send blocks
+// until the next message is allowed. Every delivery attempt counts against the limit,
+// including retries and sends from concurrent goroutines sharing the Client
+func WithRateLimit(n int, p time.Duration) Option {
+	return func(c *Client) error {
+		if n <= 0 || p <= 0 {
+			return ErrInvalidRateLimit
+		}
+		c.ratelimit = newRateLimiter(n, p)
+		return nil
+	}
+}
+
+// WithDomainThrottle limits the deliveries of the Client per recipient domain with the given
+// DomainThrottle. Send blocks until the delivery of a Msg is allowed by the limits of all its
+// recipient domains. The DomainThrottle can be shared with other Client (e.g. the Client of
+// every MX of an MXSender via WithMXClientOptions), so that the limits apply to all of them
+func WithDomainThrottle(t *DomainThrottle) Option {
+	return func(c *Client) error {
+		if t == nil {
+			return fmt.Errorf("domain throttle must not be nil")
+		}
+		c.throttle = t
+		return nil
+	}
+}
+
+// WithSuppressionChecker sets a SuppressionChecker that is consulted before each recipient is
+// added to the envelope. Suppressed recipients (e.g. unsubscribed or bounced addresses) are
+// dropped and reported via Msg.SuppressedRecipients. If all recipients of a Msg are suppressed,
+// the delivery fails with ErrAllRcptsSuppressed
+func WithSuppressionChecker(sc SuppressionChecker) Option {
+	return func(c *Client) error {
+		if sc == nil {
+			return fmt.Errorf("suppression checker must not be nil")
+		}
+		c.suppression = sc
+		return nil
+	}
+}
+
+// WithOnSend sets a callback that is called before every delivery attempt of a Msg,
+// including retries. It allows applications to record delivery attempts or to emit
+// metrics without wrapping every Send call. If messages are sent concurrently (e.g. via
+// SendConcurrently), the callback must be safe for concurrent use
+func WithOnSend(f MsgHookFunc) Option {
+	return func(c *Client) error {
+		c.onSend = f
+		return nil
+	}
+}
+
+// WithOnDelivered sets a callback that is called after a Msg has been accepted by the
+// SMTP server. If messages are sent concurrently, the callback must be safe for
+// concurrent use
+func WithOnDelivered(f MsgHookFunc) Option {
+	return func(c *Client) error {
+		c.onDelivered = f
+		return nil
+	}
+}
+
+// WithOnError sets a callback that is called with the SendError after the delivery of a Msg
+// failed and will not be retried anymore. If messages are sent concurrently, the callback
+// must be safe for concurrent use
+func WithOnError(f MsgErrorHookFunc) Option {
+	return func(c *Client) error {
+		c.onError = f
+		return nil
+	}
+}
+
+// WithMaxRcptsPerTransaction limits the number of recipients per SMTP transaction, for
+// relays that cap the number of RCPT TO commands (often at 50 or 100). Messages with more
+// recipients are automatically sent in multiple transactions with the same message content,
+// so that all recipients see the same headers. If a transaction fails, the recipients of
+// the transactions that were already delivered are skipped when the delivery is retried
+func WithMaxRcptsPerTransaction(n int) Option {
+	return func(c *Client) error {
+		if n <= 0 {
+			return ErrInvalidMaxRcpts
+		}
+		c.maxrcpts = n
+		return nil
+	}
+}
+
+// WithFailover sets an ordered list of fallback relays for the host of the Client. If the
+// connection to the host fails or a message is rejected with a temporary error (4xx reply
+// code), the Client automatically fails over to the next relay of the list. Failed relays
+// are avoided for the failover cooldown (see WithFailoverCooldown), after which new
+// connections prefer them again in the configured order. All relays use the port, TLS and
+// authentication settings of the Client. If the tls.Config of the Client is set up for the
+// host, the ServerName is adjusted to the relay
+func WithFailover(hl ...string) Option {
+	return func(c *Client) error {
+		for _, h := range hl {
+			if h == "" {
+				return ErrNoHostname
+			}
+		}
+		c.relays = append(c.relays, hl...)
+		return nil
+	}
+}
+
+// WithFailoverCooldown overrides the DefaultFailoverCooldown, which is the time a failed
+// relay is avoided
+func WithFailoverCooldown(d time.Duration) Option {
+	return func(c *Client) error {
+		if d <= 0 {
+			return ErrInvalidFailoverCooldown
+		}
+		c.relaycooldown = d
+		return nil
+	}
+}
+
+// setDefaultHelo sets the FQDN of the local host as HELO/EHLO hostname, since many relays
+// reject unqualified hostnames. If no FQDN can be detected, the address literal of the
+// local address of the connection is used instead
 func (c *Client) setDefaultHelo() error {
 	hn, err := os.Hostname()
 	if err != nil {
 		return fmt.Errorf("failed cto read local hostname: %w", err)
 	}
-	c.helo = hn
+	defaultHeloOnce.Do(func() {
+		defaultHelo = fqdn(hn, net.LookupHost, net.LookupAddr)
+	})
+	c.helo = defaultHelo
+	c.heloLiteral = c.helo == ""
 	return nil
 }
 
+// fqdn returns the fully qualified domain name of the given hostname. If the hostname is not
+// qualified, its addresses are resolved with the given lookup functions and the first
+// qualified name of their reverse lookup is returned. It returns an empty string if no
+// FQDN can be found
+func fqdn(hn string, lh, la func(string) ([]string, error)) string {
+	hn = strings.TrimSuffix(hn, ".")
+	if isFQDN(hn) {
+		return hn
+	}
+	al, err := lh(hn)
+	if err != nil {
+		return ""
+	}
+	for _, a := range al {
+		nl, err := la(a)
+		if err != nil {
+			continue
+		}
+		for _, n := range nl {
+			if n = strings.TrimSuffix(n, "."); isFQDN(n) {
+				return n
+			}
+		}
+	}
+	return ""
+}
+
+// isFQDN returns true if the given hostname is qualified with a domain and is not a name
+// of the loopback interface
+func isFQDN(hn string) bool {
+	return strings.Contains(hn, ".") && net.ParseIP(hn) == nil &&
+		!strings.HasPrefix(strings.ToLower(hn), "localhost")
+}
+
+// addrLiteral returns the address literal as described in RFC 5321 for the IP address of
+// the given net.Addr, e.g. "[192.0.2.1]" or "[IPv6:2001:db8::1]"
+func addrLiteral(a net.Addr) string {
+	h, _, err := net.SplitHostPort(a.String())
+	if err != nil {
+		h = a.String()
+	}
+	ip := net.ParseIP(h)
+	switch {
+	case ip == nil:
+		return "[127.0.0.1]"
+	case ip.To4() != nil:
+		return "[" + ip.String() + "]"
+	default:
+		return "[IPv6:" + ip.String() + "]"
+	}
+}
+
 // DialWithContext establishes a connection cto the SMTP server with a given context.Context
 func (c *Client) DialWithContext(pc context.Context) error {
-	ctx, cfn := context.WithDeadline(pc, time.Now().Add(c.cto))
-	defer cfn()
-
-	nd := net.Dialer{}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.dial(pc)
+}
 
+// dial establishes the server connection. If fallback relays are set, the relays are tried
+// in the order of their health until a connection succeeds
+func (c *Client) dial(pc context.Context) error {
+	if c.failover == nil {
+		return c.dialHost(pc, c.host)
+	}
 	var err error
-	if c.ssl {
-		td := tls.Dialer{NetDialer: &nd, Config: c.tlsconfig}
-
-		c.enc = true
-		c.co, err = td.DialContext(ctx, "tcp", c.ServerAddr())
+	for _, h := range c.failover.order() {
+		if err = c.dialHost(pc, h); err == nil {
+			c.failover.markUp(h)
+			return nil
+		}
+		c.failover.markDown(h)
+		if c.co != nil {
+			_ = c.co.Close()
+			c.co = nil
+		}
+		if pc.Err() != nil {
+			break
+		}
 	}
-	if !c.ssl {
-		c.co, err = nd.DialContext(ctx, "tcp", c.ServerAddr())
+	return fmt.Errorf("failed to connect to any relay: %w", err)
+}
+
+// dialHost establishes the server connection to the given host. In keep-alive mode, it also
+// starts the keep-alive goroutine if it is not running yet
+func (c *Client) dialHost(pc context.Context, h string) error {
+	ctx, cfn := context.WithDeadline(pc, time.Now().Add(c.timeout(c.dialto)))
+	defer cfn()
+
+	c.connHost = h
+	addr := fmt.Sprintf("%s:%d", h, c.port)
+	var err error
+	if c.dialContextFunc != nil {
+		err = c.dialCustom(ctx, addr)
+	} else {
+		nd := net.Dialer{}
+		if c.ssl {
+			td := tls.Dialer{NetDialer: &nd, Config: c.connTLSConfig()}
+
+			c.enc = true
+			c.co, err = td.DialContext(ctx, "tcp", addr)
+		}
+		if !c.ssl {
+			c.co, err = nd.DialContext(ctx, "tcp", addr)
+		}
 	}
 	if err != nil {
 		return err
 	}
+	if err := c.co.SetDeadline(time.Now().Add(c.timeout(c.cmdto))); err != nil {
+		return ErrDeadlineExtendFailed
+	}
 
-	c.sc, err = smtp.NewClient(c.co, c.host)
+	c.sc, err = smtp.NewClient(c.co, h)
 	if err != nil {
-		return err
+		return newSMTPError(err)
 	}
 	if c.l != nil {
 		c.sc.SetLogger(c.l)
@@ -507,8 +1010,13 @@ func (c *Client) DialWithContext(pc context.Context) error {
 	if c.dl {
 		c.sc.SetDebugLog(true)
 	}
-	if err := c.sc.Hello(c.helo); err != nil {
-		return err
+	c.sc.SetCommandTimeout(c.timeout(c.cmdto))
+	helo := c.helo
+	if helo == "" && c.heloLiteral {
+		helo = addrLiteral(c.co.LocalAddr())
+	}
+	if err := c.sc.Hello(helo); err != nil {
+		return newSMTPError(err)
 	}
 
 	if err := c.tls(); err != nil {
@@ -519,33 +1027,176 @@ func (c *Client) DialWithContext(pc context.Context) error {
 		return err
 	}
 
+	if c.keepalive > 0 && c.kastop == nil {
+		c.kastop = make(chan struct{})
+		go c.keepAlive(c.kastop)
+	}
+
 	return nil
 }
 
+// dialCustom establishes the server connection to the given address with the
+// DialContextFunc of the Client and performs the TLS handshake if SSL is enabled
+func (c *Client) dialCustom(ctx context.Context, addr string) error {
+	co, err := c.dialContextFunc(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if !c.ssl {
+		c.co = co
+		return nil
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		if err := co.SetDeadline(dl); err != nil {
+			_ = co.Close()
+			return ErrDeadlineExtendFailed
+		}
+	}
+	tc := tls.Client(co, c.connTLSConfig())
+	if err := tc.Handshake(); err != nil {
+		_ = co.Close()
+		return err
+	}
+	c.co = tc
+	c.enc = true
+	return nil
+}
+
+// connTLSConfig returns the tls.Config for the current server connection. If the Client
+// failed over to a relay and the tls.Config is set up for the host, a copy with the
+// ServerName of the relay is returned
+func (c *Client) connTLSConfig() *tls.Config {
+	if c.connHost == "" || c.connHost == c.host || c.tlsconfig.ServerName != c.host {
+		return c.tlsconfig
+	}
+	tc := c.tlsconfig.Clone()
+	tc.ServerName = c.connHost
+	return tc
+}
+
 // Close closes the Client connection
 func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.kastop != nil {
+		close(c.kastop)
+		c.kastop = nil
+	}
 	if err := c.checkConn(); err != nil {
 		return err
 	}
 	if err := c.sc.Quit(); err != nil {
 		return fmt.Errorf("failed to close SMTP client: %w", err)
 	}
+	c.co = nil
 
 	return nil
 }
 
 // Reset sends the RSET command to the SMTP client
 func (c *Client) Reset() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reset()
+}
+
+// reset sends the RSET command without locking the server connection
+func (c *Client) reset() error {
 	if err := c.checkConn(); err != nil {
 		return err
 	}
 	if err := c.sc.Reset(); err != nil {
-		return fmt.Errorf("failed to send RSET to SMTP client: %w", err)
+		return fmt.Errorf("failed to send RSET to SMTP client: %w", newSMTPError(err))
 	}
 
 	return nil
 }
 
+// HasExtension returns true if the connected SMTP server advertised the given ESMTP extension
+// (e.g. "SIZE", "SMTPUTF8" or "CHUNKING") in its EHLO response. The extension name is
+// case-insensitive. It returns false if the Client is not connected
+func (c *Client) HasExtension(e string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.co == nil || c.sc == nil {
+		return false
+	}
+	ok, _ := c.sc.Extension(e)
+	return ok
+}
+
+// ExtensionParam returns the parameters the connected SMTP server advertised for the given
+// ESMTP extension in its EHLO response (e.g. the maximum message size for "SIZE" or the list
+// of mechanisms for "AUTH"). It returns an empty string if the extension has no parameters,
+// the server does not support it or the Client is not connected
+func (c *Client) ExtensionParam(e string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.co == nil || c.sc == nil {
+		return ""
+	}
+	_, p := c.sc.Extension(e)
+	return p
+}
+
+// Verify asks the connected SMTP server to verify the given address via the VRFY command. If
+// Verify returns nil, the server knows the address. The returned error is an SMTPError if
+// the server rejected the address (e.g. with ErrMailboxUnavailable). If the server does not
+// implement VRFY or refuses to verify addresses (reply code 252, 500 or 502), Verify
+// returns ErrVRFYNotSupported. Many servers do not verify addresses for security reasons
+func (c *Client) Verify(addr string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.checkConn(); err != nil {
+		return err
+	}
+	if err := c.sc.Verify(addr); err != nil {
+		return commandError(err, ErrVRFYNotSupported, 252, 500, 502)
+	}
+	return nil
+}
+
+// Expand asks the connected SMTP server to expand the given mailing list via the EXPN
+// command and returns its mailboxes (e.g. "John Doe <john@example.com>"). If the server
+// does not implement EXPN (reply code 500 or 502), Expand returns ErrEXPNNotSupported. Many
+// servers do not expand mailing lists for security reasons
+func (c *Client) Expand(list string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.checkConn(); err != nil {
+		return nil, err
+	}
+	ll, err := c.sc.Expand(list)
+	if err != nil {
+		return nil, commandError(err, ErrEXPNNotSupported, 500, 502)
+	}
+	ml := make([]string, 0, len(ll))
+	for _, l := range ll {
+		if m := enhancedCodeRegexp.FindStringSubmatch(l); m != nil {
+			l = l[len(m[0]):]
+		}
+		ml = append(ml, l)
+	}
+	return ml, nil
+}
+
+// commandError returns the given error of an SMTP command as SMTPError. If the server
+// replied with one of the given reply codes, the given sentinel error is returned instead,
+// wrapping the reply of the server
+func commandError(err, ns error, codes ...int) error {
+	err = newSMTPError(err)
+	var se *SMTPError
+	if !errors.As(err, &se) {
+		return err
+	}
+	for _, c := range codes {
+		if se.Code == c {
+			return fmt.Errorf("%w: %s", ns, se)
+		}
+	}
+	return se
+}
+
 // DialAndSend establishes a connection to the SMTP server with a
 // default context.Background and sends the mail
 func (c *Client) DialAndSend(ml ...*Msg) error {
@@ -559,7 +1210,7 @@ func (c *Client) DialAndSendWithContext(ctx context.Context, ml ...*Msg) error {
 	if err := c.DialWithContext(ctx); err != nil {
 		return fmt.Errorf("dial failed: %w", err)
 	}
-	if err := c.Send(ml...); err != nil {
+	if err := c.send(ctx, ml...); err != nil {
 		return fmt.Errorf("send failed: %w", err)
 	}
 	if err := c.Close(); err != nil {
@@ -568,6 +1219,570 @@ func (c *Client) DialAndSendWithContext(ctx context.Context, ml ...*Msg) error {
 	return nil
 }
 
+// Send sends out the mail message
+func (c *Client) Send(ml ...*Msg) error {
+	return c.send(context.Background(), ml...)
+}
+
+// SendWithContext sends out the given messages and satisfies the Sender interface. If the
+// Client is not connected yet, the connection is established with the given context and
+// closed again after the messages have been sent, unless the keep-alive mode is enabled
+func (c *Client) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	dialed := false
+	if c.co == nil {
+		if err := c.dial(ctx); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("dial failed: %w", err)
+		}
+		dialed = true
+	}
+	c.mu.Unlock()
+
+	if err := c.send(ctx, ml...); err != nil {
+		if dialed && c.keepalive == 0 {
+			_ = c.Close()
+		}
+		return err
+	}
+	if dialed && c.keepalive == 0 {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("failed to close connection: %w", err)
+		}
+	}
+	return nil
+}
+
+// SendConcurrently delivers the given messages concurrently over a bounded pool of workers.
+// Each worker establishes its own connection to the SMTP server with the settings of the
+// Client, so that high volumes of messages (e.g. newsletters) can be delivered with higher
+// throughput. The Client itself does not need to be connected. The result of each message is
+// available via Msg.HasSendError and Msg.SendError, and the returned error aggregates the
+// errors of all failed messages. If a worker cannot connect to the server, the remaining
+// messages are delivered by the other workers. Messages that could not be attempted due to
+// connection failures or the cancellation of the context are marked as failed as well
+func (c *Client) SendConcurrently(ctx context.Context, ml []*Msg, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(ml) {
+		workers = len(ml)
+	}
+	jobs := make(chan int, len(ml))
+	for i := range ml {
+		ml[i].sendError = nil
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	var emu sync.Mutex
+	var werr error
+	done := make([]bool, len(ml))
+	sel := make([]*SendError, len(ml))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wc := c.newWorker()
+			wc.mu.Lock()
+			defer wc.mu.Unlock()
+			if err := wc.dial(ctx); err != nil {
+				emu.Lock()
+				werr = err
+				emu.Unlock()
+				return
+			}
+			defer func() {
+				if wc.sc != nil {
+					_ = wc.sc.Quit()
+				}
+			}()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				var se *SendError
+				if err := wc.connect(); err != nil {
+					se = &SendError{Reason: ErrConnCheck, errlist: []error{err}, isTemp: isTempError(err)}
+				} else {
+					se = wc.sendWithRetry(ctx, ml[i])
+				}
+				emu.Lock()
+				done[i] = true
+				sel[i] = se
+				emu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var errs []*SendError
+	for i, m := range ml {
+		if !done[i] {
+			err := ctx.Err()
+			if err == nil {
+				err = werr
+			}
+			sel[i] = &SendError{Reason: ErrConnCheck, errlist: []error{err}, isTemp: true}
+		}
+		if sel[i] != nil {
+			m.sendError = sel[i]
+			errs = append(errs, sel[i])
+		}
+	}
+	return joinSendErrors(errs)
+}
+
+// newWorker returns a new, unconnected Client with the settings of the Client for use by
+// SendConcurrently. The rate limit is shared with the Client
+func (c *Client) newWorker() *Client {
+	return &Client{
+		cto:             c.cto,
+		dialto:          c.dialto,
+		cmdto:           c.cmdto,
+		datato:          c.datato,
+		dsn:             c.dsn,
+		dsnmrtype:       c.dsnmrtype,
+		dsnrntype:       c.dsnrntype,
+		requireTLS:      c.requireTLS,
+		maxrcpts:        c.maxrcpts,
+		noNoop:          c.noNoop,
+		no8bitDowngrade: c.no8bitDowngrade,
+		chunking:        c.chunking,
+		chunksize:       c.chunksize,
+		helo:            c.helo,
+		heloLiteral:     c.heloLiteral,
+		host:            c.host,
+		pass:            c.pass,
+		port:            c.port,
+		sa:              c.sa,
+		sahost:          c.sahost,
+		satype:          c.satype,
+		ssl:             c.ssl,
+		tlspolicy:       c.tlspolicy,
+		tlsconfig:       c.tlsconfig,
+		user:            c.user,
+		dl:              c.dl,
+		l:               c.l,
+		retries:         c.retries,
+		retrybackoff:    c.retrybackoff,
+		dialContextFunc: c.dialContextFunc,
+		ratelimit:       c.ratelimit,
+		throttle:        c.throttle,
+		suppression:     c.suppression,
+		onSend:          c.onSend,
+		onDelivered:     c.onDelivered,
+		onError:         c.onError,
+		failover:        c.failover,
+	}
+}
+
+// sendWithRetry sends out a single message and retries the delivery according to the
+// retry policy of the Client. The retries stop when the given context is cancelled. It
+// returns the *SendError of the last attempt
+func (c *Client) sendWithRetry(ctx context.Context, m *Msg) *SendError {
+	m.rcptOffset = 0
+	m.serverResponses = nil
+	m.suppressed = nil
+	se := c.failOver(m, c.sendAttempt(m))
+	b := c.retrybackoff
+	for i := 1; i < c.retries && se != nil && se.retryable(); i++ {
+		if err := sleepContext(ctx, b); err != nil {
+			se.errlist = append(se.errlist, err)
+			break
+		}
+		b *= 2
+		if c.checkConn() != nil {
+			if err := c.redial(ctx); err != nil {
+				se = &SendError{Reason: ErrReconnect, errlist: []error{err}, isTemp: isTempError(err)}
+				continue
+			}
+		}
+		se = c.failOver(m, c.sendAttempt(m))
+	}
+	if se != nil && c.onError != nil {
+		c.onError(m, se)
+	}
+	if se == nil && c.onDelivered != nil {
+		c.onDelivered(m)
+	}
+	return se
+}
+
+// sleepContext pauses for the given duration or until the given context is cancelled, in
+// which case it returns the error of the context
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// failOver sends the Msg via the next relay, as long as its delivery failed with a temporary
+// or connection error and fallback relays are set. The failed relay is marked as failed.
+// It returns the *SendError of the last attempt
+func (c *Client) failOver(m *Msg, se *SendError) *SendError {
+	if c.failover == nil {
+		return se
+	}
+	for i := 1; i < len(c.failover.hosts) && se != nil && se.retryable(); i++ {
+		c.failover.markDown(c.connHost)
+		if c.co != nil {
+			_ = c.co.Close()
+			c.co = nil
+		}
+		if err := c.dial(context.Background()); err != nil {
+			return &SendError{Reason: ErrReconnect, errlist: []error{err}, isTemp: true}
+		}
+		se = c.sendAttempt(m)
+	}
+	return se
+}
+
+// sendAttempt calls the OnSend callback and performs a single delivery attempt of the Msg
+func (c *Client) sendAttempt(m *Msg) *SendError {
+	if c.onSend != nil {
+		c.onSend(m)
+	}
+	return c.sendSingleMsg(m)
+}
+
+// sendSingleMsg sends out a single message and returns a *SendError if the delivery
+// of the message failed
+func (c *Client) sendSingleMsg(m *Msg) *SendError {
+	if c.ratelimit != nil {
+		c.ratelimit.wait()
+	}
+	// wm is the Msg that is written to the server, which is a converted copy of the Msg
+	// if the server does not support 8BITMIME or SMTPUTF8. The default headers are set on
+	// the Msg first, so that all copies and retries share the same Date and Message-ID
+	m.addDefaultHeader()
+	wm := m
+	if m.encoding == NoEncoding {
+		if ok, _ := c.sc.Extension("8BITMIME"); !ok {
+			if c.no8bitDowngrade {
+				return &SendError{Reason: ErrNoUnencoded, isTemp: false}
+			}
+			wm = m.Clone()
+			wm.downgrade8Bit()
+		}
+	}
+	utf8ok, _ := c.sc.Extension("SMTPUTF8")
+	if !utf8ok {
+		if wm == m {
+			wm = m.Clone()
+		}
+		if err := wm.addrHeaderToASCII(); err != nil {
+			return &SendError{Reason: ErrNoSMTPUTF8, errlist: []error{err}, isTemp: false}
+		}
+	}
+	f, err := m.GetSender(false)
+	if err != nil {
+		return &SendError{Reason: ErrGetSender, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	if err = wm.checkSize(); err != nil {
+		return &SendError{Reason: ErrSizeLimit, errlist: []error{err}, isTemp: false}
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		return &SendError{Reason: ErrGetRcpts, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	if c.suppression != nil {
+		rl, m.suppressed, err = filterSuppressed(c.suppression, rl)
+		if err != nil {
+			return &SendError{Reason: ErrSuppressionCheck, errlist: []error{err}, isTemp: true}
+		}
+		if len(rl) == 0 {
+			return &SendError{Reason: ErrAllRcptsSuppressed, rcpt: m.suppressed, isTemp: false}
+		}
+	}
+	if c.throttle != nil {
+		release := c.throttle.acquire(rl)
+		defer release()
+	}
+	if !utf8ok {
+		f, rl, err = envelopeToASCII(f, rl)
+		if err != nil {
+			return &SendError{Reason: ErrNoSMTPUTF8, errlist: []error{err}, isTemp: false}
+		}
+	}
+
+	rt := c.requireTLS && !m.tlsRequiredNo()
+	if rt {
+		_, tlsok := c.sc.TLSConnectionState()
+		if ok, _ := c.sc.Extension("REQUIRETLS"); !ok || !tlsok {
+			return &SendError{Reason: ErrNoRequireTLS, isTemp: false}
+		}
+	}
+	c.sc.SetRequireTLS(rt)
+
+	if err = c.setDSNOptions(m, !utf8ok); err != nil {
+		return &SendError{Reason: ErrNoSMTPUTF8, errlist: []error{err}, isTemp: false}
+	}
+	bs := len(rl)
+	if c.maxrcpts > 0 {
+		bs = c.maxrcpts
+	}
+	for m.rcptOffset < len(rl) {
+		e := m.rcptOffset + bs
+		if e > len(rl) {
+			e = len(rl)
+		}
+		if se := c.sendTransaction(m, wm, f, rl[m.rcptOffset:e]); se != nil {
+			return se
+		}
+		m.rcptOffset = e
+	}
+	m.rcptOffset = 0
+	return nil
+}
+
+// sendTransaction sends the Msg to the given recipients within a single SMTP transaction.
+// The content is written from wm, which is either the Msg itself or a converted copy of it
+func (c *Client) sendTransaction(m, wm *Msg, f string, rl []string) *SendError {
+	// With PIPELINING, the DATA command is sent in the same batch as MAIL FROM and RCPT TO,
+	// unless the content is transferred via BDAT
+	var w io.WriteCloser
+	var rerrs []error
+	var derr, err error
+	pd := c.pipelineData()
+	if pd {
+		rerrs, w, derr, err = c.sc.MailRcptData(f, rl)
+	} else {
+		rerrs, err = c.sc.MailRcpt(f, rl)
+	}
+	if err != nil {
+		se := &SendError{Reason: ErrSMTPMailFrom, errlist: []error{newSMTPError(err)}, isTemp: isTempError(err)}
+		c.abortTransaction(se, w)
+		return se
+	}
+	failed := false
+	rse := &SendError{}
+	rse.errlist = make([]error, 0)
+	rse.rcpt = make([]string, 0)
+	for i, err := range rerrs {
+		if err != nil {
+			rse.Reason = ErrSMTPRcptTo
+			rse.errlist = append(rse.errlist, newSMTPError(err))
+			rse.rcpt = append(rse.rcpt, rl[i])
+			rse.rcptErrs = append(rse.rcptErrs, newRcptError(rl[i], err))
+			rse.isTemp = isTempError(err)
+			failed = true
+		}
+	}
+	if failed {
+		c.abortTransaction(rse, w)
+		return rse
+	}
+	if pd {
+		if derr != nil {
+			return &SendError{Reason: ErrSMTPData, errlist: []error{newSMTPError(derr)}, isTemp: isTempError(derr)}
+		}
+		w = &deadlineWriter{WriteCloser: w, co: c.co, t: c.timeout(c.datato)}
+	} else {
+		w, err = c.dataWriter()
+		if err != nil {
+			return &SendError{Reason: ErrSMTPData, errlist: []error{newSMTPError(err)}, isTemp: isTempError(err)}
+		}
+	}
+	// The size limit has already been checked by sendSingleMsg, so the Msg is not rendered
+	// another time by WriteTo
+	_, err = wm.writeMsg(w, wm.applyMiddlewares(wm), false)
+	if err != nil {
+		return &SendError{Reason: ErrWriteContent, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	if err := w.Close(); err != nil {
+		return &SendError{Reason: ErrSMTPDataClose, errlist: []error{newSMTPError(err)}, isTemp: isTempError(err)}
+	}
+	if sr, ok := w.(interface{ ServerResponse() string }); ok {
+		m.serverResponses = append(m.serverResponses, sr.ServerResponse())
+	}
+
+	if err := c.reset(); err != nil {
+		return &SendError{Reason: ErrSMTPReset, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	if err := c.checkConn(); err != nil {
+		return &SendError{Reason: ErrConnCheck, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	return nil
+}
+
+// envelopeToASCII converts the internationalized domains of the given envelope sender and
+// recipient addresses into their A-label form, for servers that do not support SMTPUTF8
+func envelopeToASCII(f string, rl []string) (string, []string, error) {
+	af, err := addrToASCII(f)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert sender address %q: %w", f, err)
+	}
+	arl := make([]string, len(rl))
+	for i := range rl {
+		arl[i], err = addrToASCII(rl[i])
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to convert recipient address %q: %w", rl[i], err)
+		}
+	}
+	return af, arl, nil
+}
+
+// dataWriter returns the io.WriteCloser for the message content. If CHUNKING is enabled
+// and supported by the server, BDAT is used, otherwise the DATA command is issued. The
+// deadline of the connection is extended by the data timeout for every write
+func (c *Client) dataWriter() (io.WriteCloser, error) {
+	var w io.WriteCloser
+	var err error
+	if ok, _ := c.sc.Extension("CHUNKING"); ok && c.chunking {
+		w, err = c.sc.Bdat(c.chunksize)
+	} else {
+		w, err = c.sc.Data()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deadlineWriter{WriteCloser: w, co: c.co, t: c.timeout(c.datato)}, nil
+}
+
+// pipelineData returns true if the DATA command can be pipelined with the MAIL FROM and
+// RCPT TO commands
+func (c *Client) pipelineData() bool {
+	if ok, _ := c.sc.Extension("PIPELINING"); !ok {
+		return false
+	}
+	ok, _ := c.sc.Extension("CHUNKING")
+	return !ok || !c.chunking
+}
+
+// abortTransaction ends a failed mail transaction. Usually the transaction is reset, but if
+// the server accepted a pipelined DATA command, the mail data cannot be cancelled. In that
+// case the connection is closed without ending the mail data, so that the server discards
+// the transaction, and is re-established for further messages. Errors of the reset or
+// reconnect are added to the given SendError
+func (c *Client) abortTransaction(se *SendError, w io.WriteCloser) {
+	if w == nil {
+		if err := c.sc.Reset(); err != nil {
+			se.errlist = append(se.errlist, newSMTPError(err))
+		}
+		return
+	}
+	if err := c.redial(context.Background()); err != nil {
+		se.errlist = append(se.errlist, err)
+	}
+}
+
+// deadlineWriter is an io.WriteCloser that extends the deadline of the connection by the
+// timeout before every write and before closing the underlying io.WriteCloser
+type deadlineWriter struct {
+	io.WriteCloser
+	co net.Conn
+	t  time.Duration
+}
+
+// Write implements the io.Writer interface for the deadlineWriter
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.co.SetDeadline(time.Now().Add(w.t)); err != nil {
+		return 0, ErrDeadlineExtendFailed
+	}
+	return w.WriteCloser.Write(p)
+}
+
+// Close implements the io.Closer interface for the deadlineWriter
+func (w *deadlineWriter) Close() error {
+	if err := w.co.SetDeadline(time.Now().Add(w.t)); err != nil {
+		return ErrDeadlineExtendFailed
+	}
+	return w.WriteCloser.Close()
+}
+
+// ServerResponse returns the reply text of the server to the mail data, if the underlying
+// io.WriteCloser provides it
+func (w *deadlineWriter) ServerResponse() string {
+	if sr, ok := w.WriteCloser.(interface{ ServerResponse() string }); ok {
+		return sr.ServerResponse()
+	}
+	return ""
+}
+
+// setDSNOptions applies the DSN settings of the Client and the given Msg to the smtp.Client.
+// The DSN settings of the Msg take precedence over the ones of the Client. If ascii is set,
+// the recipient addresses of the Msg are converted like the ones of the envelope
+func (c *Client) setDSNOptions(m *Msg, ascii bool) error {
+	var mrt, rnt string
+	if c.dsn {
+		mrt = string(c.dsnmrtype)
+		rnt = strings.Join(c.dsnrntype, ",")
+	}
+	if m.dsnmrtype != "" {
+		mrt = string(m.dsnmrtype)
+	}
+	var rno map[string]string
+	if len(m.dsnrntype) > 0 {
+		rno = make(map[string]string, len(m.dsnrntype))
+		for r, rnol := range m.dsnrntype {
+			if ascii {
+				ar, err := addrToASCII(r)
+				if err != nil {
+					return fmt.Errorf("failed to convert recipient address %q: %w", r, err)
+				}
+				r = ar
+			}
+			rno[r] = strings.Join(rnol, ",")
+		}
+	}
+	c.sc.SetDSNMailReturnOption(mrt)
+	c.sc.SetDSNRcptNotifyOption(rnt)
+	c.sc.SetDSNRcptNotifyOptions(rno)
+	return nil
+}
+
+// connect makes sure that a usable server connection is available before messages are
+// sent. In keep-alive mode or if retries are enabled, a broken or timed out connection
+// is re-established
+func (c *Client) connect() error {
+	err := c.checkConn()
+	if err == nil || (c.keepalive == 0 && (c.retries < 2 || c.co == nil)) {
+		return err
+	}
+	return c.redial(context.Background())
+}
+
+// redial closes the current connection to the SMTP server, if any, and establishes a new one
+func (c *Client) redial(ctx context.Context) error {
+	if c.co != nil {
+		_ = c.co.Close()
+		c.co = nil
+	}
+	if err := c.dial(ctx); err != nil {
+		return fmt.Errorf("failed to reconnect to SMTP server: %w", err)
+	}
+	return nil
+}
+
+// keepAlive sends a NOOP command in the keep-alive interval of the Client, until the
+// given channel is closed
+func (c *Client) keepAlive(stop <-chan struct{}) {
+	t := time.NewTicker(c.keepalive)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			c.mu.Lock()
+			if c.co != nil && c.sc.Noop() == nil {
+				_ = c.co.SetDeadline(time.Now().Add(c.timeout(c.cmdto)))
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
 // checkConn makes sure that a required server connection is available and extends the
 // connection deadline
 func (c *Client) checkConn() error {
@@ -581,12 +1796,20 @@ func (c *Client) checkConn() error {
 		}
 	}
 
-	if err := c.co.SetDeadline(time.Now().Add(c.cto)); err != nil {
+	if err := c.co.SetDeadline(time.Now().Add(c.timeout(c.cmdto))); err != nil {
 		return ErrDeadlineExtendFailed
 	}
 	return nil
 }
 
+// timeout returns the given specific timeout or the connection timeout, if it is not set
+func (c *Client) timeout(t time.Duration) time.Duration {
+	if t > 0 {
+		return t
+	}
+	return c.cto
+}
+
 // tls tries to make sure that the STARTTLS requirements are satisfied
 func (c *Client) tls() error {
 	if c.co == nil {
@@ -608,8 +1831,8 @@ func (c *Client) tls() error {
 			}
 		}
 		if est {
-			if err := c.sc.StartTLS(c.tlsconfig); err != nil {
-				return err
+			if err := c.sc.StartTLS(c.connTLSConfig()); err != nil {
+				return newSMTPError(err)
 			}
 		}
 		_, c.enc = c.sc.TLSConnectionState()
@@ -622,7 +1845,7 @@ func (c *Client) auth() error {
 	if err := c.checkConn(); err != nil {
 		return fmt.Errorf("failed to authenticate: %w", err)
 	}
-	if c.sa == nil && c.satype != "" {
+	if c.satype != "" && (c.sa == nil || (c.sahost != "" && c.sahost != c.connHost)) {
 		sa, sat := c.sc.Extension("AUTH")
 		if !sa {
 			return fmt.Errorf("server does not support SMTP AUTH")
@@ -633,12 +1856,12 @@ func (c *Client) auth() error {
 			if !strings.Contains(sat, string(SMTPAuthPlain)) {
 				return ErrPlainAuthNotSupported
 			}
-			c.sa = smtp.PlainAuth("", c.user, c.pass, c.host)
+			c.sa = smtp.PlainAuth("", c.user, c.pass, c.connHost)
 		case SMTPAuthLogin:
 			if !strings.Contains(sat, string(SMTPAuthLogin)) {
 				return ErrLoginAuthNotSupported
 			}
-			c.sa = smtp.LoginAuth(c.user, c.pass, c.host)
+			c.sa = smtp.LoginAuth(c.user, c.pass, c.connHost)
 		case SMTPAuthCramMD5:
 			if !strings.Contains(sat, string(SMTPAuthCramMD5)) {
 				return ErrCramMD5AuthNotSupported
@@ -647,11 +1870,12 @@ func (c *Client) auth() error {
 		default:
 			return fmt.Errorf("unsupported SMTP AUTH type %q", c.satype)
 		}
+		c.sahost = c.connHost
 	}
 
 	if c.sa != nil {
 		if err := c.sc.Auth(c.sa); err != nil {
-			return fmt.Errorf("SMTP AUTH failed: %w", err)
+			return fmt.Errorf("SMTP AUTH failed: %w", newSMTPError(err))
 		}
 	}
 	return nil
@@ -5,18 +5,24 @@
 package mail
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/wneessen/go-mail/log"
 	"github.com/wneessen/go-mail/smtp"
+	"github.com/wneessen/go-mail/smtptest"
 )
 
 // DefaultHost is used as default hostname for the Client
@@ -467,6 +473,703 @@ func TestWithDSNRcptNotifyType(t *testing.T) {
 	}
 }
 
+// TestClient_Send_withDSN tests that the DSN options are sent to a server that supports DSN
+func TestClient_Send_withDSN(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		mail string
+		rcpt string
+	}{
+		{"No DSN", nil, "MAIL FROM:<sender@example.com>", "RCPT TO:<rcpt@example.com>"},
+		{
+			"Default DSN", []Option{WithDSN()}, "MAIL FROM:<sender@example.com> RET=FULL",
+			"RCPT TO:<rcpt@example.com> NOTIFY=FAILURE,SUCCESS",
+		},
+		{
+			"DSN with HDRS and DELAY",
+			[]Option{WithDSNMailReturnType(DSNMailReturnHeadersOnly), WithDSNRcptNotifyType(DSNRcptNotifyDelay)},
+			"MAIL FROM:<sender@example.com> RET=HDRS", "RCPT TO:<rcpt@example.com> NOTIFY=DELAY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := smtptest.NewTestServer(t, smtptest.WithExtensions("DSN"))
+			c := testClient(t, s, tt.opts...)
+			if err := c.DialAndSend(testMsg(t)); err != nil {
+				t.Fatalf("failed to send mail: %s", err)
+			}
+			if !hasCommand(s, tt.mail) {
+				t.Errorf("expected command %q, got: %v", tt.mail, s.Commands())
+			}
+			if !hasCommand(s, tt.rcpt) {
+				t.Errorf("expected command %q, got: %v", tt.rcpt, s.Commands())
+			}
+		})
+	}
+}
+
+// TestClient_Send_withDSNUnsupported tests that no DSN options are sent to a server that does
+// not advertise DSN support
+func TestClient_Send_withDSNUnsupported(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s, WithDSN())
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+	if !hasCommand(s, "MAIL FROM:<sender@example.com>") {
+		t.Errorf("expected MAIL FROM without DSN options, got: %v", s.Commands())
+	}
+	if !hasCommand(s, "RCPT TO:<rcpt@example.com>") {
+		t.Errorf("expected RCPT TO without DSN options, got: %v", s.Commands())
+	}
+}
+
+// TestClient_Send_withMsgDSN tests that the DSN options of the Msg take precedence over the ones
+// of the Client and are not applied to the following messages
+func TestClient_Send_withMsgDSN(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("DSN"))
+	c := testClient(t, s, WithDSNRcptNotifyType(DSNRcptNotifyFailure))
+	if err := c.DialWithContext(context.Background()); err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	m := testMsg(t)
+	if err := m.AddTo("Second Recipient <rcpt2@example.com>"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	if err := m.AddBcc("rcpt3@example.com"); err != nil {
+		t.Fatalf("failed to set BCC address: %s", err)
+	}
+	if err := m.SetDSNMailReturnType(DSNMailReturnHeadersOnly); err != nil {
+		t.Fatalf("failed to set DSN mail return type: %s", err)
+	}
+	if err := m.SetDSNRcptNotifyType("rcpt2@example.com", DSNRcptNotifySuccess, DSNRcptNotifyDelay); err != nil {
+		t.Fatalf("failed to set DSN notify type: %s", err)
+	}
+	if err := m.SetDSNRcptNotifyType("rcpt3@example.com"); err != nil {
+		t.Fatalf("failed to set DSN notify type: %s", err)
+	}
+	if err := c.Send(m, testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+
+	want := []string{
+		"MAIL FROM:<sender@example.com> RET=HDRS",
+		"RCPT TO:<rcpt@example.com> NOTIFY=FAILURE",
+		"RCPT TO:<rcpt2@example.com> NOTIFY=SUCCESS,DELAY",
+		"RCPT TO:<rcpt3@example.com>",
+		"MAIL FROM:<sender@example.com>",
+		"RCPT TO:<rcpt@example.com> NOTIFY=FAILURE",
+	}
+	var got []string
+	for _, cmd := range s.Commands() {
+		if strings.HasPrefix(cmd, "MAIL") || strings.HasPrefix(cmd, "RCPT") {
+			got = append(got, cmd)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected envelope commands. Want: %q, got: %q", want, got)
+	}
+}
+
+// TestClient_Send_withPipelining tests sending a mail to a server that supports PIPELINING
+func TestClient_Send_withPipelining(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("PIPELINING"))
+	c := testClient(t, s)
+	m := testMsg(t)
+	if err := m.AddTo("rcpt2@example.com"); err != nil {
+		t.Fatalf("failed to add TO address: %s", err)
+	}
+	if err := c.DialAndSend(m); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+	if len(testMessages(s)) != 1 {
+		t.Errorf("expected 1 delivered message, got: %d", len(testMessages(s)))
+	}
+
+	s.RejectRcpt("rcpt2@example.com", "550 5.1.1 No such user")
+	err := c.DialAndSend(m)
+	if err == nil {
+		t.Fatalf("sending mail with rejected recipient was supposed to fail but didn't")
+	}
+	var se *SendError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected *SendError type as returned error, but didn't")
+	}
+	if se.Reason != ErrSMTPRcptTo {
+		t.Errorf("expected ErrSMTPRcptTo as reason, got: %s", se.Reason)
+	}
+	if len(se.rcpt) != 1 || se.rcpt[0] != "rcpt2@example.com" {
+		t.Errorf("expected rcpt2@example.com as affected recipient, got: %v", se.rcpt)
+	}
+	if len(testMessages(s)) != 1 {
+		t.Errorf("expected no additional delivered message, got: %d", len(testMessages(s)))
+	}
+
+	// The pipelined DATA command was accepted, so the connection has to be re-established
+	var data, ehlo int
+	for _, cmd := range s.Commands() {
+		switch {
+		case cmd == "DATA":
+			data++
+		case strings.HasPrefix(cmd, "EHLO "):
+			ehlo++
+		}
+	}
+	if data != 2 || ehlo != 3 {
+		t.Errorf("expected 2 pipelined DATA commands and 3 connections, got: %d/%d", data, ehlo)
+	}
+}
+
+// TestClient_Send_rcptErrors tests the per-message and per-recipient errors of a Send
+// operation with multiple messages
+func TestClient_Send_rcptErrors(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("PIPELINING"))
+	s.RejectRcpt("unknown@example.com", "550 5.1.1 No such user")
+	s.RejectRcpt("full@example.com", "452 4.2.2 Mailbox full")
+	c := testClient(t, s)
+	m1 := testMsg(t)
+	if err := m1.AddTo("unknown@example.com"); err != nil {
+		t.Fatalf("failed to add TO address: %s", err)
+	}
+	if err := m1.AddTo("full@example.com"); err != nil {
+		t.Fatalf("failed to add TO address: %s", err)
+	}
+	m2 := testMsg(t)
+	if err := c.DialAndSend(m1, m2); err == nil {
+		t.Fatalf("sending mail with rejected recipients was supposed to fail but didn't")
+	}
+	if len(testMessages(s)) != 1 {
+		t.Errorf("expected the second message to be delivered, got %d messages", len(testMessages(s)))
+	}
+	if m2.HasSendError() {
+		t.Errorf("second message is not expected to have a SendError: %s", m2.SendError())
+	}
+	var se *SendError
+	if !errors.As(m1.SendError(), &se) {
+		t.Fatalf("expected *SendError type as SendError of the first message")
+	}
+	if len(se.Rcpts()) != 2 {
+		t.Errorf("expected 2 affected recipients, got: %v", se.Rcpts())
+	}
+	want := []RcptError{
+		{Rcpt: "unknown@example.com", Code: 550, EnhancedCode: "5.1.1", Msg: "No such user"},
+		{Rcpt: "full@example.com", Code: 452, EnhancedCode: "4.2.2", Msg: "Mailbox full"},
+	}
+	rel := se.RcptErrors()
+	if len(rel) != len(want) {
+		t.Fatalf("expected %d RcptErrors, got: %d", len(want), len(rel))
+	}
+	for i := range want {
+		if *rel[i] != want[i] {
+			t.Errorf("RcptError mismatch. Expected: %+v, got: %+v", want[i], *rel[i])
+		}
+	}
+	if rel[0].IsTemp() || !rel[1].IsTemp() {
+		t.Errorf("RcptError.IsTemp returned unexpected results")
+	}
+}
+
+// TestClient_Send_withRetry tests the retry policy of the Client
+func TestClient_Send_withRetry(t *testing.T) {
+	if _, err := NewClient("127.0.0.1", WithRetry(0, time.Second)); !errors.Is(err, ErrInvalidRetryPolicy) {
+		t.Errorf("WithRetry(0) was expected to fail with %q, got: %s", ErrInvalidRetryPolicy, err)
+	}
+	if _, err := NewClient("127.0.0.1", WithRetry(3, -1)); !errors.Is(err, ErrInvalidRetryPolicy) {
+		t.Errorf("WithRetry with negative backoff was expected to fail with %q, got: %s",
+			ErrInvalidRetryPolicy, err)
+	}
+
+	rcptCmds := func(s *smtptest.Server) int {
+		n := 0
+		for _, cmd := range s.Commands() {
+			if strings.HasPrefix(cmd, "RCPT TO:") {
+				n++
+			}
+		}
+		return n
+	}
+	t.Run("greylisted", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		s.GreylistRcpt("rcpt@example.com", 2)
+		c := testClient(t, s, WithRetry(3, time.Millisecond*10))
+		st := time.Now()
+		if err := c.DialAndSend(testMsg(t)); err != nil {
+			t.Fatalf("failed to send mail with retries: %s", err)
+		}
+		if d := time.Since(st); d < time.Millisecond*30 {
+			t.Errorf("expected exponential backoff of at least 30ms, got: %s", d)
+		}
+		if n := rcptCmds(s); n != 3 {
+			t.Errorf("expected 3 delivery attempts, got: %d", n)
+		}
+		if len(testMessages(s)) != 1 {
+			t.Errorf("expected 1 delivered message, got: %d", len(testMessages(s)))
+		}
+	})
+	t.Run("attempts exceeded", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		s.GreylistRcpt("rcpt@example.com", 5)
+		c := testClient(t, s, WithRetry(2, 0))
+		err := c.DialAndSend(testMsg(t))
+		var se *SendError
+		if !errors.As(err, &se) || !se.IsTemp() {
+			t.Errorf("expected temporary SendError after retries, got: %s", err)
+		}
+		if n := rcptCmds(s); n != 2 {
+			t.Errorf("expected 2 delivery attempts, got: %d", n)
+		}
+	})
+	t.Run("permanent error", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		s.RejectRcpt("rcpt@example.com", "550 5.1.1 No such user")
+		c := testClient(t, s, WithRetry(3, 0))
+		if err := c.DialAndSend(testMsg(t)); err == nil {
+			t.Errorf("sending mail with rejected recipient was supposed to fail but didn't")
+		}
+		if n := rcptCmds(s); n != 1 {
+			t.Errorf("expected permanent errors not to be retried, got %d attempts", n)
+		}
+	})
+	t.Run("connection reset", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		c := testClient(t, s, WithRetry(2, 0))
+		if err := c.DialWithContext(context.Background()); err != nil {
+			t.Fatalf("failed to dial: %s", err)
+		}
+		s.DropConnections()
+		if err := c.Send(testMsg(t)); err != nil {
+			t.Errorf("failed to send mail after connection reset: %s", err)
+		}
+		if len(testMessages(s)) != 1 {
+			t.Errorf("expected 1 delivered message, got: %d", len(testMessages(s)))
+		}
+	})
+	t.Run("reconnect failed", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		dials := 0
+		df := func(ctx context.Context, n, a string) (net.Conn, error) {
+			dials++
+			if dials == 2 {
+				return nil, errors.New("connection refused")
+			}
+			nd := net.Dialer{}
+			return nd.DialContext(ctx, n, a)
+		}
+		sends := 0
+		c := testClient(t, s, WithRetry(3, 0), WithDialContextFunc(df), WithOnSend(func(*Msg) {
+			sends++
+			if sends == 1 {
+				s.DropConnections()
+			}
+		}))
+		if err := c.DialWithContext(context.Background()); err != nil {
+			t.Fatalf("failed to dial: %s", err)
+		}
+		if err := c.Send(testMsg(t)); err != nil {
+			t.Errorf("failed to send mail after failed reconnect: %s", err)
+		}
+		if dials != 3 {
+			t.Errorf("expected 3 dials, got: %d", dials)
+		}
+		if len(testMessages(s)) != 1 {
+			t.Errorf("expected 1 delivered message, got: %d", len(testMessages(s)))
+		}
+	})
+	t.Run("context cancelled", func(t *testing.T) {
+		s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+		s.GreylistRcpt("rcpt@example.com", 5)
+		c := testClient(t, s, WithRetry(3, time.Hour))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancel()
+		st := time.Now()
+		err := c.SendWithContext(ctx, testMsg(t))
+		if err == nil {
+			t.Fatalf("sending mail with cancelled retries was supposed to fail but didn't")
+		}
+		if d := time.Since(st); d > time.Second*5 {
+			t.Errorf("expected the backoff to stop when the context is cancelled, took: %s", d)
+		}
+		if n := rcptCmds(s); n != 1 {
+			t.Errorf("expected 1 delivery attempt, got: %d", n)
+		}
+	})
+}
+
+// TestClient_Send_withRateLimit tests the rate limiting of the Client
+func TestClient_Send_withRateLimit(t *testing.T) {
+	if _, err := NewClient("127.0.0.1", WithRateLimit(0, time.Second)); !errors.Is(err, ErrInvalidRateLimit) {
+		t.Errorf("WithRateLimit(0) was expected to fail with %q, got: %s", ErrInvalidRateLimit, err)
+	}
+	if _, err := NewClient("127.0.0.1", WithRateLimit(1, 0)); !errors.Is(err, ErrInvalidRateLimit) {
+		t.Errorf("WithRateLimit with zero period was expected to fail with %q, got: %s",
+			ErrInvalidRateLimit, err)
+	}
+
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s, WithRateLimit(2, time.Millisecond*50))
+	st := time.Now()
+	if err := c.DialAndSend(testMsg(t), testMsg(t), testMsg(t), testMsg(t), testMsg(t)); err != nil {
+		t.Fatalf("failed to send mails: %s", err)
+	}
+	if d := time.Since(st); d < time.Millisecond*100 {
+		t.Errorf("5 messages with a limit of 2 per 50ms were sent within %s", d)
+	}
+	if len(testMessages(s)) != 5 {
+		t.Errorf("expected 5 delivered messages, got: %d", len(testMessages(s)))
+	}
+}
+
+// TestClient_DialWithContext_withDialContextFunc tests connecting to the SMTP server with
+// a custom DialContextFunc
+func TestClient_DialWithContext_withDialContextFunc(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	var addrs []string
+	df := func(ctx context.Context, n, a string) (net.Conn, error) {
+		addrs = append(addrs, a)
+		nd := net.Dialer{}
+		return nd.DialContext(ctx, n, a)
+	}
+	c := testClient(t, s, WithDialContextFunc(df))
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail with custom dialer: %s", err)
+	}
+	if len(addrs) != 1 || addrs[0] != c.ServerAddr() {
+		t.Errorf("expected custom dialer to be called for %s, got: %v", c.ServerAddr(), addrs)
+	}
+
+	ef := func(context.Context, string, string) (net.Conn, error) {
+		return nil, errors.New("proxy refused connection")
+	}
+	c = testClient(t, s, WithDialContextFunc(ef))
+	if err := c.DialWithContext(context.Background()); err == nil ||
+		!strings.Contains(err.Error(), "proxy refused connection") {
+		t.Errorf("expected dial error of custom dialer, got: %s", err)
+	}
+
+	k, crt := testSMIMECert(t, testRSAKey(t))
+	tc := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{crt.Raw}, PrivateKey: k}}}
+	tl, err := tls.Listen("tcp", "127.0.0.1:0", tc)
+	if err != nil {
+		t.Fatalf("failed to start TLS listener: %s", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	// The TLS listener terminates the implicit TLS and forwards the session to the server
+	go func() {
+		for {
+			co, err := tl.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = co.Close() }()
+				so, err := net.Dial("tcp", s.Addr())
+				if err != nil {
+					return
+				}
+				defer func() { _ = so.Close() }()
+				go func() {
+					_, _ = io.Copy(so, co)
+					_ = so.Close()
+				}()
+				_, _ = io.Copy(co, so)
+			}()
+		}
+	}()
+	sf := func(ctx context.Context, n, _ string) (net.Conn, error) {
+		nd := net.Dialer{}
+		return nd.DialContext(ctx, n, tl.Addr().String())
+	}
+	c = testClient(t, s, WithDialContextFunc(sf), WithSSL(),
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail with custom dialer and SSL: %s", err)
+	}
+	if len(testMessages(s)) != 2 {
+		t.Errorf("expected 2 delivered messages, got: %d", len(testMessages(s)))
+	}
+}
+
+// TestClient_Send_withEnvelopeFrom tests that the envelope FROM address is used for the
+// MAIL FROM command while the From header stays untouched
+func TestClient_Send_withEnvelopeFrom(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s)
+	m := testMsg(t)
+	if err := m.EnvelopeFrom("bounce+rcpt=example.com@bounces.example.com"); err != nil {
+		t.Fatalf("failed to set envelope FROM address: %s", err)
+	}
+	if err := c.DialAndSend(m); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+	if !hasCommand(s, "MAIL FROM:<bounce+rcpt=example.com@bounces.example.com>") {
+		t.Errorf("expected envelope FROM address in MAIL FROM command, got: %v", s.Commands())
+	}
+	ml := testMessages(s)
+	if len(ml) != 1 {
+		t.Fatalf("expected 1 delivered message, got: %d", len(ml))
+	}
+	if !strings.Contains(ml[0], "From: <sender@example.com>\r\n") {
+		t.Errorf("expected From header with header FROM address, got: %s", ml[0])
+	}
+	if strings.Contains(ml[0], "bounces.example.com") {
+		t.Errorf("envelope FROM address is not expected in the message")
+	}
+}
+
+// TestWithChunkSize tests the WithChunkSize method for the Client object
+func TestWithChunkSize(t *testing.T) {
+	c, err := NewClient(DefaultHost, WithChunkSize(1024))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	if !c.chunking {
+		t.Errorf("WithChunkSize failed. c.chunking expected to be: %t, got: %t", true, c.chunking)
+	}
+	if c.chunksize != 1024 {
+		t.Errorf("WithChunkSize failed. c.chunksize expected to be: %d, got: %d", 1024, c.chunksize)
+	}
+	if _, err := NewClient(DefaultHost, WithChunkSize(0)); !errors.Is(err, ErrInvalidChunkSize) {
+		t.Errorf("WithChunkSize with invalid size was supposed to fail with ErrInvalidChunkSize, got: %s", err)
+	}
+}
+
+// TestClient_Send_withChunking tests sending a mail via BDAT to a server that supports CHUNKING
+func TestClient_Send_withChunking(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  []string
+		bdat bool
+	}{
+		{"Server supports CHUNKING", []string{"CHUNKING"}, true},
+		{"Server does not support CHUNKING", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := smtptest.NewTestServer(t, smtptest.WithExtensions(tt.ext...))
+			c := testClient(t, s, WithChunkSize(64))
+			m := testMsg(t)
+			m.SetBodyString(TypeTextPlain, "This is a test body\n.\nwith a single dot line\n")
+			if err := c.DialAndSend(m); err != nil {
+				t.Fatalf("failed to send mail: %s", err)
+			}
+			hasBdat := false
+			for _, cmd := range s.Commands() {
+				if strings.HasPrefix(cmd, "BDAT") {
+					hasBdat = true
+				}
+			}
+			if hasBdat != tt.bdat {
+				t.Errorf("expected BDAT usage to be %t, got: %v", tt.bdat, s.Commands())
+			}
+			ml := testMessages(s)
+			if len(ml) != 1 {
+				t.Fatalf("expected 1 delivered message, got: %d", len(ml))
+			}
+			if !strings.Contains(ml[0], "\r\n.\r\n") {
+				t.Errorf("expected single dot line in message content, got: %s", ml[0])
+			}
+			if strings.Contains(strings.ReplaceAll(ml[0], "\r\n", ""), "\n") {
+				t.Errorf("message content contains bare LF line endings: %q", ml[0])
+			}
+		})
+	}
+}
+
+// TestClient_Send_8BitMIME tests sending an unencoded 8bit message to servers with and
+// without 8BITMIME support
+func TestClient_Send_8BitMIME(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  []string
+		opts []Option
+		mail string
+		dg   bool
+		sf   bool
+	}{
+		{
+			"Server supports 8BITMIME", []string{"8BITMIME"}, nil,
+			"MAIL FROM:<sender@example.com> BODY=8BITMIME", false, false,
+		},
+		{
+			"Server does not support 8BITMIME", nil, nil,
+			"MAIL FROM:<sender@example.com>", true, false,
+		},
+		{
+			"Server does not support 8BITMIME without downgrade", nil,
+			[]Option{Without8BitDowngrade()}, "", false, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := smtptest.NewTestServer(t, smtptest.WithExtensions(tt.ext...))
+			c := testClient(t, s, tt.opts...)
+			m := testMsg(t)
+			m.SetEncoding(NoEncoding)
+			m.SetBodyString(TypeTextPlain, "Grüße aus Köln")
+			m.AttachReader("test.txt", strings.NewReader("Grüße"), WithFileEncoding(NoEncoding))
+			err := c.DialAndSend(m)
+			if err != nil && !tt.sf {
+				t.Fatalf("failed to send mail: %s", err)
+			}
+			if err == nil && tt.sf {
+				t.Fatalf("sending mail was supposed to fail, but didn't")
+			}
+			if tt.sf {
+				if !errors.Is(err, &SendError{Reason: ErrNoUnencoded}) {
+					t.Errorf("expected ErrNoUnencoded, got: %s", err)
+				}
+				return
+			}
+			if !hasCommand(s, tt.mail) {
+				t.Errorf("expected command %q, got: %v", tt.mail, s.Commands())
+			}
+			if m.encoding != NoEncoding || m.GetParts()[0].enc != NoEncoding ||
+				m.GetAttachments()[0].Enc != NoEncoding {
+				t.Errorf("sending was not expected to change the encoding of the Msg")
+			}
+			ml := testMessages(s)
+			if len(ml) != 1 {
+				t.Fatalf("expected 1 delivered message, got: %d", len(ml))
+			}
+			if tt.dg {
+				if strings.Contains(ml[0], "Grüße") {
+					t.Errorf("downgraded message contains 8bit content: %s", ml[0])
+				}
+				if strings.Contains(ml[0], "Content-Transfer-Encoding: 8bit") {
+					t.Errorf("downgraded message contains 8bit transfer encoding: %s", ml[0])
+				}
+			}
+		})
+	}
+}
+
+// TestClient_Send_SMTPUTF8 tests sending a mail with internationalized addresses to servers
+// with and without SMTPUTF8 support
+func TestClient_Send_SMTPUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  []string
+		from string
+		to   string
+		mail string
+		rcpt string
+		hdr  string
+		sf   bool
+	}{
+		{
+			"SMTPUTF8 with UTF-8 local part", []string{"SMTPUTF8"}, "jörg@bücher.de", "用户@例子.测试",
+			"MAIL FROM:<jörg@bücher.de> SMTPUTF8", "RCPT TO:<用户@例子.测试>", "", false,
+		},
+		{
+			"No SMTPUTF8 with IDN domain", nil, "toni@bücher.de", "toni@例子.测试",
+			"MAIL FROM:<toni@xn--bcher-kva.de>", "RCPT TO:<toni@xn--fsqu00a.xn--0zwm56d>",
+			"To: <toni@xn--fsqu00a.xn--0zwm56d>", false,
+		},
+		{"No SMTPUTF8 with UTF-8 local part", nil, "jörg@bücher.de", "toni@example.com", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := smtptest.NewTestServer(t, smtptest.WithExtensions(tt.ext...))
+			c := testClient(t, s)
+			m := testMsg(t)
+			if err := m.From(tt.from); err != nil {
+				t.Fatalf("failed to set FROM address: %s", err)
+			}
+			if err := m.To(tt.to); err != nil {
+				t.Fatalf("failed to set TO address: %s", err)
+			}
+			err := c.DialAndSend(m)
+			if tt.sf {
+				if !errors.Is(err, &SendError{Reason: ErrNoSMTPUTF8}) {
+					t.Errorf("expected ErrNoSMTPUTF8, got: %s", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to send mail: %s", err)
+			}
+			if !hasCommand(s, tt.mail) {
+				t.Errorf("expected command %q, got: %v", tt.mail, s.Commands())
+			}
+			if !hasCommand(s, tt.rcpt) {
+				t.Errorf("expected command %q, got: %v", tt.rcpt, s.Commands())
+			}
+			if ml := testMessages(s); tt.hdr != "" &&
+				(len(ml) != 1 || !strings.Contains(ml[0], tt.hdr) || strings.Contains(ml[0], "bücher.de")) {
+				t.Errorf("expected address headers with A-label domains (%q), got: %v", tt.hdr, ml)
+			}
+		})
+	}
+}
+
+// TestClient_Send_SMTPUTF8_unchanged tests that the conversion of the address headers for a
+// server without SMTPUTF8 support does not alter the Msg
+func TestClient_Send_SMTPUTF8_unchanged(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	c := testClient(t, s)
+	m := testMsg(t)
+	if err := m.To("toni@bücher.de"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.DialAndSend(m); err != nil {
+			t.Fatalf("failed to send mail: %s", err)
+		}
+		if tl := m.GetTo(); len(tl) != 1 || tl[0].Address != "toni@bücher.de" {
+			t.Errorf("expected TO address of the Msg to be unchanged, got: %v", tl)
+		}
+	}
+	ml := testMessages(s)
+	if len(ml) != 2 {
+		t.Fatalf("expected 2 delivered messages, got: %d", len(ml))
+	}
+	for _, msg := range ml {
+		if !strings.Contains(msg, "To: <toni@xn--bcher-kva.de>") {
+			t.Errorf("expected address header with A-label domain, got: %s", msg)
+		}
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), "bücher.de") {
+		t.Errorf("expected WriteTo to render the original address, got: %s", buf.String())
+	}
+}
+
+// TestClient_Send_SMTPUTF8_8BitDowngrade tests the conversion of the address headers if the
+// Msg is downgraded for a server without 8BITMIME and SMTPUTF8 support
+func TestClient_Send_SMTPUTF8_8BitDowngrade(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s)
+	m := testMsg(t)
+	WithEncoding(NoEncoding)(m)
+	m.SetBodyString(TypeTextPlain, "Grüße aus Köln")
+	if err := m.To("toni@bücher.de"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	if err := c.DialAndSend(m); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+	if !hasCommand(s, "RCPT TO:<toni@xn--bcher-kva.de>") {
+		t.Errorf("expected RCPT TO with A-label domain, got: %v", s.Commands())
+	}
+	ml := testMessages(s)
+	if len(ml) != 1 || !strings.Contains(ml[0], "To: <toni@xn--bcher-kva.de>") ||
+		strings.Contains(ml[0], "bücher.de") {
+		t.Errorf("expected address header with A-label domain, got: %v", ml)
+	}
+	if len(ml) == 1 && strings.Contains(ml[0], "Content-Transfer-Encoding: 8bit") {
+		t.Errorf("expected downgraded message without 8bit encoding, got: %s", ml[0])
+	}
+	if tl := m.GetTo(); len(tl) != 1 || tl[0].Address != "toni@bücher.de" {
+		t.Errorf("expected TO address of the Msg to be unchanged, got: %v", tl)
+	}
+}
+
 // TestWithoutNoop tests the WithoutNoop method for the Client object
 func TestWithoutNoop(t *testing.T) {
 	c, err := NewClient(DefaultHost, WithoutNoop())
@@ -1240,3 +1943,681 @@ func getTestConnectionWithDSN(auth bool) (*Client, error) {
 	}
 	return c, nil
 }
+
+// TestClient_Send_withKeepAlive tests the keep-alive mode of the Client
+func TestClient_Send_withKeepAlive(t *testing.T) {
+	if _, err := NewClient("127.0.0.1", WithKeepAlive(0)); !errors.Is(err, ErrInvalidKeepAlive) {
+		t.Errorf("WithKeepAlive(0) was expected to fail with %q, got: %s", ErrInvalidKeepAlive, err)
+	}
+
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	c := testClient(t, s, WithKeepAlive(time.Millisecond*20))
+	if err := c.Send(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail without prior dial: %s", err)
+	}
+	n := len(s.Commands())
+	time.Sleep(time.Millisecond * 100)
+	noop := 0
+	for _, cmd := range s.Commands()[n:] {
+		if cmd == "NOOP" {
+			noop++
+		}
+	}
+	if noop < 2 {
+		t.Errorf("expected keep-alive NOOP commands on idle connection, got: %d", noop)
+	}
+	if err := c.Send(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail on idle connection: %s", err)
+	}
+	ehlo := 0
+	for _, cmd := range s.Commands() {
+		if strings.HasPrefix(cmd, "EHLO") {
+			ehlo++
+		}
+	}
+	if ehlo != 1 {
+		t.Errorf("expected the connection to be reused, got %d EHLO commands", ehlo)
+	}
+
+	s.DropConnections()
+	if err := c.Send(testMsg(t)); err != nil {
+		t.Fatalf("failed to send mail after connection was dropped: %s", err)
+	}
+	if l := len(testMessages(s)); l != 3 {
+		t.Errorf("expected 3 delivered messages, got: %d", l)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("failed to close connection: %s", err)
+	}
+	if !hasCommand(s, "QUIT") {
+		t.Errorf("expected QUIT command on Close")
+	}
+}
+
+// testClient returns a new Client that is configured to connect to the given smtptest.Server
+func testClient(t *testing.T, s *smtptest.Server, o ...Option) *Client {
+	t.Helper()
+	o = append([]Option{WithPort(s.Port()), WithTLSPolicy(NoTLS)}, o...)
+	c, err := NewClient(s.Host(), o...)
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	return c
+}
+
+// testMessages returns the contents of all messages the smtptest.Server received so far
+func testMessages(s *smtptest.Server) []string {
+	var ml []string
+	for _, m := range s.Messages() {
+		ml = append(ml, string(m.Data))
+	}
+	return ml
+}
+
+// hasCommand returns true if the smtptest.Server received the given command
+func hasCommand(s *smtptest.Server, cmd string) bool {
+	for _, c := range s.Commands() {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// testMsg returns a simple Msg that can be used for Client tests with the smtptest.Server
+func testMsg(t *testing.T) *Msg {
+	t.Helper()
+	m := NewMsg()
+	if err := m.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set FROM address: %s", err)
+	}
+	if err := m.To("rcpt@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	m.Subject("This is a test subject")
+	m.SetBodyString(TypeTextPlain, "This is a test body")
+	return m
+}
+
+// TestClient_SendWithContext tests the Sender implementation of the Client
+func TestClient_SendWithContext(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	var sd Sender = testClient(t, s)
+	for i := 0; i < 2; i++ {
+		if err := sd.SendWithContext(context.Background(), testMsg(t)); err != nil {
+			t.Fatalf("SendWithContext failed: %s", err)
+		}
+	}
+	if l := len(testMessages(s)); l != 2 {
+		t.Errorf("expected 2 delivered messages, got: %d", l)
+	}
+	quit := 0
+	for _, cmd := range s.Commands() {
+		if cmd == "QUIT" {
+			quit++
+		}
+	}
+	if quit != 2 {
+		t.Errorf("expected the connection to be closed after each send, got %d QUIT commands", quit)
+	}
+
+	ctx, cfn := context.WithCancel(context.Background())
+	cfn()
+	if err := sd.SendWithContext(ctx, testMsg(t)); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendWithContext with canceled context was expected to fail, got: %v", err)
+	}
+}
+
+// TestClient_SendConcurrently tests the concurrent delivery of messages with a pool of workers
+func TestClient_SendConcurrently(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	s.RejectRcpt("rejected@example.com", "550 5.1.1 User unknown")
+	c := testClient(t, s)
+
+	var ml []*Msg
+	for i := 0; i < 10; i++ {
+		m := testMsg(t)
+		m.Subject(fmt.Sprintf("Message %d", i))
+		ml = append(ml, m)
+	}
+	if err := ml[3].To("rejected@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	err := c.SendConcurrently(context.Background(), ml, 3)
+	if err == nil {
+		t.Fatal("SendConcurrently was expected to fail for the rejected recipient")
+	}
+	var se *SendError
+	if !errors.As(err, &se) || se.Reason != ErrSMTPRcptTo {
+		t.Errorf("SendConcurrently returned unexpected error: %s", err)
+	}
+	for i, m := range ml {
+		if m.HasSendError() != (i == 3) {
+			t.Errorf("SendConcurrently failed. Unexpected send error state for message %d: %v", i,
+				m.SendError())
+		}
+	}
+	if n := len(testMessages(s)); n != 9 {
+		t.Errorf("SendConcurrently failed. Expected 9 delivered messages, got: %d", n)
+	}
+	if n := strings.Count(strings.Join(s.Commands(), "\n"), "QUIT"); n != 3 {
+		t.Errorf("SendConcurrently failed. Expected 3 worker connections to be closed, got: %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ml = []*Msg{testMsg(t), testMsg(t)}
+	if err := c.SendConcurrently(ctx, ml, 2); err == nil {
+		t.Error("SendConcurrently with cancelled context was expected to fail")
+	}
+	for _, m := range ml {
+		if !m.HasSendError() || !strings.Contains(m.SendError().Error(), context.Canceled.Error()) {
+			t.Errorf("SendConcurrently with cancelled context was expected to mark the message as "+
+				"failed, got: %v", m.SendError())
+		}
+	}
+}
+
+// TestClient_SendConcurrently_failover tests that the workers rebuild the SMTP AUTH of the
+// Client for the backup server if they fail over
+func TestClient_SendConcurrently_failover(t *testing.T) {
+	primary := smtptest.NewTestServer(t, smtptest.WithAuth("user", "secret"))
+	backup := smtptest.NewTestServer(t, smtptest.WithAuth("user", "secret"))
+	var mu sync.Mutex
+	down := false
+	// Both host names are treated as local hosts, so that PLAIN auth does not require TLS
+	df := func(ctx context.Context, n, a string) (net.Conn, error) {
+		mu.Lock()
+		pd := down
+		mu.Unlock()
+		h, _, _ := net.SplitHostPort(a)
+		switch {
+		case h == "localhost" && pd:
+			return nil, errors.New("connection refused")
+		case h == "localhost":
+			return (&net.Dialer{}).DialContext(ctx, n, primary.Addr())
+		}
+		return (&net.Dialer{}).DialContext(ctx, n, backup.Addr())
+	}
+	c, err := NewClient("localhost", WithTLSPolicy(NoTLS), WithDialContextFunc(df),
+		WithFailover("127.0.0.1"), WithSMTPAuth(SMTPAuthPlain), WithUsername("user"),
+		WithPassword("secret"))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend to primary server failed: %s", err)
+	}
+
+	mu.Lock()
+	down = true
+	mu.Unlock()
+	ml := []*Msg{testMsg(t), testMsg(t), testMsg(t)}
+	if err := c.SendConcurrently(context.Background(), ml, 2); err != nil {
+		t.Fatalf("SendConcurrently with failover failed: %s", err)
+	}
+	if n := len(testMessages(backup)); n != 3 {
+		t.Errorf("SendConcurrently failed. Expected 3 messages on the backup server, got: %d", n)
+	}
+}
+
+// TestClient_hooks tests the OnSend, OnDelivered and OnError callbacks of the Client
+func TestClient_hooks(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	s.GreylistRcpt("grey@example.com", 1)
+	s.RejectRcpt("rejected@example.com", "550 5.1.1 User unknown")
+	var sent, delivered []string
+	var failed []error
+	c := testClient(t, s, WithRetry(2, 0),
+		WithOnSend(func(m *Msg) { sent = append(sent, m.GetToString()[0]) }),
+		WithOnDelivered(func(m *Msg) { delivered = append(delivered, m.GetToString()[0]) }),
+		WithOnError(func(m *Msg, err error) { failed = append(failed, err) }),
+	)
+
+	gm := testMsg(t)
+	if err := gm.To("grey@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	rm := testMsg(t)
+	if err := rm.To("rejected@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	if err := c.DialAndSend(gm, rm); err == nil {
+		t.Fatal("DialAndSend was expected to fail for the rejected recipient")
+	}
+	if es := "<grey@example.com>,<grey@example.com>,<rejected@example.com>"; strings.Join(sent, ",") != es {
+		t.Errorf("OnSend callback failed. Expected: %s, got: %s", es, strings.Join(sent, ","))
+	}
+	if len(delivered) != 1 || delivered[0] != "<grey@example.com>" {
+		t.Errorf("OnDelivered callback failed. Expected greylisted message, got: %v", delivered)
+	}
+	var se *SendError
+	if len(failed) != 1 || !errors.As(failed[0], &se) || se.Reason != ErrSMTPRcptTo {
+		t.Errorf("OnError callback failed. Expected RCPT TO error, got: %v", failed)
+	}
+}
+
+// TestClient_RequireTLS tests the REQUIRETLS parameter of the MAIL FROM command
+func TestClient_RequireTLS(t *testing.T) {
+	tlssrv := smtptest.NewTestServer(t, smtptest.WithSTARTTLS(nil), smtptest.WithExtensions("REQUIRETLS"))
+	plainsrv := smtptest.NewTestServer(t, smtptest.WithExtensions("REQUIRETLS"))
+	notlssrv := smtptest.NewTestServer(t, smtptest.WithSTARTTLS(nil))
+	tests := []struct {
+		name  string
+		srv   *smtptest.Server
+		tlsno bool
+		want  string
+		sf    bool
+	}{
+		{"REQUIRETLS via STARTTLS", tlssrv, false, "MAIL FROM:<sender@example.com> REQUIRETLS", false},
+		{"REQUIRETLS without STARTTLS", plainsrv, false, "", true},
+		{"REQUIRETLS not supported", notlssrv, false, "", true},
+		{"TLS-Required: No", plainsrv, true, "MAIL FROM:<sender@example.com>", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.srv.Reset()
+			o := []Option{WithPort(tt.srv.Port()), WithTLSPolicy(NoTLS), WithRequireTLS()}
+			if tc := tt.srv.ClientTLSConfig(); tc != nil {
+				o = append(o, WithTLSPolicy(TLSMandatory), WithTLSConfig(tc))
+			}
+			c, err := NewClient(tt.srv.Host(), o...)
+			if err != nil {
+				t.Fatalf("failed to create new client: %s", err)
+			}
+			m := testMsg(t)
+			if tt.tlsno {
+				m.SetTLSRequiredNo()
+			}
+			err = c.DialAndSend(m)
+			if tt.sf {
+				var se *SendError
+				if !errors.As(err, &se) || se.Reason != ErrNoRequireTLS || se.IsTemp() {
+					t.Errorf("DialAndSend was expected to fail with ErrNoRequireTLS, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DialAndSend failed: %s", err)
+			}
+			tt.srv.AssertCommand(t, tt.want)
+		})
+	}
+}
+
+// TestClient_failover tests the failover to fallback relays and the preference of recovered
+// relays
+func TestClient_failover(t *testing.T) {
+	primary := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	backup := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	var mu sync.Mutex
+	down := true
+	var dials []string
+	df := func(ctx context.Context, n, a string) (net.Conn, error) {
+		mu.Lock()
+		dials = append(dials, a)
+		pd := down
+		mu.Unlock()
+		h, _, _ := net.SplitHostPort(a)
+		switch {
+		case h == "primary.test" && pd:
+			return nil, errors.New("connection refused")
+		case h == "primary.test":
+			return (&net.Dialer{}).DialContext(ctx, n, primary.Addr())
+		}
+		return (&net.Dialer{}).DialContext(ctx, n, backup.Addr())
+	}
+	c, err := NewClient("primary.test", WithTLSPolicy(NoTLS), WithDialContextFunc(df),
+		WithFailover("backup.test"), WithFailoverCooldown(time.Minute))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	now := time.Now()
+	c.failover.now = func() time.Time { return now }
+
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	if len(testMessages(backup)) != 1 {
+		t.Errorf("failover failed. Expected message to be delivered via backup relay")
+	}
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	if ed := "primary.test:25,backup.test:25,backup.test:25"; strings.Join(dials, ",") != ed {
+		t.Errorf("failover failed. Expected dials: %s, got: %s", ed, strings.Join(dials, ","))
+	}
+
+	// After the cooldown, the recovered primary is preferred again
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	now = now.Add(time.Minute * 2)
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	if len(testMessages(primary)) != 1 {
+		t.Errorf("failover failed. Expected message to be delivered via recovered primary relay")
+	}
+
+	// A temporary rejection by the primary fails over to the backup
+	primary.RejectRcpt("rcpt@example.com", "451 4.3.0 Try again later")
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	if len(testMessages(primary)) != 1 || len(testMessages(backup)) != 3 {
+		t.Errorf("failover failed. Expected temporary rejection to fail over to backup relay")
+	}
+
+	// A permanent rejection does not fail over
+	c.failover.markUp("primary.test")
+	primary.RejectRcpt("rcpt@example.com", "550 5.1.1 User unknown")
+	if err := c.DialAndSend(testMsg(t)); err == nil {
+		t.Error("DialAndSend was expected to fail with a permanent rejection")
+	}
+	if len(testMessages(backup)) != 3 {
+		t.Errorf("failover failed. Permanent rejections must not fail over")
+	}
+}
+
+// TestClient_WithMaxRcptsPerTransaction tests the splitting of recipients into multiple
+// SMTP transactions
+func TestClient_WithMaxRcptsPerTransaction(t *testing.T) {
+	if _, err := NewClient("127.0.0.1", WithMaxRcptsPerTransaction(0)); !errors.Is(err, ErrInvalidMaxRcpts) {
+		t.Errorf("WithMaxRcptsPerTransaction with 0 was expected to fail, got: %v", err)
+	}
+
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	s.GreylistRcpt("rcpt5@example.com", 1)
+	c := testClient(t, s, WithMaxRcptsPerTransaction(2), WithRetry(2, 0))
+	m := testMsg(t)
+	if err := m.To("rcpt1@example.com", "rcpt2@example.com", "rcpt3@example.com", "rcpt4@example.com",
+		"rcpt5@example.com"); err != nil {
+		t.Fatalf("failed to set TO addresses: %s", err)
+	}
+	if err := c.DialAndSend(m); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	ml := testMessages(s)
+	if len(ml) != 3 {
+		t.Fatalf("DialAndSend failed. Expected 3 transactions, got: %d", len(ml))
+	}
+	for _, msg := range ml {
+		if msg != ml[0] || !strings.Contains(msg, "rcpt5@example.com") {
+			t.Errorf("DialAndSend failed. Expected identical messages with all recipients in the header")
+		}
+	}
+	n := 0
+	for _, cmd := range s.Commands() {
+		if strings.HasPrefix(cmd, "MAIL FROM:") {
+			n++
+		}
+		if cmd == "RCPT TO:<rcpt1@example.com>" && n != 1 {
+			t.Errorf("DialAndSend failed. Recipients of delivered transactions must not be retried")
+		}
+	}
+	if n != 4 {
+		t.Errorf("DialAndSend failed. Expected 4 MAIL FROM commands including the retry, got: %d", n)
+	}
+}
+
+// TestClient_serverResponse tests that the server response to the mail data is recorded in
+// the Msg
+func TestClient_serverResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		rcpt []string
+		want string
+	}{
+		{"DATA", nil, []string{"rcpt@example.com"}, "2.0.0 Ok: queued"},
+		{"BDAT", []Option{WithChunking()}, []string{"rcpt@example.com"}, "2.0.0 Ok: queued"},
+		{
+			"Multiple transactions", []Option{WithMaxRcptsPerTransaction(1)},
+			[]string{"rcpt1@example.com", "rcpt2@example.com"},
+			"2.0.0 Ok: queued\n2.0.0 Ok: queued",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME", "CHUNKING"))
+			m := testMsg(t)
+			if err := m.To(tt.rcpt...); err != nil {
+				t.Fatalf("failed to set TO addresses: %s", err)
+			}
+			if m.ServerResponse() != "" {
+				t.Errorf("ServerResponse of unsent message was expected to be empty")
+			}
+			if err := testClient(t, s, tt.opts...).DialAndSend(m); err != nil {
+				t.Fatalf("DialAndSend failed: %s", err)
+			}
+			if r := m.ServerResponse(); r != tt.want {
+				t.Errorf("ServerResponse failed. Expected: %q, got: %q", tt.want, r)
+			}
+		})
+	}
+}
+
+// TestClient_Verify tests the VRFY and EXPN commands of the Client
+func TestClient_Verify(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"),
+		smtptest.WithMailboxes("rcpt@example.com"),
+		smtptest.WithMailingList("staff", "Alice <alice@example.com>", "Bob <bob@example.com>"))
+	c := testClient(t, s)
+	if err := c.Verify("rcpt@example.com"); !errors.Is(err, ErrNoActiveConnection) {
+		t.Errorf("Verify without connection was expected to fail, got: %v", err)
+	}
+	if err := c.DialWithContext(context.Background()); err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer func() { _ = c.Close() }()
+	if err := c.Verify("rcpt@example.com"); err != nil {
+		t.Errorf("Verify failed: %s", err)
+	}
+	if err := c.Verify("unknown@example.com"); !errors.Is(err, ErrMailboxUnavailable) {
+		t.Errorf("Verify of unknown address was expected to fail with ErrMailboxUnavailable, got: %v", err)
+	}
+	ml, err := c.Expand("staff")
+	if err != nil {
+		t.Fatalf("Expand failed: %s", err)
+	}
+	if len(ml) != 2 || ml[0] != "Alice <alice@example.com>" || ml[1] != "Bob <bob@example.com>" {
+		t.Errorf("Expand failed. Unexpected mailboxes: %q", ml)
+	}
+	if _, err := c.Expand("unknown"); err == nil || errors.Is(err, ErrEXPNNotSupported) {
+		t.Errorf("Expand of unknown list was expected to fail, got: %v", err)
+	}
+
+	// By default, the smtptest.Server refuses VRFY with 252 and does not implement EXPN
+	ts := smtptest.NewTestServer(t)
+	c, err = NewClient(ts.Host(), WithPort(ts.Port()), WithTLSPolicy(NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	if err := c.DialWithContext(context.Background()); err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	if err := c.Verify("rcpt@example.com"); !errors.Is(err, ErrVRFYNotSupported) {
+		t.Errorf("Verify was expected to fail with ErrVRFYNotSupported, got: %v", err)
+	}
+	if _, err := c.Expand("staff"); !errors.Is(err, ErrEXPNNotSupported) {
+		t.Errorf("Expand was expected to fail with ErrEXPNNotSupported, got: %v", err)
+	}
+}
+
+// TestClient_HasExtension tests the inspection of the ESMTP extensions of the server
+func TestClient_HasExtension(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME", "SIZE 10240000", "AUTH PLAIN LOGIN"))
+	c := testClient(t, s)
+	if c.HasExtension("SIZE") || c.ExtensionParam("SIZE") != "" {
+		t.Errorf("HasExtension without connection was expected to return false")
+	}
+	if err := c.DialWithContext(context.Background()); err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	defer func() { _ = c.Close() }()
+	tests := []struct {
+		ext   string
+		has   bool
+		param string
+	}{
+		{"8BITMIME", true, ""},
+		{"size", true, "10240000"},
+		{"AUTH", true, "PLAIN LOGIN"},
+		{"SMTPUTF8", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			if h := c.HasExtension(tt.ext); h != tt.has {
+				t.Errorf("HasExtension failed. Expected: %t, got: %t", tt.has, h)
+			}
+			if p := c.ExtensionParam(tt.ext); p != tt.param {
+				t.Errorf("ExtensionParam failed. Expected: %q, got: %q", tt.param, p)
+			}
+		})
+	}
+}
+
+// TestFQDN tests the detection of the FQDN of the local host
+func TestFQDN(t *testing.T) {
+	lh := func(h string) ([]string, error) {
+		switch h {
+		case "host":
+			return []string{"127.0.1.1", "192.0.2.1"}, nil
+		case "loopback":
+			return []string{"127.0.0.1"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: h, IsNotFound: true}
+	}
+	la := func(a string) ([]string, error) {
+		switch a {
+		case "127.0.0.1", "127.0.1.1":
+			return []string{"localhost.localdomain.", "localhost."}, nil
+		case "192.0.2.1":
+			return []string{"host.example.com."}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: a, IsNotFound: true}
+	}
+	tests := []struct {
+		hn   string
+		want string
+	}{
+		{"mail.example.com", "mail.example.com"},
+		{"mail.example.com.", "mail.example.com"},
+		{"host", "host.example.com"},
+		{"loopback", ""},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hn, func(t *testing.T) {
+			if n := fqdn(tt.hn, lh, la); n != tt.want {
+				t.Errorf("fqdn failed. Expected: %q, got: %q", tt.want, n)
+			}
+		})
+	}
+}
+
+// TestClient_heloLiteral tests the address literal as HELO/EHLO greeting if no FQDN of the
+// local host could be detected
+func TestClient_heloLiteral(t *testing.T) {
+	tests := []struct {
+		addr net.Addr
+		want string
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 25}, "[192.0.2.1]"},
+		{&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 25}, "[IPv6:2001:db8::1]"},
+	}
+	for _, tt := range tests {
+		if l := addrLiteral(tt.addr); l != tt.want {
+			t.Errorf("addrLiteral failed. Expected: %s, got: %s", tt.want, l)
+		}
+	}
+
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	c := testClient(t, s)
+	c.helo, c.heloLiteral = "", true
+	if err := c.DialAndSend(testMsg(t)); err != nil {
+		t.Fatalf("DialAndSend failed: %s", err)
+	}
+	if !hasCommand(s, "EHLO [127.0.0.1]") {
+		t.Errorf("Expected address literal as EHLO greeting, got: %v", s.Commands())
+	}
+}
+
+// TestClient_timeouts tests the separate timeouts for commands and the mail data
+func TestClient_timeouts(t *testing.T) {
+	for _, o := range []Option{WithDialTimeout(0), WithCommandTimeout(-1), WithDataTimeout(0)} {
+		if _, err := NewClient(DefaultHost, o); !errors.Is(err, ErrInvalidTimeout) {
+			t.Errorf("timeout option with invalid value was expected to fail, got: %v", err)
+		}
+	}
+
+	// The server takes longer than the command timeout to respond to the mail data
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start test SMTP server: %s", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			co, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = co.Close() }()
+				r := bufio.NewReader(co)
+				_, _ = co.Write([]byte("220 127.0.0.1 ESMTP slow test server\r\n"))
+				indata := false
+				for {
+					cl, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch {
+					case indata && cl == ".\r\n":
+						indata = false
+						time.Sleep(time.Millisecond * 200)
+						_, _ = co.Write([]byte("250 2.0.0 Ok\r\n"))
+					case indata:
+					case strings.HasPrefix(cl, "DATA"):
+						indata = true
+						_, _ = co.Write([]byte("354 Go ahead\r\n"))
+					case strings.HasPrefix(cl, "QUIT"):
+						_, _ = co.Write([]byte("221 2.0.0 Bye\r\n"))
+						return
+					default:
+						_, _ = co.Write([]byte("250 127.0.0.1\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	tests := []struct {
+		name string
+		dto  time.Duration
+		sf   bool
+	}{
+		{"Data timeout longer than the response", time.Second * 5, false},
+		{"Data timeout shorter than the response", time.Millisecond * 50, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient("127.0.0.1", WithPort(port), WithTLSPolicy(NoTLS),
+				WithCommandTimeout(time.Millisecond*100), WithDataTimeout(tt.dto))
+			if err != nil {
+				t.Fatalf("failed to create new client: %s", err)
+			}
+			err = c.DialAndSend(testMsg(t))
+			if !tt.sf && err != nil {
+				t.Errorf("DialAndSend failed: %s", err)
+			}
+			var se *SendError
+			if tt.sf && (!errors.As(err, &se) || se.Reason != ErrSMTPDataClose) {
+				t.Errorf("DialAndSend was expected to fail with ErrSMTPDataClose, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// cssRule is a rule of a style sheet with a selector that can be inlined
+type cssRule struct {
+	// sel is the compound selector of the rule
+	sel cssSelector
+	// decls is the list of declarations of the rule
+	decls []string
+	// order is the position of the rule in the style sheets
+	order int
+}
+
+// cssSelector is a compound selector of an optional type selector and any number of class and
+// ID selectors (e.g. "p", ".note", "td.price#total")
+type cssSelector struct {
+	tag     string
+	classes []string
+	ids     []string
+}
+
+// cssDecl is a style declaration that is inlined into an element
+type cssDecl struct {
+	prop string
+	decl string
+	imp  bool
+}
+
+// cssSkipElements is the list of elements that do not get inline styles
+var cssSkipElements = map[string]bool{
+	"html": true, "head": true, "title": true, "meta": true, "link": true, "base": true,
+	"style": true, "script": true,
+}
+
+// inlineCSSWriteFunc returns a writer function that renders the content of the given writer
+// function with the rules of its style elements inlined into the style attributes
+func inlineCSSWriteFunc(f func(io.Writer) (int64, error)) func(io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		buf := bytes.Buffer{}
+		if _, err := f(&buf); err != nil {
+			return 0, err
+		}
+		n, err := io.WriteString(w, inlineCSS(buf.String()))
+		return int64(n), err
+	}
+}
+
+// inlineCSS inlines the rules of the style elements of the given HTML into the style
+// attributes of the matching elements, since many webmail clients remove style elements.
+// Only rules with simple selectors (type, class and ID selectors and combinations of them)
+// are inlined and removed from the style elements. At-rules (e.g. media queries) and rules
+// with other selectors (e.g. descendant combinators or pseudo-classes) are kept. Style
+// elements that only contained inlined rules are removed. Existing style attributes take
+// precedence over the inlined rules, unless a declaration is marked as !important
+func inlineCSS(h string) string {
+	var rl []cssRule
+	out := strings.Builder{}
+	lh := strings.ToLower(h)
+	for i := 0; i < len(h); {
+		j := strings.IndexByte(h[i:], '<')
+		if j < 0 {
+			out.WriteString(h[i:])
+			break
+		}
+		out.WriteString(h[i : i+j])
+		i += j
+		t := h[i:]
+		switch {
+		case strings.HasPrefix(t, "<!--"):
+			e := strings.Index(t, "-->")
+			if e < 0 {
+				e = len(t) - 3
+			}
+			out.WriteString(t[:e+3])
+			i += e + 3
+			continue
+		case len(t) < 2 || !isASCIILetter(t[1]):
+			out.WriteByte('<')
+			i++
+			continue
+		}
+		n, al, _, l := scanHTMLTag(t)
+		if n != "style" && n != "script" {
+			out.WriteString(t[:l])
+			i += l
+			continue
+		}
+		e := strings.Index(lh[i+l:], "</"+n)
+		if e < 0 {
+			e = len(h) - i - l
+		}
+		if n == "script" || !cssInlinable(al) {
+			out.WriteString(t[:l+e])
+			i += l + e
+			continue
+		}
+		ss, srl := parseCSS(h[i+l:i+l+e], len(rl))
+		rl = append(rl, srl...)
+		i += l + e
+		if strings.TrimSpace(ss) == "" {
+			// Skip the end tag of the removed style element
+			if c := strings.IndexByte(h[i:], '>'); c >= 0 {
+				i += c + 1
+			}
+			continue
+		}
+		out.WriteString(t[:l])
+		out.WriteString(ss)
+	}
+	if len(rl) == 0 {
+		return h
+	}
+	return applyCSS(out.String(), rl)
+}
+
+// cssInlinable returns true if the style element with the given attributes applies to all
+// media and its rules can therefore be inlined
+func cssInlinable(al []htmlAttr) bool {
+	for _, a := range al {
+		if a.key == "media" {
+			m := strings.ToLower(strings.TrimSpace(a.val))
+			return m == "" || m == "all" || m == "screen"
+		}
+	}
+	return true
+}
+
+// applyCSS adds the declarations of the given rules to the style attributes of the matching
+// elements of the given HTML
+func applyCSS(h string, rl []cssRule) string {
+	out := strings.Builder{}
+	lh := strings.ToLower(h)
+	for i := 0; i < len(h); {
+		j := strings.IndexByte(h[i:], '<')
+		if j < 0 {
+			out.WriteString(h[i:])
+			break
+		}
+		out.WriteString(h[i : i+j])
+		i += j
+		t := h[i:]
+		if strings.HasPrefix(t, "<!--") {
+			e := strings.Index(t, "-->")
+			if e < 0 {
+				e = len(t) - 3
+			}
+			out.WriteString(t[:e+3])
+			i += e + 3
+			continue
+		}
+		if len(t) < 2 || !isASCIILetter(t[1]) {
+			out.WriteByte('<')
+			i++
+			continue
+		}
+		n, al, _, l := scanHTMLTag(t)
+		if n == "style" || n == "script" {
+			e := strings.Index(lh[i+l:], "</"+n)
+			if e < 0 {
+				e = len(h) - i - l
+			}
+			out.WriteString(t[:l+e])
+			i += l + e
+			continue
+		}
+		out.WriteString(styleTag(t[:l], n, al, rl))
+		i += l
+	}
+	return out.String()
+}
+
+// styleTag returns the given start tag with the declarations of the matching rules added to
+// its style attribute
+func styleTag(t, n string, al []htmlAttr, rl []cssRule) string {
+	if cssSkipElements[n] || !strings.HasSuffix(t, ">") {
+		return t
+	}
+	var cl, il []string
+	sa := -1
+	for i, a := range al {
+		switch a.key {
+		case "class":
+			cl = strings.Fields(a.val)
+		case "id":
+			il = append(il, a.val)
+		case "style":
+			sa = i
+		}
+	}
+	var ml []cssRule
+	for _, r := range rl {
+		if r.sel.matches(n, cl, il) {
+			ml = append(ml, r)
+		}
+	}
+	if len(ml) == 0 {
+		return t
+	}
+	sort.SliceStable(ml, func(i, j int) bool {
+		si, sj := ml[i].sel.specificity(), ml[j].sel.specificity()
+		if si != sj {
+			return si < sj
+		}
+		return ml[i].order < ml[j].order
+	})
+
+	var dl []cssDecl
+	for _, r := range ml {
+		for _, d := range r.decls {
+			dl = append(dl, newCSSDecl(d))
+		}
+	}
+	if sa >= 0 {
+		for _, d := range splitCSS(al[sa].val, ';') {
+			dl = append(dl, newCSSDecl(d))
+		}
+	}
+	// Important declarations take precedence over the normal ones
+	sort.SliceStable(dl, func(i, j int) bool { return !dl[i].imp && dl[j].imp })
+	pi := make(map[string]int)
+	var sl []string
+	for _, d := range dl {
+		if i, ok := pi[d.prop]; ok {
+			sl[i] = ""
+		}
+		pi[d.prop] = len(sl)
+		sl = append(sl, d.decl)
+	}
+	var sv []string
+	for _, s := range sl {
+		if s != "" {
+			sv = append(sv, s)
+		}
+	}
+	st := `style="` + html.EscapeString(strings.Join(sv, "; ")) + `"`
+	if sa >= 0 {
+		return t[:al[sa].start] + st + t[al[sa].end:]
+	}
+	e := len(t) - 1
+	if e > 0 && t[e-1] == '/' {
+		e--
+	}
+	p := strings.TrimRight(t[:e], " \t\r\n")
+	return p + " " + st + t[len(p):]
+}
+
+// newCSSDecl returns the cssDecl for the given declaration
+func newCSSDecl(d string) cssDecl {
+	p := d
+	if i := strings.IndexByte(d, ':'); i >= 0 {
+		p = d[:i]
+	}
+	return cssDecl{
+		prop: strings.ToLower(strings.TrimSpace(p)),
+		decl: d,
+		imp:  strings.HasSuffix(strings.ToLower(strings.ReplaceAll(d, " ", "")), "!important"),
+	}
+}
+
+// parseCSS parses the given style sheet and returns the style sheet without the rules that
+// can be inlined and the inlinable rules. The order of the rules starts at the given offset
+func parseCSS(ss string, o int) (string, []cssRule) {
+	// Remove comments
+	for {
+		s := strings.Index(ss, "/*")
+		if s < 0 {
+			break
+		}
+		e := strings.Index(ss[s+2:], "*/")
+		if e < 0 {
+			ss = ss[:s]
+			break
+		}
+		ss = ss[:s] + ss[s+2+e+2:]
+	}
+
+	var rl []cssRule
+	rest := strings.Builder{}
+	for len(strings.TrimSpace(ss)) > 0 {
+		b := strings.IndexByte(ss, '{')
+		if b < 0 {
+			rest.WriteString(ss)
+			break
+		}
+		// Find the matching closing brace, at-rules like media queries contain nested blocks
+		d, e := 0, -1
+		for i := b; i < len(ss) && e < 0; i++ {
+			switch ss[i] {
+			case '{':
+				d++
+			case '}':
+				d--
+				if d == 0 {
+					e = i
+				}
+			}
+		}
+		if e < 0 {
+			rest.WriteString(ss)
+			break
+		}
+		pre, body := strings.TrimSpace(ss[:b]), ss[b+1:e]
+		blk := ss[:e+1]
+		ss = ss[e+1:]
+
+		var sl []cssSelector
+		ok := !strings.HasPrefix(pre, "@")
+		for _, s := range strings.Split(pre, ",") {
+			if !ok {
+				break
+			}
+			var sel cssSelector
+			sel, ok = parseCSSSelector(strings.TrimSpace(s))
+			sl = append(sl, sel)
+		}
+		if !ok {
+			rest.WriteString(blk)
+			continue
+		}
+		dl := splitCSS(body, ';')
+		for _, sel := range sl {
+			rl = append(rl, cssRule{sel: sel, decls: dl, order: o + len(rl)})
+		}
+	}
+	return rest.String(), rl
+}
+
+// parseCSSSelector parses the given compound selector. It returns false if the selector is not
+// supported for inlining
+func parseCSSSelector(s string) (cssSelector, bool) {
+	var sel cssSelector
+	if s == "" || strings.ContainsAny(s, " \t\r\n>+~:[()") {
+		return sel, false
+	}
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '#' {
+		i++
+	}
+	if t := strings.ToLower(s[:i]); t != "*" {
+		sel.tag = t
+	}
+	for i < len(s) {
+		k := s[i]
+		j := i + 1
+		for j < len(s) && s[j] != '.' && s[j] != '#' {
+			j++
+		}
+		if j == i+1 {
+			return sel, false
+		}
+		if k == '.' {
+			sel.classes = append(sel.classes, s[i+1:j])
+		} else {
+			sel.ids = append(sel.ids, s[i+1:j])
+		}
+		i = j
+	}
+	return sel, true
+}
+
+// matches returns true if the selector matches an element with the given name, classes and
+// IDs
+func (s cssSelector) matches(n string, cl, il []string) bool {
+	if s.tag != "" && s.tag != n {
+		return false
+	}
+	for _, c := range s.classes {
+		if !containsString(cl, c) {
+			return false
+		}
+	}
+	for _, id := range s.ids {
+		if !containsString(il, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity returns the specificity of the selector
+func (s cssSelector) specificity() int {
+	sp := len(s.ids)*100 + len(s.classes)*10
+	if s.tag != "" {
+		sp++
+	}
+	return sp
+}
+
+// splitCSS splits the given CSS at the given separator outside of quotes and parentheses and
+// returns the non-empty trimmed parts
+func splitCSS(s string, sep byte) []string {
+	var pl []string
+	var q byte
+	d, st := 0, 0
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			c := s[i]
+			switch {
+			case q != 0:
+				if c == q {
+					q = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				q = c
+				continue
+			case c == '(':
+				d++
+				continue
+			case c == ')':
+				d--
+				continue
+			case c != sep || d > 0:
+				continue
+			}
+		}
+		if p := strings.TrimSpace(s[st:i]); p != "" {
+			pl = append(pl, p)
+		}
+		st = i + 1
+	}
+	return pl
+}
+
+// containsString returns true if the given string is in the given list
+func containsString(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestInlineCSS tests the inlining of style sheets into style attributes
+func TestInlineCSS(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			"Type, class and ID selectors",
+			`<style>p { color: red; } .note, #main { margin: 0 }</style><p class="note">A</p><div id="main">B</div><br/>`,
+			`<p class="note" style="color: red; margin: 0">A</p><div id="main" style="margin: 0">B</div><br/>`,
+		},
+		{
+			"Specificity and inline precedence",
+			`<style>p.a { color: blue } p { color: red; font-size: 12px } p { font-weight: bold !important }</style>` +
+				`<p class="a" style="font-size: 14px; font-weight: normal">A</p>`,
+			`<p class="a" style="color: blue; font-size: 14px; font-weight: bold !important">A</p>`,
+		},
+		{
+			"Unsupported rules are kept",
+			`<style>a:hover { color: red } td { padding: 2px } @media (max-width: 600px) { td { padding: 0 } }</style>` +
+				`<table><tr><td>A</td></tr></table>`,
+			`<style>a:hover { color: red } @media (max-width: 600px) { td { padding: 0 } }</style>` +
+				`<table><tr><td style="padding: 2px">A</td></tr></table>`,
+		},
+		{
+			"Print style sheets are ignored",
+			`<style media="print">p { color: red }</style><p>A</p>`,
+			`<style media="print">p { color: red }</style><p>A</p>`,
+		},
+		{
+			"Quotes and comments",
+			`<style>/* c */ p { font-family: "Open Sans"; background: url("a;b.png") }</style><!-- <p> --><p>A</p>`,
+			`<!-- <p> --><p style="font-family: &#34;Open Sans&#34;; background: url(&#34;a;b.png&#34;)">A</p>`,
+		},
+		{"No style sheet", `<p class="x">A</p>`, `<p class="x">A</p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inlineCSS(tt.html); got != tt.want {
+				t.Errorf("inlineCSS failed.\nExpected: %s\ngot:      %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestWithPartCSSInlining tests that the style sheets of HTML parts are inlined when the Msg is
+// written
+func TestWithPartCSSInlining(t *testing.T) {
+	m := NewMsg()
+	m.SetBodyString(TypeTextHTML, `<style>p { color: red }</style><p>Hello</p>`, WithPartCSSInlining(),
+		WithPartEncoding(NoEncoding))
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), `<p style="color: red">Hello</p>`) || strings.Contains(buf.String(), "<style>") {
+		t.Errorf("WithPartCSSInlining failed. Expected inlined style, got: %s", buf.String())
+	}
+}
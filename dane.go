@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// List of TLSA certificate usages as described in RFC 6698. For SMTP, only DANE-TA and
+// DANE-EE are usable, as described in RFC 7672
+const (
+	// TLSAUsageDANETA represents the DANE-TA(2) certificate usage, that pins a trust anchor
+	// of the certificate chain of the server
+	TLSAUsageDANETA uint8 = 2
+
+	// TLSAUsageDANEEE represents the DANE-EE(3) certificate usage, that pins the certificate
+	// of the server
+	TLSAUsageDANEEE uint8 = 3
+)
+
+// List of TLSA selectors as described in RFC 6698
+const (
+	// TLSASelectorCert selects the full certificate
+	TLSASelectorCert uint8 = 0
+
+	// TLSASelectorSPKI selects the SubjectPublicKeyInfo of the certificate
+	TLSASelectorSPKI uint8 = 1
+)
+
+// List of TLSA matching types as described in RFC 6698
+const (
+	// TLSAMatchFull matches the selected content exactly
+	TLSAMatchFull uint8 = 0
+
+	// TLSAMatchSHA256 matches the SHA-256 hash of the selected content
+	TLSAMatchSHA256 uint8 = 1
+
+	// TLSAMatchSHA512 matches the SHA-512 hash of the selected content
+	TLSAMatchSHA512 uint8 = 2
+)
+
+// ErrDANEMismatch is returned if the certificate of the server does not match any of the
+// published TLSA records
+var ErrDANEMismatch = errors.New("server certificate does not match the TLSA records")
+
+// TLSARecord represents a TLSA DNS resource record as described in RFC 6698
+type TLSARecord struct {
+	// Usage is the certificate usage of the record
+	Usage uint8
+	// Selector defines which part of the certificate is matched
+	Selector uint8
+	// MatchingType defines how the certificate data is matched
+	MatchingType uint8
+	// Data is the certificate association data
+	Data []byte
+}
+
+// TLSALookupFunc is a function that looks up the TLSA records for the given name (e.g.
+// "_25._tcp.mx.example.com"). Since DANE relies on DNSSEC, the function must only return
+// records that were validated by a DNSSEC-aware resolver, and it must return an error if
+// the validation failed. If no TLSA records are published, it returns an empty list
+type TLSALookupFunc func(ctx context.Context, name string) ([]TLSARecord, error)
+
+// usableTLSA returns the TLSA records of the given list that are usable for SMTP as
+// described in RFC 7672
+func usableTLSA(rl []TLSARecord) []TLSARecord {
+	var ul []TLSARecord
+	for _, r := range rl {
+		if r.Usage != TLSAUsageDANETA && r.Usage != TLSAUsageDANEEE {
+			continue
+		}
+		if r.Selector > TLSASelectorSPKI || r.MatchingType > TLSAMatchSHA512 {
+			continue
+		}
+		ul = append(ul, r)
+	}
+	return ul
+}
+
+// daneTLSConfig returns a tls.Config for the given host that authenticates the server
+// certificate against the given TLSA records instead of the system roots
+func daneTLSConfig(h string, rl []TLSARecord) *tls.Config {
+	return &tls.Config{
+		ServerName: h,
+		MinVersion: DefaultTLSMinVersion,
+		// The certificate is verified against the TLSA records by VerifyPeerCertificate
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rc [][]byte, _ [][]*x509.Certificate) error {
+			return verifyDANE(h, rc, rl)
+		},
+	}
+}
+
+// verifyDANE verifies the given raw certificate chain of the server against the given TLSA
+// records. DANE-EE records must match the server certificate, for which neither the name
+// nor the validity period is checked, as described in RFC 7672. DANE-TA records must match
+// a certificate of the chain that the server certificate chains up to for the given host
+func verifyDANE(h string, rc [][]byte, rl []TLSARecord) error {
+	cl := make([]*x509.Certificate, 0, len(rc))
+	for _, r := range rc {
+		c, err := x509.ParseCertificate(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse server certificate: %w", err)
+		}
+		cl = append(cl, c)
+	}
+	if len(cl) == 0 {
+		return fmt.Errorf("server did not present a certificate")
+	}
+	for _, r := range rl {
+		switch r.Usage {
+		case TLSAUsageDANEEE:
+			if matchTLSA(cl[0], r) {
+				return nil
+			}
+		case TLSAUsageDANETA:
+			for _, ta := range cl[1:] {
+				if !matchTLSA(ta, r) {
+					continue
+				}
+				roots := x509.NewCertPool()
+				roots.AddCert(ta)
+				ip := x509.NewCertPool()
+				for _, c := range cl[1:] {
+					ip.AddCert(c)
+				}
+				if _, err := cl[0].Verify(x509.VerifyOptions{DNSName: h, Roots: roots, Intermediates: ip}); err == nil {
+					return nil
+				}
+			}
+		}
+	}
+	return ErrDANEMismatch
+}
+
+// matchTLSA returns true if the given certificate matches the given TLSA record
+func matchTLSA(c *x509.Certificate, r TLSARecord) bool {
+	d := c.Raw
+	if r.Selector == TLSASelectorSPKI {
+		d = c.RawSubjectPublicKeyInfo
+	}
+	switch r.MatchingType {
+	case TLSAMatchSHA256:
+		h := sha256.Sum256(d)
+		d = h[:]
+	case TLSAMatchSHA512:
+		h := sha512.Sum512(d)
+		d = h[:]
+	}
+	return bytes.Equal(d, r.Data)
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestVerifyDANE tests the authentication of certificate chains against TLSA records
+func TestVerifyDANE(t *testing.T) {
+	ca, leaf := testDANECerts(t)
+	rc := leaf.Certificate
+	spki := sha256.Sum256(leaf.Leaf.RawSubjectPublicKeyInfo)
+	caspki := sha256.Sum256(ca.RawSubjectPublicKeyInfo)
+	tests := []struct {
+		name string
+		host string
+		rl   []TLSARecord
+		werr error
+	}{
+		{"DANE-EE SPKI SHA-256", "mx.example.com", []TLSARecord{{3, 1, 1, spki[:]}}, nil},
+		{"DANE-EE full certificate", "other.example.com", []TLSARecord{{3, 0, 0, leaf.Leaf.Raw}}, nil},
+		{"DANE-EE mismatch", "mx.example.com", []TLSARecord{{3, 1, 1, caspki[:]}}, ErrDANEMismatch},
+		{"DANE-TA SPKI SHA-256", "mx.example.com", []TLSARecord{{2, 1, 1, caspki[:]}}, nil},
+		{"DANE-TA with wrong host", "other.example.com", []TLSARecord{{2, 1, 1, caspki[:]}}, ErrDANEMismatch},
+		{"Mismatch and match", "mx.example.com", []TLSARecord{{3, 1, 1, caspki[:]}, {3, 1, 1, spki[:]}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyDANE(tt.host, rc, tt.rl); !errors.Is(err, tt.werr) {
+				t.Errorf("verifyDANE failed. Expected error: %v, got: %v", tt.werr, err)
+			}
+		})
+	}
+}
+
+// TestUsableTLSA tests that only TLSA records that are usable for SMTP are considered
+func TestUsableTLSA(t *testing.T) {
+	rl := []TLSARecord{{0, 0, 1, nil}, {1, 1, 1, nil}, {2, 1, 1, nil}, {3, 1, 1, nil}, {3, 2, 1, nil}, {3, 1, 3, nil}}
+	ul := usableTLSA(rl)
+	if len(ul) != 2 || ul[0].Usage != TLSAUsageDANETA || ul[1].Usage != TLSAUsageDANEEE {
+		t.Errorf("usableTLSA failed. Unexpected usable records: %+v", ul)
+	}
+}
+
+// TestMXSender_DANE tests the DANE authentication of the MX connections
+func TestMXSender_DANE(t *testing.T) {
+	_, leaf := testDANECerts(t)
+	spki := sha256.Sum256(leaf.Leaf.RawSubjectPublicKeyInfo)
+	tlssrv := smtptest.NewTestServer(t, smtptest.WithSTARTTLS(&tls.Config{
+		Certificates: []tls.Certificate{leaf}, MinVersion: tls.VersionTLS12,
+	}))
+	plainsrv := smtptest.NewTestServer(t)
+	tests := []struct {
+		name string
+		srv  *smtptest.Server
+		rl   []TLSARecord
+		lerr error
+		fail string
+	}{
+		{"Matching TLSA record", tlssrv, []TLSARecord{{3, 1, 1, spki[:]}}, nil, ""},
+		{"No TLSA records", plainsrv, nil, nil, ""},
+		{"Only unusable TLSA records", plainsrv, []TLSARecord{{1, 1, 1, spki[:]}}, nil, ""},
+		{"Mismatching TLSA record", tlssrv, []TLSARecord{{3, 1, 1, make([]byte, 32)}}, nil, ErrDANEMismatch.Error()},
+		{"TLSA record without STARTTLS", plainsrv, []TLSARecord{{3, 1, 1, spki[:]}}, nil, "STARTTLS"},
+		{"Failed TLSA lookup", plainsrv, nil, errors.New("DNSSEC validation failed"), "DNSSEC validation failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ln string
+			s, err := NewMXSender(WithMXPort(tt.srv.Port()),
+				WithMXLookupFunc(func(context.Context, string) ([]*net.MX, error) {
+					return []*net.MX{{Host: "127.0.0.1.", Pref: 10}}, nil
+				}),
+				WithMXDANE(func(_ context.Context, n string) ([]TLSARecord, error) {
+					ln = n
+					return tt.rl, tt.lerr
+				}))
+			if err != nil {
+				t.Fatalf("NewMXSender failed: %s", err)
+			}
+			err = s.Send(testMsg(t))
+			if en := "_" + tt.srv.Addr()[strings.LastIndex(tt.srv.Addr(), ":")+1:] + "._tcp.127.0.0.1"; ln != en {
+				t.Errorf("TLSA lookup failed. Expected name: %s, got: %s", en, ln)
+			}
+			if tt.fail == "" && err != nil {
+				t.Errorf("Send failed: %s", err)
+			}
+			if tt.fail != "" && (err == nil || !strings.Contains(err.Error(), tt.fail)) {
+				t.Errorf("Send was expected to fail with %q, got: %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+// testDANECerts returns a CA certificate and a server certificate for mx.example.com that
+// is issued by the CA
+func testDANECerts(t *testing.T) (*x509.Certificate, tls.Certificate) {
+	t.Helper()
+	ck, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %s", err)
+	}
+	ctpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "go-mail DANE test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cder, err := x509.CreateCertificate(rand.Reader, ctpl, ctpl, &ck.PublicKey, ck)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %s", err)
+	}
+	ca, err := x509.ParseCertificate(cder)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %s", err)
+	}
+	lk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate server key: %s", err)
+	}
+	ltpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "mx.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"mx.example.com"},
+	}
+	lder, err := x509.CreateCertificate(rand.Reader, ltpl, ca, &lk.PublicKey, ck)
+	if err != nil {
+		t.Fatalf("failed to create server certificate: %s", err)
+	}
+	leaf, err := x509.ParseCertificate(lder)
+	if err != nil {
+		t.Fatalf("failed to parse server certificate: %s", err)
+	}
+	return ca, tls.Certificate{Certificate: [][]byte{lder, cder}, PrivateKey: lk, Leaf: leaf}
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import "fmt"
+
+// AddToDigest adds the given messages to the multipart/digest container of the Msg, e.g. for
+// mailing-list style digests. The messages are rendered at the time of the call. If the Msg
+// has body parts or attachments, the digest is rendered as part of a multipart/mixed Msg after
+// the body parts (e.g. a table of contents), otherwise the Msg itself is a multipart/digest
+func (m *Msg) AddToDigest(ml ...*Msg) error {
+	for _, om := range ml {
+		f, err := fileFromMsg(om)
+		if err != nil {
+			return fmt.Errorf("failed to add message to digest: %w", err)
+		}
+		m.digest = append(m.digest, f)
+	}
+	return nil
+}
+
+// GetDigest returns the rendered messages of the multipart/digest container of the Msg
+func (m *Msg) GetDigest() []*File {
+	return m.digest
+}
+
+// hasDigest returns true if the Msg has a multipart/digest container
+func (m *Msg) hasDigest() bool {
+	return m.pgptype == 0 && len(m.digest) > 0
+}
+
+// writeDigest writes the multipart/digest container of the Msg with the given boundary. The parts of a digest have
+// the default content type message/rfc822, but it is set explicitly for clients that do not
+// know the default
+func (mw *msgWriter) writeDigest(m *Msg, b string) {
+	mw.startMP(MIMEDigest, b)
+	if mw.d == 1 {
+		mw.writeString(DoubleNewLine)
+	}
+	for _, f := range m.digest {
+		mw.newPart(map[string][]string{string(HeaderContentType): {string(TypeMessageRFC822)}})
+		mw.writeBody(f.Writer, NoEncoding)
+	}
+	mw.stopMP()
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+)
+
+// TestMsg_AddToDigest tests the multipart/digest composition of the Msg
+func TestMsg_AddToDigest(t *testing.T) {
+	tests := []struct {
+		name string
+		toc  bool
+	}{
+		{"digest only", false},
+		{"digest with table of contents", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			for _, s := range []string{"First issue", "Second issue"} {
+				om := NewMsg()
+				if err := om.From("list@example.com"); err != nil {
+					t.Fatalf("failed to set FROM address: %s", err)
+				}
+				om.Subject(s)
+				om.SetBodyString(TypeTextPlain, "Body of "+s)
+				if err := m.AddToDigest(om); err != nil {
+					t.Fatalf("AddToDigest failed: %s", err)
+				}
+			}
+			if len(m.GetDigest()) != 2 {
+				t.Fatalf("AddToDigest failed. Expected 2 messages, got: %d", len(m.GetDigest()))
+			}
+			if tt.toc {
+				m.SetBodyString(TypeTextPlain, "Table of contents")
+			}
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+
+			pm, err := mail.ReadMessage(&buf)
+			if err != nil {
+				t.Fatalf("failed to parse message: %s", err)
+			}
+			mt, p, err := mime.ParseMediaType(pm.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatalf("failed to parse content type: %s", err)
+			}
+			mr := multipart.NewReader(pm.Body, p["boundary"])
+			if tt.toc {
+				if mt != "multipart/mixed" {
+					t.Fatalf("expected multipart/mixed, got: %s", mt)
+				}
+				if _, err := mr.NextPart(); err != nil {
+					t.Fatalf("failed to read table of contents: %s", err)
+				}
+				dp, err := mr.NextPart()
+				if err != nil {
+					t.Fatalf("failed to read digest part: %s", err)
+				}
+				if mt, p, err = mime.ParseMediaType(dp.Header.Get("Content-Type")); err != nil {
+					t.Fatalf("failed to parse content type: %s", err)
+				}
+				mr = multipart.NewReader(dp, p["boundary"])
+			}
+			if mt != "multipart/digest" {
+				t.Fatalf("expected multipart/digest, got: %s", mt)
+			}
+			for _, s := range []string{"First issue", "Second issue"} {
+				dp, err := mr.NextPart()
+				if err != nil {
+					t.Fatalf("failed to read digest part: %s", err)
+				}
+				if ct := dp.Header.Get("Content-Type"); ct != string(TypeMessageRFC822) {
+					t.Errorf("expected message/rfc822 digest part, got: %s", ct)
+				}
+				em, err := mail.ReadMessage(dp)
+				if err != nil {
+					t.Fatalf("failed to parse digest message: %s", err)
+				}
+				if em.Header.Get("Subject") != s {
+					t.Errorf("expected digest message %q, got: %q", s, em.Header.Get("Subject"))
+				}
+			}
+			if _, err := mr.NextPart(); !errors.Is(err, io.EOF) {
+				t.Errorf("expected end of digest, got: %v", err)
+			}
+		})
+	}
+
+	if err := NewMsg().AddToDigest(nil); err == nil {
+		t.Errorf("AddToDigest with nil message was expected to fail")
+	}
+}
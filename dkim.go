@@ -0,0 +1,376 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DKIMCanonicalization represents a DKIM canonicalization algorithm as described in
+// RFC 6376, section 3.4
+type DKIMCanonicalization string
+
+const (
+	// DKIMCanonicalizationSimple is the "simple" canonicalization algorithm that tolerates
+	// almost no modification of the message
+	DKIMCanonicalizationSimple DKIMCanonicalization = "simple"
+
+	// DKIMCanonicalizationRelaxed is the "relaxed" canonicalization algorithm that tolerates
+	// common modifications like whitespace replacement and header field line rewrapping
+	DKIMCanonicalizationRelaxed DKIMCanonicalization = "relaxed"
+)
+
+// HeaderDKIMSignature is the "DKIM-Signature" header field
+const HeaderDKIMSignature Header = "DKIM-Signature"
+
+// DefaultDKIMHeaders is the default list of header fields that are signed by a DKIMSigner
+var DefaultDKIMHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID", "In-Reply-To", "References",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding", "List-Unsubscribe",
+	"List-Unsubscribe-Post",
+}
+
+var (
+	// ErrDKIMInvalidDomain should be used if an empty signing domain is provided
+	ErrDKIMInvalidDomain = errors.New("DKIM signing domain must not be empty")
+
+	// ErrDKIMInvalidSelector should be used if an empty selector is provided
+	ErrDKIMInvalidSelector = errors.New("DKIM selector must not be empty")
+
+	// ErrDKIMInvalidKey should be used if the provided key is not a supported crypto.Signer
+	ErrDKIMInvalidKey = errors.New("DKIM key must be a RSA or Ed25519 private key")
+
+	// ErrDKIMInvalidCanonicalization should be used if an unsupported canonicalization
+	// algorithm is provided
+	ErrDKIMInvalidCanonicalization = errors.New("DKIM canonicalization can only be simple or relaxed")
+
+	// ErrDKIMNoFromHeader should be used if the list of signed headers does not include
+	// the From header field, which is required by RFC 6376
+	ErrDKIMNoFromHeader = errors.New("DKIM signed headers must include the From header field")
+
+	// ErrDKIMInvalidExpiration should be used if a signature validity period is set that is
+	// zero or negative
+	ErrDKIMInvalidExpiration = errors.New("DKIM signature expiration cannot be zero or negative")
+
+	// ErrDKIMMalformedMessage should be used if the message to be signed has no header section
+	ErrDKIMMalformedMessage = errors.New("message to be DKIM signed is malformed")
+)
+
+// DKIMSigner signs rendered messages with a DKIM signature as described in RFC 6376.
+// RSA keys (rsa-sha256) and Ed25519 keys (ed25519-sha256, RFC 8463) are supported
+type DKIMSigner struct {
+	// domain is the signing domain (d= tag)
+	domain string
+
+	// selector is the selector of the public key in the DNS (s= tag)
+	selector string
+
+	// key is the private key used for the signature
+	key crypto.Signer
+
+	// algo is the signing algorithm (a= tag)
+	algo string
+
+	// hcanon is the canonicalization algorithm for the header fields
+	hcanon DKIMCanonicalization
+
+	// bcanon is the canonicalization algorithm for the body
+	bcanon DKIMCanonicalization
+
+	// headers is the list of header fields that are signed (h= tag)
+	headers []string
+
+	// exp is the optional validity period of the signature (x= tag)
+	exp time.Duration
+}
+
+// DKIMOption returns a function that can be used for grouping DKIMSigner options
+type DKIMOption func(*DKIMSigner) error
+
+// NewDKIMSigner returns a new DKIMSigner for the given signing domain, selector and private
+// key. By default, the relaxed canonicalization is used for both, the header and the body,
+// and the DefaultDKIMHeaders are signed
+func NewDKIMSigner(d, s string, k crypto.Signer, o ...DKIMOption) (*DKIMSigner, error) {
+	if d == "" {
+		return nil, ErrDKIMInvalidDomain
+	}
+	if s == "" {
+		return nil, ErrDKIMInvalidSelector
+	}
+	if k == nil {
+		return nil, ErrDKIMInvalidKey
+	}
+	ds := &DKIMSigner{
+		domain:   d,
+		selector: s,
+		key:      k,
+		hcanon:   DKIMCanonicalizationRelaxed,
+		bcanon:   DKIMCanonicalizationRelaxed,
+		headers:  DefaultDKIMHeaders,
+	}
+	switch k.Public().(type) {
+	case *rsa.PublicKey:
+		ds.algo = "rsa-sha256"
+	case ed25519.PublicKey:
+		ds.algo = "ed25519-sha256"
+	default:
+		return nil, ErrDKIMInvalidKey
+	}
+
+	// Override defaults with optionally provided DKIMOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(ds); err != nil {
+			return nil, fmt.Errorf("failed to apply DKIM option: %w", err)
+		}
+	}
+	return ds, nil
+}
+
+// WithDKIMCanonicalization overrides the default canonicalization algorithms for the
+// header fields and the body
+func WithDKIMCanonicalization(h, b DKIMCanonicalization) DKIMOption {
+	return func(ds *DKIMSigner) error {
+		for _, c := range []DKIMCanonicalization{h, b} {
+			if c != DKIMCanonicalizationSimple && c != DKIMCanonicalizationRelaxed {
+				return ErrDKIMInvalidCanonicalization
+			}
+		}
+		ds.hcanon = h
+		ds.bcanon = b
+		return nil
+	}
+}
+
+// WithDKIMHeaders overrides the default list of signed header fields. The From header
+// field is mandatory. A header field name that is listed more often than it is present
+// in the message prevents the addition of further instances of this field ("oversigning")
+func WithDKIMHeaders(h ...string) DKIMOption {
+	return func(ds *DKIMSigner) error {
+		hf := false
+		for _, ch := range h {
+			if strings.EqualFold(ch, string(HeaderFrom)) {
+				hf = true
+			}
+		}
+		if !hf {
+			return ErrDKIMNoFromHeader
+		}
+		ds.headers = h
+		return nil
+	}
+}
+
+// WithDKIMExpiration sets the validity period of the signature. The expiration time
+// (x= tag) is calculated from the signing time
+func WithDKIMExpiration(d time.Duration) DKIMOption {
+	return func(ds *DKIMSigner) error {
+		if d <= 0 {
+			return ErrDKIMInvalidExpiration
+		}
+		ds.exp = d
+		return nil
+	}
+}
+
+// Sign calculates the DKIM signature for the given rendered message and returns the
+// complete DKIM-Signature header field (including the trailing CRLF) that has to be
+// prepended to the message
+func (ds *DKIMSigner) Sign(msg []byte) (string, error) {
+	return ds.signAt(msg, time.Now())
+}
+
+// signAt calculates the DKIM signature for the given rendered message with t as signing time
+func (ds *DKIMSigner) signAt(msg []byte, t time.Time) (string, error) {
+	hb, bb, err := splitMsg(msg)
+	if err != nil {
+		return "", err
+	}
+	bh := sha256.Sum256(canonicalizeBody(bb, ds.bcanon))
+
+	tl := []string{
+		"v=1", "a=" + ds.algo, fmt.Sprintf("c=%s/%s", ds.hcanon, ds.bcanon),
+		"d=" + ds.domain, "s=" + ds.selector, fmt.Sprintf("t=%d", t.Unix()),
+	}
+	if ds.exp > 0 {
+		tl = append(tl, fmt.Sprintf("x=%d", t.Add(ds.exp).Unix()))
+	}
+	tl = append(tl, "h="+strings.Join(ds.headers, ":"),
+		"bh="+base64.StdEncoding.EncodeToString(bh[:]), "b=")
+	sh := foldDKIMTags(tl)
+
+	var sd bytes.Buffer
+	for _, h := range selectHeaders(parseHeaders(hb), ds.headers) {
+		sd.WriteString(canonicalizeHeader(h, ds.hcanon))
+	}
+	sd.WriteString(strings.TrimSuffix(canonicalizeHeader(sh, ds.hcanon), SingleNewLine))
+	hs := sha256.Sum256(sd.Bytes())
+
+	var sig []byte
+	switch ds.algo {
+	case "ed25519-sha256":
+		sig, err = ds.key.Sign(rand.Reader, hs[:], crypto.Hash(0))
+	default:
+		sig, err = ds.key.Sign(rand.Reader, hs[:], crypto.SHA256)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to calculate DKIM signature: %w", err)
+	}
+
+	var ob strings.Builder
+	ob.WriteString(sh)
+	es := base64.StdEncoding.EncodeToString(sig)
+	for len(es) > 0 {
+		l := MaxHeaderLength - 4
+		if l > len(es) {
+			l = len(es)
+		}
+		ob.WriteString(SingleNewLine + " " + es[:l])
+		es = es[l:]
+	}
+	ob.WriteString(SingleNewLine)
+	return ob.String(), nil
+}
+
+// foldDKIMTags joins the given DKIM tags into a folded DKIM-Signature header field
+func foldDKIMTags(tl []string) string {
+	var sb strings.Builder
+	sb.WriteString(string(HeaderDKIMSignature) + ":")
+	ll := sb.Len()
+	for i, t := range tl {
+		if i > 0 {
+			sb.WriteString(";")
+			ll++
+		}
+		if ll+len(t)+2 > MaxHeaderLength {
+			sb.WriteString(SingleNewLine)
+			ll = 0
+		}
+		sb.WriteString(" " + t)
+		ll += len(t) + 1
+	}
+	return sb.String()
+}
+
+// splitMsg splits a rendered message into its header section (including the CRLF of
+// the last header field) and its body
+func splitMsg(msg []byte) ([]byte, []byte, error) {
+	i := bytes.Index(msg, []byte(DoubleNewLine))
+	if i < 0 {
+		if bytes.HasSuffix(msg, []byte(SingleNewLine)) {
+			return msg, nil, nil
+		}
+		return nil, nil, ErrDKIMMalformedMessage
+	}
+	return msg[:i+2], msg[i+4:], nil
+}
+
+// parseHeaders splits a header section into the raw header fields, including the folding
+// whitespace and the trailing CRLF
+func parseHeaders(hb []byte) []string {
+	var hl []string
+	for _, l := range strings.SplitAfter(string(hb), SingleNewLine) {
+		if l == "" {
+			continue
+		}
+		if (l[0] == ' ' || l[0] == '\t') && len(hl) > 0 {
+			hl[len(hl)-1] += l
+			continue
+		}
+		hl = append(hl, l)
+	}
+	return hl
+}
+
+// selectHeaders returns the header fields that are signed for the given list of header
+// names. If a header field occurs multiple times, the instances are selected from the
+// bottom of the header section upwards as described in RFC 6376, section 5.4.2
+func selectHeaders(hl []string, names []string) []string {
+	used := make(map[int]bool)
+	var sl []string
+	for _, n := range names {
+		for i := len(hl) - 1; i >= 0; i-- {
+			if used[i] {
+				continue
+			}
+			if hn := strings.SplitN(hl[i], ":", 2)[0]; strings.EqualFold(strings.TrimSpace(hn), n) {
+				used[i] = true
+				sl = append(sl, hl[i])
+				break
+			}
+		}
+	}
+	return sl
+}
+
+// canonicalizeHeader canonicalizes a single raw header field with the given algorithm
+func canonicalizeHeader(h string, c DKIMCanonicalization) string {
+	if c == DKIMCanonicalizationSimple {
+		if !strings.HasSuffix(h, SingleNewLine) {
+			h += SingleNewLine
+		}
+		return h
+	}
+	kv := strings.SplitN(h, ":", 2)
+	k := strings.ToLower(strings.TrimSpace(kv[0]))
+	v := ""
+	if len(kv) > 1 {
+		v = strings.NewReplacer("\r", "", "\n", "").Replace(kv[1])
+		v = strings.TrimSpace(compressWSP(v))
+	}
+	return k + ":" + v + SingleNewLine
+}
+
+// canonicalizeBody canonicalizes the message body with the given algorithm
+func canonicalizeBody(b []byte, c DKIMCanonicalization) []byte {
+	if c == DKIMCanonicalizationRelaxed {
+		ll := strings.Split(string(b), SingleNewLine)
+		for i := range ll {
+			ll[i] = strings.TrimRight(compressWSP(ll[i]), " \t")
+		}
+		b = []byte(strings.Join(ll, SingleNewLine))
+	}
+	for bytes.HasSuffix(b, []byte(SingleNewLine)) {
+		b = b[:len(b)-2]
+	}
+	if len(b) == 0 {
+		if c == DKIMCanonicalizationRelaxed {
+			return []byte{}
+		}
+		return []byte(SingleNewLine)
+	}
+	return append(b, SingleNewLine...)
+}
+
+// compressWSP reduces all sequences of whitespace characters to a single space
+func compressWSP(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	ws := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			if !ws {
+				sb.WriteByte(' ')
+			}
+			ws = true
+			continue
+		}
+		ws = false
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCanonicalizeHeader tests the header canonicalization with the example of RFC 6376,
+// section 3.4.5
+func TestCanonicalizeHeader(t *testing.T) {
+	hl := parseHeaders([]byte("A: X\r\nB : Y\t\r\n\tZ  \r\n"))
+	if len(hl) != 2 {
+		t.Fatalf("parseHeaders failed. Expected 2 header fields, got: %d", len(hl))
+	}
+	var rs, ss string
+	for _, h := range hl {
+		rs += canonicalizeHeader(h, DKIMCanonicalizationRelaxed)
+		ss += canonicalizeHeader(h, DKIMCanonicalizationSimple)
+	}
+	if rs != "a:X\r\nb:Y Z\r\n" {
+		t.Errorf("relaxed header canonicalization failed. Got: %q", rs)
+	}
+	if ss != "A: X\r\nB : Y\t\r\n\tZ  \r\n" {
+		t.Errorf("simple header canonicalization failed. Got: %q", ss)
+	}
+}
+
+// TestCanonicalizeBody tests the body canonicalization with the example of RFC 6376,
+// section 3.4.5
+func TestCanonicalizeBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		c    DKIMCanonicalization
+		want string
+	}{
+		{"relaxed", " C \r\nD \t E\r\n\r\n\r\n", DKIMCanonicalizationRelaxed, " C\r\nD E\r\n"},
+		{"simple", " C \r\nD \t E\r\n\r\n\r\n", DKIMCanonicalizationSimple, " C \r\nD \t E\r\n"},
+		{"relaxed empty", "", DKIMCanonicalizationRelaxed, ""},
+		{"simple empty", "", DKIMCanonicalizationSimple, "\r\n"},
+		{"simple without CRLF", "test", DKIMCanonicalizationSimple, "test\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if b := string(canonicalizeBody([]byte(tt.body), tt.c)); b != tt.want {
+				t.Errorf("body canonicalization failed. Expected: %q, got: %q", tt.want, b)
+			}
+		})
+	}
+}
+
+// TestCanonicalizeBody_hash tests the body hash with the sample message of RFC 8463
+func TestCanonicalizeBody_hash(t *testing.T) {
+	b := "Hi.\r\n\r\nWe lost the game.  Are you hungry yet?\r\n\r\nJoe.\r\n"
+	bh := sha256.Sum256(canonicalizeBody([]byte(b), DKIMCanonicalizationRelaxed))
+	if e := base64.StdEncoding.EncodeToString(bh[:]); e != "2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=" {
+		t.Errorf("body hash mismatch. Got: %s", e)
+	}
+}
+
+// TestNewDKIMSigner tests the NewDKIMSigner method and its options
+func TestNewDKIMSigner(t *testing.T) {
+	_, ek, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tests := []struct {
+		name string
+		d    string
+		s    string
+		k    crypto.Signer
+		o    []DKIMOption
+		err  error
+	}{
+		{"Valid", "example.com", "default", ek, nil, nil},
+		{"Empty domain", "", "default", ek, nil, ErrDKIMInvalidDomain},
+		{"Empty selector", "example.com", "", ek, nil, ErrDKIMInvalidSelector},
+		{"No key", "example.com", "default", nil, nil, ErrDKIMInvalidKey},
+		{
+			"Invalid canonicalization", "example.com", "default", ek,
+			[]DKIMOption{WithDKIMCanonicalization("nofws", DKIMCanonicalizationSimple)},
+			ErrDKIMInvalidCanonicalization,
+		},
+		{
+			"Headers without From", "example.com", "default", ek,
+			[]DKIMOption{WithDKIMHeaders("To", "Subject")}, ErrDKIMNoFromHeader,
+		},
+		{
+			"Invalid expiration", "example.com", "default", ek,
+			[]DKIMOption{WithDKIMExpiration(0)}, ErrDKIMInvalidExpiration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDKIMSigner(tt.d, tt.s, tt.k, tt.o...)
+			if tt.err == nil && err != nil {
+				t.Errorf("NewDKIMSigner failed: %s", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("NewDKIMSigner was expected to fail with %q, got: %s", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestMsg_WriteTo_DKIM tests the rendering of a DKIM signed Msg with RSA and Ed25519 keys
+func TestMsg_WriteTo_DKIM(t *testing.T) {
+	rk, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	_, ek, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %s", err)
+	}
+	tests := []struct {
+		name string
+		k    crypto.Signer
+		a    string
+		c    DKIMCanonicalization
+	}{
+		{"RSA/relaxed", rk, "rsa-sha256", DKIMCanonicalizationRelaxed},
+		{"RSA/simple", rk, "rsa-sha256", DKIMCanonicalizationSimple},
+		{"Ed25519/relaxed", ek, "ed25519-sha256", DKIMCanonicalizationRelaxed},
+		{"Ed25519/simple", ek, "ed25519-sha256", DKIMCanonicalizationSimple},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := NewDKIMSigner("example.com", "test", tt.k, WithDKIMCanonicalization(tt.c, tt.c),
+				WithDKIMExpiration(time.Hour))
+			if err != nil {
+				t.Fatalf("failed to create DKIM signer: %s", err)
+			}
+			m := NewMsg(WithDKIMSigner(ds))
+			_ = m.From("Toni Tester <tester@example.com>")
+			_ = m.To("Alice <alice@example.com>")
+			m.Subject("This is a long subject to make sure that header folding is covered by the test")
+			m.SetBodyString(TypeTextPlain, "This is a test body  \r\n\r\n")
+			buf := bytes.Buffer{}
+			n, err := m.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("failed to write DKIM signed message: %s", err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo returned wrong byte count. Expected: %d, got: %d", buf.Len(), n)
+			}
+			if !strings.HasPrefix(buf.String(), "DKIM-Signature: v=1; a="+tt.a) {
+				t.Errorf("DKIM signed message does not start with DKIM-Signature header: %s", buf.String())
+			}
+			if err := verifyDKIM(buf.Bytes(), 0, tt.k.Public()); err != nil {
+				t.Errorf("DKIM signature verification failed: %s", err)
+			}
+			tm := bytes.Replace(buf.Bytes(), []byte("This is a test body"), []byte("This is a fake body"), 1)
+			if err := verifyDKIM(tm, 0, tt.k.Public()); err == nil {
+				t.Errorf("DKIM signature verification of tampered message was expected to fail")
+			}
+		})
+	}
+}
+
+// TestMsg_WriteTo_multipleDKIM tests the rendering of a Msg with multiple DKIMSigners
+func TestMsg_WriteTo_multipleDKIM(t *testing.T) {
+	_, k1, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	_, k2, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	d1, err := NewDKIMSigner("example.com", "corp", k1)
+	if err != nil {
+		t.Fatalf("failed to create DKIM signer: %s", err)
+	}
+	d2, err := NewDKIMSigner("esp.example.org", "esp", k2,
+		WithDKIMCanonicalization(DKIMCanonicalizationSimple, DKIMCanonicalizationSimple))
+	if err != nil {
+		t.Fatalf("failed to create DKIM signer: %s", err)
+	}
+	m := NewMsg(WithDKIMSigner(d1, nil))
+	m.AddDKIMSigner(d2)
+	_ = m.From("tester@example.com")
+	_ = m.To("alice@example.com")
+	m.Subject("DKIM test")
+	m.SetBodyString(TypeTextPlain, "This is a test body")
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write DKIM signed message: %s", err)
+	}
+	if c := strings.Count(buf.String(), "DKIM-Signature:"); c != 2 {
+		t.Fatalf("expected 2 DKIM-Signature headers, got: %d", c)
+	}
+	if err := verifyDKIM(buf.Bytes(), 0, k1.Public()); err != nil {
+		t.Errorf("DKIM signature verification of first signature failed: %s", err)
+	}
+	if err := verifyDKIM(buf.Bytes(), 1, k2.Public()); err != nil {
+		t.Errorf("DKIM signature verification of second signature failed: %s", err)
+	}
+
+	m.SetDKIMSigner()
+	buf.Reset()
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if strings.Contains(buf.String(), "DKIM-Signature:") {
+		t.Errorf("message without DKIMSigner is not expected to contain a DKIM-Signature header")
+	}
+}
+
+// verifyDKIM verifies the n-th DKIM-Signature of the given message with the given public key
+func verifyDKIM(msg []byte, n int, pk crypto.PublicKey) error {
+	hb, bb, err := splitMsg(msg)
+	if err != nil {
+		return err
+	}
+	var sl, hl []string
+	for _, h := range parseHeaders(hb) {
+		if strings.HasPrefix(h, "DKIM-Signature:") {
+			sl = append(sl, h)
+			continue
+		}
+		hl = append(hl, h)
+	}
+	sh := sl[n]
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(sh[len("DKIM-Signature:"):], ";") {
+		kv := strings.SplitN(tag, "=", 2)
+		v := regexp.MustCompile(`\s+`).ReplaceAllString(kv[1], "")
+		tags[strings.TrimSpace(kv[0])] = v
+	}
+	cl := strings.Split(tags["c"], "/")
+	hc, bc := DKIMCanonicalization(cl[0]), DKIMCanonicalization(cl[1])
+	bh := sha256.Sum256(canonicalizeBody(bb, bc))
+	if base64.StdEncoding.EncodeToString(bh[:]) != tags["bh"] {
+		return errors.New("body hash mismatch")
+	}
+
+	var sd bytes.Buffer
+	for _, h := range selectHeaders(hl, strings.Split(tags["h"], ":")) {
+		sd.WriteString(canonicalizeHeader(h, hc))
+	}
+	us := sh[:strings.LastIndex(sh, "b=")+2]
+	sd.WriteString(strings.TrimSuffix(canonicalizeHeader(us, hc), "\r\n"))
+	hs := sha256.Sum256(sd.Bytes())
+	sig, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err
+	}
+	switch k := pk.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hs[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, hs[:], sig) {
+			return errors.New("ed25519 signature mismatch")
+		}
+	}
+	return nil
+}
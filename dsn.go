@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strings"
+)
+
+// DSNAction is the action of a per-recipient delivery status as described in RFC 3464
+type DSNAction string
+
+// List of DSN actions
+const (
+	// DSNActionFailed indicates that the message could not be delivered to the recipient
+	DSNActionFailed DSNAction = "failed"
+
+	// DSNActionDelayed indicates that the delivery to the recipient is delayed and still
+	// being attempted
+	DSNActionDelayed DSNAction = "delayed"
+
+	// DSNActionDelivered indicates that the message was delivered to the recipient
+	DSNActionDelivered DSNAction = "delivered"
+
+	// DSNActionRelayed indicates that the message was relayed to an environment that does
+	// not accept responsibility for generating DSNs
+	DSNActionRelayed DSNAction = "relayed"
+
+	// DSNActionExpanded indicates that the message was delivered to the recipient and
+	// forwarded to multiple additional recipients
+	DSNActionExpanded DSNAction = "expanded"
+)
+
+// ErrNoDeliveryStatus is returned if a message is not a delivery status notification
+var ErrNoDeliveryStatus = errors.New("message is not a delivery status notification")
+
+// DeliveryStatus is a parsed delivery status notification (DSN) as described in RFC 3464,
+// i.e. the machine-readable part of a bounce message
+type DeliveryStatus struct {
+	// ReportingMTA is the MTA that generated the DSN
+	ReportingMTA string
+	// OriginalEnvelopeID is the envelope ID of the original message, if it was provided
+	OriginalEnvelopeID string
+	// Recipients holds the delivery status of every recipient of the DSN
+	Recipients []RecipientStatus
+}
+
+// RecipientStatus is the delivery status of a single recipient of a DSN
+type RecipientStatus struct {
+	// FinalRecipient is the address of the recipient the delivery was attempted to
+	FinalRecipient string
+	// OriginalRecipient is the address of the recipient in the original envelope, if the
+	// reporting MTA provided it
+	OriginalRecipient string
+	// Action is the action that was performed for the recipient
+	Action DSNAction
+	// Status is the enhanced status code (RFC 3463) of the delivery (e.g. "5.1.1")
+	Status string
+	// DiagnosticCode is the diagnostic text of the remote MTA (e.g. the SMTP reply),
+	// without the diagnostic type
+	DiagnosticCode string
+	// RemoteMTA is the MTA that reported the delivery status, if it was provided
+	RemoteMTA string
+}
+
+// IsPermanent returns true if the delivery to the recipient failed permanently
+func (r RecipientStatus) IsPermanent() bool {
+	return strings.HasPrefix(r.Status, "5")
+}
+
+// IsTemp returns true if the delivery to the recipient failed temporarily
+func (r RecipientStatus) IsTemp() bool {
+	return strings.HasPrefix(r.Status, "4")
+}
+
+// ParseDeliveryStatus parses the given message as a delivery status notification. The
+// message must be a "multipart/report" message with the "delivery-status" report type and
+// a "message/delivery-status" part (or "message/global-delivery-status" as described in
+// RFC 6533). Otherwise ErrNoDeliveryStatus is returned
+func ParseDeliveryStatus(r io.Reader) (*DeliveryStatus, error) {
+	var ds *DeliveryStatus
+	err := readReport(r, "delivery-status", func(mt string, pr io.Reader) error {
+		if ds != nil || (mt != "message/delivery-status" && mt != "message/global-delivery-status") {
+			return nil
+		}
+		var err error
+		ds, err = parseDeliveryStatusFields(pr)
+		return err
+	})
+	if errors.Is(err, errNoReport) || (err == nil && ds == nil) {
+		return nil, ErrNoDeliveryStatus
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// parseDeliveryStatusFields parses the per-message and per-recipient field groups of the
+// body of a "message/delivery-status" part
+func parseDeliveryStatusFields(r io.Reader) (*DeliveryStatus, error) {
+	tr := textproto.NewReader(bufio.NewReader(r))
+	var gl []textproto.MIMEHeader
+	for {
+		h, err := tr.ReadMIMEHeader()
+		if len(h) > 0 {
+			gl = append(gl, h)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse delivery status: %w", err)
+		}
+	}
+	if len(gl) == 0 {
+		return nil, ErrNoDeliveryStatus
+	}
+
+	ds := &DeliveryStatus{
+		ReportingMTA:       dsnValue(gl[0].Get("Reporting-MTA")),
+		OriginalEnvelopeID: gl[0].Get("Original-Envelope-Id"),
+	}
+	for _, h := range gl[1:] {
+		ds.Recipients = append(ds.Recipients, RecipientStatus{
+			FinalRecipient:    dsnValue(h.Get("Final-Recipient")),
+			OriginalRecipient: dsnValue(h.Get("Original-Recipient")),
+			Action:            DSNAction(strings.ToLower(strings.TrimSpace(h.Get("Action")))),
+			Status:            dsnStatus(h.Get("Status")),
+			DiagnosticCode:    dsnValue(h.Get("Diagnostic-Code")),
+			RemoteMTA:         dsnValue(h.Get("Remote-MTA")),
+		})
+	}
+	return ds, nil
+}
+
+// dsnValue returns the value of a DSN field without its type (e.g. "rfc822;" or "smtp;")
+func dsnValue(v string) string {
+	if i := strings.IndexByte(v, ';'); i >= 0 {
+		v = v[i+1:]
+	}
+	return strings.TrimSpace(v)
+}
+
+// dsnStatus returns the enhanced status code of a DSN status field without a comment
+func dsnStatus(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexAny(v, " \t("); i >= 0 {
+		v = v[:i]
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testDSN is a bounce message with a delivery status notification for two recipients
+const testDSN = "From: Mail Delivery System <MAILER-DAEMON@mx.example.com>\r\n" +
+	"To: sender@example.com\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status;\r\n" +
+	"\tboundary=\"BOUNDARY\"\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/plain; charset=us-ascii\r\n" +
+	"\r\n" +
+	"I'm sorry to have to inform you that your message could not be delivered.\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"Original-Envelope-Id: ENVID123\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; rcpt@example.com\r\n" +
+	"Original-Recipient: rfc822;Rcpt@Example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Remote-MTA: dns; in.example.com\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 <rcpt@example.com>:\r\n" +
+	"    Recipient address rejected: User unknown\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; other@example.com\r\n" +
+	"Action: Delayed\r\n" +
+	"Status: 4.4.1 (connection timed out)\r\n" +
+	"\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"Subject: Hello\r\n" +
+	"--BOUNDARY--\r\n"
+
+// TestParseDeliveryStatus tests the parsing of delivery status notifications
+func TestParseDeliveryStatus(t *testing.T) {
+	ds, err := ParseDeliveryStatus(strings.NewReader(testDSN))
+	if err != nil {
+		t.Fatalf("ParseDeliveryStatus failed: %s", err)
+	}
+	if ds.ReportingMTA != "mx.example.com" || ds.OriginalEnvelopeID != "ENVID123" {
+		t.Errorf("ParseDeliveryStatus failed. Unexpected per-message fields: %+v", ds)
+	}
+	if len(ds.Recipients) != 2 {
+		t.Fatalf("ParseDeliveryStatus failed. Expected 2 recipients, got: %d", len(ds.Recipients))
+	}
+	r := ds.Recipients[0]
+	if r.FinalRecipient != "rcpt@example.com" || r.OriginalRecipient != "Rcpt@Example.com" ||
+		r.Action != DSNActionFailed || r.Status != "5.1.1" || r.RemoteMTA != "in.example.com" {
+		t.Errorf("ParseDeliveryStatus failed. Unexpected recipient status: %+v", r)
+	}
+	if ed := "550 5.1.1 <rcpt@example.com>: Recipient address rejected: User unknown"; r.DiagnosticCode != ed {
+		t.Errorf("ParseDeliveryStatus failed. Expected diagnostic code: %q, got: %q", ed, r.DiagnosticCode)
+	}
+	if !r.IsPermanent() || r.IsTemp() {
+		t.Errorf("ParseDeliveryStatus failed. Expected permanent failure for %s", r.Status)
+	}
+	r = ds.Recipients[1]
+	if r.Action != DSNActionDelayed || r.Status != "4.4.1" || !r.IsTemp() {
+		t.Errorf("ParseDeliveryStatus failed. Unexpected recipient status: %+v", r)
+	}
+
+	nr := strings.Replace(testDSN, "report-type=delivery-status", "report-type=feedback-report", 1)
+	if _, err := ParseDeliveryStatus(strings.NewReader(nr)); !errors.Is(err, ErrNoDeliveryStatus) {
+		t.Errorf("ParseDeliveryStatus with other report type was expected to fail, got: %v", err)
+	}
+	nr = strings.Replace(testDSN, "message/delivery-status", "text/plain", 1)
+	if _, err := ParseDeliveryStatus(strings.NewReader(nr)); !errors.Is(err, ErrNoDeliveryStatus) {
+		t.Errorf("ParseDeliveryStatus without status part was expected to fail, got: %v", err)
+	}
+}
@@ -4,6 +4,8 @@
 
 package mail
 
+import "io"
+
 // Charset represents a character set for the encoding
 type Charset string
 
@@ -29,8 +31,21 @@ const (
 
 	// NoEncoding avoids any character encoding (except of the mail headers)
 	NoEncoding Encoding = "8bit"
+
+	// Encoding7bit represents content that consists of short lines of US-ASCII characters
+	// only and therefore does not need to be encoded, as specified in RFC 2045.
+	Encoding7bit Encoding = "7bit"
+
+	// EncodingAuto selects the transfer encoding based on the content of each part: 7bit
+	// for pure US-ASCII with short lines, quoted-printable for mostly textual content and
+	// Base64 for binary-heavy content. Mail headers use the "Q" encoding
+	EncodingAuto Encoding = "auto"
 )
 
+// maxLineLength7bit is the maximum length of a line (excluding the CRLF) of 7bit content as
+// specified in RFC 5322
+const maxLineLength7bit = 998
+
 // List of common charsets
 const (
 	// CharsetUTF7 represents the "UTF-7" charset
@@ -141,6 +156,9 @@ const (
 	TypeTextPlain      ContentType = "text/plain"
 	TypeTextHTML       ContentType = "text/html"
 	TypeAppOctetStream ContentType = "application/octet-stream"
+	TypeMessageRFC822  ContentType = "message/rfc822"
+	TypeAppZip         ContentType = "application/zip"
+	TypeTextVCard      ContentType = "text/vcard"
 	TypePGPSignature   ContentType = "application/pgp-signature"
 	TypePGPEncrypted   ContentType = "application/pgp-encrypted"
 )
@@ -148,6 +166,7 @@ const (
 // List of MIMETypes
 const (
 	MIMEAlternative MIMEType = "alternative"
+	MIMEDigest      MIMEType = "digest"
 	MIMEMixed       MIMEType = "mixed"
 	MIMERelated     MIMEType = "related"
 )
@@ -161,3 +180,78 @@ func (e Encoding) String() string {
 func (c Charset) String() string {
 	return string(c)
 }
+
+// encodingDetector is an io.Writer that inspects the content written to it, so that the most
+// compact transfer encoding can be selected for EncodingAuto
+type encodingDetector struct {
+	// n is the number of bytes written
+	n int64
+	// esc is the number of bytes that need to be escaped in quoted-printable
+	esc int64
+	// ll is the length of the current line
+	ll int
+	// non7bit is true if the content contains characters that are not allowed in 7bit
+	non7bit bool
+	// long is true if the content contains lines longer than maxLineLength7bit
+	long bool
+	// binary is true if the content contains NUL bytes
+	binary bool
+}
+
+// Write satisfies the io.Writer interface for the encodingDetector
+func (d *encodingDetector) Write(p []byte) (int, error) {
+	for _, b := range p {
+		d.n++
+		switch {
+		case b == '\n':
+			d.ll = 0
+			continue
+		case b == 0:
+			d.binary = true
+			d.non7bit = true
+			d.esc++
+		case b >= 0x80 || (b < 0x20 && b != '\t' && b != '\r') || b == 0x7f:
+			d.non7bit = true
+			d.esc++
+		case b == '=':
+			d.esc++
+		}
+		if b != '\r' {
+			d.ll++
+		}
+		if d.ll > maxLineLength7bit {
+			d.long = true
+		}
+	}
+	return len(p), nil
+}
+
+// encoding returns the transfer encoding for the content that was written to the
+// encodingDetector. Since quoted-printable expands each escaped byte to three characters
+// and Base64 expands the content by a third, quoted-printable is only selected if less
+// than a sixth of the content needs to be escaped
+func (d *encodingDetector) encoding() Encoding {
+	switch {
+	case d.binary:
+		return EncodingB64
+	case !d.non7bit && !d.long:
+		return Encoding7bit
+	case d.esc*6 < d.n:
+		return EncodingQP
+	default:
+		return EncodingB64
+	}
+}
+
+// autoEncoding returns the transfer encoding that the encodingDetector selects for the
+// content of the given writer function. If the content cannot be read, Base64 is used
+func autoEncoding(f func(io.Writer) (int64, error)) Encoding {
+	if f == nil {
+		return EncodingB64
+	}
+	d := &encodingDetector{}
+	if _, err := f(d); err != nil {
+		return EncodingB64
+	}
+	return d.encoding()
+}
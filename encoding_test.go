@@ -4,7 +4,11 @@
 
 package mail
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
 
 // TestEncoding_String tests the string method of the Encoding object
 func TestEncoding_String(t *testing.T) {
@@ -16,6 +20,8 @@ func TestEncoding_String(t *testing.T) {
 		{"Encoding: Base64", EncodingB64, "base64"},
 		{"Encoding: QP", EncodingQP, "quoted-printable"},
 		{"Encoding: None/8bit", NoEncoding, "8bit"},
+		{"Encoding: 7bit", Encoding7bit, "7bit"},
+		{"Encoding: Auto", EncodingAuto, "auto"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -76,3 +82,36 @@ func TestCharset_String(t *testing.T) {
 		})
 	}
 }
+
+// TestEncodingAuto tests the transfer encoding selection of EncodingAuto
+func TestEncodingAuto(t *testing.T) {
+	tests := []struct {
+		name string
+		c    string
+		want Encoding
+	}{
+		{"Plain ASCII", "This is a test body\r\nwith two lines", Encoding7bit},
+		{"ASCII with long line", strings.Repeat("a", maxLineLength7bit+1), EncodingQP},
+		{"Mostly ASCII with umlauts", "Dies ist ein Test mit Umlauten: äöü", EncodingQP},
+		{"Mostly non-ASCII", "これは日本語のテキストです", EncodingB64},
+		{"Binary", "GIF89a\x00\x01\x00", EncodingB64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if e := autoEncoding(writeFuncFromBuffer(bytes.NewBufferString(tt.c))); e != tt.want {
+				t.Errorf("autoEncoding failed. Expected: %s, got: %s", tt.want, e)
+			}
+
+			m := NewMsg(WithEncoding(EncodingAuto))
+			m.SetBodyString(TypeTextPlain, tt.c)
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			w := "Content-Transfer-Encoding: " + tt.want.String() + "\r\n"
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("EncodingAuto failed. Expected %q in message, got: %s", w, buf.String())
+			}
+		})
+	}
+}
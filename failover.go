@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultFailoverCooldown is the default time a relay is avoided after it failed
+const DefaultFailoverCooldown = time.Minute
+
+// failover tracks the health of an ordered list of relays. A relay that failed is avoided
+// until its cooldown has passed, so that recovered relays are preferred again. It is safe
+// for concurrent use
+type failover struct {
+	mu sync.Mutex
+	// hosts is the ordered list of relays, starting with the primary host
+	hosts []string
+	// cooldown is the time a relay is avoided after it failed
+	cooldown time.Duration
+	// down holds the time until which a failed relay is avoided
+	down map[string]time.Time
+	// now returns the current time
+	now func() time.Time
+}
+
+// newFailover returns a new failover for the given ordered list of relays
+func newFailover(hl []string, cd time.Duration) *failover {
+	return &failover{hosts: hl, cooldown: cd, down: make(map[string]time.Time), now: time.Now}
+}
+
+// order returns the relays in the order in which connections should be attempted. Healthy
+// relays are returned in their configured order, followed by the failed relays in the
+// order in which their cooldown ends
+func (f *failover) order() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	hl := make([]string, 0, len(f.hosts))
+	var dl []string
+	for _, h := range f.hosts {
+		if t, ok := f.down[h]; ok && now.Before(t) {
+			dl = append(dl, h)
+			continue
+		}
+		hl = append(hl, h)
+	}
+	sort.SliceStable(dl, func(i, j int) bool { return f.down[dl[i]].Before(f.down[dl[j]]) })
+	return append(hl, dl...)
+}
+
+// markDown marks the given relay as failed
+func (f *failover) markDown(h string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down[h] = f.now().Add(f.cooldown)
+}
+
+// markUp marks the given relay as healthy
+func (f *failover) markUp(h string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.down, h)
+}
@@ -5,21 +5,69 @@
 package mail
 
 import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
+	"net/http"
 	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is the maximum number of bytes that are considered for the content type detection
+const sniffLen = 512
+
+// errSniffDone is returned by the sniffWriter once it holds sniffLen bytes, so that the
+// File.Writer stops instead of copying the remaining content
+var errSniffDone = errors.New("content type detection done")
+
+const (
+	// DispositionAttachment is the "attachment" disposition type of the Content-Disposition header
+	DispositionAttachment Disposition = "attachment"
+
+	// DispositionInline is the "inline" disposition type of the Content-Disposition header
+	DispositionInline Disposition = "inline"
 )
 
 // FileOption returns a function that can be used for grouping File options
 type FileOption func(*File)
 
+// Disposition represents the disposition type of the Content-Disposition header of a File
+// as described in RFC2183
+type Disposition string
+
 // File is an attachment or embedded file of the Msg
 type File struct {
+	ContentID   string
 	ContentType ContentType
 	Desc        string
+	Disposition Disposition
 	Enc         Encoding
 	Header      textproto.MIMEHeader
 	Name        string
 	Writer      func(w io.Writer) (int64, error)
+
+	// path is the absolute path of a File that was added from the system's file system. It is
+	// used to reference the File in the JSON representation of the Msg
+	path string
+
+	// filter decides whether the File is added by AttachGlob or AttachDir (see WithFileFilter)
+	filter func(*File) bool
+
+	// checksums are the checksum header fields of the File (see WithFileChecksum)
+	checksums []Header
+
+	// password is the password of the encrypted ZIP archive of the File (see WithFilePassword)
+	password string
+
+	// size returns the length of the content of the File without reading it. It is nil if
+	// the length is not known in advance
+	size func() (int64, error)
 }
 
 // WithFileName sets the filename of the File
@@ -37,6 +85,23 @@ func WithFileDescription(d string) FileOption {
 	}
 }
 
+// WithFileContentID sets the Content-ID of the File, so that it can be referenced in the
+// HTML body via "cid:<id>". The angle brackets of the header value are added automatically.
+// If no Content-ID is set, embedded files use their file name as Content-ID
+func WithFileContentID(id string) FileOption {
+	return func(f *File) {
+		f.ContentID = strings.TrimSuffix(strings.TrimPrefix(id, "<"), ">")
+	}
+}
+
+// WithFileDisposition overrides the disposition type of the File. By default attachments
+// use DispositionAttachment and embeds use DispositionInline
+func WithFileDisposition(d Disposition) FileOption {
+	return func(f *File) {
+		f.Disposition = d
+	}
+}
+
 // WithFileEncoding sets the encoding of the File. By default we should always use
 // Base64 encoding but there might be exceptions, where this might come handy.
 // Please note that quoted-printable should never be used for attachments/embeds. If this
@@ -62,6 +127,65 @@ func WithFileContentType(t ContentType) FileOption {
 	}
 }
 
+// WithFileChecksum adds checksum header fields of the content to the File, so that receivers
+// can verify its integrity. Supported are HeaderContentMD5 (RFC 1864) and HeaderXContentSHA256.
+// If no header field is given, both are added. The checksums are calculated when the Msg is
+// written, which requires to read the content one additional time
+func WithFileChecksum(hl ...Header) FileOption {
+	return func(f *File) {
+		if len(hl) == 0 {
+			hl = []Header{HeaderContentMD5, HeaderXContentSHA256}
+		}
+		f.checksums = hl
+	}
+}
+
+// setChecksumHeaders calculates the checksums of the File content and sets the checksum header
+// fields that were requested via WithFileChecksum
+func (f *File) setChecksumHeaders() error {
+	if len(f.checksums) == 0 || f.Writer == nil {
+		return nil
+	}
+	mh, sh := md5.New(), sha256.New()
+	if _, err := f.Writer(io.MultiWriter(mh, sh)); err != nil {
+		return fmt.Errorf("failed to calculate checksum of file %q: %w", f.Name, err)
+	}
+	for _, h := range f.checksums {
+		switch h {
+		case HeaderContentMD5:
+			f.setHeader(h, base64.StdEncoding.EncodeToString(mh.Sum(nil)))
+		case HeaderXContentSHA256:
+			f.setHeader(h, hex.EncodeToString(sh.Sum(nil)))
+		}
+	}
+	return nil
+}
+
+// mediaType returns the content type of the File. If no content type is set, it is guessed
+// by the file extension or, as last resort, by the content
+func (f *File) mediaType() string {
+	mt := string(f.ContentType)
+	if mt == "" {
+		mt = mime.TypeByExtension(filepath.Ext(f.Name))
+	}
+	if mt == "" {
+		mt = f.sniffContentType()
+	}
+	return mt
+}
+
+// contentID returns the Content-ID of the File without angle brackets. Files without
+// Content-ID use their file name, like embeds do when the Msg is written
+func (f *File) contentID() string {
+	if v, ok := f.getHeader(HeaderContentID); ok {
+		return strings.TrimSuffix(strings.TrimPrefix(v, "<"), ">")
+	}
+	if f.ContentID != "" {
+		return f.ContentID
+	}
+	return f.Name
+}
+
 // setHeader sets header fields to a File
 func (f *File) setHeader(h Header, v string) {
 	f.Header.Set(string(h), v)
@@ -72,3 +196,47 @@ func (f *File) getHeader(h Header) (string, bool) {
 	v := f.Header.Get(string(h))
 	return v, v != ""
 }
+
+// sniffContentType detects the content type of the File based on its first bytes, using
+// the algorithm of http.DetectContentType. If the content cannot be read, it falls back to
+// application/octet-stream
+func (f *File) sniffContentType() string {
+	if f.Writer == nil {
+		return string(TypeAppOctetStream)
+	}
+	sw := &sniffWriter{}
+	if _, err := f.Writer(sw); err != nil && !errors.Is(err, errSniffDone) {
+		return string(TypeAppOctetStream)
+	}
+	return http.DetectContentType(sw.buf)
+}
+
+// sniffWriter is an io.Writer that keeps the first sniffLen bytes written to it and returns
+// errSniffDone afterwards
+type sniffWriter struct {
+	buf []byte
+}
+
+// Write satisfies the io.Writer interface for the sniffWriter
+func (sw *sniffWriter) Write(p []byte) (int, error) {
+	if r := sniffLen - len(sw.buf); r > 0 {
+		if len(p) < r {
+			r = len(p)
+		}
+		sw.buf = append(sw.buf, p[:r]...)
+		if len(sw.buf) < sniffLen {
+			return len(p), nil
+		}
+		return r, errSniffDone
+	}
+	return 0, errSniffDone
+}
+
+// WithFileFilter sets a filter function for the helpers that add multiple files at once, like
+// AttachGlob or AttachDir. Files for which the filter returns false are skipped. The filter is
+// called after all other FileOption functions have been applied
+func WithFileFilter(ff func(*File) bool) FileOption {
+	return func(f *File) {
+		f.filter = ff
+	}
+}
@@ -4,7 +4,13 @@
 
 package mail
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
 
 // TestFile_SetGetHeader tests the set-/getHeader method of the File object
 func TestFile_SetGetHeader(t *testing.T) {
@@ -56,6 +62,61 @@ func TestFile_WithFileDescription(t *testing.T) {
 	}
 }
 
+// TestFile_WithFileContentID tests the WithFileContentID option
+func TestFile_WithFileContentID(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		attach bool
+		want   string
+	}{
+		{"Embed with Content-ID", "logo@example.com", false, "<logo@example.com>"},
+		{"Embed with bracketed Content-ID", "<logo@example.com>", false, "<logo@example.com>"},
+		{"Embed without Content-ID", "", false, "<file.go>"},
+		{"Attachment with Content-ID", "doc@example.com", true, "<doc@example.com>"},
+		{"Attachment without Content-ID", "", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			var o []FileOption
+			if tt.id != "" {
+				o = append(o, WithFileContentID(tt.id))
+			}
+			fl := func() []*File {
+				if tt.attach {
+					m.AttachFile("file.go", o...)
+					return m.GetAttachments()
+				}
+				m.EmbedFile("file.go", o...)
+				return m.GetEmbeds()
+			}()
+			if _, err := m.WriteTo(io.Discard); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			if v, _ := fl[0].getHeader(HeaderContentID); v != tt.want {
+				t.Errorf("WithFileContentID() failed. Expected Content-ID: %q, got: %q", tt.want, v)
+			}
+		})
+	}
+}
+
+// TestFile_WithFileDisposition tests the WithFileDisposition option
+func TestFile_WithFileDisposition(t *testing.T) {
+	m := NewMsg()
+	m.AttachFile("file.go", WithFileDisposition(DispositionInline))
+	m.EmbedFile("file_test.go", WithFileDisposition(DispositionAttachment))
+	if _, err := m.WriteTo(io.Discard); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if v, _ := m.GetAttachments()[0].getHeader(HeaderContentDisposition); !strings.HasPrefix(v, "inline;") {
+		t.Errorf("WithFileDisposition() failed. Expected inline disposition, got: %s", v)
+	}
+	if v, _ := m.GetEmbeds()[0].getHeader(HeaderContentDisposition); !strings.HasPrefix(v, "attachment;") {
+		t.Errorf("WithFileDisposition() failed. Expected attachment disposition, got: %s", v)
+	}
+}
+
 // TestFile_WithFileEncoding tests the WithFileEncoding option
 func TestFile_WithFileEncoding(t *testing.T) {
 	tests := []struct {
@@ -111,3 +172,128 @@ func TestFile_WithFileContentType(t *testing.T) {
 		})
 	}
 }
+
+// TestFile_sniffContentType tests the content type detection of the File
+func TestFile_sniffContentType(t *testing.T) {
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 1024)...)
+	tests := []struct {
+		name string
+		w    func(io.Writer) (int64, error)
+		want string
+	}{
+		{"PNG", writeFuncFromBuffer(bytes.NewBuffer(png)), "image/png"},
+		{"PDF", writeFuncFromBuffer(bytes.NewBufferString("%PDF-1.7\n")), "application/pdf"},
+		{"Text", writeFuncFromBuffer(bytes.NewBufferString("This is a test")), "text/plain; charset=utf-8"},
+		{"Binary", writeFuncFromBuffer(bytes.NewBuffer([]byte{0, 1, 2, 3})), "application/octet-stream"},
+		{"No writer", nil, "application/octet-stream"},
+		{"Broken writer", func(io.Writer) (int64, error) {
+			return 0, errors.New("failing intentionally")
+		}, "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &File{Name: "noext", Header: make(map[string][]string), Writer: tt.w}
+			if ct := f.sniffContentType(); ct != tt.want {
+				t.Errorf("sniffContentType() failed. Expected: %q, got: %q", tt.want, ct)
+			}
+		})
+	}
+}
+
+// TestFile_sniffContentType_msg tests that the detected content type is used for the
+// rendered attachment and that the content stays intact
+func TestFile_sniffContentType_msg(t *testing.T) {
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{1}, 1024)...)
+	m := NewMsg()
+	m.SetBodyString(TypeTextPlain, "This is a test")
+	m.AttachReader("logo", bytes.NewReader(png))
+	m.AttachReader("test.txt", bytes.NewReader(png))
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), `Content-Type: image/png; name="logo"`) {
+		t.Errorf("expected sniffed image/png Content-Type for attachment without extension")
+	}
+	if !strings.Contains(buf.String(), `Content-Type: text/plain; charset=utf-8; name="test.txt"`) {
+		t.Errorf("expected Content-Type by extension for attachment with extension")
+	}
+	f := m.GetAttachments()[0]
+	wbuf := bytes.Buffer{}
+	if _, err := f.Writer(&wbuf); err != nil {
+		t.Fatalf("failed to execute WriterFunc: %s", err)
+	}
+	if !bytes.Equal(wbuf.Bytes(), png) {
+		t.Errorf("attachment content was altered by the content type detection")
+	}
+}
+
+// TestFile_sniffContentType_stop tests that the content type detection stops reading the
+// content once it has enough bytes and that an io.ReadSeeker is rewound afterwards
+func TestFile_sniffContentType_stop(t *testing.T) {
+	var n int64
+	f := &File{Name: "noext", Header: make(map[string][]string), Writer: func(w io.Writer) (int64, error) {
+		for i := 0; i < 1024; i++ {
+			c, err := w.Write(bytes.Repeat([]byte("a"), 100))
+			n += int64(c)
+			if err != nil {
+				return n, err
+			}
+		}
+		return n, nil
+	}}
+	if ct := f.sniffContentType(); ct != "text/plain; charset=utf-8" {
+		t.Errorf("sniffContentType() failed. Expected: %q, got: %q", "text/plain; charset=utf-8", ct)
+	}
+	if n != sniffLen {
+		t.Errorf("sniffContentType() was expected to read %d bytes, got: %d", sniffLen, n)
+	}
+
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{1}, 1024)...)
+	m := NewMsg()
+	m.AttachReadSeeker("logo", bytes.NewReader(png))
+	f = m.GetAttachments()[0]
+	if ct := f.sniffContentType(); ct != "image/png" {
+		t.Errorf("sniffContentType() failed. Expected: %q, got: %q", "image/png", ct)
+	}
+	buf := bytes.Buffer{}
+	if _, err := f.Writer(&buf); err != nil {
+		t.Fatalf("failed to execute WriterFunc: %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), png) {
+		t.Errorf("attachment content was altered by the content type detection")
+	}
+}
+
+// TestFile_WithFileChecksum tests the WithFileChecksum option
+func TestFile_WithFileChecksum(t *testing.T) {
+	tests := []struct {
+		name string
+		hl   []Header
+		md5  bool
+		sha  bool
+	}{
+		{"default", nil, true, true},
+		{"Content-MD5", []Header{HeaderContentMD5}, true, false},
+		{"X-Content-SHA256", []Header{HeaderXContentSHA256}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			m.AttachReader("hello.txt", strings.NewReader("hello"), WithFileChecksum(tt.hl...))
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			// Long header fields are folded
+			ms := strings.ReplaceAll(buf.String(), "\r\n ", " ")
+			if c := strings.Contains(ms, "Content-Md5: XUFAKrxLKna5cZ2REBfFkg==\r\n"); c != tt.md5 {
+				t.Errorf("WithFileChecksum failed. Expected Content-MD5: %t, got: %s", tt.md5, ms)
+			}
+			sha := "X-Content-Sha256: 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824\r\n"
+			if c := strings.Contains(ms, sha); c != tt.sha {
+				t.Errorf("WithFileChecksum failed. Expected X-Content-SHA256: %t, got: %s", tt.sha, ms)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// FlowedLineLength is the length at which the lines of format=flowed parts are wrapped.
+// RFC 3676 recommends 66 to 78 characters
+const FlowedLineLength = 72
+
+// flowedWriteFunc returns a writer function that renders the content of the given writer
+// function as format=flowed text with DelSp=yes as described in RFC 3676
+func flowedWriteFunc(f func(io.Writer) (int64, error)) func(io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		buf := bytes.Buffer{}
+		if _, err := f(&buf); err != nil {
+			return 0, err
+		}
+		n, err := io.WriteString(w, flowText(buf.String()))
+		return int64(n), err
+	}
+}
+
+// flowText wraps the lines of the given text at FlowedLineLength characters. Each wrapped
+// line ends with a soft break (a space that is deleted when the text is reflowed due to
+// DelSp=yes), trailing spaces of hard breaks are removed and lines that start with a space,
+// ">" or "From " are space-stuffed
+func flowText(s string) string {
+	ll := strings.Split(strings.ReplaceAll(s, SingleNewLine, "\n"), "\n")
+	buf := strings.Builder{}
+	for i, l := range ll {
+		// The signature separator is the only hard line that keeps its trailing space
+		if l != "-- " {
+			l = strings.TrimRight(l, " ")
+		}
+		for utf8.RuneCountInString(l) > FlowedLineLength {
+			j := flowBreak(l)
+			if j < 0 {
+				break
+			}
+			writeStuffed(&buf, l[:j+1]+" ")
+			buf.WriteString(SingleNewLine)
+			l = l[j+1:]
+		}
+		writeStuffed(&buf, l)
+		if i < len(ll)-1 {
+			buf.WriteString(SingleNewLine)
+		}
+	}
+	return buf.String()
+}
+
+// flowBreak returns the byte index of the last space within the first FlowedLineLength
+// characters of the given line, or of the first space after it, if the line has no space
+// within the limit. It returns -1 if the line cannot be wrapped
+func flowBreak(l string) int {
+	b := -1
+	c := 0
+	for i, r := range l {
+		if c > FlowedLineLength && b > 0 {
+			break
+		}
+		if r == ' ' && i > 0 {
+			b = i
+			if c >= FlowedLineLength {
+				break
+			}
+		}
+		c++
+	}
+	return b
+}
+
+// writeStuffed writes the given line to the strings.Builder and adds a leading space if the
+// line starts with a space, ">" or "From "
+func writeStuffed(buf *strings.Builder, l string) {
+	if strings.HasPrefix(l, " ") || strings.HasPrefix(l, ">") || strings.HasPrefix(l, "From ") {
+		buf.WriteString(" ")
+	}
+	buf.WriteString(l)
+}
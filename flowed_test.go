@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestFlowText tests the format=flowed rendering of flowText
+func TestFlowText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"Short line", "This is a test"},
+		{"Long paragraph", strings.Repeat("This is a long paragraph with many words. ", 20)},
+		{"Long paragraph with umlauts", strings.Repeat("Dies ist ein Absatz mit Ümläuten. ", 20)},
+		{"Long word", strings.Repeat("x", 100) + " and some more text"},
+		{"Multiple paragraphs", "First paragraph\r\n\r\n" + strings.Repeat("Second paragraph. ", 10)},
+		{"Stuffing", "> not a quote\r\nFrom here\r\n indented"},
+		{"Signature separator", "Text\r\n-- \r\nSignature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := flowText(tt.text)
+			for _, l := range strings.Split(ft, SingleNewLine) {
+				if utf8.RuneCountInString(l) > 78 && strings.Contains(strings.TrimSpace(l), " ") {
+					t.Errorf("flowText failed. Line exceeds 78 characters: %q", l)
+				}
+			}
+			ev := tt.text
+			if tt.name != "Signature separator" {
+				ev = strings.TrimRight(ev, " ")
+			}
+			if u := testUnflow(ft); u != ev {
+				t.Errorf("flowText failed. Reflowed text mismatch.\nExpected: %q\ngot:      %q", ev, u)
+			}
+		})
+	}
+}
+
+// TestWithPartFlowed tests the WithPartFlowed option
+func TestWithPartFlowed(t *testing.T) {
+	m := NewMsg()
+	m.SetBodyString(TypeTextPlain, strings.Repeat("This is a long paragraph with many words. ", 5),
+		WithPartFlowed())
+	m.AddAlternativeString(TypeTextHTML, "<p>This is a test</p>", WithPartFlowed())
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), "text/plain; charset=UTF-8; format=flowed; delsp=yes") {
+		t.Errorf("WithPartFlowed failed. Expected format=flowed parameter in message, got: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "text/html; charset=UTF-8; format=flowed") {
+		t.Errorf("WithPartFlowed failed. format=flowed is only supported for text/plain parts")
+	}
+	if !strings.Contains(buf.String(), " =20\r\n") {
+		t.Errorf("WithPartFlowed failed. Expected soft break in message, got: %s", buf.String())
+	}
+}
+
+// testUnflow reflows the given format=flowed text with DelSp=yes as described in RFC 3676
+func testUnflow(s string) string {
+	buf := strings.Builder{}
+	ll := strings.Split(s, SingleNewLine)
+	for i, l := range ll {
+		l = strings.TrimPrefix(l, " ")
+		if strings.HasSuffix(l, " ") && l != "-- " {
+			buf.WriteString(strings.TrimSuffix(l, " "))
+			continue
+		}
+		buf.WriteString(l)
+		if i < len(ll)-1 {
+			buf.WriteString(SingleNewLine)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// GobEncode satisfies the gob.GobEncoder interface for the Msg. The binary representation
+// holds the same data as the JSON representation (see MarshalJSON), but without the overhead
+// of the Base64 encoded files, so that it suits disk spools and cross-process queues. With
+// WithJSONFileReferences, files of the file system are referenced by their path and are only
+// read when the decoded Msg is rendered
+func (m *Msg) GobEncode() ([]byte, error) {
+	md, err := m.data()
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(md); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode satisfies the gob.GobDecoder interface for the Msg. It replaces the Msg with the
+// Msg of the given binary representation (see GobEncode)
+func (m *Msg) GobDecode(b []byte) error {
+	var md msgData
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&md); err != nil {
+		return err
+	}
+	return m.setData(&md)
+}
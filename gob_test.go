@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMsg_GobEncode tests that a Msg is reconstructed from its gob encoding
+func TestMsg_GobEncode(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "lazy.bin")
+	if err := os.WriteFile(p, []byte("original"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	m := NewMsg(WithJSONFileReferences(), WithBoundary("test-boundary"))
+	if err := m.From("from@example.com"); err != nil {
+		t.Fatalf("failed to set From: %s", err)
+	}
+	if err := m.To("to@example.com"); err != nil {
+		t.Fatalf("failed to set To: %s", err)
+	}
+	m.Subject("Gob test")
+	m.SetDateWithValue(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	m.SetMessageIDWithValue("gob.test@example.com")
+	m.SetBodyString(TypeTextPlain, "Plain text")
+	data := bytes.Repeat([]byte{0x00, 0xff}, 4096)
+	m.AttachReader("data.bin", bytes.NewReader(data))
+	m.AttachFile(p)
+
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatalf("failed to encode Msg: %s", err)
+	}
+	jb, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal Msg: %s", err)
+	}
+	if buf.Len() >= len(jb) {
+		t.Errorf("gob encoding (%d bytes) was expected to be smaller than JSON (%d bytes)", buf.Len(), len(jb))
+	}
+
+	// Referenced files are read when the decoded Msg is rendered
+	if err := os.WriteFile(p, []byte("changed"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	var dm Msg
+	if err := gob.NewDecoder(&buf).Decode(&dm); err != nil {
+		t.Fatalf("failed to decode Msg: %s", err)
+	}
+	al := dm.GetAttachments()
+	if len(al) != 2 {
+		t.Fatalf("expected 2 attachments after decoding, got: %d", len(al))
+	}
+	ab := bytes.Buffer{}
+	if _, err := al[0].Writer(&ab); err != nil || !bytes.Equal(ab.Bytes(), data) {
+		t.Errorf("attachment content differs after decoding (err: %v)", err)
+	}
+	ab.Reset()
+	if _, err := al[1].Writer(&ab); err != nil || ab.String() != "changed" {
+		t.Errorf("referenced attachment was expected to be read lazily, got: %q (err: %v)", ab.String(), err)
+	}
+	out := bytes.Buffer{}
+	if _, err := dm.WriteTo(&out); err != nil {
+		t.Fatalf("failed to write decoded Msg: %s", err)
+	}
+	if !strings.Contains(out.String(), "Subject: Gob test\r\n") {
+		t.Errorf("decoded Msg has no subject: %s", out.String())
+	}
+
+	if err := dm.GobDecode([]byte("invalid")); err == nil {
+		t.Errorf("GobDecode with invalid data was expected to fail")
+	}
+}
@@ -4,6 +4,11 @@
 
 package mail
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Header represents a generic mail header field name
 type Header string
 
@@ -13,8 +18,32 @@ type AddrHeader string
 // Importance represents a Importance/Priority value string
 type Importance int
 
+// AutoSubmitted represents a value of the Auto-Submitted header field as described in RFC 3834
+type AutoSubmitted string
+
+// AutoResponseSuppress represents a value of the X-Auto-Response-Suppress header field, which
+// tells Microsoft Exchange which automatic responses to suppress
+type AutoResponseSuppress string
+
+// HeaderPolicy defines how a Msg handles header fields with names or values that contain
+// characters that are not allowed in a mail header, like CR or LF
+type HeaderPolicy int
+
+// HeaderError is returned if the name or value of a header field contains characters that
+// are not allowed in a mail header. Such values could otherwise be abused for header injection
+type HeaderError struct {
+	Header Header
+	Value  string
+}
+
 // List of common generic header field names
 const (
+	// HeaderAutoSubmitted is the "Auto-Submitted" header field as described in RFC 3834
+	HeaderAutoSubmitted Header = "Auto-Submitted"
+
+	// HeaderComments is the "Comments" header field
+	HeaderComments Header = "Comments"
+
 	// HeaderContentDescription is the "Content-Description" header
 	HeaderContentDescription Header = "Content-Description"
 
@@ -27,6 +56,9 @@ const (
 	// HeaderContentLang is the "Content-Language" header
 	HeaderContentLang Header = "Content-Language"
 
+	// HeaderContentMD5 is the "Content-MD5" header (RFC 1864)
+	HeaderContentMD5 Header = "Content-MD5"
+
 	// HeaderContentLocation is the "Content-Location" header (RFC 2110)
 	HeaderContentLocation Header = "Content-Location"
 
@@ -73,18 +105,30 @@ const (
 	// HeaderPriority represents the "Priority" field
 	HeaderPriority Header = "Priority"
 
-	// HeaderReplyTo is the "Reply-To" header field
-	HeaderReplyTo Header = "Reply-To"
+	// HeaderReferences is the "References" header field
+	HeaderReferences Header = "References"
 
 	// HeaderSubject is the "Subject" header field
 	HeaderSubject Header = "Subject"
 
+	// HeaderTLSRequired is the "TLS-Required" header field as described in RFC 8689
+	// See: https://www.rfc-editor.org/rfc/rfc8689#section-5
+	HeaderTLSRequired Header = "TLS-Required"
+
 	// HeaderUserAgent is the "User-Agent" header field
 	HeaderUserAgent Header = "User-Agent"
 
 	// HeaderXMailer is the "X-Mailer" header field
 	HeaderXMailer Header = "X-Mailer"
 
+	// HeaderXAutoResponseSuppress is the Microsoft Exchange specific "X-Auto-Response-Suppress"
+	// header field
+	HeaderXAutoResponseSuppress Header = "X-Auto-Response-Suppress"
+
+	// HeaderXContentSHA256 is the "X-Content-SHA256" header field, which holds the hex encoded
+	// SHA-256 checksum of an attachment
+	HeaderXContentSHA256 Header = "X-Content-SHA256"
+
 	// HeaderXMSMailPriority is the "X-MSMail-Priority" header field
 	HeaderXMSMailPriority Header = "X-MSMail-Priority"
 
@@ -107,10 +151,68 @@ const (
 	// HeaderFrom is the "From" header field
 	HeaderFrom AddrHeader = "From"
 
+	// HeaderReplyTo is the "Reply-To" header field
+	HeaderReplyTo AddrHeader = "Reply-To"
+
 	// HeaderTo is the "Receipient" header field
 	HeaderTo AddrHeader = "To"
 )
 
+// List of HeaderPolicy values
+const (
+	// HeaderPolicyReject rejects invalid header fields. The header field is not set and
+	// rendering the Msg fails with a HeaderError
+	HeaderPolicyReject HeaderPolicy = iota
+
+	// HeaderPolicySanitize replaces control characters in header values with spaces and
+	// removes invalid characters from header names
+	HeaderPolicySanitize
+)
+
+// List of AutoSubmitted values
+const (
+	// AutoSubmittedNo indicates that the Msg was originated by a human
+	AutoSubmittedNo AutoSubmitted = "no"
+
+	// AutoGenerated indicates that the Msg was generated by an automatic process (e.g. a
+	// notification) and is not a response to another message
+	AutoGenerated AutoSubmitted = "auto-generated"
+
+	// AutoReplied indicates that the Msg is an automatic response to another message (e.g. an
+	// out-of-office reply)
+	AutoReplied AutoSubmitted = "auto-replied"
+
+	// AutoNotified indicates that the Msg is a Sieve notification as described in RFC 5436
+	AutoNotified AutoSubmitted = "auto-notified"
+)
+
+// List of AutoResponseSuppress values
+const (
+	// AutoResponseSuppressNone suppresses no automatic responses
+	AutoResponseSuppressNone AutoResponseSuppress = "None"
+
+	// AutoResponseSuppressAll suppresses all automatic responses
+	AutoResponseSuppressAll AutoResponseSuppress = "All"
+
+	// AutoResponseSuppressDR suppresses delivery reports
+	AutoResponseSuppressDR AutoResponseSuppress = "DR"
+
+	// AutoResponseSuppressNDR suppresses non-delivery reports
+	AutoResponseSuppressNDR AutoResponseSuppress = "NDR"
+
+	// AutoResponseSuppressRN suppresses read notifications
+	AutoResponseSuppressRN AutoResponseSuppress = "RN"
+
+	// AutoResponseSuppressNRN suppresses not read notifications
+	AutoResponseSuppressNRN AutoResponseSuppress = "NRN"
+
+	// AutoResponseSuppressOOF suppresses out-of-office replies
+	AutoResponseSuppressOOF AutoResponseSuppress = "OOF"
+
+	// AutoResponseSuppressAutoReply suppresses auto replies other than out-of-office replies
+	AutoResponseSuppressAutoReply AutoResponseSuppress = "AutoReply"
+)
+
 // List of Importance values
 const (
 	ImportanceLow Importance = iota
@@ -177,3 +279,128 @@ func (h Header) String() string {
 func (a AddrHeader) String() string {
 	return string(a)
 }
+
+// Error implements the error interface for the HeaderError type
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("header field %q contains invalid characters", e.Header)
+}
+
+// validHeaderName returns true if the given Header only consists of printable US-ASCII
+// characters except colon as required by RFC 5322, section 2.2
+func validHeaderName(h Header) bool {
+	if h == "" {
+		return false
+	}
+	for i := 0; i < len(h); i++ {
+		if h[i] < '!' || h[i] > '~' || h[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeHeaderName removes all characters from the given Header that are not allowed in
+// a header field name
+func sanitizeHeaderName(h Header) Header {
+	sb := strings.Builder{}
+	for i := 0; i < len(h); i++ {
+		if h[i] >= '!' && h[i] <= '~' && h[i] != ':' {
+			sb.WriteByte(h[i])
+		}
+	}
+	return Header(sb.String())
+}
+
+// validHeaderValue returns true if the given header value contains no control characters
+// other than tab. If pf is true, the value is preformatted and may contain folding white
+// space (CRLF followed by a space or tab)
+func validHeaderValue(v string, pf bool) bool {
+	for i := 0; i < len(v); i++ {
+		if pf && isFold(v, i) {
+			i += 2
+			continue
+		}
+		if isCtl(v[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeHeaderValue replaces all control characters other than tab (and folding white
+// space for preformatted values) in the given header value with spaces
+func sanitizeHeaderValue(v string, pf bool) string {
+	sb := strings.Builder{}
+	for i := 0; i < len(v); i++ {
+		if pf && isFold(v, i) {
+			sb.WriteString(v[i : i+3])
+			i += 2
+			continue
+		}
+		if isCtl(v[i]) {
+			sb.WriteByte(' ')
+			continue
+		}
+		sb.WriteByte(v[i])
+	}
+	return sb.String()
+}
+
+// isFold returns true if the given string has a folding white space at position i
+func isFold(v string, i int) bool {
+	return i+2 < len(v) && v[i] == '\r' && v[i+1] == '\n' && (v[i+2] == ' ' || v[i+2] == '\t')
+}
+
+// isCtl returns true if the given byte is a control character other than tab
+func isCtl(b byte) bool {
+	return (b < ' ' && b != '\t') || b == 0x7f
+}
+
+// canonicalHeaderOrder is the canonical order of the header fields of a rendered Msg: trace
+// and authentication fields, the origination date, the originator, destination, identification
+// and informational fields as described in RFC 5322, section 3.6. Fields that are not listed
+// follow in alphabetical order and the MIME fields come last
+var canonicalHeaderOrder = [][]string{
+	{
+		"return-path", "received", "arc-seal", "arc-message-signature", "arc-authentication-results",
+		"authentication-results", "dkim-signature",
+	},
+	{"date"},
+	{"from", "sender", "reply-to"},
+	{"to", "cc", "bcc"},
+	{"message-id", "in-reply-to", "references"},
+	{"subject", "comments", "keywords"},
+	nil,
+	{"mime-version", "content-type", "content-transfer-encoding"},
+}
+
+// headerRank returns the position of the given header field name within the given custom
+// order or, if it is not part of it, within the canonical order. Header fields of the same
+// rank are sorted by their name
+func headerRank(h Header, co []Header) int {
+	for i, ch := range co {
+		if strings.EqualFold(string(ch), string(h)) {
+			return i
+		}
+	}
+	n := strings.ToLower(string(h))
+	r, ur := len(co), -1
+	for _, g := range canonicalHeaderOrder {
+		if g == nil {
+			ur = r
+			r++
+			continue
+		}
+		for _, gh := range g {
+			if gh == n {
+				return r
+			}
+			r++
+		}
+	}
+	// Unlisted MIME fields follow the listed ones, all other unlisted fields share a rank
+	if strings.HasPrefix(n, "content-") {
+		return r
+	}
+	return ur
+}
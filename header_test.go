@@ -53,6 +53,7 @@ func TestAddrHeader_String(t *testing.T) {
 		{"Address header: To", HeaderTo, "To"},
 		{"Address header: Cc", HeaderCc, "Cc"},
 		{"Address header: Bcc", HeaderBcc, "Bcc"},
+		{"Address header: Reply-To", HeaderReplyTo, "Reply-To"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -71,10 +72,13 @@ func TestHeader_String(t *testing.T) {
 		h    Header
 		want string
 	}{
+		{"Header: Auto-Submitted", HeaderAutoSubmitted, "Auto-Submitted"},
+		{"Header: Comments", HeaderComments, "Comments"},
 		{"Header: Content-Disposition", HeaderContentDisposition, "Content-Disposition"},
 		{"Header: Content-ID", HeaderContentID, "Content-ID"},
 		{"Header: Content-Language", HeaderContentLang, "Content-Language"},
 		{"Header: Content-Location", HeaderContentLocation, "Content-Location"},
+		{"Header: Content-MD5", HeaderContentMD5, "Content-MD5"},
 		{"Header: Content-Transfer-Encoding", HeaderContentTransferEnc, "Content-Transfer-Encoding"},
 		{"Header: Content-Type", HeaderContentType, "Content-Type"},
 		{"Header: Date", HeaderDate, "Date"},
@@ -87,9 +91,10 @@ func TestHeader_String(t *testing.T) {
 		{"Header: Organization", HeaderOrganization, "Organization"},
 		{"Header: Precedence", HeaderPrecedence, "Precedence"},
 		{"Header: Priority", HeaderPriority, "Priority"},
-		{"Header: Reply-To", HeaderReplyTo, "Reply-To"},
 		{"Header: Subject", HeaderSubject, "Subject"},
 		{"Header: User-Agent", HeaderUserAgent, "User-Agent"},
+		{"Header: X-Auto-Response-Suppress", HeaderXAutoResponseSuppress, "X-Auto-Response-Suppress"},
+		{"Header: X-Content-SHA256", HeaderXContentSHA256, "X-Content-SHA256"},
 		{"Header: X-Mailer", HeaderXMailer, "X-Mailer"},
 		{"Header: X-MSMail-Priority", HeaderXMSMailPriority, "X-MSMail-Priority"},
 		{"Header: X-Priority", HeaderXPriority, "X-Priority"},
@@ -103,3 +108,45 @@ func TestHeader_String(t *testing.T) {
 		})
 	}
 }
+
+// TestHeader_validation tests the validation and sanitization of header field names and values
+func TestHeader_validation(t *testing.T) {
+	tests := []struct {
+		name string
+		h    Header
+		v    string
+		pf   bool
+		hok  bool
+		vok  bool
+		sh   Header
+		sv   string
+	}{
+		{"valid", "X-Test", "value\twith tab", false, true, true, "X-Test", "value\twith tab"},
+		{"value with CRLF", "X-Test", "foo\r\nBcc: a@b.c", false, true, false, "X-Test", "foo  Bcc: a@b.c"},
+		{"value with LF", "X-Test", "foo\nbar", false, true, false, "X-Test", "foo bar"},
+		{"value with NUL", "X-Test", "foo\x00", false, true, false, "X-Test", "foo "},
+		{"folded value", "X-Test", "foo\r\n bar", false, true, false, "X-Test", "foo   bar"},
+		{"preformatted folded value", "X-Test", "foo\r\n bar", true, true, true, "X-Test", "foo\r\n bar"},
+		{"preformatted bare CRLF", "X-Test", "foo\r\nBcc: x", true, true, false, "X-Test", "foo  Bcc: x"},
+		{"name with CRLF", "X-Test\r\nBcc", "foo", false, false, true, "X-TestBcc", "foo"},
+		{"name with colon", "X-Test:", "foo", false, false, true, "X-Test", "foo"},
+		{"name with space", "X Test", "foo", false, false, true, "XTest", "foo"},
+		{"empty name", "", "foo", false, false, true, "", "foo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok := validHeaderName(tt.h); ok != tt.hok {
+				t.Errorf("validHeaderName(%q) failed. Expected: %t, got: %t", tt.h, tt.hok, ok)
+			}
+			if ok := validHeaderValue(tt.v, tt.pf); ok != tt.vok {
+				t.Errorf("validHeaderValue(%q) failed. Expected: %t, got: %t", tt.v, tt.vok, ok)
+			}
+			if h := sanitizeHeaderName(tt.h); h != tt.sh {
+				t.Errorf("sanitizeHeaderName(%q) failed. Expected: %q, got: %q", tt.h, tt.sh, h)
+			}
+			if v := sanitizeHeaderValue(tt.v, tt.pf); v != tt.sv {
+				t.Errorf("sanitizeHeaderValue(%q) failed. Expected: %q, got: %q", tt.v, tt.sv, v)
+			}
+		})
+	}
+}
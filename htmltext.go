@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// htmlText converts an HTML document into readable plain text. It is not a full HTML parser,
+// but handles the markup that is common in HTML mails
+type htmlText struct {
+	// buf is the rendered text
+	buf strings.Builder
+	// nl is the number of trailing newlines of buf
+	nl int
+	// bl is the number of newlines that are written before the next text
+	bl int
+	// sp indicates that a space is written before the next text
+	sp bool
+	// pre is the nesting depth of pre elements, in which whitespace is preserved
+	pre int
+	// quote is the nesting depth of blockquote elements
+	quote int
+	// lists holds the item counters of the open lists, with -1 for unordered lists
+	lists []int
+	// marker is the list marker that is written before the next text
+	marker string
+	// links holds the URLs of the link footnotes
+	links []string
+	// href is the URL of the open link
+	href string
+	// ltext holds the text of the open link
+	ltext strings.Builder
+}
+
+// htmlToText returns a plain text version of the given HTML. Links are rendered as numbered
+// footnotes, list items with markers, headings with a Markdown-like prefix and the content
+// of script and style elements is removed
+func htmlToText(h string) string {
+	t := &htmlText{}
+	for len(h) > 0 {
+		i := strings.IndexByte(h, '<')
+		if i < 0 {
+			t.text(html.UnescapeString(h))
+			break
+		}
+		if i > 0 {
+			t.text(html.UnescapeString(h[:i]))
+			h = h[i:]
+		}
+		switch {
+		case strings.HasPrefix(h, "<!--"):
+			e := strings.Index(h, "-->")
+			if e < 0 {
+				return t.String()
+			}
+			h = h[e+3:]
+		case len(h) > 1 && (h[1] == '!' || h[1] == '?'):
+			e := strings.IndexByte(h, '>')
+			if e < 0 {
+				return t.String()
+			}
+			h = h[e+1:]
+		case len(h) > 2 && h[1] == '/' && isASCIILetter(h[2]), len(h) > 1 && isASCIILetter(h[1]):
+			n, a, cl, rest := parseHTMLTag(h)
+			h = rest
+			if cl {
+				t.end(n)
+				continue
+			}
+			if n == "script" || n == "style" || n == "head" || n == "title" {
+				e := strings.Index(strings.ToLower(h), "</"+n)
+				if e < 0 {
+					return t.String()
+				}
+				h = h[e:]
+				continue
+			}
+			t.start(n, a)
+		default:
+			t.text("<")
+			h = h[1:]
+		}
+	}
+	return t.String()
+}
+
+// String returns the rendered text with the link footnotes
+func (t *htmlText) String() string {
+	s := strings.TrimRight(t.buf.String(), " \n")
+	if len(t.links) == 0 {
+		return s + "\n"
+	}
+	sb := strings.Builder{}
+	sb.WriteString(s)
+	sb.WriteString("\n\n")
+	for i, l := range t.links {
+		sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, l))
+	}
+	return sb.String()
+}
+
+// start handles the start tag of the element with the given name and attributes
+func (t *htmlText) start(n string, a map[string]string) {
+	switch n {
+	case "br":
+		t.newline(1, true)
+	case "p", "table", "blockquote", "ul", "ol", "pre", "h1", "h2", "h3", "h4", "h5", "h6":
+		if len(t.lists) == 0 || (n != "ul" && n != "ol") {
+			t.newline(2, false)
+		} else {
+			t.newline(1, false)
+		}
+		switch n {
+		case "blockquote":
+			t.quote++
+		case "ul":
+			t.lists = append(t.lists, -1)
+		case "ol":
+			t.lists = append(t.lists, 0)
+		case "pre":
+			t.pre++
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			t.marker = strings.Repeat("#", int(n[1]-'0')) + " "
+		}
+	case "div", "tr", "section", "article", "header", "footer", "dl", "dt", "dd":
+		t.newline(1, false)
+	case "li":
+		t.newline(1, false)
+		if len(t.lists) == 0 {
+			t.marker = "* "
+			break
+		}
+		l := len(t.lists) - 1
+		if t.lists[l] < 0 {
+			t.marker = "* "
+			break
+		}
+		t.lists[l]++
+		t.marker = fmt.Sprintf("%d. ", t.lists[l])
+	case "td", "th":
+		t.sp = true
+	case "hr":
+		t.newline(2, false)
+		t.write("----------")
+		t.newline(2, false)
+	case "img":
+		if alt := strings.TrimSpace(a["alt"]); alt != "" {
+			t.text(alt)
+		}
+	case "a":
+		t.href = strings.TrimSpace(a["href"])
+		t.ltext.Reset()
+	}
+}
+
+// end handles the end tag of the element with the given name
+func (t *htmlText) end(n string) {
+	switch n {
+	case "p", "table", "h1", "h2", "h3", "h4", "h5", "h6":
+		t.newline(2, false)
+	case "blockquote":
+		t.newline(2, false)
+		if t.quote > 0 {
+			t.quote--
+		}
+	case "pre":
+		t.newline(2, false)
+		if t.pre > 0 {
+			t.pre--
+		}
+	case "ul", "ol":
+		if len(t.lists) > 0 {
+			t.lists = t.lists[:len(t.lists)-1]
+		}
+		if len(t.lists) == 0 {
+			t.newline(2, false)
+		} else {
+			t.newline(1, false)
+		}
+	case "div", "tr", "li", "section", "article", "header", "footer", "dl", "dt", "dd":
+		t.newline(1, false)
+	case "a":
+		t.link()
+	}
+}
+
+// link writes the footnote reference of the closed link. Links without a URL, links to
+// anchors or scripts and links whose text is the URL itself are not referenced
+func (t *htmlText) link() {
+	u, lt := t.href, strings.TrimSpace(t.ltext.String())
+	t.href = ""
+	lu := strings.ToLower(u)
+	if u == "" || strings.HasPrefix(u, "#") || strings.HasPrefix(lu, "javascript:") ||
+		lt == u || "mailto:"+lt == u {
+		return
+	}
+	n := 0
+	for i, l := range t.links {
+		if l == u {
+			n = i + 1
+			break
+		}
+	}
+	if n == 0 {
+		t.links = append(t.links, u)
+		n = len(t.links)
+	}
+	t.sp = true
+	t.write(fmt.Sprintf("[%d]", n))
+}
+
+// newline requests n newlines before the next text. If f is true, the newline is written even
+// if the current line is empty (e.g. for consecutive br elements)
+func (t *htmlText) newline(n int, f bool) {
+	t.sp = false
+	if f {
+		t.buf.WriteString("\n")
+		t.nl++
+		return
+	}
+	if n > t.bl {
+		t.bl = n
+	}
+}
+
+// text writes the given text. Outside of pre elements, whitespace is collapsed
+func (t *htmlText) text(s string) {
+	if t.href != "" {
+		t.ltext.WriteString(s)
+	}
+	if t.pre > 0 {
+		for i, l := range strings.Split(s, "\n") {
+			if i > 0 {
+				t.newline(1, true)
+			}
+			if l != "" {
+				t.write(l)
+			}
+		}
+		return
+	}
+	if s != "" && isHTMLSpace(s[0]) {
+		t.sp = true
+	}
+	wl := strings.Fields(s)
+	for i, w := range wl {
+		if i > 0 {
+			t.sp = true
+		}
+		t.write(w)
+	}
+	if len(wl) > 0 && isHTMLSpace(s[len(s)-1]) {
+		t.sp = true
+	}
+}
+
+// write writes the given string to the current line and starts a new line with the pending
+// newlines, quotes and list markers first
+func (t *htmlText) write(s string) {
+	if t.buf.Len() > 0 {
+		for t.nl < t.bl {
+			t.buf.WriteString("\n")
+			t.nl++
+		}
+	}
+	t.bl = 0
+	if t.buf.Len() == 0 || t.nl > 0 {
+		t.buf.WriteString(strings.Repeat("> ", t.quote))
+		ind := len(t.lists)
+		if t.marker != "" && ind > 0 {
+			ind--
+		}
+		t.buf.WriteString(strings.Repeat("  ", ind))
+		t.buf.WriteString(t.marker)
+		t.marker = ""
+		t.sp = false
+	}
+	if t.sp {
+		t.buf.WriteString(" ")
+		t.sp = false
+	}
+	t.buf.WriteString(s)
+	t.nl = 0
+}
+
+// htmlAttr is an attribute of an HTML tag with its byte offsets within the tag
+type htmlAttr struct {
+	key, val   string
+	start, end int
+}
+
+// parseHTMLTag parses the tag at the beginning of the given HTML and returns the lower-case
+// element name, the attributes, whether it is an end tag and the remaining HTML
+func parseHTMLTag(h string) (string, map[string]string, bool, string) {
+	n, al, cl, l := scanHTMLTag(h)
+	a := make(map[string]string, len(al))
+	for _, at := range al {
+		a[at.key] = at.val
+	}
+	return n, a, cl, h[l:]
+}
+
+// scanHTMLTag scans the tag at the beginning of the given HTML and returns the lower-case
+// element name, the attributes with their unescaped values, whether it is an end tag and the
+// length of the tag
+func scanHTMLTag(h string) (string, []htmlAttr, bool, int) {
+	cl := h[1] == '/'
+	i := 1
+	if cl {
+		i = 2
+	}
+	s := i
+	for i < len(h) && !isHTMLSpace(h[i]) && h[i] != '>' && h[i] != '/' {
+		i++
+	}
+	n := strings.ToLower(h[s:i])
+	var al []htmlAttr
+	for i < len(h) && h[i] != '>' {
+		if isHTMLSpace(h[i]) || h[i] == '/' {
+			i++
+			continue
+		}
+		s = i
+		for i < len(h) && !isHTMLSpace(h[i]) && h[i] != '=' && h[i] != '>' && h[i] != '/' {
+			i++
+		}
+		a := htmlAttr{key: strings.ToLower(h[s:i]), start: s}
+		if i >= len(h) || h[i] != '=' {
+			a.end = i
+			al = append(al, a)
+			continue
+		}
+		i++
+		if i < len(h) && (h[i] == '"' || h[i] == '\'') {
+			q := h[i]
+			e := strings.IndexByte(h[i+1:], q)
+			if e < 0 {
+				return n, al, cl, len(h)
+			}
+			a.val = html.UnescapeString(h[i+1 : i+1+e])
+			i += e + 2
+			a.end = i
+			al = append(al, a)
+			continue
+		}
+		vs := i
+		for i < len(h) && !isHTMLSpace(h[i]) && h[i] != '>' {
+			i++
+		}
+		a.val = html.UnescapeString(h[vs:i])
+		a.end = i
+		al = append(al, a)
+	}
+	if i < len(h) {
+		i++
+	}
+	return n, al, cl, i
+}
+
+// isASCIILetter returns true if the given byte is an ASCII letter
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// isHTMLSpace returns true if the given byte is an ASCII whitespace character as defined by
+// the HTML specification
+func isHTMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
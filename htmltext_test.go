@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestHTMLToText tests the conversion of HTML into plain text
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"Paragraphs", "<p>Hello\n  World</p><p>Second&nbsp;&amp; last</p>", "Hello World\n\nSecond & last\n"},
+		{"Line breaks", "One<br>Two<br/><br />Three", "One\nTwo\n\nThree\n"},
+		{
+			"Links as footnotes",
+			`<p>Visit <a href="https://example.com">our site</a> or <a href="https://example.com">this</a>,
+			<a href="https://example.org">https://example.org</a> and <a href="#top">top</a></p>`,
+			"Visit our site [1] or this [1], https://example.org and top\n\n[1] https://example.com\n",
+		},
+		{
+			"Lists",
+			"<ul><li>One</li><li>Two<ol><li>Nested</li><li>Second</li></ol></li></ul><p>After</p>",
+			"* One\n* Two\n  1. Nested\n  2. Second\n\nAfter\n",
+		},
+		{"Headings", "<h1>Title</h1><h2>Sub title</h2>Text", "# Title\n\n## Sub title\n\nText\n"},
+		{
+			"Head, scripts and comments",
+			"<html><head><title>T</title><style>p{color:red}</style></head><body><!-- c -->" +
+				"<script>var a = '<p>';</script><div>Content</div></body></html>",
+			"Content\n",
+		},
+		{"Blockquote", "<p>Quote:</p><blockquote>Quoted text</blockquote>", "Quote:\n\n> Quoted text\n"},
+		{"Preformatted", "<pre>a  b\n  c</pre>", "a  b\n  c\n"},
+		{"Images and tables", `<table><tr><td>A</td><td><img src="x.png" alt="Logo"></td></tr></table>`, "A Logo\n"},
+		{"Plain text with brackets", "1 < 2 and <3", "1 < 2 and <3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToText(tt.html); got != tt.want {
+				t.Errorf("htmlToText failed. Expected: %q, got: %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestMsg_SetBodyHTMLStringWithAutoText tests that a text/plain alternative is added to the
+// HTML body
+func TestMsg_SetBodyHTMLStringWithAutoText(t *testing.T) {
+	m := NewMsg()
+	m.SetBodyHTMLStringWithAutoText(`<p>Hello <a href="https://example.com">World</a></p>`)
+	pl := m.GetParts()
+	if len(pl) != 2 || pl[0].ctype != TypeTextPlain || pl[1].ctype != TypeTextHTML {
+		t.Fatalf("SetBodyHTMLStringWithAutoText failed. Expected text/plain and text/html parts")
+	}
+	buf := bytes.Buffer{}
+	if _, err := pl[0].w(&buf); err != nil {
+		t.Fatalf("failed to write text part: %s", err)
+	}
+	if want := "Hello World [1]\n\n[1] https://example.com\n"; buf.String() != want {
+		t.Errorf("SetBodyHTMLStringWithAutoText failed. Expected text: %q, got: %q", want, buf.String())
+	}
+	buf.Reset()
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), "multipart/alternative") {
+		t.Errorf("SetBodyHTMLStringWithAutoText failed. Expected multipart/alternative message")
+	}
+}
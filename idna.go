@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode parameters as described in RFC 3492
+const (
+	pcBase        = 36
+	pcTMin        = 1
+	pcTMax        = 26
+	pcSkew        = 38
+	pcDamp        = 700
+	pcInitialBias = 72
+	pcInitialN    = 128
+)
+
+// idnaACEPrefix is the ACE prefix for internationalized domain name labels
+const idnaACEPrefix = "xn--"
+
+// ErrPunycodeOverflow is returned if a string could not be converted to punycode due
+// to an integer overflow
+var ErrPunycodeOverflow = errors.New("punycode conversion overflow")
+
+// ErrNonASCIILocalPart is returned if an address with a non-ASCII local part has to be
+// converted into an ASCII-only address
+var ErrNonASCIILocalPart = errors.New("local part of address contains non-ASCII characters")
+
+// idnaToASCII converts an internationalized domain name into its ASCII compatible
+// A-label form (e. g. "bücher.de" becomes "xn--bcher-kva.de") as described in RFC 5891.
+// Labels that are already ASCII are left untouched
+func idnaToASCII(d string) (string, error) {
+	if isASCII(d) {
+		return d, nil
+	}
+	ll := strings.Split(d, ".")
+	for i, l := range ll {
+		if isASCII(l) {
+			continue
+		}
+		pl, err := punycodeEncode(strings.ToLower(l))
+		if err != nil {
+			return "", err
+		}
+		ll[i] = idnaACEPrefix + pl
+	}
+	return strings.Join(ll, "."), nil
+}
+
+// addrToASCII converts the domain part of the given mail address into its A-label
+// form. Since there is no ASCII representation for internationalized local parts,
+// an ErrNonASCIILocalPart is returned for such addresses
+func addrToASCII(a string) (string, error) {
+	if isASCII(a) {
+		return a, nil
+	}
+	i := strings.LastIndex(a, "@")
+	if i < 0 {
+		return idnaToASCII(a)
+	}
+	if !isASCII(a[:i]) {
+		return "", ErrNonASCIILocalPart
+	}
+	d, err := idnaToASCII(a[i+1:])
+	if err != nil {
+		return "", err
+	}
+	return a[:i+1] + d, nil
+}
+
+// punycodeEncode encodes the given string as punycode as described in RFC 3492
+func punycodeEncode(s string) (string, error) {
+	rl := []rune(s)
+	out := make([]byte, 0, len(s))
+	for _, r := range rl {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	b := len(out)
+	h := b
+	if b > 0 {
+		out = append(out, '-')
+	}
+
+	n, d, bias := pcInitialN, 0, pcInitialBias
+	for h < len(rl) {
+		m := math.MaxInt32
+		for _, r := range rl {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		if m-n > (math.MaxInt32-d)/(h+1) {
+			return "", ErrPunycodeOverflow
+		}
+		d += (m - n) * (h + 1)
+		n = m
+		for _, r := range rl {
+			if int(r) < n {
+				d++
+				if d == math.MaxInt32 {
+					return "", ErrPunycodeOverflow
+				}
+			}
+			if int(r) != n {
+				continue
+			}
+			q := d
+			for k := pcBase; ; k += pcBase {
+				t := k - bias
+				if t < pcTMin {
+					t = pcTMin
+				}
+				if t > pcTMax {
+					t = pcTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(pcBase-t)))
+				q = (q - t) / (pcBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(d, h+1, h == b)
+			d = 0
+			h++
+		}
+		d++
+		n++
+	}
+	return string(out), nil
+}
+
+// punycodeAdapt is the bias adaptation function as described in RFC 3492, section 6.1
+func punycodeAdapt(d, np int, first bool) int {
+	if first {
+		d /= pcDamp
+	} else {
+		d /= 2
+	}
+	d += d / np
+	k := 0
+	for d > ((pcBase-pcTMin)*pcTMax)/2 {
+		d /= pcBase - pcTMin
+		k += pcBase
+	}
+	return k + (pcBase-pcTMin+1)*d/(d+pcSkew)
+}
+
+// punycodeDigit returns the basic code point for the given digit value
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+// isASCII returns true if the given string only consists of ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPunycodeEncode tests the punycodeEncode method with the sample strings of RFC 3492
+func TestPunycodeEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"ASCII only", "example", "example-"},
+		{"German umlaut", "bücher", "bcher-kva"},
+		{"German umlaut 2", "münchen", "mnchen-3ya"},
+		{"Chinese (simplified)", "他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+		{"Japanese", "なぜみんな日本語を話してくれないのか", "n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+		{"Arabic (Egyptian)", "ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+		{"Mixed", "3年B組金八先生", "3B-ww4c5e180e575a65lsy2b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := punycodeEncode(tt.value)
+			if err != nil {
+				t.Errorf("punycodeEncode failed: %s", err)
+				return
+			}
+			if p != tt.want {
+				t.Errorf("punycodeEncode failed. Expected: %s, got: %s", tt.want, p)
+			}
+		})
+	}
+}
+
+// TestAddrToASCII tests the addrToASCII method
+func TestAddrToASCII(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		sf    bool
+	}{
+		{"ASCII address", "toni@example.com", "toni@example.com", false},
+		{"IDN domain", "toni@bücher.de", "toni@xn--bcher-kva.de", false},
+		{"IDN domain with uppercase", "toni@Bücher.example.com", "toni@xn--bcher-kva.example.com", false},
+		{"IDN TLD", "toni@例子.测试", "toni@xn--fsqu00a.xn--0zwm56d", false},
+		{"UTF-8 local part", "jörg@example.com", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := addrToASCII(tt.value)
+			if err != nil && !tt.sf {
+				t.Errorf("addrToASCII failed: %s", err)
+				return
+			}
+			if tt.sf {
+				if !errors.Is(err, ErrNonASCIILocalPart) {
+					t.Errorf("addrToASCII was expected to fail with ErrNonASCIILocalPart, got: %s", err)
+				}
+				return
+			}
+			if a != tt.want {
+				t.Errorf("addrToASCII failed. Expected: %s, got: %s", tt.want, a)
+			}
+		})
+	}
+}
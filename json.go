@@ -0,0 +1,260 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+)
+
+// msgData is the serializable representation of a Msg, which is used for its JSON and gob
+// encoding
+type msgData struct {
+	Charset      Charset                        `json:"charset,omitempty"`
+	Encoding     Encoding                       `json:"encoding,omitempty"`
+	MIMEVersion  MIMEVersion                    `json:"mime_version,omitempty"`
+	Boundary     string                         `json:"boundary,omitempty"`
+	ReportType   string                         `json:"report_type,omitempty"`
+	AddrHeaders  map[AddrHeader][]string        `json:"addr_headers,omitempty"`
+	AddrGroups   map[AddrHeader][]addrGroupData `json:"addr_groups,omitempty"`
+	Headers      map[Header][]string            `json:"headers,omitempty"`
+	PreformHeads map[Header]string              `json:"preformatted_headers,omitempty"`
+	Parts        []partData                     `json:"parts,omitempty"`
+	Attachments  []fileData                     `json:"attachments,omitempty"`
+	Embeds       []fileData                     `json:"embeds,omitempty"`
+	RelatedRoot  string                         `json:"related_root,omitempty"`
+	Digest       []fileData                     `json:"digest,omitempty"`
+}
+
+// addrGroupData is the serializable representation of an address group
+type addrGroupData struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// partData is the serializable representation of a Part
+type partData struct {
+	ContentType ContentType `json:"content_type"`
+	Charset     Charset     `json:"charset,omitempty"`
+	Encoding    Encoding    `json:"encoding,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Flowed      bool        `json:"flowed,omitempty"`
+	CSS         bool        `json:"css_inlining,omitempty"`
+	Content     string      `json:"content"`
+}
+
+// fileData is the serializable representation of a File. Either the content of the File is
+// included or the File is referenced by its path
+type fileData struct {
+	Name        string              `json:"name"`
+	ContentType ContentType         `json:"content_type,omitempty"`
+	ContentID   string              `json:"content_id,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Disposition Disposition         `json:"disposition,omitempty"`
+	Encoding    Encoding            `json:"encoding,omitempty"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Path        string              `json:"path,omitempty"`
+	Data        []byte              `json:"data,omitempty"`
+}
+
+// WithJSONFileReferences lets the JSON and gob representations of the Msg reference the
+// attachments and embeds that were added from the system's file system (e.g. via AttachFile)
+// by their path, instead of including their content. The files must be available under the
+// same path when the Msg is decoded, e.g. on a shared volume, and are only read when the Msg
+// is rendered
+func WithJSONFileReferences() MsgOption {
+	return func(m *Msg) {
+		m.jsonFileRefs = true
+	}
+}
+
+// MarshalJSON satisfies the json.Marshaler interface for the Msg. The JSON representation
+// holds the headers, the body parts as strings and the attachments and embeds as Base64 or,
+// with WithJSONFileReferences, as file references, so that a Msg can be queued (e.g. in Redis
+// or SQS) and be reconstructed by a separate sender. Middlewares, signers and encryption
+// settings are not part of the JSON representation
+func (m *Msg) MarshalJSON() ([]byte, error) {
+	md, err := m.data()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(md)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface for the Msg. It replaces the Msg
+// with the Msg of the given JSON representation (see MarshalJSON)
+func (m *Msg) UnmarshalJSON(b []byte) error {
+	var md msgData
+	if err := json.Unmarshal(b, &md); err != nil {
+		return err
+	}
+	return m.setData(&md)
+}
+
+// data returns the serializable representation of the Msg
+func (m *Msg) data() (*msgData, error) {
+	md := &msgData{
+		Charset:      m.charset,
+		Encoding:     m.encoding,
+		MIMEVersion:  m.mimever,
+		Boundary:     m.boundary,
+		ReportType:   m.reportType,
+		AddrHeaders:  make(map[AddrHeader][]string, len(m.addrHeader)),
+		Headers:      m.genHeader,
+		PreformHeads: m.preformHeader,
+		RelatedRoot:  m.relatedRoot,
+	}
+	for h, al := range m.addrHeader {
+		for _, a := range al {
+			md.AddrHeaders[h] = append(md.AddrHeaders[h], a.String())
+		}
+	}
+	for h, gl := range m.addrGroups {
+		if md.AddrGroups == nil {
+			md.AddrGroups = make(map[AddrHeader][]addrGroupData)
+		}
+		for _, g := range gl {
+			gd := addrGroupData{Name: g.name}
+			for _, a := range g.addrs {
+				gd.Addresses = append(gd.Addresses, a.String())
+			}
+			md.AddrGroups[h] = append(md.AddrGroups[h], gd)
+		}
+	}
+	for _, p := range m.parts {
+		if p.del {
+			continue
+		}
+		c, err := p.GetContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get content of part: %w", err)
+		}
+		md.Parts = append(md.Parts, partData{
+			ContentType: p.ctype, Charset: p.charset, Encoding: p.enc, Description: p.desc,
+			Flowed: p.flowed, CSS: p.css, Content: string(c),
+		})
+	}
+	var err error
+	if md.Attachments, err = m.filesToData(m.attachments); err != nil {
+		return nil, err
+	}
+	if md.Embeds, err = m.filesToData(m.embeds); err != nil {
+		return nil, err
+	}
+	if md.Digest, err = m.filesToData(m.digest); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// setData replaces the Msg with the Msg of the given serializable representation
+func (m *Msg) setData(md *msgData) error {
+	nm := NewMsg()
+	if md.Charset != "" {
+		nm.SetCharset(md.Charset)
+	}
+	if md.Encoding != "" {
+		nm.SetEncoding(md.Encoding)
+	}
+	if md.MIMEVersion != "" {
+		nm.SetMIMEVersion(md.MIMEVersion)
+	}
+	nm.boundary = md.Boundary
+	nm.reportType = md.ReportType
+	nm.relatedRoot = md.RelatedRoot
+	for h, al := range md.AddrHeaders {
+		if err := nm.SetAddrHeader(h, al...); err != nil {
+			return fmt.Errorf("failed to set %s address header: %w", h, err)
+		}
+	}
+	for h, gl := range md.AddrGroups {
+		for _, g := range gl {
+			if err := nm.addAddrGroup(h, g.Name, g.Addresses...); err != nil {
+				return fmt.Errorf("failed to set %s address group: %w", h, err)
+			}
+		}
+	}
+	for h, vl := range md.Headers {
+		nm.SetGenHeader(h, vl...)
+	}
+	for h, v := range md.PreformHeads {
+		nm.SetGenHeaderPreformatted(h, v)
+	}
+	if nm.headerErr != nil {
+		return nm.headerErr
+	}
+	for _, pd := range md.Parts {
+		p := nm.newPart(pd.ContentType)
+		p.SetContent(pd.Content)
+		p.charset, p.enc, p.desc, p.flowed, p.css = pd.Charset, pd.Encoding, pd.Description, pd.Flowed, pd.CSS
+		nm.parts = append(nm.parts, p)
+	}
+	var err error
+	if nm.attachments, err = filesFromData(md.Attachments); err != nil {
+		return err
+	}
+	if nm.embeds, err = filesFromData(md.Embeds); err != nil {
+		return err
+	}
+	if nm.digest, err = filesFromData(md.Digest); err != nil {
+		return err
+	}
+	*m = *nm
+	return nil
+}
+
+// filesToData returns the serializable representation of the given files
+func (m *Msg) filesToData(fl []*File) ([]fileData, error) {
+	var dl []fileData
+	for _, f := range fl {
+		fd := fileData{
+			Name: f.Name, ContentType: f.ContentType, ContentID: f.ContentID, Description: f.Desc,
+			Disposition: f.Disposition, Encoding: f.Enc, Header: f.Header,
+		}
+		if m.jsonFileRefs && f.path != "" {
+			fd.Path = f.path
+			dl = append(dl, fd)
+			continue
+		}
+		buf := bytes.Buffer{}
+		if _, err := f.Writer(&buf); err != nil {
+			return nil, fmt.Errorf("failed to read file %q: %w", f.Name, err)
+		}
+		fd.Data = buf.Bytes()
+		dl = append(dl, fd)
+	}
+	return dl, nil
+}
+
+// filesFromData returns the files of the given serializable representation
+func filesFromData(dl []fileData) ([]*File, error) {
+	var fl []*File
+	for _, fd := range dl {
+		var f *File
+		if fd.Path != "" {
+			var err error
+			if f, err = fileFromFS(fd.Path); err != nil {
+				return nil, fmt.Errorf("failed to read referenced file: %w", err)
+			}
+		} else {
+			d := fd.Data
+			f = &File{Writer: func(w io.Writer) (int64, error) {
+				n, err := w.Write(d)
+				return int64(n), err
+			}}
+		}
+		f.Name, f.ContentType, f.ContentID, f.Desc = fd.Name, fd.ContentType, fd.ContentID, fd.Description
+		f.Disposition, f.Enc = fd.Disposition, fd.Encoding
+		f.Header = make(textproto.MIMEHeader, len(fd.Header))
+		for k, vl := range fd.Header {
+			f.Header[k] = vl
+		}
+		fl = append(fl, f)
+	}
+	return fl, nil
+}
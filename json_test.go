@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMsg_MarshalJSON tests that a Msg is reconstructed from its JSON representation
+func TestMsg_MarshalJSON(t *testing.T) {
+	m := NewMsg(WithCharset(CharsetISO88591), WithBoundary("test-boundary"))
+	if err := m.FromFormat("Toni Tester", "from@example.com"); err != nil {
+		t.Fatalf("failed to set From: %s", err)
+	}
+	if err := m.To("to@example.com", "other@example.com"); err != nil {
+		t.Fatalf("failed to set To: %s", err)
+	}
+	if err := m.CcGroup("Team", "team@example.com"); err != nil {
+		t.Fatalf("failed to set Cc group: %s", err)
+	}
+	if err := m.Bcc("bcc@example.com"); err != nil {
+		t.Fatalf("failed to set Bcc: %s", err)
+	}
+	m.Subject("JSON test")
+	m.SetDateWithValue(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	m.SetMessageIDWithValue("json.test@example.com")
+	m.SetGenHeaderPreformatted("X-Preformatted", "foo\r\n bar")
+	m.SetBodyString(TypeTextPlain, "Plain text", WithPartFlowed())
+	m.AddAlternativeString(TypeTextHTML, "<p>HTML</p>")
+	m.AttachReader("data.txt", strings.NewReader("attachment data"), WithFileDescription("Data"))
+	m.EmbedReader("image.png", bytes.NewReader([]byte{0x89, 'P', 'N', 'G'}), WithFileContentID("img"))
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal Msg: %s", err)
+	}
+	var um Msg
+	if err := json.Unmarshal(b, &um); err != nil {
+		t.Fatalf("failed to unmarshal Msg: %s", err)
+	}
+	want, got := bytes.Buffer{}, bytes.Buffer{}
+	if _, err := m.WriteTo(&want); err != nil {
+		t.Fatalf("failed to write original Msg: %s", err)
+	}
+	if _, err := um.WriteTo(&got); err != nil {
+		t.Fatalf("failed to write unmarshaled Msg: %s", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("unmarshaled Msg differs from original.\nExpected:\n%s\nGot:\n%s", want.String(), got.String())
+	}
+	rl, err := um.GetRecipients()
+	if err != nil {
+		t.Fatalf("GetRecipients failed: %s", err)
+	}
+	if len(rl) != 4 {
+		t.Errorf("expected 4 recipients after unmarshaling, got: %v", rl)
+	}
+
+	if err := json.Unmarshal([]byte(`{"addr_headers":{"To":["invalid"]}}`), &um); err == nil {
+		t.Errorf("Unmarshal with invalid address was expected to fail")
+	}
+	if err := json.Unmarshal([]byte(`{"headers":{"X-Test":["foo\r\nBcc: x"]}}`), &um); err == nil {
+		t.Errorf("Unmarshal with invalid header was expected to fail")
+	}
+}
+
+// TestMsg_MarshalJSON_fileReferences tests the references to files of the file system
+func TestMsg_MarshalJSON_fileReferences(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "ref.txt")
+	if err := os.WriteFile(p, []byte("referenced data"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	for _, refs := range []bool{false, true} {
+		var o []MsgOption
+		if refs {
+			o = append(o, WithJSONFileReferences())
+		}
+		m := NewMsg(o...)
+		m.AttachFile(p)
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("failed to marshal Msg: %s", err)
+		}
+		if strings.Contains(string(b), `"path"`) != refs {
+			t.Errorf("file reference in JSON expected: %t, got: %s", refs, b)
+		}
+		var um Msg
+		if err := json.Unmarshal(b, &um); err != nil {
+			t.Fatalf("failed to unmarshal Msg: %s", err)
+		}
+		buf := bytes.Buffer{}
+		if _, err := um.GetAttachments()[0].Writer(&buf); err != nil {
+			t.Fatalf("failed to read attachment: %s", err)
+		}
+		if buf.String() != "referenced data" || um.GetAttachments()[0].Name != "ref.txt" {
+			t.Errorf("unexpected attachment after unmarshaling: %s: %q", um.GetAttachments()[0].Name, buf.String())
+		}
+	}
+	if err := json.Unmarshal([]byte(`{"attachments":[{"name":"x","path":"/nonexistent/x"}]}`), &Msg{}); err == nil {
+		t.Errorf("Unmarshal with missing referenced file was expected to fail")
+	}
+}
+
+// TestMsg_MarshalJSON_digestRelated tests that the multipart/digest messages and the root of
+// the multipart/related container survive the JSON and gob encoding
+func TestMsg_MarshalJSON_digestRelated(t *testing.T) {
+	m := NewMsg(WithBoundary("test-boundary"))
+	if err := m.From("from@example.com"); err != nil {
+		t.Fatalf("failed to set From: %s", err)
+	}
+	if err := m.To("to@example.com"); err != nil {
+		t.Fatalf("failed to set To: %s", err)
+	}
+	m.SetDateWithValue(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+	m.SetMessageIDWithValue("digest.test@example.com")
+	m.SetBodyString(TypeTextHTML, `<img src="cid:img">`)
+	m.EmbedReader("image.png", bytes.NewReader([]byte{0x89, 'P', 'N', 'G'}), WithFileContentID("img"))
+	m.SetRelatedRoot("img")
+	dm := testMsg(t)
+	dm.SetMessageIDWithValue("digested.test@example.com")
+	if err := m.AddToDigest(dm); err != nil {
+		t.Fatalf("AddToDigest failed: %s", err)
+	}
+
+	want := bytes.Buffer{}
+	if _, err := m.WriteTo(&want); err != nil {
+		t.Fatalf("failed to write original Msg: %s", err)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to marshal Msg: %s", err)
+	}
+	var jm Msg
+	if err := json.Unmarshal(b, &jm); err != nil {
+		t.Fatalf("failed to unmarshal Msg: %s", err)
+	}
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatalf("failed to encode Msg: %s", err)
+	}
+	var gm Msg
+	if err := gob.NewDecoder(&buf).Decode(&gm); err != nil {
+		t.Fatalf("failed to decode Msg: %s", err)
+	}
+	for n, um := range map[string]*Msg{"JSON": &jm, "gob": &gm} {
+		if len(um.GetDigest()) != 1 || um.relatedRoot != "img" {
+			t.Errorf("%s: digest or related root lost. Digest: %d, root: %q", n, len(um.GetDigest()),
+				um.relatedRoot)
+		}
+		got := bytes.Buffer{}
+		if _, err := um.WriteTo(&got); err != nil {
+			t.Fatalf("%s: failed to write decoded Msg: %s", n, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: decoded Msg differs from original.\nExpected:\n%s\nGot:\n%s", n, want.String(),
+				got.String())
+		}
+	}
+}
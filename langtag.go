@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidLanguageTag is returned if a language tag is not a well-formed BCP 47 language tag
+var ErrInvalidLanguageTag = errors.New("invalid BCP 47 language tag")
+
+// irregularLanguageTags is the list of the irregular grandfathered language tags of RFC 5646,
+// which do not match the language tag syntax
+var irregularLanguageTags = map[string]bool{
+	"en-gb-oed": true, "i-ami": true, "i-bnn": true, "i-default": true, "i-enochian": true,
+	"i-hak": true, "i-klingon": true, "i-lux": true, "i-mingo": true, "i-navajo": true, "i-pwn": true,
+	"i-tao": true, "i-tay": true, "i-tsu": true, "sgn-be-fr": true, "sgn-be-nl": true, "sgn-ch-de": true,
+}
+
+// validLanguageTag returns true if the given language tag is well-formed as described in
+// RFC 5646, section 2.2.9 (e.g. "en", "de-CH", "zh-Hant-TW" or "sl-rozaj-biske")
+func validLanguageTag(t string) bool {
+	t = strings.ToLower(t)
+	if irregularLanguageTags[t] {
+		return true
+	}
+	sl := strings.Split(t, "-")
+	for _, s := range sl {
+		if len(s) < 1 || len(s) > 8 || !isAlphaNum(s) {
+			return false
+		}
+	}
+	if sl[0] == "x" {
+		return len(sl) > 1
+	}
+
+	// Primary language subtag with optional extended language subtags
+	i := 0
+	switch l := len(sl[0]); {
+	case (l == 2 || l == 3) && isAlpha(sl[0]):
+		i++
+		for e := 0; e < 3 && i < len(sl) && len(sl[i]) == 3 && isAlpha(sl[i]); e++ {
+			i++
+		}
+	case l >= 4 && isAlpha(sl[0]):
+		i++
+	default:
+		return false
+	}
+	// Script subtag
+	if i < len(sl) && len(sl[i]) == 4 && isAlpha(sl[i]) {
+		i++
+	}
+	// Region subtag
+	if i < len(sl) && ((len(sl[i]) == 2 && isAlpha(sl[i])) || (len(sl[i]) == 3 && isDigits(sl[i]))) {
+		i++
+	}
+	// Variant subtags
+	for i < len(sl) && (len(sl[i]) >= 5 || (len(sl[i]) == 4 && sl[i][0] >= '0' && sl[i][0] <= '9')) {
+		i++
+	}
+	// Extension subtags and private use subtags
+	for i < len(sl) {
+		if len(sl[i]) != 1 {
+			return false
+		}
+		x := sl[i] == "x"
+		i++
+		n := 0
+		for ; i < len(sl) && (x || len(sl[i]) >= 2); i++ {
+			n++
+		}
+		if n == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlpha returns true if the given string only consists of ASCII letters
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isASCIILetter(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits returns true if the given string only consists of ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isAlphaNum returns true if the given string only consists of ASCII letters and digits
+func isAlphaNum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isASCIILetter(s[i]) && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestValidLanguageTag tests the validation of BCP 47 language tags
+func TestValidLanguageTag(t *testing.T) {
+	tests := []struct {
+		tag   string
+		valid bool
+	}{
+		{"en", true},
+		{"de-CH", true},
+		{"zh-Hant-TW", true},
+		{"es-419", true},
+		{"zh-yue-HK", true},
+		{"sl-rozaj-biske", true},
+		{"de-CH-1901", true},
+		{"en-US-u-islamcal", true},
+		{"en-a-bbb-x-a-ccc", true},
+		{"x-whatever", true},
+		{"i-klingon", true},
+		{"art-lojban", true},
+		{"", false},
+		{"e", false},
+		{"en-", false},
+		{"en_US", false},
+		{"123", false},
+		{"en-a", false},
+		{"x", false},
+		{"deutsch-de-ch-toolong12", false},
+		{"de-ÄT", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if v := validLanguageTag(tt.tag); v != tt.valid {
+				t.Errorf("validLanguageTag(%q) failed. Expected: %t, got: %t", tt.tag, tt.valid, v)
+			}
+		})
+	}
+}
+
+// TestMsg_SetContentLanguage tests the Content-Language header
+func TestMsg_SetContentLanguage(t *testing.T) {
+	m := NewMsg()
+	if err := m.SetContentLanguage("de-CH", "en"); err != nil {
+		t.Fatalf("SetContentLanguage failed: %s", err)
+	}
+	if h := m.GetGenHeader(HeaderContentLang); len(h) != 1 || h[0] != "de-CH, en" {
+		t.Errorf("SetContentLanguage failed. Expected: %q, got: %q", "de-CH, en", h)
+	}
+	if err := m.SetContentLanguage("en", "en_US"); !errors.Is(err, ErrInvalidLanguageTag) {
+		t.Errorf("SetContentLanguage with invalid tag was expected to fail, got: %v", err)
+	}
+	if err := m.SetContentLanguage(); !errors.Is(err, ErrInvalidLanguageTag) {
+		t.Errorf("SetContentLanguage without tags was expected to fail, got: %v", err)
+	}
+	if h := m.GetGenHeader(HeaderContentLang); len(h) != 1 || h[0] != "de-CH, en" {
+		t.Errorf("failed SetContentLanguage must not change the header, got: %q", h)
+	}
+}
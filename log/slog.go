@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: Copyright (c) 2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Slog is a logger that writes the log messages to a log/slog.Logger and satisfies the
+// Logger interface. The debug log messages of the SMTP session (commands and responses)
+// are logged at debug level
+type Slog struct {
+	l *slog.Logger
+}
+
+// NewSlog returns a new Slog type that satisfies the Logger interface. If the given
+// slog.Logger is nil, slog.Default is used
+func NewSlog(l *slog.Logger) *Slog {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &Slog{l: l}
+}
+
+// Debugf logs the formatted message at slog.LevelDebug
+func (l *Slog) Debugf(f string, v ...interface{}) {
+	l.log(slog.LevelDebug, f, v...)
+}
+
+// Infof logs the formatted message at slog.LevelInfo
+func (l *Slog) Infof(f string, v ...interface{}) {
+	l.log(slog.LevelInfo, f, v...)
+}
+
+// Warnf logs the formatted message at slog.LevelWarn
+func (l *Slog) Warnf(f string, v ...interface{}) {
+	l.log(slog.LevelWarn, f, v...)
+}
+
+// Errorf logs the formatted message at slog.LevelError
+func (l *Slog) Errorf(f string, v ...interface{}) {
+	l.log(slog.LevelError, f, v...)
+}
+
+// log formats and logs the message at the given level, if the level is enabled
+func (l *Slog) log(lv slog.Level, f string, v ...interface{}) {
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, lv) {
+		return
+	}
+	l.l.Log(ctx, lv, fmt.Sprintf(f, v...))
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: Copyright (c) 2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewSlog(t *testing.T) {
+	if l := NewSlog(nil); l.l != slog.Default() {
+		t.Error("Expected slog.Default to be used for nil logger")
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var b bytes.Buffer
+	l := NewSlog(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	tests := []struct {
+		f  func(string, ...interface{})
+		lv string
+	}{
+		{l.Debugf, "level=DEBUG"},
+		{l.Infof, "level=INFO"},
+		{l.Warnf, "level=WARN"},
+		{l.Errorf, "level=ERROR"},
+	}
+	for _, tt := range tests {
+		b.Reset()
+		tt.f("test %s", "foo")
+		if !strings.Contains(b.String(), tt.lv) || !strings.Contains(b.String(), `msg="test foo"`) {
+			t.Errorf("Expected %s with message %q, got %q", tt.lv, "test foo", b.String())
+		}
+	}
+
+	b.Reset()
+	l = NewSlog(slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	l.Debugf("test %s", "foo")
+	if b.String() != "" {
+		t.Error("Debug message was not expected to be logged")
+	}
+}
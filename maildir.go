@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMaildirNoPath should be used if a Maildir is created without a directory path
+var ErrMaildirNoPath = errors.New("maildir path cannot be empty")
+
+// maildirSeq is the delivery counter that makes the unique names of the Maildir files unique
+// within the process
+var maildirSeq uint64
+
+// Maildir delivers messages into a Maildir, for local delivery or for integration tests
+// against IMAP servers that serve Maildirs. Every message is written into the tmp/ directory
+// first and then atomically moved into the new/ directory, so that readers of the Maildir
+// never see a partial message. The CRLF line endings of the messages are converted to LF
+type Maildir struct {
+	// path is the path of the Maildir
+	path string
+
+	// now returns the time of the delivery, which is part of the unique file name
+	now func() time.Time
+}
+
+// NewMaildir returns a new Maildir that delivers messages into the Maildir at the given path.
+// The Maildir and its tmp/, new/ and cur/ directories are created, if they do not exist
+func NewMaildir(p string) (*Maildir, error) {
+	if p == "" {
+		return nil, ErrMaildirNoPath
+	}
+	for _, d := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(p, d), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create maildir %q: %w", p, err)
+		}
+	}
+	return &Maildir{path: p, now: time.Now}, nil
+}
+
+// Path returns the path of the Maildir
+func (md *Maildir) Path() string {
+	return md.path
+}
+
+// Send delivers the given messages into the Maildir. See SendWithContext for details
+func (md *Maildir) Send(ml ...*Msg) error {
+	return md.SendWithContext(context.Background(), ml...)
+}
+
+// SendWithContext delivers the given messages into the Maildir and satisfies the Sender
+// interface. The result of each message is available via Msg.HasSendError and Msg.SendError
+// and the returned error aggregates the errors of all failed deliveries
+func (md *Maildir) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	var errs []*SendError
+	for _, m := range ml {
+		m.sendError = nil
+		err := ctx.Err()
+		if err == nil {
+			err = md.deliver(m)
+		}
+		if err != nil {
+			se := &SendError{Reason: ErrWriteContent, errlist: []error{err}, isTemp: isTempError(err)}
+			m.sendError = se
+			errs = append(errs, se)
+		}
+	}
+	return joinSendErrors(errs)
+}
+
+// deliver writes the given Msg into the tmp/ directory of the Maildir and moves it into the
+// new/ directory
+func (md *Maildir) deliver(m *Msg) error {
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+	b := bytes.ReplaceAll(buf.Bytes(), []byte("\r\n"), []byte("\n"))
+
+	n, err := md.uniqueName()
+	if err != nil {
+		return err
+	}
+	tp := filepath.Join(md.path, "tmp", n)
+	f, err := os.OpenFile(tp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create maildir file %q: %w", tp, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tp)
+		return fmt.Errorf("failed to write maildir file %q: %w", tp, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tp)
+		return fmt.Errorf("failed to sync maildir file %q: %w", tp, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tp)
+		return fmt.Errorf("failed to close maildir file %q: %w", tp, err)
+	}
+	if err := os.Rename(tp, filepath.Join(md.path, "new", n)); err != nil {
+		_ = os.Remove(tp)
+		return fmt.Errorf("failed to move maildir file %q to new: %w", tp, err)
+	}
+	return nil
+}
+
+// uniqueName returns a unique file name for a delivery into the Maildir in the format
+// "<seconds>.M<microseconds>P<pid>Q<counter>.<hostname>"
+func (md *Maildir) uniqueName() (string, error) {
+	hn, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up hostname: %w", err)
+	}
+	hn = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(hn)
+	t := md.now()
+	return fmt.Sprintf("%d.M%dP%dQ%d.%s", t.Unix(), t.Nanosecond()/1000, os.Getpid(),
+		atomic.AddUint64(&maildirSeq, 1), hn), nil
+}
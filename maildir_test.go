@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMaildir_Send tests that messages are delivered into the new/ directory of the Maildir
+func TestMaildir_Send(t *testing.T) {
+	if _, err := NewMaildir(""); !errors.Is(err, ErrMaildirNoPath) {
+		t.Errorf("NewMaildir with empty path was expected to fail, got: %v", err)
+	}
+	p := filepath.Join(t.TempDir(), "Maildir")
+	md, err := NewMaildir(p)
+	if err != nil {
+		t.Fatalf("NewMaildir failed: %s", err)
+	}
+	if md.Path() != p {
+		t.Errorf("NewMaildir failed. Expected path: %s, got: %s", p, md.Path())
+	}
+	for _, d := range []string{"tmp", "new", "cur"} {
+		if fi, err := os.Stat(filepath.Join(p, d)); err != nil || !fi.IsDir() {
+			t.Errorf("NewMaildir failed. Expected directory %s to exist: %v", d, err)
+		}
+	}
+
+	m := testMsg(t)
+	if err := md.Send(m, testMsg(t)); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	if m.HasSendError() {
+		t.Errorf("Send failed. Unexpected send error: %s", m.SendError())
+	}
+	el, err := os.ReadDir(filepath.Join(p, "new"))
+	if err != nil {
+		t.Fatalf("failed to read maildir: %s", err)
+	}
+	if len(el) != 2 || el[0].Name() == el[1].Name() {
+		t.Fatalf("Send failed. Expected 2 unique files in new, got: %d", len(el))
+	}
+	if tl, _ := os.ReadDir(filepath.Join(p, "tmp")); len(tl) != 0 {
+		t.Errorf("Send failed. Expected tmp to be empty, got %d files", len(tl))
+	}
+	b, err := os.ReadFile(filepath.Join(p, "new", el[0].Name()))
+	if err != nil {
+		t.Fatalf("failed to read maildir file: %s", err)
+	}
+	if strings.Contains(string(b), "\r") {
+		t.Errorf("Send failed. Expected LF line endings only")
+	}
+	if !strings.Contains(string(b), "To: <rcpt@example.com>\n") {
+		t.Errorf("Send failed. Expected To header in message, got: %s", b)
+	}
+}
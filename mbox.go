@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// mboxDateFormat is the time format of the "From " separator line of an mbox message
+const mboxDateFormat = "Mon Jan _2 15:04:05 2006"
+
+// ErrMboxNoPath should be used if an Mbox is created without a file path
+var ErrMboxNoPath = errors.New("mbox file path cannot be empty")
+
+// Mbox appends messages to an mbox file in the mboxrd format, so that generated messages can
+// be archived or imported into local mail clients. Every message is preceded by a "From "
+// separator line with the envelope sender and the current time, and lines of the message
+// that start with any number of ">" followed by "From " are quoted with another ">". Mbox
+// satisfies the Sender interface and is safe for concurrent use within a single process
+type Mbox struct {
+	// path is the path of the mbox file
+	path string
+
+	// mu serializes the writes to the mbox file
+	mu sync.Mutex
+
+	// now returns the time of the separator line
+	now func() time.Time
+}
+
+// NewMbox returns a new Mbox that appends messages to the mbox file at the given path. The
+// file is created on the first write, if it does not exist
+func NewMbox(p string) (*Mbox, error) {
+	if p == "" {
+		return nil, ErrMboxNoPath
+	}
+	return &Mbox{path: p, now: time.Now}, nil
+}
+
+// Send appends the given messages to the mbox file. See SendWithContext for details
+func (mb *Mbox) Send(ml ...*Msg) error {
+	return mb.SendWithContext(context.Background(), ml...)
+}
+
+// SendWithContext appends the given messages to the mbox file and satisfies the Sender
+// interface. The messages are rendered before the file is opened, so that a message that
+// fails to render does not leave a partial message in the file
+func (mb *Mbox) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	buf := bytes.Buffer{}
+	for _, m := range ml {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeMbox(&buf, m, mb.now()); err != nil {
+			return err
+		}
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	f, err := os.OpenFile(mb.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mbox file %q: %w", mb.path, err)
+	}
+	if _, err := buf.WriteTo(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write to mbox file %q: %w", mb.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close mbox file %q: %w", mb.path, err)
+	}
+	return nil
+}
+
+// writeMbox writes the given Msg in the mboxrd format with a separator line for the given
+// time to the given io.Writer. The CRLF line endings of the message are converted to LF
+func writeMbox(w io.Writer, m *Msg, t time.Time) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := m.WriteTo(buf); err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+	f, err := m.GetSender(false)
+	if err != nil {
+		f = "MAILER-DAEMON"
+	}
+
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "From %s %s\n", f, t.UTC().Format(mboxDateFormat))
+	b := buf.Bytes()
+	for len(b) > 0 {
+		l := b
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			l, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		l = bytes.TrimSuffix(l, []byte("\r"))
+		if bytes.HasPrefix(bytes.TrimLeft(l, ">"), []byte("From ")) {
+			_ = bw.WriteByte('>')
+		}
+		_, _ = bw.Write(l)
+		_ = bw.WriteByte('\n')
+	}
+	_ = bw.WriteByte('\n')
+	return bw.Flush()
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMbox_Send tests that messages are appended to the mbox file with separator lines and
+// mboxrd quoting
+func TestMbox_Send(t *testing.T) {
+	if _, err := NewMbox(""); !errors.Is(err, ErrMboxNoPath) {
+		t.Errorf("NewMbox with empty path was expected to fail, got: %v", err)
+	}
+	p := filepath.Join(t.TempDir(), "archive.mbox")
+	mb, err := NewMbox(p)
+	if err != nil {
+		t.Fatalf("NewMbox failed: %s", err)
+	}
+	mb.now = func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	m := testMsg(t)
+	m.SetBodyString(TypeTextPlain, "From here\n>From there\n>>From everywhere\nFromage")
+	if err := mb.Send(m, testMsg(t)); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	if err := mb.Send(NewMsg()); err != nil {
+		t.Fatalf("Send of message without sender failed: %s", err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("failed to read mbox file: %s", err)
+	}
+	s := string(b)
+	sep := "From sender@example.com Mon Jan  2 03:04:05 2023\n"
+	if !strings.HasPrefix(s, sep) {
+		t.Errorf("Send failed. Expected separator line %q, got: %q", sep, s[:strings.IndexByte(s, '\n')+1])
+	}
+	if c := strings.Count(s, "\n"+sep); c != 1 {
+		t.Errorf("Send failed. Expected 1 following separator line, got: %d", c)
+	}
+	if !strings.Contains(s, "\n\nFrom MAILER-DAEMON Mon Jan  2 03:04:05 2023\n") {
+		t.Errorf("Send failed. Expected MAILER-DAEMON separator line for message without sender")
+	}
+	for _, l := range []string{"\n>From here\n", "\n>>From there\n", "\n>>>From everywhere\n", "\nFromage\n"} {
+		if !strings.Contains(s, l) {
+			t.Errorf("Send failed. Expected quoted line %q in mbox", l)
+		}
+	}
+	if strings.Contains(s, "\r") {
+		t.Errorf("Send failed. Expected LF line endings only")
+	}
+	if !strings.HasSuffix(s, "\n\n") {
+		t.Errorf("Send failed. Expected message to end with an empty line")
+	}
+}
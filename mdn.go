@@ -0,0 +1,256 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// DispositionType is the disposition type of a message disposition notification (MDN) as
+// described in RFC 8098
+type DispositionType string
+
+// List of disposition types
+const (
+	// DispositionDisplayed indicates that the message has been displayed to the recipient
+	DispositionDisplayed DispositionType = "displayed"
+
+	// DispositionDeleted indicates that the message has been deleted without being displayed
+	DispositionDeleted DispositionType = "deleted"
+
+	// DispositionDispatched indicates that the message has been sent somewhere else (e.g.
+	// forwarded) without being displayed
+	DispositionDispatched DispositionType = "dispatched"
+
+	// DispositionProcessed indicates that the message has been processed in some manner
+	// without being displayed
+	DispositionProcessed DispositionType = "processed"
+)
+
+// List of MDN related errors
+var (
+	// ErrNoDispositionNotification is returned if a message is not a disposition notification
+	ErrNoDispositionNotification = errors.New("message is not a disposition notification")
+
+	// ErrNoMDNRequest is returned if a received message does not request a disposition
+	// notification
+	ErrNoMDNRequest = errors.New("message does not request a disposition notification")
+)
+
+// DispositionNotification is a message disposition notification (MDN) as described in RFC
+// 8098, i.e. the machine-readable part of a read receipt
+type DispositionNotification struct {
+	// ReportingUA is the user agent that generated the MDN
+	ReportingUA string
+	// OriginalRecipient is the original recipient address of the message, if it is known
+	OriginalRecipient string
+	// FinalRecipient is the recipient address the message was delivered to
+	FinalRecipient string
+	// OriginalMessageID is the Message-ID of the message the MDN is about
+	OriginalMessageID string
+	// AutomaticAction indicates that the disposition was performed automatically instead of
+	// by the user
+	AutomaticAction bool
+	// SentAutomatically indicates that the MDN was sent automatically instead of being
+	// confirmed by the user
+	SentAutomatically bool
+	// Disposition is the disposition type of the message
+	Disposition DispositionType
+	// Original is the header section of the message the MDN is about. It is attached to
+	// generated MDNs and set by ParseDispositionNotification
+	Original []byte
+}
+
+// NewMsgFromMDNRequest returns a new MDN Msg with the given disposition type as response to
+// the given received message, which must request an MDN via the Disposition-Notification-To
+// header. Otherwise ErrNoMDNRequest is returned. The MDN is addressed to the requested
+// recipients and sent from the first To address of the received message, which is also used
+// as final recipient. The MDN is marked as manual action that was reported automatically.
+// Use NewMsgFromDispositionNotification for other modes
+func NewMsgFromMDNRequest(r io.Reader, dt DispositionType, o ...MsgOption) (*Msg, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	rm, err := mail.ReadMessage(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	dnt := rm.Header.Get(HeaderDispositionNotificationTo.String())
+	if dnt == "" {
+		return nil, ErrNoMDNRequest
+	}
+	tl, err := mail.ParseAddressList(dnt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse disposition notification address: %w", err)
+	}
+	hb, _, err := splitMsg(b)
+	if err != nil {
+		return nil, err
+	}
+
+	dn := &DispositionNotification{
+		OriginalMessageID: strings.TrimSpace(rm.Header.Get(HeaderMessageID.String())),
+		SentAutomatically: true,
+		Disposition:       dt,
+		Original:          hb,
+	}
+	var fa *mail.Address
+	if al, err := rm.Header.AddressList(HeaderTo.String()); err == nil && len(al) > 0 {
+		fa = al[0]
+		dn.FinalRecipient = fa.Address
+	}
+	s, _ := (&mime.WordDecoder{}).DecodeHeader(rm.Header.Get(HeaderSubject.String()))
+	desc := fmt.Sprintf("The message with the subject %q has been %s.", s, dt)
+	if dt == DispositionDisplayed {
+		desc += " This is no guarantee that the message has been read or understood."
+	}
+
+	m, err := NewMsgFromDispositionNotification(dn, desc, o...)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tl {
+		if err := m.AddTo(t.String()); err != nil {
+			return nil, err
+		}
+	}
+	if fa != nil {
+		if err := m.From(fa.String()); err != nil {
+			return nil, err
+		}
+	}
+	m.Subject(fmt.Sprintf("Disposition notification: %s", s))
+	if dn.OriginalMessageID != "" {
+		m.SetGenHeader(HeaderInReplyTo, dn.OriginalMessageID)
+		m.SetGenHeader(HeaderReferences, dn.OriginalMessageID)
+	}
+	return m, nil
+}
+
+// NewMsgFromDispositionNotification returns a new multipart/report Msg for the given
+// DispositionNotification. The Msg consists of the given human-readable description, the
+// machine-readable message/disposition-notification part and, if available, the header
+// section of the original message as text/rfc822-headers. The sender, recipient and
+// subject of the Msg have to be set by the caller
+func NewMsgFromDispositionNotification(dn *DispositionNotification, desc string, o ...MsgOption) (*Msg, error) {
+	if dn == nil {
+		return nil, fmt.Errorf("disposition notification must not be nil")
+	}
+	if dn.Disposition == "" {
+		return nil, fmt.Errorf("disposition type must not be empty")
+	}
+
+	m := NewMsg(o...)
+	m.reportType = "disposition-notification"
+	m.SetBodyString(TypeTextPlain, desc)
+	m.attachments = append(m.attachments, newReportFile("message/disposition-notification",
+		Encoding7bit, dn.fields()))
+	if len(dn.Original) > 0 {
+		m.attachments = append(m.attachments, newReportFile("text/rfc822-headers", NoEncoding,
+			dn.Original))
+	}
+	return m, nil
+}
+
+// ParseDispositionNotification parses the given message as a disposition notification. The
+// message must be a "multipart/report" message with the "disposition-notification" report
+// type and a "message/disposition-notification" part. Otherwise ErrNoDispositionNotification
+// is returned
+func ParseDispositionNotification(r io.Reader) (*DispositionNotification, error) {
+	var dn *DispositionNotification
+	var orig []byte
+	err := readReport(r, "disposition-notification", func(mt string, pr io.Reader) error {
+		switch mt {
+		case "message/disposition-notification", "message/global-disposition-notification":
+			if dn != nil {
+				return nil
+			}
+			h, err := textproto.NewReader(bufio.NewReader(pr)).ReadMIMEHeader()
+			if err != nil && !errors.Is(err, io.EOF) {
+				return fmt.Errorf("failed to parse disposition notification: %w", err)
+			}
+			dn = parseDispositionNotificationFields(h)
+		case "text/rfc822-headers", "message/rfc822":
+			if orig != nil {
+				return nil
+			}
+			b, err := io.ReadAll(pr)
+			if err != nil {
+				return fmt.Errorf("failed to read original message: %w", err)
+			}
+			orig = b
+		}
+		return nil
+	})
+	if errors.Is(err, errNoReport) || (err == nil && dn == nil) {
+		return nil, ErrNoDispositionNotification
+	}
+	if err != nil {
+		return nil, err
+	}
+	dn.Original = orig
+	return dn, nil
+}
+
+// fields returns the rendered fields of the message/disposition-notification part
+func (dn *DispositionNotification) fields() []byte {
+	buf := bytes.Buffer{}
+	ua := dn.ReportingUA
+	if ua == "" {
+		ua = fmt.Sprintf("go-mail; go-mail/%s", VERSION)
+	}
+	buf.WriteString(fmt.Sprintf("Reporting-UA: %s%s", ua, SingleNewLine))
+	if dn.OriginalRecipient != "" {
+		buf.WriteString(fmt.Sprintf("Original-Recipient: rfc822;%s%s", dn.OriginalRecipient, SingleNewLine))
+	}
+	if dn.FinalRecipient != "" {
+		buf.WriteString(fmt.Sprintf("Final-Recipient: rfc822;%s%s", dn.FinalRecipient, SingleNewLine))
+	}
+	if dn.OriginalMessageID != "" {
+		buf.WriteString(fmt.Sprintf("Original-Message-ID: %s%s", dn.OriginalMessageID, SingleNewLine))
+	}
+	am, sm := "manual-action", "MDN-sent-manually"
+	if dn.AutomaticAction {
+		am = "automatic-action"
+	}
+	if dn.SentAutomatically {
+		sm = "MDN-sent-automatically"
+	}
+	buf.WriteString(fmt.Sprintf("Disposition: %s/%s; %s%s", am, sm, dn.Disposition, SingleNewLine))
+	return buf.Bytes()
+}
+
+// parseDispositionNotificationFields returns the DispositionNotification for the given fields
+// of a message/disposition-notification part
+func parseDispositionNotificationFields(h textproto.MIMEHeader) *DispositionNotification {
+	dn := &DispositionNotification{
+		ReportingUA:       strings.TrimSpace(h.Get("Reporting-UA")),
+		OriginalRecipient: dsnValue(h.Get("Original-Recipient")),
+		FinalRecipient:    dsnValue(h.Get("Final-Recipient")),
+		OriginalMessageID: strings.TrimSpace(h.Get("Original-Message-ID")),
+	}
+	// The Disposition field has the format "action-mode/sending-mode; type[/modifiers]"
+	d := h.Get("Disposition")
+	if i := strings.IndexByte(d, ';'); i >= 0 {
+		ml := strings.SplitN(strings.ToLower(strings.TrimSpace(d[:i])), "/", 2)
+		dn.AutomaticAction = ml[0] == "automatic-action"
+		dn.SentAutomatically = len(ml) == 2 && ml[1] == "mdn-sent-automatically"
+		d = d[i+1:]
+	}
+	if i := strings.IndexByte(d, '/'); i >= 0 {
+		d = d[:i]
+	}
+	dn.Disposition = DispositionType(strings.ToLower(strings.TrimSpace(d)))
+	return dn
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestNewMsgFromMDNRequest tests that generated MDNs respond to the request and can be parsed
+// again
+func TestNewMsgFromMDNRequest(t *testing.T) {
+	om := testMsg(t)
+	om.Subject("Hello")
+	om.SetMessageIDWithValue("original@example.com")
+	if err := om.RequestMDNTo("receipts@example.com"); err != nil {
+		t.Fatalf("RequestMDNTo failed: %s", err)
+	}
+	ob := bytes.Buffer{}
+	if _, err := om.WriteTo(&ob); err != nil {
+		t.Fatalf("failed to write original message: %s", err)
+	}
+
+	m, err := NewMsgFromMDNRequest(bytes.NewReader(ob.Bytes()), DispositionDisplayed)
+	if err != nil {
+		t.Fatalf("NewMsgFromMDNRequest failed: %s", err)
+	}
+	if to := m.GetToString(); len(to) != 1 || to[0] != "<receipts@example.com>" {
+		t.Errorf("NewMsgFromMDNRequest failed. Expected MDN recipient, got: %v", to)
+	}
+	if fr := m.GetFromString(); len(fr) != 1 || fr[0] != "<rcpt@example.com>" {
+		t.Errorf("NewMsgFromMDNRequest failed. Expected MDN sender, got: %v", fr)
+	}
+	if irt := m.GetGenHeader(HeaderInReplyTo); len(irt) != 1 || irt[0] != "<original@example.com>" {
+		t.Errorf("NewMsgFromMDNRequest failed. Expected In-Reply-To header, got: %v", irt)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write MDN: %s", err)
+	}
+	if !strings.Contains(buf.String(), "Content-Type: multipart/report; report-type=disposition-notification;") {
+		t.Errorf("NewMsgFromMDNRequest failed. Expected multipart/report content type, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Disposition: manual-action/MDN-sent-automatically; displayed\r\n") {
+		t.Errorf("NewMsgFromMDNRequest failed. Expected disposition field, got: %s", buf.String())
+	}
+
+	dn, err := ParseDispositionNotification(&buf)
+	if err != nil {
+		t.Fatalf("ParseDispositionNotification failed: %s", err)
+	}
+	if dn.Disposition != DispositionDisplayed || dn.AutomaticAction || !dn.SentAutomatically ||
+		dn.FinalRecipient != "rcpt@example.com" || dn.OriginalMessageID != "<original@example.com>" ||
+		!strings.HasPrefix(dn.ReportingUA, "go-mail") {
+		t.Errorf("ParseDispositionNotification failed. Unexpected notification: %+v", dn)
+	}
+	if !bytes.Contains(dn.Original, []byte("Subject: Hello")) {
+		t.Errorf("ParseDispositionNotification failed. Expected original header section, got: %q", dn.Original)
+	}
+
+	om.RemoveHeader(HeaderDispositionNotificationTo)
+	ob.Reset()
+	if _, err := om.WriteTo(&ob); err != nil {
+		t.Fatalf("failed to write original message: %s", err)
+	}
+	if _, err := NewMsgFromMDNRequest(&ob, DispositionDisplayed); !errors.Is(err, ErrNoMDNRequest) {
+		t.Errorf("NewMsgFromMDNRequest without request was expected to fail, got: %v", err)
+	}
+	if _, err := ParseDispositionNotification(strings.NewReader(testDSN)); !errors.Is(err, ErrNoDispositionNotification) {
+		t.Errorf("ParseDispositionNotification of a DSN was expected to fail, got: %v", err)
+	}
+}
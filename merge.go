@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	ht "html/template"
+	"reflect"
+	"strings"
+	tt "text/template"
+)
+
+// DefaultMergeBatchSize is the default number of messages that Merge.Send generates and
+// delivers at once
+const DefaultMergeBatchSize = 100
+
+// ErrNoMergeRecipient is returned if a Merge is created without a recipient template
+var ErrNoMergeRecipient = errors.New("merge recipient template must be set")
+
+// Merge generates personalized messages (a mail merge) from a base Msg and a list of
+// recipient data. For every recipient, the base Msg is cloned and the recipient address,
+// the subject and the bodies are rendered from templates with the data of the recipient.
+// A Merge is safe for concurrent use
+type Merge struct {
+	// base is the Msg that is cloned for every recipient
+	base *Msg
+
+	// to is the template of the recipient address
+	to *tt.Template
+
+	// subject is the template of the subject
+	subject *tt.Template
+
+	// html is the template of the HTML body
+	html *ht.Template
+
+	// text is the template of the text body
+	text *tt.Template
+
+	// batch is the number of messages that Send generates and delivers at once
+	batch int
+}
+
+// MergeOption returns a function that can be used for grouping Merge options
+type MergeOption func(*Merge) error
+
+// NewMerge returns a new Merge for the given base Msg. The base Msg holds the common headers,
+// attachments and, if no body templates are set, the body of the generated messages. The
+// recipient template must be set via WithMergeTo
+func NewMerge(base *Msg, o ...MergeOption) (*Merge, error) {
+	if base == nil {
+		return nil, fmt.Errorf("base message must not be nil")
+	}
+	mm := &Merge{base: base, batch: DefaultMergeBatchSize}
+
+	// Override defaults with optionally provided MergeOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(mm); err != nil {
+			return nil, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	if mm.to == nil {
+		return nil, ErrNoMergeRecipient
+	}
+	return mm, nil
+}
+
+// WithMergeTo sets the template of the recipient address (e.g. `{{ .Name }} <{{ .Email }}>`)
+func WithMergeTo(s string) MergeOption {
+	return func(mm *Merge) error {
+		t, err := tt.New("to").Parse(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse recipient template: %w", err)
+		}
+		mm.to = t
+		return nil
+	}
+}
+
+// WithMergeSubject sets the template of the subject (e.g. `Welcome, {{ .Name }}!`)
+func WithMergeSubject(s string) MergeOption {
+	return func(mm *Merge) error {
+		t, err := tt.New("subject").Parse(s)
+		if err != nil {
+			return fmt.Errorf("failed to parse subject template: %w", err)
+		}
+		mm.subject = t
+		return nil
+	}
+}
+
+// WithMergeHTMLTemplate sets the template of the HTML body. Templates of a TemplateSet can be
+// used via TemplateSet.HTML
+func WithMergeHTMLTemplate(t *ht.Template) MergeOption {
+	return func(mm *Merge) error {
+		if t == nil {
+			return fmt.Errorf(errTplPointerNil)
+		}
+		mm.html = t
+		return nil
+	}
+}
+
+// WithMergeTextTemplate sets the template of the text body. Templates of a TemplateSet can be
+// used via TemplateSet.Text
+func WithMergeTextTemplate(t *tt.Template) MergeOption {
+	return func(mm *Merge) error {
+		if t == nil {
+			return fmt.Errorf(errTplPointerNil)
+		}
+		mm.text = t
+		return nil
+	}
+}
+
+// WithMergeBatchSize overrides the number of messages that Send generates and delivers at once
+func WithMergeBatchSize(n int) MergeOption {
+	return func(mm *Merge) error {
+		if n < 1 {
+			return fmt.Errorf("merge batch size must be greater than 0")
+		}
+		mm.batch = n
+		return nil
+	}
+}
+
+// Msg returns the personalized Msg for the given recipient data
+func (mm *Merge) Msg(d interface{}) (*Msg, error) {
+	m := mm.base.Clone()
+	to, err := execMergeTemplate(mm.to, d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute recipient template: %w", err)
+	}
+	if err := m.To(strings.TrimSpace(to)); err != nil {
+		return nil, err
+	}
+	if mm.subject != nil {
+		s, err := execMergeTemplate(mm.subject, d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute subject template: %w", err)
+		}
+		m.Subject(s)
+	}
+	switch {
+	case mm.text != nil && mm.html != nil:
+		if err := m.SetBodyTextTemplate(mm.text, d); err != nil {
+			return nil, err
+		}
+		if err := m.AddAlternativeHTMLTemplate(mm.html, d); err != nil {
+			return nil, err
+		}
+	case mm.text != nil:
+		if err := m.SetBodyTextTemplate(mm.text, d); err != nil {
+			return nil, err
+		}
+	case mm.html != nil:
+		if err := m.SetBodyHTMLTemplate(mm.html, d); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Generate returns the personalized messages for the given recipient data, which must be a
+// slice or an array (e.g. a slice of structs or maps)
+func (mm *Merge) Generate(dl interface{}) ([]*Msg, error) {
+	v, err := mergeData(dl)
+	if err != nil {
+		return nil, err
+	}
+	ml := make([]*Msg, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		m, err := mm.Msg(v.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message %d: %w", i, err)
+		}
+		ml = append(ml, m)
+	}
+	return ml, nil
+}
+
+// Send generates the personalized messages for the given recipient data and delivers them via
+// Client.SendConcurrently with the given number of workers. The messages are generated and
+// delivered in batches, so that large recipient lists do not have to be held in memory at
+// once. The rate limit of the Client (see WithRateLimit) applies to all workers. If a message
+// cannot be generated, Send returns before delivering the batch. The returned error
+// aggregates the errors of all failed deliveries
+func (mm *Merge) Send(ctx context.Context, c *Client, dl interface{}, workers int) error {
+	if c == nil {
+		return fmt.Errorf("client must not be nil")
+	}
+	v, err := mergeData(dl)
+	if err != nil {
+		return err
+	}
+	var errs []*SendError
+	for s := 0; s < v.Len(); s += mm.batch {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, &SendError{Reason: ErrConnCheck, errlist: []error{err}, isTemp: true})
+			break
+		}
+		e := s + mm.batch
+		if e > v.Len() {
+			e = v.Len()
+		}
+		ml := make([]*Msg, 0, e-s)
+		for i := s; i < e; i++ {
+			m, err := mm.Msg(v.Index(i).Interface())
+			if err != nil {
+				return fmt.Errorf("failed to generate message %d: %w", i, err)
+			}
+			ml = append(ml, m)
+		}
+		_ = c.SendConcurrently(ctx, ml, workers)
+		for _, m := range ml {
+			var se *SendError
+			if errors.As(m.sendError, &se) {
+				errs = append(errs, se)
+			}
+		}
+	}
+	return joinSendErrors(errs)
+}
+
+// mergeData returns the reflect.Value of the given recipient data, if it is a slice or array
+func mergeData(dl interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(dl)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return v, fmt.Errorf("merge data must be a slice or an array, got: %T", dl)
+	}
+	return v, nil
+}
+
+// execMergeTemplate returns the output of the given template for the given data
+func execMergeTemplate(t *tt.Template, d interface{}) (string, error) {
+	buf := bytes.Buffer{}
+	if err := t.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
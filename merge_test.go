@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	ht "html/template"
+	"strings"
+	"testing"
+	tt "text/template"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestMerge tests the generation and delivery of personalized messages
+func TestMerge(t *testing.T) {
+	type user struct {
+		Name  string
+		Email string
+	}
+	ul := []user{{"Alice", "alice@example.com"}, {"Bob", "bob@example.com"}, {"Carol", "rejected@example.com"}}
+	base := NewMsg()
+	if err := base.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set From address: %s", err)
+	}
+	base.SetGenHeader(HeaderXPriority, "1")
+	mm, err := NewMerge(base,
+		WithMergeTo(`"{{ .Name }}" <{{ .Email }}>`),
+		WithMergeSubject(`Hello {{ .Name }}`),
+		WithMergeTextTemplate(tt.Must(tt.New("text").Parse(`Hi {{ .Name }}`))),
+		WithMergeHTMLTemplate(ht.Must(ht.New("html").Parse(`<p>Hi {{ .Name }}</p>`))),
+		WithMergeBatchSize(2))
+	if err != nil {
+		t.Fatalf("NewMerge failed: %s", err)
+	}
+
+	ml, err := mm.Generate(ul)
+	if err != nil {
+		t.Fatalf("Generate failed: %s", err)
+	}
+	if len(ml) != 3 {
+		t.Fatalf("Generate failed. Expected 3 messages, got: %d", len(ml))
+	}
+	m := ml[1]
+	if to := m.GetToString(); len(to) != 1 || to[0] != `"Bob" <bob@example.com>` {
+		t.Errorf("Generate failed. Unexpected recipient: %v", to)
+	}
+	if s := m.GetGenHeader(HeaderSubject); len(s) != 1 || s[0] != "Hello Bob" {
+		t.Errorf("Generate failed. Unexpected subject: %v", s)
+	}
+	if p := m.GetGenHeader(HeaderXPriority); len(p) != 1 || p[0] != "1" {
+		t.Errorf("Generate failed. Expected header of the base message, got: %v", p)
+	}
+	if pl := m.GetParts(); len(pl) != 2 {
+		t.Errorf("Generate failed. Expected text and HTML part, got: %d parts", len(pl))
+	} else if c, _ := pl[0].GetContent(); string(c) != "Hi Bob" {
+		t.Errorf("Generate failed. Unexpected text body: %s", c)
+	}
+	if len(base.GetToString()) != 0 {
+		t.Errorf("Generate failed. The base message must not be changed")
+	}
+
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	s.RejectRcpt("rejected@example.com", "550 5.1.1 User unknown")
+	err = mm.Send(context.Background(), testClient(t, s), ul, 2)
+	var se *SendError
+	if !errors.As(err, &se) || !strings.Contains(err.Error(), "rejected@example.com") {
+		t.Errorf("Send was expected to fail for the rejected recipient, got: %v", err)
+	}
+	if n := len(testMessages(s)); n != 2 {
+		t.Errorf("Send failed. Expected 2 delivered messages, got: %d", n)
+	}
+
+	if _, err := NewMerge(base); !errors.Is(err, ErrNoMergeRecipient) {
+		t.Errorf("NewMerge without recipient template was expected to fail, got: %v", err)
+	}
+	if _, err := mm.Generate(ul[0]); err == nil {
+		t.Errorf("Generate with non-slice data was expected to fail")
+	}
+	if _, err := mm.Generate([]user{{"Invalid", "invalid"}}); err == nil {
+		t.Errorf("Generate with invalid recipient address was expected to fail")
+	}
+}
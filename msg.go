@@ -5,18 +5,28 @@
 package mail
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/rsa"
+	"crypto/x509"
 	"embed"
 	"errors"
 	"fmt"
 	ht "html/template"
 	"io"
+	"io/fs"
 	"mime"
+	"net/http"
 	"net/mail"
+	"net/textproto"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"syscall"
 	tt "text/template"
 	"time"
@@ -28,8 +38,14 @@ var (
 
 	// ErrNoRcptAddresses should be used when the list of RCPTs is empty
 	ErrNoRcptAddresses = errors.New("no recipient addresses set")
+
+	// ErrNoMessageID should be used when a Message-ID is required but not set
+	ErrNoMessageID = errors.New("no Message-ID set")
 )
 
+// imgSrcRegexp matches the src attribute of HTML <img> tags
+var imgSrcRegexp = regexp.MustCompile(`(?i)(<img\b[^>]*?\ssrc\s*=\s*)(?:"([^"]*)"|'([^']*)')`)
+
 const (
 	// errTplExecuteFailed is issued when the template execution was not successful
 	errTplExecuteFailed = "failed to execute template: %w"
@@ -72,6 +88,9 @@ type Msg struct {
 	// addrHeader is a slice of strings that the different mail AddrHeader fields
 	addrHeader map[AddrHeader][]*mail.Address
 
+	// addrGroups holds the RFC 5322 address groups of the To and Cc address header fields
+	addrGroups map[AddrHeader][]addrGroup
+
 	// attachments represent the different attachment File of the Msg
 	attachments []*File
 
@@ -84,6 +103,19 @@ type Msg struct {
 	// embeds represent the different embedded File of the Msg
 	embeds []*File
 
+	// relatedRoot is the Content-ID of the embed that is the root of the multipart/related
+	// container (see SetRelatedRoot)
+	relatedRoot string
+
+	// dsnmrtype overrides the DSNMailReturnOption of the Client for the Msg
+	dsnmrtype DSNMailReturnOption
+
+	// dsnrntype holds the DSN notify options of single recipients of the Msg
+	dsnrntype map[string][]string
+
+	// digest holds the rendered messages of the multipart/digest container of the Msg
+	digest []*File
+
 	// encoder represents a mime.WordEncoder from the std lib
 	encoder mime.WordEncoder
 
@@ -113,8 +145,94 @@ type Msg struct {
 
 	// sendError holds the SendError in case a Msg could not be delivered during the Client.Send operation
 	sendError error
+
+	// serverResponses holds the reply texts of the server to the mail data of every SMTP
+	// transaction of the last delivery
+	serverResponses []string
+
+	// suppressed holds the recipient addresses that were dropped from the envelope by the
+	// SuppressionChecker of the Client during the last delivery
+	suppressed []string
+
+	// rcptOffset is the number of recipients the Msg has already been delivered to, if the
+	// Client splits the recipients into multiple transactions
+	rcptOffset int
+
+	// dkimSigners is the list of DKIMSigner that are used to sign the rendered Msg
+	dkimSigners []*DKIMSigner
+
+	// smimeSigner is the SMIMESigner that is used to S/MIME sign the rendered Msg
+	smimeSigner *SMIMESigner
+
+	// smimeRecipients is the list of certificates the rendered Msg is S/MIME encrypted for
+	smimeRecipients []*x509.Certificate
+
+	// pgpSigner is the PGPSigner that is used to PGP/MIME sign the rendered Msg
+	pgpSigner PGPSigner
+
+	// pgpEncrypter is the PGPEncrypter that is used to PGP/MIME encrypt the rendered Msg
+	pgpEncrypter PGPEncrypter
+
+	// midDomain is the domain part of generated Message-IDs (defaults to the hostname)
+	midDomain string
+
+	// headerPolicy defines how invalid header fields are handled
+	headerPolicy HeaderPolicy
+
+	// headerOrder is the custom order of the header fields of the rendered Msg
+	headerOrder []Header
+
+	// jsonFileRefs indicates that files are referenced by their path in the JSON representation
+	jsonFileRefs bool
+
+	// headerErr holds the first HeaderError of a rejected header field
+	headerErr error
+
+	// fixedDate is the time that is used for the Date header, if no Date header is set
+	fixedDate time.Time
+
+	// noDefaultUserAgent indicates that the default User-Agent/X-Mailer header is not set
+	noDefaultUserAgent bool
+
+	// userAgent overrides the default User-Agent/X-Mailer header
+	userAgent string
+
+	// nowFunc returns the current time for the Date header and Message-ID (defaults to time.Now)
+	nowFunc func() time.Time
+
+	// location is the time zone of the Date header (defaults to the local time zone)
+	location *time.Location
+
+	// httpClient is the http.Client that fetches the attachments of AttachFromURL
+	httpClient *http.Client
+
+	// urlAttachLimit is the maximum size of an attachment that is fetched via AttachFromURL
+	urlAttachLimit int64
+
+	// maxSize is the size limit of the rendered Msg (see WithMaxMessageSize)
+	maxSize int64
+
+	// reader holds the rendered Msg while it is consumed via Read
+	reader *Reader
+
+	// raw holds an already rendered message that is written instead of rendering the Msg.
+	// It is used for messages that are restored from a Spool
+	raw []byte
+
+	// reportType is the report type of a multipart/report Msg (RFC 6522), e.g. for feedback
+	// reports. If set, the parts and attachments are rendered as multipart/report
+	reportType string
 }
 
+// Compile-time checks that the Msg satisfies the io.WriterTo and io.Reader interfaces
+var (
+	_ io.WriterTo = (*Msg)(nil)
+	_ io.Reader   = (*Msg)(nil)
+)
+
+// FixedBoundary is the MIME boundary that is used with WithFixedBoundary
+const FixedBoundary = "go-mail-fixed-boundary"
+
 // SendmailPath is the default system path to the sendmail binary
 const SendmailPath = "/usr/sbin/sendmail"
 
@@ -174,6 +292,84 @@ func WithBoundary(b string) MsgOption {
 	}
 }
 
+// WithFixedBoundary tells the Msg to use the constant FixedBoundary as MIME boundary instead
+// of a random one, so that the rendered output is deterministic. Nested multiparts use the
+// boundary with a suffix of their nesting depth. It is intended for tests, e.g. with golden
+// files, in combination with WithFixedDate, WithNoDefaultUserAgent and SetMessageIDWithValue
+func WithFixedBoundary() MsgOption {
+	return func(m *Msg) {
+		m.boundary = FixedBoundary
+	}
+}
+
+// WithFixedDate tells the Msg to use the given time for the Date header instead of the current
+// time, if no Date header is set explicitly. See WithFixedBoundary for deterministic output
+func WithFixedDate(t time.Time) MsgOption {
+	return func(m *Msg) {
+		m.fixedDate = t
+	}
+}
+
+// WithNowFunc sets the function that the Msg uses to get the current time for the Date
+// header and the Message-ID, instead of time.Now. This is useful for reproducible tests and
+// for environments with a simulated clock
+func WithNowFunc(f func() time.Time) MsgOption {
+	return func(m *Msg) {
+		if f != nil {
+			m.nowFunc = f
+		}
+	}
+}
+
+// WithLocation sets the time zone that the Msg uses for the Date header, instead of the local
+// time zone of the host
+func WithLocation(l *time.Location) MsgOption {
+	return func(m *Msg) {
+		m.location = l
+	}
+}
+
+// WithNoDefaultUserAgent tells the Msg to not set the default User-Agent and X-Mailer headers,
+// which contain the go-mail version. See WithFixedBoundary for deterministic output
+func WithNoDefaultUserAgent() MsgOption {
+	return func(m *Msg) {
+		m.noDefaultUserAgent = true
+	}
+}
+
+// WithUserAgent overrides the default User-Agent and X-Mailer headers of the Msg, which contain
+// the go-mail version, with the given string (e.g. for white-label products). Headers that
+// are set via SetUserAgent or SetGenHeader take precedence
+func WithUserAgent(a string) MsgOption {
+	return func(m *Msg) {
+		m.userAgent = a
+	}
+}
+
+// WithMessageIDDomain overrides the domain part of generated Message-IDs, which defaults
+// to the hostname of the system
+func WithMessageIDDomain(d string) MsgOption {
+	return func(m *Msg) {
+		m.midDomain = d
+	}
+}
+
+// WithHeaderPolicy overrides the default HeaderPolicy (HeaderPolicyReject) of the Msg
+func WithHeaderPolicy(p HeaderPolicy) MsgOption {
+	return func(m *Msg) {
+		m.headerPolicy = p
+	}
+}
+
+// WithHeaderOrder overrides the canonical order of the header fields of the rendered Msg. The
+// given header fields are rendered first in the given order, all others follow in the canonical
+// order (trace, originator, destination, identification, informational and MIME fields)
+func WithHeaderOrder(hl ...Header) MsgOption {
+	return func(m *Msg) {
+		m.headerOrder = hl
+	}
+}
+
 // WithMiddleware add the given middleware in the end of the list of the client middlewares
 func WithMiddleware(mw Middleware) MsgOption {
 	return func(m *Msg) {
@@ -188,6 +384,35 @@ func WithPGPType(t PGPType) MsgOption {
 	}
 }
 
+// WithDKIMSigner tells the Msg to sign the rendered message with the given DKIMSigner(s)
+func WithDKIMSigner(ds ...*DKIMSigner) MsgOption {
+	return func(m *Msg) {
+		m.SetDKIMSigner(ds...)
+	}
+}
+
+// WithSMIMESigner tells the Msg to S/MIME sign the rendered message with the given SMIMESigner
+func WithSMIMESigner(s *SMIMESigner) MsgOption {
+	return func(m *Msg) {
+		m.smimeSigner = s
+	}
+}
+
+// WithPGPSigner tells the Msg to PGP/MIME sign the rendered message with the given PGPSigner
+func WithPGPSigner(s PGPSigner) MsgOption {
+	return func(m *Msg) {
+		m.pgpSigner = s
+	}
+}
+
+// WithPGPEncrypter tells the Msg to PGP/MIME encrypt the rendered message with the given
+// PGPEncrypter
+func WithPGPEncrypter(e PGPEncrypter) MsgOption {
+	return func(m *Msg) {
+		m.pgpEncrypter = e
+	}
+}
+
 // SetCharset sets the encoding charset of the Msg
 func (m *Msg) SetCharset(c Charset) {
 	m.charset = c
@@ -204,6 +429,11 @@ func (m *Msg) SetBoundary(b string) {
 	m.boundary = b
 }
 
+// SetMessageIDDomain sets the domain part of generated Message-IDs of the Msg
+func (m *Msg) SetMessageIDDomain(d string) {
+	m.midDomain = d
+}
+
 // SetMIMEVersion sets the MIME version of the Msg
 func (m *Msg) SetMIMEVersion(mv MIMEVersion) {
 	m.mimever = mv
@@ -214,6 +444,62 @@ func (m *Msg) SetPGPType(t PGPType) {
 	m.pgptype = t
 }
 
+// SetDKIMSigner sets the DKIMSigner(s) that are used to sign the rendered Msg, replacing
+// any previously set signer. Calling it without a (non-nil) signer disables the DKIM signing
+func (m *Msg) SetDKIMSigner(ds ...*DKIMSigner) {
+	m.dkimSigners = nil
+	for _, d := range ds {
+		m.AddDKIMSigner(d)
+	}
+}
+
+// SetSMIMESigner sets the SMIMESigner that is used to S/MIME sign the rendered Msg. A nil
+// value disables the S/MIME signing
+func (m *Msg) SetSMIMESigner(s *SMIMESigner) {
+	m.smimeSigner = s
+}
+
+// SetPGPSigner sets the PGPSigner that is used to PGP/MIME sign the rendered Msg. A nil
+// value disables the PGP/MIME signing
+func (m *Msg) SetPGPSigner(s PGPSigner) {
+	m.pgpSigner = s
+}
+
+// SetPGPEncrypter sets the PGPEncrypter that is used to PGP/MIME encrypt the rendered Msg.
+// If a PGPSigner is set as well, the message is signed first and encrypted afterwards.
+// A nil value disables the PGP/MIME encryption
+func (m *Msg) SetPGPEncrypter(e PGPEncrypter) {
+	m.pgpEncrypter = e
+}
+
+// EncryptSMIME tells the Msg to S/MIME encrypt the rendered message for the given recipient
+// certificates, replacing any previously set certificate. Only RSA certificates are supported.
+// If a SMIMESigner is set as well, the message is signed first and encrypted afterwards
+func (m *Msg) EncryptSMIME(cl ...*x509.Certificate) error {
+	if len(cl) == 0 {
+		return ErrSMIMENoRecipients
+	}
+	for _, c := range cl {
+		if c == nil {
+			return ErrSMIMENoRecipients
+		}
+		if _, ok := c.PublicKey.(*rsa.PublicKey); !ok {
+			return ErrSMIMEUnsupportedCert
+		}
+	}
+	m.smimeRecipients = cl
+	return nil
+}
+
+// AddDKIMSigner adds an additional DKIMSigner to the Msg. Each signer adds its own
+// DKIM-Signature header to the rendered message, in the order they have been added
+func (m *Msg) AddDKIMSigner(ds *DKIMSigner) {
+	if ds == nil {
+		return
+	}
+	m.dkimSigners = append(m.dkimSigners, ds)
+}
+
 // Encoding returns the currently set encoding of the Msg
 func (m *Msg) Encoding() string {
 	return m.encoding.String()
@@ -234,10 +520,17 @@ func (m *Msg) SetHeader(h Header, v ...string) {
 
 // SetGenHeader sets a generic header field of the Msg
 // For adding address headers like "To:" or "From", see SetAddrHeader
+//
+// Header fields with invalid names or values are handled according to the HeaderPolicy
+// of the Msg
 func (m *Msg) SetGenHeader(h Header, v ...string) {
 	if m.genHeader == nil {
 		m.genHeader = make(map[Header][]string)
 	}
+	h, ok := m.checkHeader(h, false, v...)
+	if !ok {
+		return
+	}
 	for i, hv := range v {
 		v[i] = m.encodeString(hv)
 	}
@@ -258,7 +551,8 @@ func (m *Msg) SetHeaderPreformatted(h Header, v string) {
 //
 // This method does not take a slice of values but only a single value. This is
 // due to the fact, that we do not perform any content alteration and expect the
-// user has already done so
+// user has already done so. The value is neither word-encoded nor re-folded, which
+// allows to inject externally generated header fields like DKIM-Signature or ARC-Seal
 //
 // **Please note:** This method should be used only as a last resort. Since the
 // user is respondible for the formating of the message header, go-mail cannot
@@ -268,7 +562,36 @@ func (m *Msg) SetGenHeaderPreformatted(h Header, v string) {
 	if m.preformHeader == nil {
 		m.preformHeader = make(map[Header]string)
 	}
-	m.preformHeader[h] = v
+	vl := []string{v}
+	h, ok := m.checkHeader(h, true, vl...)
+	if !ok {
+		return
+	}
+	m.preformHeader[h] = vl[0]
+}
+
+// checkHeader validates the given header field name and values. If the header field is
+// invalid, it is either sanitized or rejected, depending on the HeaderPolicy of the Msg.
+// It returns the header field name to use and false if the header field was rejected
+func (m *Msg) checkHeader(h Header, pf bool, vl ...string) (Header, bool) {
+	ok := validHeaderName(h)
+	for _, v := range vl {
+		ok = ok && validHeaderValue(v, pf)
+	}
+	if ok {
+		return h, true
+	}
+	if m.headerPolicy == HeaderPolicySanitize {
+		for i := range vl {
+			vl[i] = sanitizeHeaderValue(vl[i], pf)
+		}
+		h = sanitizeHeaderName(h)
+		return h, h != ""
+	}
+	if m.headerErr == nil {
+		m.headerErr = &HeaderError{Header: h, Value: strings.Join(vl, ", ")}
+	}
+	return h, false
 }
 
 // SetAddrHeader sets an address related header field of the Msg
@@ -293,6 +616,20 @@ func (m *Msg) SetAddrHeader(h AddrHeader, v ...string) error {
 	return nil
 }
 
+// setAddrHeaderFromString parses the given string of comma-separated mail addresses and sets
+// them as the given address related header field of the Msg
+func (m *Msg) setAddrHeaderFromString(h AddrHeader, v string) error {
+	al, err := mail.ParseAddressList(v)
+	if err != nil {
+		return fmt.Errorf(errParseMailAddr, v, err)
+	}
+	if m.addrHeader == nil {
+		m.addrHeader = make(map[AddrHeader][]*mail.Address)
+	}
+	m.addrHeader[h] = al
+	return nil
+}
+
 // SetAddrHeaderIgnoreInvalid sets an address related header field of the Msg and ignores invalid address
 // in the validation process
 func (m *Msg) SetAddrHeaderIgnoreInvalid(h AddrHeader, v ...string) {
@@ -316,7 +653,7 @@ func (m *Msg) EnvelopeFrom(f string) error {
 // EnvelopeFromFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // the envelope FROM address header field
 func (m *Msg) EnvelopeFromFormat(n, a string) error {
-	return m.SetAddrHeader(HeaderEnvelopeFrom, fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.SetAddrHeader(HeaderEnvelopeFrom, formatAddr(n, a))
 }
 
 // From takes and validates a given mail address and sets it as "From" genHeader of the Msg
@@ -327,7 +664,7 @@ func (m *Msg) From(f string) error {
 // FromFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // the From address header field
 func (m *Msg) FromFormat(n, a string) error {
-	return m.SetAddrHeader(HeaderFrom, fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.SetAddrHeader(HeaderFrom, formatAddr(n, a))
 }
 
 // To takes and validates a given mail address list sets the To: addresses of the Msg
@@ -343,7 +680,7 @@ func (m *Msg) AddTo(t string) error {
 // AddToFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // as additional To address header field
 func (m *Msg) AddToFormat(n, a string) error {
-	return m.addAddr(HeaderTo, fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.addAddr(HeaderTo, formatAddr(n, a))
 }
 
 // ToIgnoreInvalid takes and validates a given mail address list sets the To: addresses of the Msg
@@ -352,6 +689,12 @@ func (m *Msg) ToIgnoreInvalid(t ...string) {
 	m.SetAddrHeaderIgnoreInvalid(HeaderTo, t...)
 }
 
+// ToFromString takes and validates a given string of comma-separated mail addresses and sets
+// them as To: addresses of the Msg (e.g. "a@example.com, Bob <b@example.com>")
+func (m *Msg) ToFromString(v string) error {
+	return m.setAddrHeaderFromString(HeaderTo, v)
+}
+
 // Cc takes and validates a given mail address list sets the Cc: addresses of the Msg
 func (m *Msg) Cc(c ...string) error {
 	return m.SetAddrHeader(HeaderCc, c...)
@@ -365,7 +708,7 @@ func (m *Msg) AddCc(t string) error {
 // AddCcFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // as additional Cc address header field
 func (m *Msg) AddCcFormat(n, a string) error {
-	return m.addAddr(HeaderCc, fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.addAddr(HeaderCc, formatAddr(n, a))
 }
 
 // CcIgnoreInvalid takes and validates a given mail address list sets the Cc: addresses of the Msg
@@ -374,6 +717,12 @@ func (m *Msg) CcIgnoreInvalid(c ...string) {
 	m.SetAddrHeaderIgnoreInvalid(HeaderCc, c...)
 }
 
+// CcFromString takes and validates a given string of comma-separated mail addresses and sets
+// them as Cc: addresses of the Msg (e.g. "a@example.com, Bob <b@example.com>")
+func (m *Msg) CcFromString(v string) error {
+	return m.setAddrHeaderFromString(HeaderCc, v)
+}
+
 // Bcc takes and validates a given mail address list sets the Bcc: addresses of the Msg
 func (m *Msg) Bcc(b ...string) error {
 	return m.SetAddrHeader(HeaderBcc, b...)
@@ -387,7 +736,7 @@ func (m *Msg) AddBcc(t string) error {
 // AddBccFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // as additional Bcc address header field
 func (m *Msg) AddBccFormat(n, a string) error {
-	return m.addAddr(HeaderBcc, fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.addAddr(HeaderBcc, formatAddr(n, a))
 }
 
 // BccIgnoreInvalid takes and validates a given mail address list sets the Bcc: addresses of the Msg
@@ -396,20 +745,63 @@ func (m *Msg) BccIgnoreInvalid(b ...string) {
 	m.SetAddrHeaderIgnoreInvalid(HeaderBcc, b...)
 }
 
+// BccFromString takes and validates a given string of comma-separated mail addresses and sets
+// them as Bcc: addresses of the Msg (e.g. "a@example.com, Bob <b@example.com>")
+func (m *Msg) BccFromString(v string) error {
+	return m.setAddrHeaderFromString(HeaderBcc, v)
+}
+
+// DedupRecipients removes recipient addresses that appear multiple times across the To, Cc and
+// Bcc address header fields, so that recipients don't receive duplicate copies of the Msg.
+// Addresses are compared case-insensitively. The first occurrence is kept, with To taking
+// precedence over Cc and Cc taking precedence over Bcc
+func (m *Msg) DedupRecipients() {
+	seen := make(map[string]bool)
+	for _, h := range []AddrHeader{HeaderTo, HeaderCc, HeaderBcc} {
+		al, ok := m.addrHeader[h]
+		if !ok {
+			continue
+		}
+		ul := make([]*mail.Address, 0, len(al))
+		for _, a := range al {
+			k := strings.ToLower(a.Address)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			ul = append(ul, a)
+		}
+		m.addrHeader[h] = ul
+	}
+}
+
 // ReplyTo takes and validates a given mail address and sets it as "Reply-To" addrHeader of the Msg
 func (m *Msg) ReplyTo(r string) error {
-	rt, err := mail.ParseAddress(r)
-	if err != nil {
-		return fmt.Errorf("failed to parse reply-to address: %w", err)
-	}
-	m.SetGenHeader(HeaderReplyTo, rt.String())
-	return nil
+	return m.SetAddrHeader(HeaderReplyTo, r)
+}
+
+// ReplyToMultiple takes and validates a given mail address list and sets it as "Reply-To"
+// addrHeader of the Msg
+func (m *Msg) ReplyToMultiple(r ...string) error {
+	return m.SetAddrHeader(HeaderReplyTo, r...)
+}
+
+// AddReplyTo adds an additional address to the Reply-To address header field
+func (m *Msg) AddReplyTo(r string) error {
+	return m.addAddr(HeaderReplyTo, r)
 }
 
 // ReplyToFormat takes a name and address, formats them RFC5322 compliant and stores them as
 // the Reply-To header field
 func (m *Msg) ReplyToFormat(n, a string) error {
-	return m.ReplyTo(fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.ReplyTo(formatAddr(n, a))
+}
+
+// formatAddr returns the RFC 5322 representation of the given display name and address. The
+// display name is quoted and escaped or, if it contains non-ASCII characters, encoded as needed
+func formatAddr(n, a string) string {
+	ma := mail.Address{Name: n, Address: a}
+	return ma.String()
 }
 
 // addAddr adds an additional address to the given addrHeader of the Msg
@@ -427,17 +819,21 @@ func (m *Msg) Subject(s string) {
 	m.SetGenHeader(HeaderSubject, s)
 }
 
-// SetMessageID generates a random message id for the mail
+// SetMessageID generates a cryptographically random message id for the mail. The domain part
+// is the hostname of the system, unless overridden with WithMessageIDDomain
 func (m *Msg) SetMessageID() {
-	hn, err := os.Hostname()
-	if err != nil {
-		hn = "localhost.localdomain"
+	hn := m.midDomain
+	if hn == "" {
+		var err error
+		if hn, err = os.Hostname(); err != nil {
+			hn = "localhost.localdomain"
+		}
 	}
 	rn, _ := randNum(100000000)
 	rm, _ := randNum(10000)
 	rs, _ := randomStringSecure(17)
 	pid := os.Getpid() * rm
-	mid := fmt.Sprintf("%d.%d%d.%s@%s", pid, rn, rm, rs, hn)
+	mid := fmt.Sprintf("%d.%d.%d%d.%s@%s", m.now().Unix(), pid, rn, rm, rs, hn)
 	m.SetMessageIDWithValue(mid)
 }
 
@@ -446,6 +842,81 @@ func (m *Msg) SetMessageIDWithValue(v string) {
 	m.SetGenHeader(HeaderMessageID, fmt.Sprintf("<%s>", v))
 }
 
+// Reply returns a new Msg that is a reply to the Msg. See NewMsgFromReply for details
+func (m *Msg) Reply(o ...MsgOption) (*Msg, error) {
+	return NewMsgFromReply(m, o...)
+}
+
+// NewMsgFromReply returns a new Msg that is a reply to the given original Msg. The
+// recipient is set to the Reply-To address (or the From address if no Reply-To is set)
+// and the sender to the first To address of the original Msg. The subject is prefixed
+// with "Re: " if it is not already and the In-Reply-To and References headers are set
+// for threading as described in RFC 5322, section 3.6.4. The original Msg must have a
+// Message-ID set (which is the case after it has been sent)
+func NewMsgFromReply(orig *Msg, o ...MsgOption) (*Msg, error) {
+	mid := orig.GetGenHeader(HeaderMessageID)
+	if len(mid) == 0 || mid[0] == "" {
+		return nil, ErrNoMessageID
+	}
+	rm := NewMsg(o...)
+
+	ta := orig.GetFromString()
+	if rt := orig.GetAddrHeaderString(HeaderReplyTo); len(rt) > 0 {
+		ta = rt
+	}
+	if len(ta) > 0 {
+		if err := rm.To(ta...); err != nil {
+			return nil, err
+		}
+	}
+	if t := orig.GetToString(); len(t) > 0 {
+		if err := rm.From(t[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	s := orig.GetGenHeader(HeaderSubject)
+	if len(s) > 0 {
+		rs := s[0]
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(rs)), "re:") {
+			rs = "Re: " + rs
+		}
+		rm.Subject(rs)
+	}
+
+	rm.SetGenHeader(HeaderInReplyTo, mid[0])
+	rl := orig.GetGenHeader(HeaderReferences)
+	if len(rl) == 0 {
+		rl = orig.GetGenHeader(HeaderInReplyTo)
+	}
+	rm.SetGenHeader(HeaderReferences, strings.Join(append(append([]string{}, rl...), mid[0]), " "))
+	return rm, nil
+}
+
+// Forward returns a new Msg that forwards the Msg. See NewMsgFromForward for details
+func (m *Msg) Forward(o ...MsgOption) (*Msg, error) {
+	return NewMsgFromForward(m, o...)
+}
+
+// NewMsgFromForward returns a new Msg that forwards the given original Msg as message/rfc822
+// attachment. The subject is prefixed with "Fwd: " if it is not already. Sender and recipients
+// of the forward have to be set by the caller
+func NewMsgFromForward(orig *Msg, o ...MsgOption) (*Msg, error) {
+	fm := NewMsg(o...)
+	if s := orig.GetGenHeader(HeaderSubject); len(s) > 0 {
+		fs := s[0]
+		ls := strings.ToLower(strings.TrimSpace(fs))
+		if !strings.HasPrefix(ls, "fwd:") && !strings.HasPrefix(ls, "fw:") {
+			fs = "Fwd: " + fs
+		}
+		fm.Subject(fs)
+	}
+	if err := fm.AttachMsg(orig); err != nil {
+		return nil, err
+	}
+	return fm, nil
+}
+
 // SetBulk sets the "Precedence: bulk" genHeader which is recommended for
 // automated mails like OOO replies
 // See: https://www.rfc-editor.org/rfc/rfc2076#section-3.9
@@ -453,10 +924,57 @@ func (m *Msg) SetBulk() {
 	m.SetGenHeader(HeaderPrecedence, "bulk")
 }
 
+// SetListUnsubscribe sets the "List-Unsubscribe" genHeader with the given mailto: or http(s)
+// URLs as described in RFC 2369. For RFC 8058 one-click unsubscription, at least one https URL
+// is required and SetListUnsubscribePost should be called as well
+func (m *Msg) SetListUnsubscribe(ul ...string) error {
+	if len(ul) == 0 {
+		return fmt.Errorf("at least one List-Unsubscribe URL is required")
+	}
+	vl := make([]string, 0, len(ul))
+	for _, u := range ul {
+		u = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(u), "<"), ">")
+		pu, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("failed to parse List-Unsubscribe URL %q: %w", u, err)
+		}
+		switch strings.ToLower(pu.Scheme) {
+		case "mailto", "http", "https":
+		default:
+			return fmt.Errorf("invalid List-Unsubscribe URL %q: must be a mailto: or http(s) URL", u)
+		}
+		vl = append(vl, fmt.Sprintf("<%s>", u))
+	}
+	m.SetGenHeader(HeaderListUnsubscribe, vl...)
+	return nil
+}
+
+// SetListUnsubscribePost sets the "List-Unsubscribe-Post: List-Unsubscribe=One-Click" genHeader
+// that signals support for one-click unsubscription as described in RFC 8058
+func (m *Msg) SetListUnsubscribePost() {
+	m.SetGenHeader(HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
+}
+
 // SetDate sets the Date genHeader field to the current time in a valid format
 func (m *Msg) SetDate() {
-	ts := time.Now().Format(time.RFC1123Z)
-	m.SetGenHeader(HeaderDate, ts)
+	m.SetDateWithLocation(m.now(), m.location)
+}
+
+// SetDateWithLocation sets the Date genHeader field to the provided time in the given time
+// zone. If the time zone is nil, the time zone of the provided time is used
+func (m *Msg) SetDateWithLocation(t time.Time, l *time.Location) {
+	if l != nil {
+		t = t.In(l)
+	}
+	m.SetDateWithValue(t)
+}
+
+// now returns the current time of the clock of the Msg (see WithNowFunc)
+func (m *Msg) now() time.Time {
+	if m.nowFunc != nil {
+		return m.nowFunc()
+	}
+	return time.Now()
 }
 
 // SetDateWithValue sets the Date genHeader field to the provided time in a valid format
@@ -480,12 +998,93 @@ func (m *Msg) SetOrganization(o string) {
 	m.SetGenHeader(HeaderOrganization, o)
 }
 
+// SetContentLanguage validates the given BCP 47 language tags (e.g. "en", "de-CH") and sets
+// them as Content-Language header of the Msg as described in RFC 3282, which states the
+// language of the intended audience of the Msg
+func (m *Msg) SetContentLanguage(tl ...string) error {
+	if len(tl) == 0 {
+		return fmt.Errorf("%w: no language tag given", ErrInvalidLanguageTag)
+	}
+	for _, t := range tl {
+		if !validLanguageTag(t) {
+			return fmt.Errorf("%w: %q", ErrInvalidLanguageTag, t)
+		}
+	}
+	m.SetGenHeader(HeaderContentLang, strings.Join(tl, ", "))
+	return nil
+}
+
+// SetAutoSubmitted sets the Auto-Submitted header of the Msg as described in RFC 3834. Automatic
+// responders (e.g. vacation or out-of-office replies) must not respond to a Msg that is marked
+// as AutoGenerated or AutoReplied
+func (m *Msg) SetAutoSubmitted(a AutoSubmitted) {
+	m.SetGenHeader(HeaderAutoSubmitted, string(a))
+}
+
+// SetAutoResponseSuppress sets the Microsoft Exchange specific X-Auto-Response-Suppress header
+// of the Msg with the given AutoResponseSuppress values. Without values, all automatic
+// responses are suppressed (AutoResponseSuppressAll)
+func (m *Msg) SetAutoResponseSuppress(vl ...AutoResponseSuppress) {
+	if len(vl) == 0 {
+		vl = []AutoResponseSuppress{AutoResponseSuppressAll}
+	}
+	sl := make([]string, len(vl))
+	for i, v := range vl {
+		sl[i] = string(v)
+	}
+	m.SetGenHeader(HeaderXAutoResponseSuppress, strings.Join(sl, ", "))
+}
+
 // SetUserAgent sets the User-Agent/X-Mailer header for the Msg
 func (m *Msg) SetUserAgent(a string) {
 	m.SetGenHeader(HeaderUserAgent, a)
 	m.SetGenHeader(HeaderXMailer, a)
 }
 
+// SetTLSRequiredNo sets the "TLS-Required: No" header as described in RFC 8689, which requests
+// that the recipient TLS policies (e.g. MTA-STS or DANE) are ignored for the delivery of the
+// Msg. It is intended for messages that report TLS problems of the recipient domain, like
+// bounces or TLS reports, and the Client sends such messages without REQUIRETLS
+func (m *Msg) SetTLSRequiredNo() {
+	m.SetGenHeader(HeaderTLSRequired, "No")
+}
+
+// SetDSNMailReturnType requests DSNs for the Msg (if the server supports it) as described in
+// RFC 1891 and sets the MAIL FROM Return option to the given DSNMailReturnOption. It takes
+// precedence over the DSNMailReturnOption of the Client
+// See: https://www.rfc-editor.org/rfc/rfc1891
+func (m *Msg) SetDSNMailReturnType(mro DSNMailReturnOption) error {
+	switch mro {
+	case DSNMailReturnHeadersOnly:
+	case DSNMailReturnFull:
+	default:
+		return ErrInvalidDSNMailReturnOption
+	}
+	m.dsnmrtype = mro
+	return nil
+}
+
+// SetDSNRcptNotifyType requests DSNs for the given recipient of the Msg (if the server supports
+// it) as described in RFC 1891 and sets its RCPT TO notify options to the given list of
+// DSNRcptNotifyOption. It takes precedence over the DSNRcptNotifyOption of the Client. An empty
+// list requests no NOTIFY parameter for the recipient
+// See: https://www.rfc-editor.org/rfc/rfc1891
+func (m *Msg) SetDSNRcptNotifyType(r string, rno ...DSNRcptNotifyOption) error {
+	a, err := mail.ParseAddress(r)
+	if err != nil {
+		return fmt.Errorf(errParseMailAddr, r, err)
+	}
+	rnol, err := dsnRcptNotify(rno)
+	if err != nil {
+		return err
+	}
+	if m.dsnrntype == nil {
+		m.dsnrntype = make(map[string][]string)
+	}
+	m.dsnrntype[a.Address] = rnol
+	return nil
+}
+
 // RequestMDNTo adds the Disposition-Notification-To header to request a MDN from the receiving end
 // as described in RFC8098. It allows to provide a list recipient addresses.
 // Address validation is performed
@@ -508,7 +1107,7 @@ func (m *Msg) RequestMDNTo(t ...string) error {
 // accordingly. Address validation is performed
 // See: https://www.rfc-editor.org/rfc/rfc8098.html
 func (m *Msg) RequestMDNToFormat(n, a string) error {
-	return m.RequestMDNTo(fmt.Sprintf(`%s <%s>`, n, a))
+	return m.RequestMDNTo(formatAddr(n, a))
 }
 
 // RequestMDNAddTo adds an additional recipient to the recipient list of the MDN
@@ -526,7 +1125,7 @@ func (m *Msg) RequestMDNAddTo(t string) error {
 
 // RequestMDNAddToFormat adds an additional formated recipient to the recipient list of the MDN
 func (m *Msg) RequestMDNAddToFormat(n, a string) error {
-	return m.RequestMDNAddTo(fmt.Sprintf(`"%s" <%s>`, n, a))
+	return m.RequestMDNAddTo(formatAddr(n, a))
 }
 
 // GetSender returns the currently set envelope FROM address. If no envelope FROM is set it will use
@@ -558,15 +1157,26 @@ func (m *Msg) GetRecipients() ([]string, error) {
 			rl = append(rl, r.Address)
 		}
 	}
+	for _, t := range []AddrHeader{HeaderTo, HeaderCc} {
+		for _, g := range m.addrGroups[t] {
+			for _, r := range g.addrs {
+				rl = append(rl, r.Address)
+			}
+		}
+	}
 	if len(rl) <= 0 {
 		return rl, ErrNoRcptAddresses
 	}
 	return rl, nil
 }
 
-// GetAddrHeader returns the content of the requested address header of the Msg
+// GetAddrHeader returns the content of the requested address header of the Msg. The returned
+// slice is a copy, so that changes to it do not affect the Msg
 func (m *Msg) GetAddrHeader(h AddrHeader) []*mail.Address {
-	return m.addrHeader[h]
+	if m.addrHeader[h] == nil {
+		return nil
+	}
+	return append([]*mail.Address{}, m.addrHeader[h]...)
 }
 
 // GetAddrHeaderString returns the address string of the requested address header of the Msg
@@ -588,6 +1198,18 @@ func (m *Msg) GetFromString() []string {
 	return m.GetAddrHeaderString(HeaderFrom)
 }
 
+// GetEnvelopeFrom returns the content of the envelope FROM address header of the Msg. Other
+// than GetSender, it does not fall back to the From address header
+func (m *Msg) GetEnvelopeFrom() []*mail.Address {
+	return m.GetAddrHeader(HeaderEnvelopeFrom)
+}
+
+// GetEnvelopeFromString returns the content of the envelope FROM address header of the Msg
+// as string slice
+func (m *Msg) GetEnvelopeFromString() []string {
+	return m.GetAddrHeaderString(HeaderEnvelopeFrom)
+}
+
 // GetTo returns the content of the To address header of the Msg
 func (m *Msg) GetTo() []*mail.Address {
 	return m.GetAddrHeader(HeaderTo)
@@ -618,9 +1240,20 @@ func (m *Msg) GetBccString() []string {
 	return m.GetAddrHeaderString(HeaderBcc)
 }
 
-// GetGenHeader returns the content of the requested generic header of the Msg
+// GetGenHeader returns the content of the requested generic header of the Msg. The returned
+// slice is a copy, so that changes to it do not affect the Msg
 func (m *Msg) GetGenHeader(h Header) []string {
-	return m.genHeader[h]
+	if m.genHeader[h] == nil {
+		return nil
+	}
+	return append([]string{}, m.genHeader[h]...)
+}
+
+// GetGenHeaderPreformatted returns the content of the requested preformatted generic header
+// of the Msg (see SetGenHeaderPreformatted) and true, if the header is set
+func (m *Msg) GetGenHeaderPreformatted(h Header) (string, bool) {
+	v, ok := m.preformHeader[h]
+	return v, ok
 }
 
 // GetParts returns the message parts of the Msg
@@ -633,9 +1266,22 @@ func (m *Msg) GetAttachments() []*File {
 	return m.attachments
 }
 
+// SetAttachments sets the attachments of the message. This replaces all existing attachments
+// and allows middlewares to strip or swap attached files before the message is written
+func (m *Msg) SetAttachments(ff []*File) {
+	m.attachments = ff
+}
+
 // SetAttachements sets the attachements of the message.
+//
+// Deprecated: use SetAttachments instead
 func (m *Msg) SetAttachements(ff []*File) {
-	m.attachments = ff
+	m.SetAttachments(ff)
+}
+
+// UnsetAllAttachments removes all attachments from the message
+func (m *Msg) UnsetAllAttachments() {
+	m.attachments = nil
 }
 
 // GetEmbeds returns the embeds of the Msg
@@ -643,11 +1289,16 @@ func (m *Msg) GetEmbeds() []*File {
 	return m.embeds
 }
 
-// SetEmbeds sets the attachements of the message.
+// SetEmbeds sets the embedded files of the message. This replaces all existing embeds
 func (m *Msg) SetEmbeds(ff []*File) {
 	m.embeds = ff
 }
 
+// UnsetAllEmbeds removes all embedded files from the message
+func (m *Msg) UnsetAllEmbeds() {
+	m.embeds = nil
+}
+
 // SetBodyString sets the body of the message.
 func (m *Msg) SetBodyString(ct ContentType, b string, o ...PartOption) {
 	buf := bytes.NewBufferString(b)
@@ -662,6 +1313,15 @@ func (m *Msg) SetBodyWriter(ct ContentType, w func(io.Writer) (int64, error), o
 	m.parts = []*Part{p}
 }
 
+// SetBodyHTMLStringWithAutoText sets the given HTML as body of the message and adds a
+// text/plain alternative that is derived from the HTML, since HTML-only messages are penalized
+// by many spam filters. In the text version, links are rendered as numbered footnotes and
+// lists and headings are preserved in a readable form. The PartOption are applied to both parts
+func (m *Msg) SetBodyHTMLStringWithAutoText(h string, o ...PartOption) {
+	m.SetBodyString(TypeTextPlain, htmlToText(h), o...)
+	m.AddAlternativeString(TypeTextHTML, h, o...)
+}
+
 // SetBodyHTMLTemplate sets the body of the message from a given html/template.Template pointer
 // The content type will be set to text/html automatically
 func (m *Msg) SetBodyHTMLTemplate(t *ht.Template, d interface{}, o ...PartOption) error {
@@ -677,6 +1337,55 @@ func (m *Msg) SetBodyHTMLTemplate(t *ht.Template, d interface{}, o ...PartOption
 	return nil
 }
 
+// SetBodyHTMLWithEmbeds sets the given HTML as body of the message and embeds all images that
+// are referenced by relative paths in the src attribute of <img> tags. The images are looked
+// up in the given base directory, attached as related parts with a generated Content-ID and
+// the src attributes are rewritten to the corresponding cid: URLs. Images with absolute URLs
+// (like http: or data: URLs) are left untouched
+func (m *Msg) SetBodyHTMLWithEmbeds(h, bd string, o ...PartOption) error {
+	fsys := os.DirFS(bd)
+	cl := make(map[string]string)
+	var el []*File
+	var err error
+	rh := imgSrcRegexp.ReplaceAllStringFunc(h, func(t string) string {
+		sm := imgSrcRegexp.FindStringSubmatch(t)
+		src := sm[2] + sm[3]
+		if err != nil || src == "" {
+			return t
+		}
+		u, perr := url.Parse(src)
+		if perr != nil || u.Scheme != "" || u.Host != "" {
+			return t
+		}
+		fp := path.Clean(strings.TrimPrefix(u.Path, "/"))
+		if !fs.ValidPath(fp) {
+			err = fmt.Errorf("failed to embed image %q: path is outside of the base directory", src)
+			return t
+		}
+		cid, ok := cl[fp]
+		if !ok {
+			f, ferr := fileFromIOFS(fp, fsys)
+			if ferr != nil {
+				err = fmt.Errorf("failed to embed image %q: %w", src, ferr)
+				return t
+			}
+			rs, _ := randomStringSecure(16)
+			cid = fmt.Sprintf("%d.%s@go-mail", len(cl)+1, rs)
+			f.setHeader(HeaderContentID, fmt.Sprintf("<%s>", cid))
+			el = append(el, f)
+			cl[fp] = cid
+		}
+		q := t[len(t)-1:]
+		return fmt.Sprintf("%s%scid:%s%s", sm[1], q, cid, q)
+	})
+	if err != nil {
+		return err
+	}
+	m.embeds = append(m.embeds, el...)
+	m.SetBodyString(TypeTextHTML, rh, o...)
+	return nil
+}
+
 // SetBodyTextTemplate sets the body of the message from a given text/template.Template pointer
 // The content type will be set to text/plain automatically
 func (m *Msg) SetBodyTextTemplate(t *tt.Template, d interface{}, o ...PartOption) error {
@@ -736,13 +1445,134 @@ func (m *Msg) AddAlternativeTextTemplate(t *tt.Template, d interface{}, o ...Par
 	return nil
 }
 
-// AttachFile adds an attachment File to the Msg
-func (m *Msg) AttachFile(n string, o ...FileOption) {
-	f := fileFromFS(n)
-	if f == nil {
-		return
+// SetBodyHTMLTemplateSet sets the body of the message to the output of the HTML template with
+// the given name of the given TemplateSet. The content type will be set to text/html
+func (m *Msg) SetBodyHTMLTemplateSet(ts *TemplateSet, n string, d interface{}, o ...PartOption) error {
+	if ts == nil {
+		return fmt.Errorf(errTplPointerNil)
+	}
+	t, err := ts.HTML(n)
+	if err != nil {
+		return err
+	}
+	return m.SetBodyHTMLTemplate(t, d, o...)
+}
+
+// SetBodyTextTemplateSet sets the body of the message to the output of the text template with
+// the given name of the given TemplateSet. The content type will be set to text/plain
+func (m *Msg) SetBodyTextTemplateSet(ts *TemplateSet, n string, d interface{}, o ...PartOption) error {
+	if ts == nil {
+		return fmt.Errorf(errTplPointerNil)
+	}
+	t, err := ts.Text(n)
+	if err != nil {
+		return err
+	}
+	return m.SetBodyTextTemplate(t, d, o...)
+}
+
+// AddAlternativeHTMLTemplateSet sets the alternative body of the message to the output of the
+// HTML template with the given name of the given TemplateSet
+func (m *Msg) AddAlternativeHTMLTemplateSet(ts *TemplateSet, n string, d interface{}, o ...PartOption) error {
+	if ts == nil {
+		return fmt.Errorf(errTplPointerNil)
+	}
+	t, err := ts.HTML(n)
+	if err != nil {
+		return err
+	}
+	return m.AddAlternativeHTMLTemplate(t, d, o...)
+}
+
+// AddAlternativeTextTemplateSet sets the alternative body of the message to the output of the
+// text template with the given name of the given TemplateSet
+func (m *Msg) AddAlternativeTextTemplateSet(ts *TemplateSet, n string, d interface{}, o ...PartOption) error {
+	if ts == nil {
+		return fmt.Errorf(errTplPointerNil)
+	}
+	t, err := ts.Text(n)
+	if err != nil {
+		return err
+	}
+	return m.AddAlternativeTextTemplate(t, d, o...)
+}
+
+// AttachFile adds an attachment File to the Msg. It returns an error if the file does not
+// exist, is not a regular file or cannot be opened. The file is only read when the Msg is
+// written
+func (m *Msg) AttachFile(n string, o ...FileOption) error {
+	f, err := fileFromFS(n)
+	if err != nil {
+		return fmt.Errorf("failed to attach file: %w", err)
 	}
 	m.attachments = m.appendFile(m.attachments, f, o...)
+	return nil
+}
+
+// AttachGlob adds all files of the system's file system that match the given pattern (see
+// filepath.Match) as attachment File to the Msg. Directories are skipped. Use WithFileFilter
+// to skip additional files
+func (m *Msg) AttachGlob(p string, o ...FileOption) error {
+	fl, err := filepath.Glob(p)
+	if err != nil {
+		return fmt.Errorf("failed to match files: %w", err)
+	}
+	for _, n := range fl {
+		fi, err := os.Stat(n)
+		if err != nil {
+			return fmt.Errorf("failed to attach %q: %w", n, err)
+		}
+		if fi.IsDir() {
+			continue
+		}
+		if err := m.attachFiltered(n, o...); err != nil {
+			return fmt.Errorf("failed to attach %q: %w", n, err)
+		}
+	}
+	return nil
+}
+
+// AttachDir adds all files of the given directory of the system's file system as attachment
+// File to the Msg. If r is true, the files of all subdirectories are added as well. Use
+// WithFileFilter to skip files
+func (m *Msg) AttachDir(d string, r bool, o ...FileOption) error {
+	return filepath.WalkDir(d, func(n string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+		if de.IsDir() {
+			if !r && n != d {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !de.Type().IsRegular() {
+			return nil
+		}
+		if err := m.attachFiltered(n, o...); err != nil {
+			return fmt.Errorf("failed to attach %q: %w", n, err)
+		}
+		return nil
+	})
+}
+
+// attachFiltered adds the given file as attachment File to the Msg, unless the filter of
+// the FileOption functions rejects it
+func (m *Msg) attachFiltered(n string, o ...FileOption) error {
+	f, err := fileFromFS(n)
+	if err != nil {
+		return err
+	}
+	for _, co := range o {
+		if co != nil {
+			co(f)
+		}
+	}
+	if f.filter != nil && !f.filter(f) {
+		return nil
+	}
+	m.attachments = m.appendFile(m.attachments, f)
+	return nil
 }
 
 // AttachReader adds an attachment File via io.Reader to the Msg
@@ -795,13 +1625,39 @@ func (m *Msg) AttachFromEmbedFS(n string, f *embed.FS, o ...FileOption) error {
 	return nil
 }
 
-// EmbedFile adds an embedded File to the Msg
-func (m *Msg) EmbedFile(n string, o ...FileOption) {
-	f := fileFromFS(n)
-	if f == nil {
-		return
+// AttachMsg adds the given Msg as message/rfc822 attachment to the Msg. The attached Msg
+// is rendered at the time of the call
+func (m *Msg) AttachMsg(om *Msg, o ...FileOption) error {
+	f, err := fileFromMsg(om)
+	if err != nil {
+		return fmt.Errorf("failed to attach message: %w", err)
+	}
+	m.attachments = m.appendFile(m.attachments, f, o...)
+	return nil
+}
+
+// AttachFromIOFS adds an attachment File from an io/fs.FS to the Msg
+func (m *Msg) AttachFromIOFS(n string, fsys fs.FS, o ...FileOption) error {
+	if fsys == nil {
+		return fmt.Errorf("fs.FS must not be nil")
+	}
+	f, err := fileFromIOFS(n, fsys)
+	if err != nil {
+		return err
+	}
+	m.attachments = m.appendFile(m.attachments, f, o...)
+	return nil
+}
+
+// EmbedFile adds an embedded File to the Msg. It returns an error if the file does not
+// exist or is not a regular file. The file is only read when the Msg is written
+func (m *Msg) EmbedFile(n string, o ...FileOption) error {
+	f, err := fileFromFS(n)
+	if err != nil {
+		return fmt.Errorf("failed to embed file: %w", err)
 	}
 	m.embeds = m.appendFile(m.embeds, f, o...)
+	return nil
 }
 
 // EmbedReader adds an embedded File from an io.Reader to the Msg
@@ -854,14 +1710,113 @@ func (m *Msg) EmbedFromEmbedFS(n string, f *embed.FS, o ...FileOption) error {
 	return nil
 }
 
-// Reset resets all headers, body parts and attachments/embeds of the Msg
-// It leaves already set encodings, charsets, boundaries, etc. as is
+// EmbedFromIOFS adds an embedded File from an io/fs.FS to the Msg
+func (m *Msg) EmbedFromIOFS(n string, fsys fs.FS, o ...FileOption) error {
+	if fsys == nil {
+		return fmt.Errorf("fs.FS must not be nil")
+	}
+	f, err := fileFromIOFS(n, fsys)
+	if err != nil {
+		return err
+	}
+	m.embeds = m.appendFile(m.embeds, f, o...)
+	return nil
+}
+
+// Reset resets all headers, body parts, attachments/embeds, the DSN notify options of the
+// recipients and the send error of the Msg, so that it can be reused, e.g. in a mail-merge
+// loop. It leaves already set encodings, charsets, boundaries, etc. as is
 func (m *Msg) Reset() {
 	m.addrHeader = make(map[AddrHeader][]*mail.Address)
+	m.addrGroups = nil
 	m.attachments = nil
 	m.embeds = nil
+	m.relatedRoot = ""
+	m.digest = nil
+	m.dsnrntype = nil
 	m.genHeader = make(map[Header][]string)
+	m.preformHeader = make(map[Header]string)
+	m.headerErr = nil
 	m.parts = nil
+	m.raw = nil
+	m.reader = nil
+	m.reportType = ""
+	m.sendError = nil
+}
+
+// Clone returns a deep copy of the Msg. Headers, body parts, attachments and embeds are
+// copied, so that the clone can be changed and sent independently of the original, e.g. to
+// personalize a base message per recipient in concurrent senders. The content of body parts
+// and files is shared, except for files added with AttachReadSeeker or EmbedReadSeeker,
+// which cannot be rendered concurrently. Middlewares and signers are shared as well
+func (m *Msg) Clone() *Msg {
+	c := *m
+	c.addrHeader = make(map[AddrHeader][]*mail.Address, len(m.addrHeader))
+	for h, al := range m.addrHeader {
+		cl := make([]*mail.Address, len(al))
+		for i, a := range al {
+			ca := *a
+			cl[i] = &ca
+		}
+		c.addrHeader[h] = cl
+	}
+	if m.addrGroups != nil {
+		c.addrGroups = make(map[AddrHeader][]addrGroup, len(m.addrGroups))
+		for h, gl := range m.addrGroups {
+			c.addrGroups[h] = cloneAddrGroups(gl)
+		}
+	}
+	c.genHeader = make(map[Header][]string, len(m.genHeader))
+	for h, vl := range m.genHeader {
+		c.genHeader[h] = append([]string{}, vl...)
+	}
+	c.preformHeader = make(map[Header]string, len(m.preformHeader))
+	for h, v := range m.preformHeader {
+		c.preformHeader[h] = v
+	}
+	if m.parts != nil {
+		c.parts = make([]*Part, len(m.parts))
+		for i, p := range m.parts {
+			cp := *p
+			c.parts[i] = &cp
+		}
+	}
+	if m.dsnrntype != nil {
+		c.dsnrntype = make(map[string][]string, len(m.dsnrntype))
+		for r, rnol := range m.dsnrntype {
+			c.dsnrntype[r] = append([]string{}, rnol...)
+		}
+	}
+	c.attachments = cloneFiles(m.attachments)
+	c.embeds = cloneFiles(m.embeds)
+	c.digest = cloneFiles(m.digest)
+	if m.middlewares != nil {
+		c.middlewares = append([]Middleware{}, m.middlewares...)
+	}
+	if m.dkimSigners != nil {
+		c.dkimSigners = append([]*DKIMSigner{}, m.dkimSigners...)
+	}
+	if m.smimeRecipients != nil {
+		c.smimeRecipients = append([]*x509.Certificate{}, m.smimeRecipients...)
+	}
+	c.reader = nil
+	c.sendError = nil
+	c.rcptOffset = 0
+	c.serverResponses = nil
+	c.suppressed = nil
+	return &c
+}
+
+// RemoveHeader removes the given generic header field, including a preformatted one, from
+// the Msg
+func (m *Msg) RemoveHeader(h Header) {
+	delete(m.genHeader, h)
+	delete(m.preformHeader, h)
+}
+
+// RemoveAddrHeader removes the given address header field from the Msg
+func (m *Msg) RemoveAddrHeader(h AddrHeader) {
+	delete(m.addrHeader, h)
 }
 
 // ApplyMiddlewares apply the list of middlewares to a Msg
@@ -874,8 +1829,34 @@ func (m *Msg) applyMiddlewares(ms *Msg) *Msg {
 
 // WriteTo writes the formated Msg into a give io.Writer and satisfies the io.WriteTo interface
 func (m *Msg) WriteTo(w io.Writer) (int64, error) {
-	mw := &msgWriter{w: w, c: m.charset, en: m.encoder}
-	mw.writeMsg(m.applyMiddlewares(m))
+	if err := m.checkSize(); err != nil {
+		return 0, err
+	}
+	return m.writeMsg(w, m.applyMiddlewares(m), false)
+}
+
+// Size returns the size in bytes of the Msg as it would be written by WriteTo, including the
+// headers and the expansion by the transfer encoding. This allows to enforce size limits of
+// mail providers before attempting to send the Msg. The size is computed from the headers and
+// the length of the content, without encoding it. The content of files is only read if its
+// length is not known in advance. A Msg with middlewares, S/MIME, PGP/MIME or DKIM is rendered
+// into a byte counter instead. Since the size is computed on a Clone of the Msg, default
+// headers like Date or Message-ID are not set on the Msg itself
+func (m *Msg) Size() (int64, error) {
+	if m.raw != nil {
+		return int64(len(m.raw)), nil
+	}
+	c := m.Clone()
+	if len(c.middlewares) > 0 || c.hasPostProcessing() {
+		return c.writeMsg(io.Discard, c.applyMiddlewares(c), false)
+	}
+	if c.headerErr != nil {
+		return 0, c.headerErr
+	}
+	mw := getMsgWriter(io.Discard, c.charset, c.encoder, false)
+	defer putMsgWriter(mw)
+	mw.size = true
+	mw.writeMsg(c)
 	return mw.n, mw.err
 }
 
@@ -891,10 +1872,82 @@ func (m *Msg) WriteToSkipMiddleware(w io.Writer, mt MiddlewareType) (int64, erro
 		mwl = append(mwl, m.middlewares[i])
 	}
 	m.middlewares = mwl
-	mw := &msgWriter{w: w, c: m.charset, en: m.encoder}
-	mw.writeMsg(m.applyMiddlewares(m))
-	m.middlewares = omwl
-	return mw.n, mw.err
+	defer func() { m.middlewares = omwl }()
+	if err := m.checkSize(); err != nil {
+		return 0, err
+	}
+	return m.writeMsg(w, m.applyMiddlewares(m), false)
+}
+
+// writeMsg renders the given Msg into the io.Writer. If S/MIME, PGP/MIME or DKIM is used,
+// the Msg is rendered into a buffer first, so that the signatures and encryption can be
+// applied and the DKIM-Signature headers can be prepended. If bcc is true, the Bcc header
+// is rendered as well
+func (m *Msg) writeMsg(w io.Writer, ms *Msg, bcc bool) (int64, error) {
+	if m.raw != nil {
+		n, err := w.Write(m.raw)
+		return int64(n), err
+	}
+	if ms.headerErr != nil {
+		return 0, ms.headerErr
+	}
+	if !m.hasPostProcessing() {
+		mw := getMsgWriter(w, m.charset, m.encoder, bcc)
+		defer putMsgWriter(mw)
+		mw.writeMsg(ms)
+		return mw.n, mw.err
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	mw := getMsgWriter(buf, m.charset, m.encoder, bcc)
+	defer putMsgWriter(mw)
+	mw.writeMsg(ms)
+	if mw.err != nil {
+		return 0, mw.err
+	}
+	b := buf.Bytes()
+	var err error
+	if m.smimeSigner != nil {
+		if b, err = m.smimeSigner.signMsg(b); err != nil {
+			return 0, fmt.Errorf("failed to S/MIME sign message: %w", err)
+		}
+	}
+	if len(m.smimeRecipients) > 0 {
+		if b, err = encryptSMIME(b, m.smimeRecipients); err != nil {
+			return 0, fmt.Errorf("failed to S/MIME encrypt message: %w", err)
+		}
+	}
+	if m.pgpSigner != nil {
+		if b, err = signPGP(b, m.pgpSigner); err != nil {
+			return 0, fmt.Errorf("failed to PGP sign message: %w", err)
+		}
+	}
+	if m.pgpEncrypter != nil {
+		if b, err = encryptPGP(b, m.pgpEncrypter); err != nil {
+			return 0, fmt.Errorf("failed to PGP encrypt message: %w", err)
+		}
+	}
+	var sh bytes.Buffer
+	for _, ds := range m.dkimSigners {
+		s, err := ds.Sign(b)
+		if err != nil {
+			return 0, fmt.Errorf("failed to DKIM sign message for domain %q: %w", ds.domain, err)
+		}
+		sh.WriteString(s)
+	}
+	sn, err := io.WriteString(w, sh.String())
+	if err != nil {
+		return int64(sn), err
+	}
+	bn, err := w.Write(b)
+	return int64(sn) + int64(bn), err
+}
+
+// hasPostProcessing returns true if the rendered Msg needs to be signed or encrypted
+func (m *Msg) hasPostProcessing() bool {
+	return len(m.dkimSigners) > 0 || m.smimeSigner != nil || len(m.smimeRecipients) > 0 ||
+		m.pgpSigner != nil || m.pgpEncrypter != nil
 }
 
 // Write is an alias method to WriteTo due to compatibility reasons
@@ -920,18 +1973,25 @@ func (m *Msg) appendFile(c []*File, f *File, o ...FileOption) []*File {
 }
 
 // WriteToFile stores the Msg as file on disk. It will try to create the given filename
-// Already existing files will be overwritten
+// Already existing files will be truncated and overwritten. The file content is synced to
+// the storage before the file is closed
 func (m *Msg) WriteToFile(n string) error {
 	f, err := os.Create(n)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return fmt.Errorf("failed to create output file %q: %w", n, err)
 	}
 	defer func() { _ = f.Close() }()
 	_, err = m.WriteTo(f)
 	if err != nil {
-		return fmt.Errorf("failed to write to output file: %w", err)
+		return fmt.Errorf("failed to write to output file %q: %w", n, err)
 	}
-	return f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync output file %q: %w", n, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close output file %q: %w", n, err)
+	}
+	return nil
 }
 
 // WriteToSendmail returns WriteToSendmailWithCommand with a default sendmail path
@@ -950,7 +2010,14 @@ func (m *Msg) WriteToSendmailWithCommand(sp string) error {
 // WriteToSendmailWithContext opens an pipe to the local sendmail binary and tries to send the
 // mail though that. It takes a context.Context, the path to the sendmail binary and additional
 // arguments for the sendmail binary as parameters
+//
+// Since sendmail is called with the -t flag, which reads the recipients from the message
+// headers, the Bcc header is included in the piped message. sendmail removes it before
+// the message is delivered
 func (m *Msg) WriteToSendmailWithContext(ctx context.Context, sp string, a ...string) error {
+	if err := m.checkSize(); err != nil {
+		return err
+	}
 	ec := exec.CommandContext(ctx, sp)
 	ec.Args = append(ec.Args, "-oi", "-t")
 	ec.Args = append(ec.Args, a...)
@@ -969,7 +2036,7 @@ func (m *Msg) WriteToSendmailWithContext(ctx context.Context, sp string, a ...st
 	if err := ec.Start(); err != nil {
 		return fmt.Errorf("could not start sendmail execution: %w", err)
 	}
-	_, err = m.WriteTo(si)
+	_, err = m.writeMsg(si, m.applyMiddlewares(m), true)
 	if err != nil {
 		if !errors.Is(err, syscall.EPIPE) {
 			return fmt.Errorf("failed to write mail to buffer: %w", err)
@@ -1014,6 +2081,21 @@ func (m *Msg) NewReader() *Reader {
 	return r
 }
 
+// Read renders the Msg and reads the next len(p) bytes of the rendered output into p, so that
+// the Msg satisfies the io.Reader interface. The Msg is rendered on the first call to Read and
+// the rendered output is read until io.EOF is returned. A subsequent call to Read renders the
+// Msg again. Changes to the Msg while it is being read are not reflected in the output
+func (m *Msg) Read(p []byte) (int, error) {
+	if m.reader == nil {
+		m.reader = m.NewReader()
+	}
+	n, err := m.reader.Read(p)
+	if err != nil {
+		m.reader = nil
+	}
+	return n, err
+}
+
 // UpdateReader will update a Reader with the content of the given Msg and reset the
 // Reader position to the start
 func (m *Msg) UpdateReader(r *Reader) {
@@ -1040,6 +2122,23 @@ func (m *Msg) SendErrorIsTemp() bool {
 	return false
 }
 
+// ServerResponse returns the reply text of the SMTP server to the mail data of the last
+// delivery of the Msg (e.g. "2.0.0 Ok: queued as 4Q0Xyz1Lz0z3J"), which typically contains the
+// queue ID of the server. It allows to correlate sent messages with the logs of the MTA and
+// with bounce reports. If the Msg was delivered in multiple SMTP transactions (e.g. due to
+// WithMaxRcptsPerTransaction or to multiple MX), the replies are separated by newlines. It
+// is empty if the Msg was not delivered via SMTP
+func (m *Msg) ServerResponse() string {
+	return strings.Join(m.serverResponses, "\n")
+}
+
+// SuppressedRecipients returns the recipient addresses that were not mailed during the last
+// delivery, because they are suppressed by the SuppressionChecker of the Client (see
+// WithSuppressionChecker)
+func (m *Msg) SuppressedRecipients() []string {
+	return m.suppressed
+}
+
 // SendError returns the sendError field of the Msg
 func (m *Msg) SendError() error {
 	return m.sendError
@@ -1051,6 +2150,54 @@ func (m *Msg) encodeString(s string) string {
 	return m.encoder.Encode(string(m.charset), s)
 }
 
+// downgrade8Bit converts the 8bit NoEncoding of the Msg and its parts into quoted-printable
+// and the 8bit NoEncoding of the attachments/embeds into Base64, so that the Msg can be
+// delivered to servers that do not support 8BITMIME
+func (m *Msg) downgrade8Bit() {
+	if m.encoding == NoEncoding {
+		m.SetEncoding(EncodingQP)
+	}
+	for _, p := range m.parts {
+		if p.enc == NoEncoding {
+			p.enc = EncodingQP
+		}
+	}
+	for _, fl := range [][]*File{m.attachments, m.embeds} {
+		for _, f := range fl {
+			// A message/rfc822 entity must not be transfer-encoded (RFC 2046, Section 5.2.1)
+			if f.Enc == NoEncoding && f.ContentType != TypeMessageRFC822 {
+				f.Enc = EncodingB64
+				if f.Header != nil {
+					f.Header.Del(string(HeaderContentTransferEnc))
+				}
+			}
+		}
+	}
+}
+
+// addrHeaderToASCII converts the internationalized domains of the addresses in the address
+// header fields of the Msg into their A-label form, so that the Msg can be delivered to
+// servers that do not support SMTPUTF8
+func (m *Msg) addrHeaderToASCII() error {
+	var al []*mail.Address
+	for _, hl := range m.addrHeader {
+		al = append(al, hl...)
+	}
+	for _, gl := range m.addrGroups {
+		for _, g := range gl {
+			al = append(al, g.addrs...)
+		}
+	}
+	for _, a := range al {
+		aa, err := addrToASCII(a.Address)
+		if err != nil {
+			return fmt.Errorf("failed to convert address %q: %w", a.Address, err)
+		}
+		a.Address = aa
+	}
+	return nil
+}
+
 // hasAlt returns true if the Msg has more than one part
 func (m *Msg) hasAlt() bool {
 	c := 0
@@ -1062,9 +2209,11 @@ func (m *Msg) hasAlt() bool {
 	return c > 1 && m.pgptype == 0
 }
 
-// hasMixed returns true if the Msg has mixed parts
+// hasMixed returns true if the Msg has mixed parts. A multipart/report Msg is rendered in
+// place of the mixed parts
 func (m *Msg) hasMixed() bool {
-	return m.pgptype == 0 && ((len(m.parts) > 0 && len(m.attachments) > 0) || len(m.attachments) > 1)
+	return m.pgptype == 0 && (m.reportType != "" || (len(m.parts) > 0 && len(m.attachments) > 0) ||
+		len(m.attachments) > 1 || (len(m.digest) > 0 && (len(m.parts) > 0 || len(m.attachments) > 0)))
 }
 
 // hasRelated returns true if the Msg has related parts
@@ -1072,6 +2221,52 @@ func (m *Msg) hasRelated() bool {
 	return m.pgptype == 0 && ((len(m.parts) > 0 && len(m.embeds) > 0) || len(m.embeds) > 1)
 }
 
+// SetRelatedRoot sets the embedded File with the given Content-ID as root of the
+// multipart/related container of the Msg, which is referenced via the "start" parameter. By
+// default, the body parts of the Msg are the root
+func (m *Msg) SetRelatedRoot(cid string) {
+	m.relatedRoot = strings.TrimSuffix(strings.TrimPrefix(cid, "<"), ">")
+}
+
+// relatedType returns the multipart/related MIMEType with the "type" parameter, which holds
+// the content type of the root part, and the "start" parameter if the root was set via
+// SetRelatedRoot (RFC 2387)
+func (m *Msg) relatedType() (MIMEType, error) {
+	var rt, st string
+	switch {
+	case m.relatedRoot != "":
+		for _, f := range m.embeds {
+			if f.contentID() == m.relatedRoot {
+				rt = f.mediaType()
+				if v, ok := f.getHeader(HeaderContentType); ok {
+					rt = v
+				}
+				st = fmt.Sprintf("; start=\"<%s>\"", m.relatedRoot)
+				break
+			}
+		}
+		if rt == "" {
+			return "", fmt.Errorf("multipart/related root %q not found in embeds", m.relatedRoot)
+		}
+	case m.hasAlt():
+		rt = "multipart/" + string(MIMEAlternative)
+	default:
+		for _, p := range m.parts {
+			if !p.del {
+				rt = string(p.ctype)
+				break
+			}
+		}
+		if rt == "" && len(m.embeds) > 0 {
+			rt = m.embeds[0].mediaType()
+		}
+	}
+	if mt, _, err := mime.ParseMediaType(rt); err == nil {
+		rt = mt
+	}
+	return MIMEType(fmt.Sprintf("%s; type=\"%s\"%s", MIMERelated, rt, st)), nil
+}
+
 // hasPGPType returns true if the Msg should be treated as PGP encoded message
 func (m *Msg) hasPGPType() bool {
 	return m.pgptype > 0
@@ -1103,18 +2298,29 @@ func (m *Msg) setEncoder() {
 // checkUserAgent checks if a useragent/x-mailer is set and if not will set a default
 // version string
 func (m *Msg) checkUserAgent() {
+	if m.noDefaultUserAgent {
+		return
+	}
 	_, uaok := m.genHeader[HeaderUserAgent]
 	_, xmok := m.genHeader[HeaderXMailer]
-	if !uaok && !xmok {
-		m.SetUserAgent(fmt.Sprintf("go-mail v%s // https://github.com/wneessen/go-mail",
-			VERSION))
+	if uaok || xmok {
+		return
+	}
+	if m.userAgent != "" {
+		m.SetUserAgent(m.userAgent)
+		return
 	}
+	m.SetUserAgent(fmt.Sprintf("go-mail v%s // https://github.com/wneessen/go-mail", VERSION))
 }
 
 // addDefaultHeader sets some default headers, if they haven't been set before
 func (m *Msg) addDefaultHeader() {
 	if _, ok := m.genHeader[HeaderDate]; !ok {
-		m.SetDate()
+		if m.fixedDate.IsZero() {
+			m.SetDate()
+		} else {
+			m.SetDateWithLocation(m.fixedDate, m.location)
+		}
 	}
 	if _, ok := m.genHeader[HeaderMessageID]; !ok {
 		m.SetMessageID()
@@ -1122,17 +2328,88 @@ func (m *Msg) addDefaultHeader() {
 	m.SetGenHeader(HeaderMIMEVersion, string(m.mimever))
 }
 
+// newRawMsg returns a Msg that writes the given rendered message instead of rendering itself
+// and that is delivered to the given envelope sender and recipients
+func newRawMsg(raw []byte, from string, rcpts []string) (*Msg, error) {
+	m := NewMsg()
+	m.raw = raw
+	if err := m.EnvelopeFrom(from); err != nil {
+		return nil, err
+	}
+	if err := m.Bcc(rcpts...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// tlsRequiredNo returns true if the Msg has the "TLS-Required: No" header. For raw messages,
+// the header of the raw message is checked
+func (m *Msg) tlsRequiredNo() bool {
+	vl := m.genHeader[HeaderTLSRequired]
+	if m.raw != nil {
+		h, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(m.raw))).ReadMIMEHeader()
+		if err != nil && len(h) == 0 {
+			return false
+		}
+		vl = h.Values(string(HeaderTLSRequired))
+	}
+	for _, v := range vl {
+		if strings.EqualFold(strings.TrimSpace(v), "No") {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFiles returns a copy of the given list of File with copies of their headers
+func cloneFiles(fl []*File) []*File {
+	if fl == nil {
+		return nil
+	}
+	cl := make([]*File, len(fl))
+	for i, f := range fl {
+		cf := *f
+		if f.Header != nil {
+			cf.Header = make(map[string][]string, len(f.Header))
+			for h, vl := range f.Header {
+				cf.Header[h] = append([]string{}, vl...)
+			}
+		}
+		cl[i] = &cf
+	}
+	return cl
+}
+
 // fileFromEmbedFS returns a File pointer from a given file in the provided embed.FS
 func fileFromEmbedFS(n string, f *embed.FS) (*File, error) {
-	_, err := f.Open(n)
+	ef, err := fileFromIOFS(n, f)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file from embed.FS: %w", err)
 	}
+	return ef, nil
+}
+
+// fileFromIOFS returns a File pointer from a given file in the provided io/fs.FS
+func fileFromIOFS(n string, fsys fs.FS) (*File, error) {
+	h, err := fsys.Open(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file from fs.FS: %w", err)
+	}
+	if err := h.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close file from fs.FS: %w", err)
+	}
 	return &File{
 		Name:   filepath.Base(n),
 		Header: make(map[string][]string),
+		size: func() (int64, error) {
+			fi, err := fs.Stat(fsys, n)
+			if err != nil {
+				return 0, err
+			}
+			return fi.Size(), nil
+		},
 		Writer: func(w io.Writer) (int64, error) {
-			h, err := f.Open(n)
+			h, err := fsys.Open(n)
 			if err != nil {
 				return 0, err
 			}
@@ -1147,15 +2424,37 @@ func fileFromEmbedFS(n string, f *embed.FS) (*File, error) {
 }
 
 // fileFromFS returns a File pointer from a given file in the system's file system
-func fileFromFS(n string) *File {
-	_, err := os.Stat(n)
+func fileFromFS(n string) (*File, error) {
+	fi, err := os.Stat(n)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%q is not a regular file", n)
+	}
+	h, err := os.Open(n)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Close(); err != nil {
+		return nil, err
+	}
+	p, err := filepath.Abs(n)
+	if err != nil {
+		p = ""
 	}
 
 	return &File{
 		Name:   filepath.Base(n),
 		Header: make(map[string][]string),
+		path:   p,
+		size: func() (int64, error) {
+			fi, err := os.Stat(n)
+			if err != nil {
+				return 0, err
+			}
+			return fi.Size(), nil
+		},
 		Writer: func(w io.Writer) (int64, error) {
 			h, err := os.Open(n)
 			if err != nil {
@@ -1168,7 +2467,7 @@ func fileFromFS(n string) *File {
 			}
 			return nb, h.Close()
 		},
-	}
+	}, nil
 }
 
 // fileFromReader returns a File pointer from a given io.Reader
@@ -1177,17 +2476,14 @@ func fileFromReader(n string, r io.Reader) *File {
 	if err != nil {
 		return &File{}
 	}
-	br := bytes.NewReader(d)
 	return &File{
 		Name:   n,
 		Header: make(map[string][]string),
+		size: func() (int64, error) {
+			return int64(len(d)), nil
+		},
 		Writer: func(w io.Writer) (int64, error) {
-			rb, cerr := io.Copy(w, br)
-			if cerr != nil {
-				return rb, cerr
-			}
-			_, cerr = br.Seek(0, io.SeekStart)
-			return rb, cerr
+			return io.Copy(w, bytes.NewReader(d))
 		},
 	}
 }
@@ -1197,12 +2493,25 @@ func fileFromReadSeeker(n string, r io.ReadSeeker) *File {
 	return &File{
 		Name:   n,
 		Header: make(map[string][]string),
+		size: func() (int64, error) {
+			cur, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return 0, err
+			}
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return 0, err
+			}
+			if _, err := r.Seek(cur, io.SeekStart); err != nil {
+				return 0, err
+			}
+			return end - cur, nil
+		},
 		Writer: func(w io.Writer) (int64, error) {
 			rb, err := io.Copy(w, r)
-			if err != nil {
-				return rb, err
+			if _, serr := r.Seek(0, io.SeekStart); err == nil {
+				err = serr
 			}
-			_, err = r.Seek(0, io.SeekStart)
 			return rb, err
 		},
 	}
@@ -1234,6 +2543,46 @@ func fileFromTextTemplate(n string, t *tt.Template, d interface{}) (*File, error
 	return f, nil
 }
 
+// fileFromMsg returns a File pointer with the rendered Msg as message/rfc822 content. The
+// MIME-Version header is removed from the rendered Msg, since it is only required for the
+// outer message. Since RFC 2046, Section 5.2.1 does not allow to transfer-encode a
+// message/rfc822 entity, the 8bit parts of the Msg are converted into 7bit-safe encodings
+func fileFromMsg(om *Msg) (*File, error) {
+	if om == nil {
+		return nil, fmt.Errorf("message must not be nil")
+	}
+	cm := om.Clone()
+	cm.downgrade8Bit()
+	buf := bytes.Buffer{}
+	if _, err := cm.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	hb, bb, err := splitMsg(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	nb := bytes.Buffer{}
+	for _, h := range parseHeaders(hb) {
+		if strings.HasPrefix(strings.ToLower(h), strings.ToLower(string(HeaderMIMEVersion))+":") {
+			continue
+		}
+		nb.WriteString(h)
+	}
+	nb.WriteString(SingleNewLine)
+	nb.Write(bb)
+	e := NoEncoding
+	if isASCII(nb.String()) {
+		e = Encoding7bit
+	}
+	return &File{
+		ContentType: TypeMessageRFC822,
+		Enc:         e,
+		Name:        "message.eml",
+		Header:      make(map[string][]string),
+		Writer:      writeFuncFromBuffer(&nb),
+	}, nil
+}
+
 // getEncoder creates a new mime.WordEncoder based on the encoding setting of the message
 func getEncoder(e Encoding) mime.WordEncoder {
 	switch e {
@@ -9,7 +9,10 @@ package mail
 
 import (
 	"context"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -61,3 +64,47 @@ func TestMsg_WriteToSendmail(t *testing.T) {
 		t.Errorf("WriteToSendmail failed: %s", err)
 	}
 }
+
+// TestMsg_AttachGlobDir_unreadable tests that AttachGlob and AttachDir fail for files that
+// cannot be read
+func TestMsg_AttachGlobDir_unreadable(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, "secret.txt")
+	if err := os.WriteFile(p, []byte("secret"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	if err := os.Chmod(p, 0); err != nil {
+		t.Fatalf("failed to change file permissions: %s", err)
+	}
+	if f, err := os.Open(p); err == nil {
+		_ = f.Close()
+		t.Skip("file permissions are not enforced for the current user")
+	}
+	for _, af := range []func(*Msg) error{
+		func(m *Msg) error { return m.AttachGlob(filepath.Join(d, "*")) },
+		func(m *Msg) error { return m.AttachDir(d, false) },
+	} {
+		m := NewMsg()
+		err := af(m)
+		if !errors.Is(err, os.ErrPermission) || !strings.Contains(err.Error(), p) {
+			t.Errorf("attaching unreadable file was expected to fail with the path, got: %v", err)
+		}
+		if len(m.GetAttachments()) != 0 {
+			t.Errorf("unreadable file was not expected to be attached")
+		}
+	}
+}
+
+// TestMsg_AttachGlob_danglingSymlink tests that AttachGlob fails for files that disappeared
+func TestMsg_AttachGlob_danglingSymlink(t *testing.T) {
+	d := t.TempDir()
+	p := filepath.Join(d, "gone.txt")
+	if err := os.Symlink(filepath.Join(d, "missing.txt"), p); err != nil {
+		t.Fatalf("failed to create symlink: %s", err)
+	}
+	m := NewMsg()
+	err := m.AttachGlob(filepath.Join(d, "*"))
+	if !errors.Is(err, os.ErrNotExist) || !strings.Contains(err.Error(), p) {
+		t.Errorf("AttachGlob with missing file was expected to fail with the path, got: %v", err)
+	}
+}
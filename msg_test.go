@@ -14,9 +14,13 @@ import (
 	"io"
 	"net/mail"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
 	ttpl "text/template"
 	"time"
 )
@@ -320,6 +324,51 @@ func TestMsg_SetGenHeader(t *testing.T) {
 	}
 }
 
+// TestMsg_SetGenHeader_injection tests the HeaderPolicy handling of invalid header fields
+func TestMsg_SetGenHeader_injection(t *testing.T) {
+	m := NewMsg()
+	_ = m.From("tester@example.com")
+	_ = m.To("alice@example.com")
+	m.Subject("Test\r\nBcc: mallory@example.com")
+	if _, ok := m.genHeader[HeaderSubject]; ok {
+		t.Errorf("SetGenHeader with CRLF in value was expected to be rejected")
+	}
+	m.SetGenHeaderPreformatted("X-Test\r\nBcc", "mallory@example.com")
+	if len(m.preformHeader) != 0 {
+		t.Errorf("SetGenHeaderPreformatted with CRLF in name was expected to be rejected")
+	}
+	_, err := m.WriteTo(io.Discard)
+	var he *HeaderError
+	if !errors.As(err, &he) {
+		t.Fatalf("WriteTo was expected to fail with a HeaderError, got: %s", err)
+	}
+	if he.Header != HeaderSubject {
+		t.Errorf("HeaderError has wrong header field. Expected: %s, got: %s", HeaderSubject, he.Header)
+	}
+	m.Reset()
+	if _, err := m.WriteTo(io.Discard); err != nil {
+		t.Errorf("WriteTo after Reset failed: %s", err)
+	}
+
+	m = NewMsg(WithHeaderPolicy(HeaderPolicySanitize))
+	_ = m.From("tester@example.com")
+	m.Subject("Test\r\nBcc: mallory@example.com")
+	m.SetGenHeaderPreformatted("X-Test\r\nBcc", "foo\r\n bar\nBcc: mallory@example.com")
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo with sanitized headers failed: %s", err)
+	}
+	if strings.Contains(buf.String(), "\r\nBcc:") {
+		t.Errorf("sanitized message contains injected header: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Subject: Test  Bcc: mallory@example.com\r\n") {
+		t.Errorf("sanitized message does not contain the sanitized subject: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "X-TestBcc: foo\r\n bar Bcc: mallory@example.com\r\n") {
+		t.Errorf("sanitized message does not contain the sanitized preformatted header: %s", buf.String())
+	}
+}
+
 // TestMsg_SetGenHeaderPreformatted tests Msg.SetGenHeaderPreformatted
 func TestMsg_SetGenHeaderPreformatted(t *testing.T) {
 	tests := []struct {
@@ -331,6 +380,9 @@ func TestMsg_SetGenHeaderPreformatted(t *testing.T) {
 		{"set content-language", HeaderContentLang, fmt.Sprintf("%s, %s, %s, %s",
 			"en", "de", "fr", "es")},
 		{"set subject with newline", HeaderSubject, "This is Subject\r\n with 2nd line"},
+		{"set non-ASCII value", "X-Test", "Grüße aus Köln"},
+		{"set long unfolded value", "DKIM-Signature", "v=1; a=rsa-sha256; d=example.com; s=sel; " +
+			"h=from:to:subject:date; bh=" + strings.Repeat("A", 44) + "; b=" + strings.Repeat("B", 344)},
 	}
 
 	for _, tt := range tests {
@@ -686,6 +738,85 @@ func TestMsg_BccIgnoreInvalid(t *testing.T) {
 	}
 }
 
+// TestNewMsgFromReply tests the NewMsgFromReply and Msg.Reply methods
+func TestNewMsgFromReply(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  string
+		rt   string
+		ref  []string
+		wsub string
+		wto  string
+		wref string
+	}{
+		{
+			"Simple reply", "Test mail", "", nil, "Re: Test mail", "<toni@example.com>",
+			"<orig.id@example.com>",
+		},
+		{
+			"Already prefixed", "RE: Test mail", "", nil, "RE: Test mail", "<toni@example.com>",
+			"<orig.id@example.com>",
+		},
+		{
+			"With Reply-To", "Test mail", "reply@example.com", nil, "Re: Test mail", "<reply@example.com>",
+			"<orig.id@example.com>",
+		},
+		{
+			"With References", "Re: Test mail", "", []string{"<first.id@example.com> <second.id@example.com>"},
+			"Re: Test mail", "<toni@example.com>",
+			"<first.id@example.com> <second.id@example.com> <orig.id@example.com>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if err := m.From("toni@example.com"); err != nil {
+				t.Fatalf("failed to set From: %s", err)
+			}
+			if err := m.To("alice@example.com", "bob@example.com"); err != nil {
+				t.Fatalf("failed to set To: %s", err)
+			}
+			if tt.rt != "" {
+				if err := m.ReplyTo(tt.rt); err != nil {
+					t.Fatalf("failed to set Reply-To: %s", err)
+				}
+			}
+			if tt.ref != nil {
+				m.SetGenHeader(HeaderReferences, tt.ref...)
+			}
+			m.Subject(tt.sub)
+			if _, err := m.Reply(); !errors.Is(err, ErrNoMessageID) {
+				t.Errorf("Reply() without Message-ID was expected to fail, got: %s", err)
+			}
+			m.SetMessageIDWithValue("orig.id@example.com")
+
+			rm, err := NewMsgFromReply(m, WithCharset(CharsetISO88591))
+			if err != nil {
+				t.Fatalf("NewMsgFromReply() failed: %s", err)
+			}
+			if rm.charset != CharsetISO88591 {
+				t.Errorf("NewMsgFromReply() did not apply MsgOption")
+			}
+			if s := rm.GetGenHeader(HeaderSubject); len(s) != 1 || s[0] != tt.wsub {
+				t.Errorf("NewMsgFromReply() failed. Expected subject: %q, got: %q", tt.wsub, s)
+			}
+			if to := rm.GetToString(); len(to) != 1 || to[0] != tt.wto {
+				t.Errorf("NewMsgFromReply() failed. Expected To: %q, got: %q", tt.wto, to)
+			}
+			if f := rm.GetFromString(); len(f) != 1 || f[0] != "<alice@example.com>" {
+				t.Errorf("NewMsgFromReply() failed. Expected From: %q, got: %q", "<alice@example.com>", f)
+			}
+			if irt := rm.GetGenHeader(HeaderInReplyTo); len(irt) != 1 || irt[0] != "<orig.id@example.com>" {
+				t.Errorf("NewMsgFromReply() failed. Expected In-Reply-To: %q, got: %q",
+					"<orig.id@example.com>", irt)
+			}
+			if ref := rm.GetGenHeader(HeaderReferences); len(ref) != 1 || ref[0] != tt.wref {
+				t.Errorf("NewMsgFromReply() failed. Expected References: %q, got: %q", tt.wref, ref)
+			}
+		})
+	}
+}
+
 // TestMsg_SetBulk tests the Msg.SetBulk method
 func TestMsg_SetBulk(t *testing.T) {
 	m := NewMsg()
@@ -699,6 +830,51 @@ func TestMsg_SetBulk(t *testing.T) {
 	}
 }
 
+// TestMsg_SetListUnsubscribe tests the Msg.SetListUnsubscribe and Msg.SetListUnsubscribePost methods
+func TestMsg_SetListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name string
+		ul   []string
+		want string
+		sf   bool
+	}{
+		{"mailto", []string{"mailto:unsub@example.com"}, "<mailto:unsub@example.com>", false},
+		{
+			"mailto and https", []string{"mailto:unsub@example.com", "<https://example.com/unsub?id=1>"},
+			"<mailto:unsub@example.com>, <https://example.com/unsub?id=1>", false,
+		},
+		{"No URL", nil, "", true},
+		{"Invalid scheme", []string{"ftp://example.com/unsub"}, "", true},
+		{"Invalid URL", []string{"https://exa mple.com/%zz"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			err := m.SetListUnsubscribe(tt.ul...)
+			if err != nil && !tt.sf {
+				t.Errorf("SetListUnsubscribe() failed: %s", err)
+				return
+			}
+			if err == nil && tt.sf {
+				t.Errorf("SetListUnsubscribe() was expected to fail")
+				return
+			}
+			if tt.sf {
+				return
+			}
+			if v := strings.Join(m.GetGenHeader(HeaderListUnsubscribe), ", "); v != tt.want {
+				t.Errorf("SetListUnsubscribe() failed. Expected: %q, got: %q", tt.want, v)
+			}
+		})
+	}
+
+	m := NewMsg()
+	m.SetListUnsubscribePost()
+	if v := m.GetGenHeader(HeaderListUnsubscribePost); len(v) != 1 || v[0] != "List-Unsubscribe=One-Click" {
+		t.Errorf("SetListUnsubscribePost() failed. Expected: %q, got: %q", "List-Unsubscribe=One-Click", v)
+	}
+}
+
 // TestMsg_SetDate tests the Msg.SetDate and Msg.SetDateWithValue method
 func TestMsg_SetDate(t *testing.T) {
 	m := NewMsg()
@@ -766,6 +942,20 @@ func TestMsg_SetMessageIDWithValue(t *testing.T) {
 	}
 }
 
+// TestMsg_SetMessageIDDomain tests the WithMessageIDDomain option and the Msg.SetMessageIDDomain method
+func TestMsg_SetMessageIDDomain(t *testing.T) {
+	m := NewMsg(WithMessageIDDomain("example.com"))
+	m.SetMessageID()
+	if mid := m.GetGenHeader(HeaderMessageID); !strings.HasSuffix(mid[0], "@example.com>") {
+		t.Errorf("SetMessageID() failed. Expected domain example.com, got: %s", mid[0])
+	}
+	m.SetMessageIDDomain("mail.example.org")
+	m.SetMessageID()
+	if mid := m.GetGenHeader(HeaderMessageID); !strings.HasSuffix(mid[0], "@mail.example.org>") {
+		t.Errorf("SetMessageID() failed. Expected domain mail.example.org, got: %s", mid[0])
+	}
+}
+
 // TestMsg_SetMessageIDRandomness tests the randomness of Msg.SetMessageID methods
 func TestMsg_SetMessageIDRandomness(t *testing.T) {
 	var mids []string
@@ -807,6 +997,18 @@ func TestMsg_FromFormat(t *testing.T) {
 			"valid name with invalid addr", "Toni Tester", "@example.com",
 			``, true,
 		},
+		{
+			"name with quotes", `Toni "The Tester" Tester`, "tester@example.com",
+			`"Toni \"The Tester\" Tester" <tester@example.com>`, false,
+		},
+		{
+			"name with backslash", `Tester\Toni`, "tester@example.com",
+			`"Tester\\Toni" <tester@example.com>`, false,
+		},
+		{
+			"name with non-ASCII characters", "Töni \"Tester\"", "tester@example.com",
+			`=?utf-8?b?VMO2bmkgIlRlc3RlciI=?= <tester@example.com>`, false,
+		},
 	}
 
 	m := NewMsg()
@@ -843,6 +1045,83 @@ func TestMsg_FromFormat(t *testing.T) {
 	}
 }
 
+// TestMsg_AddrFromString tests the ToFromString, CcFromString and BccFromString methods
+func TestMsg_AddrFromString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+		fail  bool
+	}{
+		{"single address", "a@example.com", []string{"<a@example.com>"}, false},
+		{
+			"address list", "a@example.com, Bob <b@example.com>",
+			[]string{"<a@example.com>", `"Bob" <b@example.com>`}, false,
+		},
+		{"empty string", "", nil, true},
+		{"invalid address", "a@example.com, invalid", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			for h, f := range map[AddrHeader]func(string) error{
+				HeaderTo: m.ToFromString, HeaderCc: m.CcFromString, HeaderBcc: m.BccFromString,
+			} {
+				err := f(tt.value)
+				if tt.fail {
+					if err == nil {
+						t.Errorf("%s from string %q was expected to fail", h, tt.value)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s from string %q failed: %s", h, tt.value, err)
+					continue
+				}
+				if got := m.GetAddrHeaderString(h); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("%s from string failed. Expected: %v, got: %v", h, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// TestMsg_DedupRecipients tests the removal of duplicate recipient addresses
+func TestMsg_DedupRecipients(t *testing.T) {
+	m := NewMsg()
+	if err := m.To("to@example.com", "Other <other@example.com>", "TO@example.com"); err != nil {
+		t.Fatalf("To() failed: %s", err)
+	}
+	if err := m.Cc("cc@example.com", "Other@Example.com"); err != nil {
+		t.Fatalf("Cc() failed: %s", err)
+	}
+	if err := m.Bcc("to@EXAMPLE.com", "bcc@example.com", "cc@example.com"); err != nil {
+		t.Fatalf("Bcc() failed: %s", err)
+	}
+	m.DedupRecipients()
+	tests := []struct {
+		h    AddrHeader
+		want []string
+	}{
+		{HeaderTo, []string{"<to@example.com>", `"Other" <other@example.com>`}},
+		{HeaderCc, []string{"<cc@example.com>"}},
+		{HeaderBcc, []string{"<bcc@example.com>"}},
+	}
+	for _, tt := range tests {
+		got := m.GetAddrHeaderString(tt.h)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("DedupRecipients() failed for %s. Expected: %v, got: %v", tt.h, tt.want, got)
+		}
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		t.Fatalf("GetRecipients() failed: %s", err)
+	}
+	if len(rl) != 4 {
+		t.Errorf("DedupRecipients() failed. Expected 4 recipients, got: %v", rl)
+	}
+}
+
 func TestMsg_GetRecipients(t *testing.T) {
 	a := []string{"to@example.com", "cc@example.com", "bcc@example.com"}
 	m := NewMsg()
@@ -898,6 +1177,37 @@ func TestMsg_GetRecipients(t *testing.T) {
 	}
 }
 
+// TestMsg_ReplyToMultiple tests the Msg.ReplyToMultiple and Msg.AddReplyTo methods
+func TestMsg_ReplyToMultiple(t *testing.T) {
+	m := NewMsg()
+	if err := m.ReplyToMultiple("a@example.com", "Bob <b@example.com>"); err != nil {
+		t.Fatalf("ReplyToMultiple() failed: %s", err)
+	}
+	if err := m.AddReplyTo("c@example.com"); err != nil {
+		t.Fatalf("AddReplyTo() failed: %s", err)
+	}
+	want := `<a@example.com>, "Bob" <b@example.com>, <c@example.com>`
+	if rt := m.GetAddrHeaderString(HeaderReplyTo); strings.Join(rt, ", ") != want {
+		t.Errorf("ReplyToMultiple() failed. Expected: %s, got: %v", want, rt)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() failed: %s", err)
+	}
+	if !strings.Contains(buf.String(), "Reply-To: "+want+"\r\n") {
+		t.Errorf("Reply-To header not found in message: %s", buf.String())
+	}
+	if rl, err := m.GetRecipients(); err == nil {
+		t.Errorf("Reply-To addresses must not be recipients, got: %v", rl)
+	}
+	if err := m.ReplyToMultiple("a@example.com", "invalid"); err == nil {
+		t.Errorf("ReplyToMultiple() with invalid address was expected to fail")
+	}
+	if err := m.AddReplyTo("invalid"); err == nil {
+		t.Errorf("AddReplyTo() with invalid address was expected to fail")
+	}
+}
+
 // TestMsg_ReplyTo tests the Msg.ReplyTo and Msg.ReplyToFormat methods
 func TestMsg_ReplyTo(t *testing.T) {
 	tests := []struct {
@@ -927,32 +1237,23 @@ func TestMsg_ReplyTo(t *testing.T) {
 				t.Errorf("ReplyTo() method failed: %s", err)
 			}
 			if !tt.sf {
-				rt, ok := m.genHeader[HeaderReplyTo]
-				if !ok {
-					t.Errorf("ReplyTo() failed: ReplyTo generic header not set")
-					return
-				}
+				rt := m.GetAddrHeaderString(HeaderReplyTo)
 				if len(rt) <= 0 {
-					t.Errorf("ReplyTo() failed: length of generic ReplyTo header is zero or less than zero")
+					t.Errorf("ReplyTo() failed: ReplyTo address header not set")
 					return
 				}
 				if rt[0] != tt.want {
 					t.Errorf("ReplyTo() failed: expected value: %s, got: %s", tt.want, rt[0])
 				}
 			}
-			m.genHeader = nil
-			m.genHeader = make(map[Header][]string)
+			m.addrHeader = make(map[AddrHeader][]*mail.Address)
 			if err := m.ReplyToFormat(tt.name, tt.addr); err != nil && !tt.sf {
 				t.Errorf("ReplyToFormat() method failed: %s", err)
 			}
 			if !tt.sf {
-				rt, ok := m.genHeader[HeaderReplyTo]
-				if !ok {
-					t.Errorf("ReplyTo() failed: ReplyTo generic header not set")
-					return
-				}
+				rt := m.GetAddrHeaderString(HeaderReplyTo)
 				if len(rt) <= 0 {
-					t.Errorf("ReplyTo() failed: length of generic ReplyTo header is zero or less than zero")
+					t.Errorf("ReplyTo() failed: ReplyTo address header not set")
 					return
 				}
 				if rt[0] != tt.want {
@@ -1079,6 +1380,54 @@ func TestMsg_SetOrganization(t *testing.T) {
 	}
 }
 
+// TestMsg_SetAutoSubmitted tests the Msg.SetAutoSubmitted method
+func TestMsg_SetAutoSubmitted(t *testing.T) {
+	tests := []struct {
+		name string
+		as   AutoSubmitted
+		want string
+	}{
+		{"no", AutoSubmittedNo, "no"},
+		{"auto-generated", AutoGenerated, "auto-generated"},
+		{"auto-replied", AutoReplied, "auto-replied"},
+		{"auto-notified", AutoNotified, "auto-notified"},
+	}
+	m := NewMsg()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.SetAutoSubmitted(tt.as)
+			if h := m.GetGenHeader(HeaderAutoSubmitted); len(h) != 1 || h[0] != tt.want {
+				t.Errorf("SetAutoSubmitted() failed. Expected: %s, got: %v", tt.want, h)
+			}
+		})
+	}
+}
+
+// TestMsg_SetAutoResponseSuppress tests the Msg.SetAutoResponseSuppress method
+func TestMsg_SetAutoResponseSuppress(t *testing.T) {
+	tests := []struct {
+		name string
+		vl   []AutoResponseSuppress
+		want string
+	}{
+		{"default", nil, "All"},
+		{"single", []AutoResponseSuppress{AutoResponseSuppressOOF}, "OOF"},
+		{
+			"multiple", []AutoResponseSuppress{AutoResponseSuppressOOF, AutoResponseSuppressAutoReply, AutoResponseSuppressDR},
+			"OOF, AutoReply, DR",
+		},
+	}
+	m := NewMsg()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.SetAutoResponseSuppress(tt.vl...)
+			if h := m.GetGenHeader(HeaderXAutoResponseSuppress); len(h) != 1 || h[0] != tt.want {
+				t.Errorf("SetAutoResponseSuppress() failed. Expected: %s, got: %v", tt.want, h)
+			}
+		})
+	}
+}
+
 // TestMsg_SetUserAgent tests the Msg.SetUserAgent method
 func TestMsg_SetUserAgent(t *testing.T) {
 	tests := []struct {
@@ -1111,6 +1460,80 @@ func TestMsg_SetUserAgent(t *testing.T) {
 	}
 }
 
+// TestMsg_SetDSNMailReturnType tests the SetDSNMailReturnType method of the Msg
+func TestMsg_SetDSNMailReturnType(t *testing.T) {
+	tests := []struct {
+		name  string
+		value DSNMailReturnOption
+		sf    bool
+	}{
+		{"SetDSNMailReturnType: FULL", DSNMailReturnFull, false},
+		{"SetDSNMailReturnType: HDRS", DSNMailReturnHeadersOnly, false},
+		{"SetDSNMailReturnType: INVALID", "INVALID", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			err := m.SetDSNMailReturnType(tt.value)
+			if (err != nil) != tt.sf {
+				t.Fatalf("SetDSNMailReturnType failed. Expected error: %t, got: %s", tt.sf, err)
+			}
+			if !tt.sf && m.dsnmrtype != tt.value {
+				t.Errorf("SetDSNMailReturnType failed. Expected %s, got: %s", tt.value, m.dsnmrtype)
+			}
+		})
+	}
+}
+
+// TestMsg_SetDSNRcptNotifyType tests the SetDSNRcptNotifyType method of the Msg
+func TestMsg_SetDSNRcptNotifyType(t *testing.T) {
+	tests := []struct {
+		name  string
+		rcpt  string
+		value []DSNRcptNotifyOption
+		want  string
+		sf    bool
+	}{
+		{"SUCCESS,DELAY", "rcpt@example.com", []DSNRcptNotifyOption{DSNRcptNotifySuccess, DSNRcptNotifyDelay},
+			"SUCCESS,DELAY", false},
+		{"NEVER with display name", "Toni Tester <rcpt@example.com>", []DSNRcptNotifyOption{DSNRcptNotifyNever},
+			"NEVER", false},
+		{"no options", "rcpt@example.com", nil, "", false},
+		{"invalid address", "invalid", []DSNRcptNotifyOption{DSNRcptNotifySuccess}, "", true},
+		{"invalid option", "rcpt@example.com", []DSNRcptNotifyOption{"INVALID"}, "", true},
+		{"NEVER combination", "rcpt@example.com",
+			[]DSNRcptNotifyOption{DSNRcptNotifyNever, DSNRcptNotifyFailure}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			err := m.SetDSNRcptNotifyType(tt.rcpt, tt.value...)
+			if (err != nil) != tt.sf {
+				t.Fatalf("SetDSNRcptNotifyType failed. Expected error: %t, got: %s", tt.sf, err)
+			}
+			if tt.sf {
+				return
+			}
+			rnol, ok := m.dsnrntype["rcpt@example.com"]
+			if !ok {
+				t.Fatalf("SetDSNRcptNotifyType failed. No notify options for the recipient")
+			}
+			if strings.Join(rnol, ",") != tt.want {
+				t.Errorf("SetDSNRcptNotifyType failed. Expected %q, got: %q", tt.want, strings.Join(rnol, ","))
+			}
+			c := m.Clone()
+			c.dsnrntype["rcpt@example.com"] = nil
+			if len(m.dsnrntype["rcpt@example.com"]) != len(rnol) {
+				t.Errorf("SetDSNRcptNotifyType failed. Changes of the clone affected the Msg")
+			}
+			m.Reset()
+			if m.dsnrntype != nil {
+				t.Errorf("Reset failed. Expected DSN notify options to be cleared")
+			}
+		})
+	}
+}
+
 // TestMsg_RequestMDN tests the different RequestMDN* related methods of Msg
 func TestMsg_RequestMDN(t *testing.T) {
 	n := "Toni Tester"
@@ -1181,6 +1604,16 @@ func TestMsg_RequestMDN(t *testing.T) {
 	}
 	m.Reset()
 
+	// Formated address with a name that requires quoting
+	if err := m.RequestMDNToFormat("Tester, Toni", v); err != nil {
+		t.Errorf("RequestMDNToFormat with a name containing a comma failed: %s", err)
+	}
+	if m.genHeader[HeaderDispositionNotificationTo][0] != fmt.Sprintf(`"Tester, Toni" <%s>`, v) {
+		t.Errorf(`RequestMDNToFormat with a name containing a comma failed. Expected: "Tester, Toni" <%s>, got: %s`,
+			v, m.genHeader[HeaderDispositionNotificationTo][0])
+	}
+	m.Reset()
+
 	// Invalid formated address
 	if err := m.RequestMDNToFormat(n, iv); err == nil {
 		t.Errorf("RequestMDNToFormat with an invalid address was supposed to failed, but didn't")
@@ -1276,11 +1709,14 @@ func TestMsg_AttachFile(t *testing.T) {
 		{"File: README.md", "README.md", "README.md", false},
 		{"File: doc.go", "doc.go", "foo.go", false},
 		{"File: nonexisting", "", "invalid.file", true},
+		{"File: directory", ".", "dir", true},
 	}
 	m := NewMsg()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m.AttachFile(tt.file, WithFileName(tt.fn), nil)
+			if err := m.AttachFile(tt.file, WithFileName(tt.fn), nil); (err != nil) != tt.sf {
+				t.Errorf("AttachFile() failed. Expected failure: %t, got: %v", tt.sf, err)
+			}
 			if len(m.attachments) != 1 && !tt.sf {
 				t.Errorf("AttachFile() failed. Number of attachments expected: %d, got: %d", 1,
 					len(m.attachments))
@@ -1400,6 +1836,53 @@ func TestMsg_SetAttachments(t *testing.T) {
 	}
 }
 
+// TestMsg_SetAttachments_replace tests the Msg.SetAttachments method
+func TestMsg_SetAttachments_replace(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments []string
+		files       []string
+	}{
+		{"File: replace README.md  with doc.go", []string{"README.md"}, []string{"doc.go"}},
+		{"File: add README.md with doc.go ", []string{"doc.go"}, []string{"README.md", "doc.go"}},
+		{"File: remove README.md and doc.go", []string{"README.md", "doc.go"}, nil},
+		{"File: add README.md and doc.go", nil, []string{"README.md", "doc.go"}},
+	}
+	m := NewMsg()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.Strings(tt.attachments)
+			sort.Strings(tt.files)
+			for _, a := range tt.attachments {
+				m.AttachFile(a, WithFileName(a), nil)
+			}
+			if len(m.attachments) != len(tt.attachments) {
+				t.Errorf("AttachFile() failed. Number of attachments expected: %d, got: %d", len(tt.files),
+					len(m.attachments))
+				return
+			}
+			var files []*File
+			for _, f := range tt.files {
+				files = append(files, &File{Name: f})
+			}
+			m.SetAttachments(files)
+			if len(m.attachments) != len(files) {
+				t.Errorf("SetAttachments() failed. Number of attachments expected: %d, got: %d", len(files),
+					len(m.attachments))
+				return
+			}
+			for i, f := range tt.files {
+				if f != m.attachments[i].Name {
+					t.Errorf("SetAttachments() failed. Attachment name expected: %s, got: %s", f,
+						m.attachments[i].Name)
+					return
+				}
+			}
+			m.Reset()
+		})
+	}
+}
+
 // TestMsg_GetEmbeds tests the Msg.GetEmbeds method
 func TestMsg_GetEmbeds(t *testing.T) {
 	tests := []struct {
@@ -1537,6 +2020,159 @@ func TestMsg_AttachFromEmbedFS(t *testing.T) {
 	}
 }
 
+// TestMsg_AttachFromIOFS tests the Msg.AttachFromIOFS and Msg.EmbedFromIOFS methods
+func TestMsg_AttachFromIOFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"assets/logo.png": &fstest.MapFile{Data: []byte("This is a fake PNG")},
+	}
+	tests := []struct {
+		name string
+		file string
+		fn   string
+		sf   bool
+	}{
+		{"File: assets/logo.png", "assets/logo.png", "logo.png", false},
+		{"File: nonexisting", "assets/invalid.file", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			aerr := m.AttachFromIOFS(tt.file, fsys)
+			eerr := m.EmbedFromIOFS(tt.file, fsys)
+			if tt.sf {
+				if aerr == nil || eerr == nil {
+					t.Errorf("AttachFromIOFS()/EmbedFromIOFS() with nonexisting file were expected to fail")
+				}
+				return
+			}
+			if aerr != nil || eerr != nil {
+				t.Fatalf("AttachFromIOFS()/EmbedFromIOFS() failed: %v / %v", aerr, eerr)
+			}
+			if len(m.attachments) != 1 || len(m.embeds) != 1 {
+				t.Fatalf("expected 1 attachment and 1 embed, got: %d/%d", len(m.attachments), len(m.embeds))
+			}
+			for _, f := range []*File{m.attachments[0], m.embeds[0]} {
+				if f.Name != tt.fn {
+					t.Errorf("expected file name: %s, got: %s", tt.fn, f.Name)
+				}
+				buf := bytes.Buffer{}
+				if _, err := f.Writer(&buf); err != nil {
+					t.Errorf("failed to execute WriterFunc: %s", err)
+				}
+				if buf.String() != "This is a fake PNG" {
+					t.Errorf("expected file content: %q, got: %q", "This is a fake PNG", buf.String())
+				}
+			}
+		})
+	}
+
+	m := NewMsg()
+	if err := m.AttachFromIOFS("logo.png", nil); err == nil {
+		t.Errorf("AttachFromIOFS() with nil fs.FS was expected to fail")
+	}
+	if err := m.EmbedFromIOFS("logo.png", nil); err == nil {
+		t.Errorf("EmbedFromIOFS() with nil fs.FS was expected to fail")
+	}
+}
+
+// TestMsg_AttachMsg tests the Msg.AttachMsg, Msg.Forward and NewMsgFromForward methods
+func TestMsg_AttachMsg(t *testing.T) {
+	om := NewMsg()
+	if err := om.From("toni@example.com"); err != nil {
+		t.Fatalf("failed to set From: %s", err)
+	}
+	if err := om.To("alice@example.com"); err != nil {
+		t.Fatalf("failed to set To: %s", err)
+	}
+	om.Subject("Original mail")
+	om.SetBodyString(TypeTextPlain, "This is the original body")
+
+	m, err := om.Forward()
+	if err != nil {
+		t.Fatalf("Forward() failed: %s", err)
+	}
+	if s := m.GetGenHeader(HeaderSubject); len(s) != 1 || s[0] != "Fwd: Original mail" {
+		t.Errorf("Forward() failed. Expected subject: %q, got: %q", "Fwd: Original mail", s)
+	}
+	if len(m.attachments) != 1 {
+		t.Fatalf("Forward() failed. Number of attachments expected: %d, got: %d", 1, len(m.attachments))
+	}
+	f := m.attachments[0]
+	if f.ContentType != TypeMessageRFC822 || f.Enc != Encoding7bit {
+		t.Errorf("AttachMsg() failed. Expected %s/%s, got: %s/%s", TypeMessageRFC822, Encoding7bit,
+			f.ContentType, f.Enc)
+	}
+	wbuf := bytes.Buffer{}
+	if _, err := f.Writer(&wbuf); err != nil {
+		t.Fatalf("execute WriterFunc failed: %s", err)
+	}
+	nm, err := mail.ReadMessage(&wbuf)
+	if err != nil {
+		t.Fatalf("failed to parse attached message: %s", err)
+	}
+	if nm.Header.Get("Subject") != "Original mail" {
+		t.Errorf("AttachMsg() failed. Expected subject: %q, got: %q", "Original mail", nm.Header.Get("Subject"))
+	}
+	if nm.Header.Get(string(HeaderMIMEVersion)) != "" {
+		t.Errorf("AttachMsg() failed. Attached message is not expected to have a MIME-Version header")
+	}
+
+	fm, err := NewMsgFromForward(m)
+	if err != nil {
+		t.Fatalf("NewMsgFromForward() failed: %s", err)
+	}
+	if s := fm.GetGenHeader(HeaderSubject); len(s) != 1 || s[0] != "Fwd: Original mail" {
+		t.Errorf("NewMsgFromForward() failed. Expected subject: %q, got: %q", "Fwd: Original mail", s)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if c := strings.Count(buf.String(), "MIME-Version:"); c != 1 {
+		t.Errorf("expected 1 MIME-Version header in rendered message, got: %d", c)
+	}
+	if !strings.Contains(buf.String(), "Content-Type: message/rfc822; name=\"message.eml\"") {
+		t.Errorf("rendered message does not contain the message/rfc822 part: %s", buf.String())
+	}
+	if err := m.AttachMsg(nil); err == nil {
+		t.Errorf("AttachMsg() with nil message was expected to fail")
+	}
+}
+
+// TestMsg_AttachMsg_8bit tests that an attached 8bit message is converted into a 7bit-safe
+// message/rfc822 entity, which is not transfer-encoded by the 8BITMIME downgrade
+func TestMsg_AttachMsg_8bit(t *testing.T) {
+	om := NewMsg(WithEncoding(NoEncoding))
+	om.Subject("Grüße")
+	om.SetBodyString(TypeTextPlain, "Schöne Grüße")
+	om.AttachReader("data.txt", strings.NewReader("Dätä"), WithFileEncoding(NoEncoding))
+	m := NewMsg(WithEncoding(NoEncoding))
+	if err := m.AttachMsg(om); err != nil {
+		t.Fatalf("AttachMsg() failed: %s", err)
+	}
+	if om.encoding != NoEncoding || om.GetParts()[0].enc != NoEncoding {
+		t.Errorf("AttachMsg() was not expected to change the attached Msg")
+	}
+	f := m.GetAttachments()[0]
+	if f.Enc != Encoding7bit {
+		t.Errorf("AttachMsg() failed. Expected %s, got: %s", Encoding7bit, f.Enc)
+	}
+	m.downgrade8Bit()
+	if f.Enc != Encoding7bit {
+		t.Errorf("downgrade8Bit() was not expected to transfer-encode the attached message, got: %s", f.Enc)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !isASCII(buf.String()) {
+		t.Errorf("rendered message was expected to be 7bit-safe: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Content-Transfer-Encoding: 7bit\r\nContent-Type: message/rfc822") {
+		t.Errorf("attached message was expected to be 7bit: %s", buf.String())
+	}
+}
+
 // TestMsg_AttachFileBrokenFunc tests WriterFunc of the Msg.AttachFile  method
 func TestMsg_AttachFileBrokenFunc(t *testing.T) {
 	m := NewMsg()
@@ -1560,6 +2196,61 @@ func TestMsg_AttachFileBrokenFunc(t *testing.T) {
 	}
 }
 
+// TestMsg_AttachGlobDir tests the Msg.AttachGlob and Msg.AttachDir methods
+func TestMsg_AttachGlobDir(t *testing.T) {
+	d := t.TempDir()
+	for _, n := range []string{"a.csv", "b.csv", "notes.txt", filepath.Join("sub", "c.csv")} {
+		p := filepath.Join(d, n)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %s", err)
+		}
+		if err := os.WriteFile(p, []byte(n), 0o600); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+	names := func(m *Msg) string {
+		var nl []string
+		for _, f := range m.GetAttachments() {
+			nl = append(nl, f.Name)
+		}
+		sort.Strings(nl)
+		return strings.Join(nl, ",")
+	}
+	noTxt := WithFileFilter(func(f *File) bool { return !strings.HasSuffix(f.Name, ".txt") })
+
+	tests := []struct {
+		name string
+		add  func(*Msg) error
+		want string
+	}{
+		{"glob", func(m *Msg) error { return m.AttachGlob(filepath.Join(d, "*.csv")) }, "a.csv,b.csv"},
+		{"glob skips dirs", func(m *Msg) error { return m.AttachGlob(filepath.Join(d, "*")) }, "a.csv,b.csv,notes.txt"},
+		{"glob with filter", func(m *Msg) error { return m.AttachGlob(filepath.Join(d, "*"), noTxt) }, "a.csv,b.csv"},
+		{"dir", func(m *Msg) error { return m.AttachDir(d, false) }, "a.csv,b.csv,notes.txt"},
+		{"dir recursive", func(m *Msg) error { return m.AttachDir(d, true) }, "a.csv,b.csv,c.csv,notes.txt"},
+		{"dir with filter", func(m *Msg) error { return m.AttachDir(d, true, noTxt) }, "a.csv,b.csv,c.csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if err := tt.add(m); err != nil {
+				t.Fatalf("failed to attach files: %s", err)
+			}
+			if n := names(m); n != tt.want {
+				t.Errorf("failed to attach files. Expected: %s, got: %s", tt.want, n)
+			}
+		})
+	}
+
+	m := NewMsg()
+	if err := m.AttachGlob("["); err == nil {
+		t.Errorf("AttachGlob with invalid pattern was expected to fail")
+	}
+	if err := m.AttachDir(filepath.Join(d, "missing"), false); err == nil {
+		t.Errorf("AttachDir with missing directory was expected to fail")
+	}
+}
+
 // TestMsg_AttachReader tests the Msg.AttachReader method
 func TestMsg_AttachReader(t *testing.T) {
 	m := NewMsg()
@@ -1602,11 +2293,14 @@ func TestMsg_EmbedFile(t *testing.T) {
 		{"File: README.md", "README.md", "README.md", false},
 		{"File: doc.go", "doc.go", "foo.go", false},
 		{"File: nonexisting", "", "invalid.file", true},
+		{"File: directory", ".", "dir", true},
 	}
 	m := NewMsg()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m.EmbedFile(tt.file, WithFileName(tt.fn), nil)
+			if err := m.EmbedFile(tt.file, WithFileName(tt.fn), nil); (err != nil) != tt.sf {
+				t.Errorf("EmbedFile() failed. Expected failure: %t, got: %v", tt.sf, err)
+			}
 			if len(m.embeds) != 1 && !tt.sf {
 				t.Errorf("EmbedFile() failed. Number of embeds expected: %d, got: %d", 1,
 					len(m.embeds))
@@ -1751,6 +2445,57 @@ func TestMsg_hasRelated(t *testing.T) {
 	}
 }
 
+// TestMsg_relatedType tests the type and start parameters of multipart/related
+func TestMsg_relatedType(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  func(*Msg)
+		want string
+		sf   bool
+	}{
+		{"HTML body", func(m *Msg) {
+			m.SetBodyString(TypeTextHTML, "<p>HTML</p>")
+		}, `related; type="text/html"`, false},
+		{"alternative body", func(m *Msg) {
+			m.SetBodyString(TypeTextPlain, "Plain")
+			m.AddAlternativeString(TypeTextHTML, "<p>HTML</p>")
+		}, `related; type="multipart/alternative"`, false},
+		{"embeds only", func(m *Msg) {
+			m.EmbedReader("logo.png", strings.NewReader("PNG"))
+		}, `related; type="image/jpeg"`, false},
+		{"root embed", func(m *Msg) {
+			m.SetBodyString(TypeTextPlain, "Plain")
+			m.EmbedReader("index.html", strings.NewReader("<p>HTML</p>"), WithFileContentID("root@example.com"))
+			m.SetRelatedRoot("<root@example.com>")
+		}, `related; type="text/html"; start="<root@example.com>"`, false},
+		{"missing root embed", func(m *Msg) {
+			m.SetBodyString(TypeTextPlain, "Plain")
+			m.SetRelatedRoot("missing@example.com")
+		}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			m.EmbedReader("image.jpg", strings.NewReader("JPG"))
+			tt.msg(m)
+			mt, err := m.relatedType()
+			if (err != nil) != tt.sf {
+				t.Fatalf("relatedType failed. Expected failure: %t, got: %v", tt.sf, err)
+			}
+			if string(mt) != tt.want && !tt.sf {
+				t.Errorf("relatedType failed. Expected: %s, got: %s", tt.want, mt)
+			}
+			buf := bytes.Buffer{}
+			if _, err = m.WriteTo(&buf); (err != nil) != tt.sf {
+				t.Errorf("WriteTo failed. Expected failure: %t, got: %v", tt.sf, err)
+			}
+			if !tt.sf && !strings.Contains(buf.String(), "Content-Type: multipart/"+tt.want+";") {
+				t.Errorf("WriteTo failed. Expected multipart/%s, got: %s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
 // TestMsg_hasMixed tests the hasMixed() method of the Msg
 func TestMsg_hasMixed(t *testing.T) {
 	m := NewMsg()
@@ -2009,6 +2754,39 @@ func TestMsg_NewReader_ioCopy(t *testing.T) {
 	}
 }
 
+// TestMsg_Read tests the io.Reader implementation of the Msg
+func TestMsg_Read(t *testing.T) {
+	m := NewMsg()
+	m.Subject("Subject-Run 1")
+	m.SetBodyString(TypeTextPlain, "TEST123")
+	wbuf := bytes.Buffer{}
+	if _, err := m.WriteTo(&wbuf); err != nil {
+		t.Fatalf("failed to write message to buffer: %s", err)
+	}
+
+	b, err := io.ReadAll(iotest.OneByteReader(m))
+	if err != nil {
+		t.Fatalf("failed to read message: %s", err)
+	}
+	if string(b) != wbuf.String() {
+		t.Errorf("message content of WriteTo and Read differ")
+	}
+
+	m.Subject("Subject-Run 2")
+	b, err = io.ReadAll(m)
+	if err != nil {
+		t.Fatalf("2nd read of message failed: %s", err)
+	}
+	if !strings.Contains(string(b), "Subject: Subject-Run 2") {
+		t.Errorf("2nd read of message failed. Expected to find %q in output", "Subject-Run 2")
+	}
+
+	m.SetGenHeader(HeaderSubject, "invalid\r\nsubject")
+	if _, err := io.ReadAll(m); err == nil {
+		t.Errorf("read of message with invalid header was expected to fail")
+	}
+}
+
 // TestMsg_UpdateReader tests the Msg.UpdateReader method
 func TestMsg_UpdateReader(t *testing.T) {
 	m := NewMsg()
@@ -2125,6 +2903,85 @@ func TestMsg_SetBodyHTMLTemplate(t *testing.T) {
 	}
 }
 
+// TestMsg_SetBodyHTMLWithEmbeds tests the Msg.SetBodyHTMLWithEmbeds method
+func TestMsg_SetBodyHTMLWithEmbeds(t *testing.T) {
+	bd := t.TempDir()
+	if err := os.Mkdir(filepath.Join(bd, "img"), 0o755); err != nil {
+		t.Fatalf("failed to create image directory: %s", err)
+	}
+	for _, fn := range []string{"logo.png", "img/banner.gif"} {
+		if err := os.WriteFile(filepath.Join(bd, fn), []byte("GIF89a"), 0o600); err != nil {
+			t.Fatalf("failed to create test image: %s", err)
+		}
+	}
+
+	m := NewMsg()
+	h := `<p><img src="logo.png" alt="Logo"><IMG class="x" SRC='./img/banner.gif'>` +
+		`<img src="logo.png"><img src="https://example.com/remote.png"><img src="cid:foo@bar">` +
+		`<img src="data:image/png;base64,AAAA"></p>`
+	if err := m.SetBodyHTMLWithEmbeds(h, bd); err != nil {
+		t.Fatalf("SetBodyHTMLWithEmbeds failed: %s", err)
+	}
+	if len(m.embeds) != 2 {
+		t.Fatalf("SetBodyHTMLWithEmbeds failed. Expected 2 embeds, got: %d", len(m.embeds))
+	}
+	if m.embeds[0].Name != "logo.png" || m.embeds[1].Name != "banner.gif" {
+		t.Errorf("SetBodyHTMLWithEmbeds failed. Unexpected embed names: %s, %s", m.embeds[0].Name,
+			m.embeds[1].Name)
+	}
+	var cl []string
+	for _, f := range m.embeds {
+		cid, _ := f.getHeader(HeaderContentID)
+		if !strings.HasPrefix(cid, "<") || !strings.HasSuffix(cid, "@go-mail>") {
+			t.Errorf("SetBodyHTMLWithEmbeds failed. Unexpected Content-ID: %s", cid)
+		}
+		cl = append(cl, strings.Trim(cid, "<>"))
+	}
+
+	pc, err := m.parts[0].GetContent()
+	if err != nil {
+		t.Fatalf("failed to get part content: %s", err)
+	}
+	ex := fmt.Sprintf(`<p><img src="cid:%s" alt="Logo"><IMG class="x" SRC='cid:%s'>`+
+		`<img src="cid:%s"><img src="https://example.com/remote.png"><img src="cid:foo@bar">`+
+		`<img src="data:image/png;base64,AAAA"></p>`, cl[0], cl[1], cl[0])
+	if string(pc) != ex {
+		t.Errorf("SetBodyHTMLWithEmbeds failed. Expected body: %s, got: %s", ex, pc)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	for _, ex := range []string{
+		"Content-Type: multipart/related", fmt.Sprintf("Content-Id: <%s>", cl[0]),
+		fmt.Sprintf("Content-Id: <%s>", cl[1]),
+	} {
+		if !strings.Contains(buf.String(), ex) {
+			t.Errorf("SetBodyHTMLWithEmbeds failed. Message does not contain: %s", ex)
+		}
+	}
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"missing file", "missing.png"},
+		{"outside of base directory", "../logo.png"},
+		{"partially missing", `logo.png"><img src="missing.png`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if err := m.SetBodyHTMLWithEmbeds(fmt.Sprintf(`<img src="%s">`, tt.src), bd); err == nil {
+				t.Errorf("SetBodyHTMLWithEmbeds with %q was expected to fail", tt.src)
+			}
+			if len(m.embeds) != 0 || len(m.parts) != 0 {
+				t.Errorf("failed SetBodyHTMLWithEmbeds is not expected to modify the message")
+			}
+		})
+	}
+}
+
 // TestMsg_AddAlternativeTextTemplate tests the Msg.AddAlternativeTextTemplate method
 func TestMsg_AddAlternativeTextTemplate(t *testing.T) {
 	tests := []struct {
@@ -2454,6 +3311,23 @@ func TestMsg_WriteToFile(t *testing.T) {
 	if fi.Size() <= 0 {
 		t.Errorf("output file is expected to contain data but its size is zero")
 	}
+
+	// An existing file is truncated
+	if err := os.WriteFile(f.Name(), bytes.Repeat([]byte("x"), int(fi.Size())*2), 0o600); err != nil {
+		t.Fatalf("failed to overwrite output file: %s", err)
+	}
+	if err := m.WriteToFile(f.Name()); err != nil {
+		t.Errorf("failed to write to existing output file: %s", err)
+	}
+	if nfi, err := os.Stat(f.Name()); err != nil || nfi.Size() != fi.Size() {
+		t.Errorf("existing output file was expected to be truncated")
+	}
+
+	err = m.WriteToFile(filepath.Join(t.TempDir(), "nonexisting", "test.eml"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("WriteToFile to non-existing directory was expected to fail with %q, got: %v",
+			os.ErrNotExist, err)
+	}
 }
 
 // TestMsg_GetGenHeader will test the GetGenHeader method of the Msg
@@ -2471,6 +3345,38 @@ func TestMsg_GetGenHeader(t *testing.T) {
 	if sa[0] != "this is a test" {
 		t.Errorf("GetGenHeader on subject failed. Expected: %q, got: %q", "this is a test", sa[0])
 	}
+	sa[0] = "changed"
+	if m.GetGenHeader(HeaderSubject)[0] != "this is a test" {
+		t.Errorf("GetGenHeader on subject failed. Changes to the returned slice affect the Msg")
+	}
+	if m.GetGenHeader(HeaderOrganization) != nil {
+		t.Errorf("GetGenHeader on unset header failed. Expected nil slice")
+	}
+}
+
+// TestMsg_GetGenHeaderPreformatted tests the Msg.GetGenHeaderPreformatted method
+func TestMsg_GetGenHeaderPreformatted(t *testing.T) {
+	m := NewMsg()
+	m.SetGenHeaderPreformatted(HeaderContentLang, "de-DE")
+	if v, ok := m.GetGenHeaderPreformatted(HeaderContentLang); !ok || v != "de-DE" {
+		t.Errorf("GetGenHeaderPreformatted failed. Expected: %q, got: %q", "de-DE", v)
+	}
+	if _, ok := m.GetGenHeaderPreformatted(HeaderSubject); ok {
+		t.Errorf("GetGenHeaderPreformatted on unset header was expected to return false")
+	}
+}
+
+// TestMsg_GetAddrHeader_copy tests that the slice returned by Msg.GetAddrHeader is a copy
+func TestMsg_GetAddrHeader_copy(t *testing.T) {
+	m := NewMsg()
+	if err := m.To("rcpt1@example.com", "rcpt2@example.com"); err != nil {
+		t.Fatalf("failed to set TO addresses: %s", err)
+	}
+	al := m.GetTo()
+	al[0] = al[1]
+	if m.GetToString()[0] != "<rcpt1@example.com>" {
+		t.Errorf("GetAddrHeader failed. Changes to the returned slice affect the Msg")
+	}
 }
 
 // TestMsg_GetAddrHeader will test the Msg.GetAddrHeader method
@@ -2558,6 +3464,33 @@ func TestMsg_GetFrom(t *testing.T) {
 	}
 }
 
+// TestMsg_GetEnvelopeFrom will test the Msg.GetEnvelopeFrom and Msg.GetEnvelopeFromString methods
+func TestMsg_GetEnvelopeFrom(t *testing.T) {
+	m := NewMsg()
+	if err := m.From("sender@example.com"); err != nil {
+		t.Errorf("failed to set FROM address: %s", err)
+	}
+	if ef := m.GetEnvelopeFrom(); len(ef) != 0 {
+		t.Errorf("GetEnvelopeFrom failed. Expected empty slice, got: %v", ef)
+	}
+	if err := m.EnvelopeFrom("bounce+alice=example.com@bounces.example.com"); err != nil {
+		t.Errorf("failed to set envelope FROM address: %s", err)
+	}
+	ef := m.GetEnvelopeFrom()
+	if len(ef) != 1 || ef[0].Address != "bounce+alice=example.com@bounces.example.com" {
+		t.Errorf("GetEnvelopeFrom failed. Expected: %q, got: %v",
+			"bounce+alice=example.com@bounces.example.com", ef)
+	}
+	efs := m.GetEnvelopeFromString()
+	if len(efs) != 1 || efs[0] != "<bounce+alice=example.com@bounces.example.com>" {
+		t.Errorf("GetEnvelopeFromString failed. Expected: %q, got: %v",
+			"<bounce+alice=example.com@bounces.example.com>", efs)
+	}
+	if f := m.GetFromString(); len(f) != 1 || f[0] != "<sender@example.com>" {
+		t.Errorf("GetFromString failed. Expected: %q, got: %v", "<sender@example.com>", f)
+	}
+}
+
 // TestMsg_GetFromString will test the Msg.GetFromString method
 func TestMsg_GetFromString(t *testing.T) {
 	m := NewMsg()
@@ -2821,3 +3754,396 @@ func TestMsg_EmbedReadSeeker(t *testing.T) {
 		t.Errorf("EmbedReadSeeker() failed. Expected string: %q, got: %q", ts, wbuf.String())
 	}
 }
+
+// TestMsg_WithUserAgent tests the customization of the default User-Agent and X-Mailer headers
+func TestMsg_WithUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []MsgOption
+		set  string
+		want string
+	}{
+		{"default", nil, "", "go-mail v" + VERSION},
+		{"custom", []MsgOption{WithUserAgent("Acme Mailer 1.0")}, "", "Acme Mailer 1.0"},
+		{"SetUserAgent precedes", []MsgOption{WithUserAgent("Acme Mailer 1.0")}, "Other", "Other"},
+		{"suppressed", []MsgOption{WithUserAgent("Acme Mailer 1.0"), WithNoDefaultUserAgent()}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg(tt.opts...)
+			if tt.set != "" {
+				m.SetUserAgent(tt.set)
+			}
+			m.SetBodyString(TypeTextPlain, "Test")
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			for _, h := range []Header{HeaderUserAgent, HeaderXMailer} {
+				if tt.want == "" {
+					if strings.Contains(buf.String(), string(h)+":") {
+						t.Errorf("%s header was expected to be suppressed: %s", h, buf.String())
+					}
+					continue
+				}
+				if !strings.Contains(buf.String(), string(h)+": "+tt.want) {
+					t.Errorf("%s header was expected to be %q: %s", h, tt.want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+// TestMsg_WithNowFunc tests that the Date header and the Message-ID use the clock of the
+// WithNowFunc option
+func TestMsg_WithNowFunc(t *testing.T) {
+	now := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+	m := NewMsg(WithNowFunc(func() time.Time { return now }))
+	m.SetDate()
+	if d := m.GetGenHeader(HeaderDate); len(d) != 1 || d[0] != "Tue, 14 Mar 2023 15:09:26 +0000" {
+		t.Errorf("SetDate with WithNowFunc failed. Got: %v", d)
+	}
+	m.SetMessageID()
+	mid := m.GetGenHeader(HeaderMessageID)
+	if len(mid) != 1 || !strings.HasPrefix(mid[0], fmt.Sprintf("<%d.", now.Unix())) {
+		t.Errorf("SetMessageID with WithNowFunc failed. Got: %v", mid)
+	}
+	if m := NewMsg(WithNowFunc(nil)); m.nowFunc != nil {
+		t.Errorf("WithNowFunc with nil function was expected to be ignored")
+	}
+}
+
+// TestMsg_SetDateWithLocation tests the WithLocation option and the Msg.SetDateWithLocation method
+func TestMsg_SetDateWithLocation(t *testing.T) {
+	now := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+	loc := time.FixedZone("EST", -5*60*60)
+	m := NewMsg()
+	m.SetDateWithLocation(now, loc)
+	if d := m.GetGenHeader(HeaderDate); len(d) != 1 || d[0] != "Tue, 14 Mar 2023 10:09:26 -0500" {
+		t.Errorf("SetDateWithLocation failed. Got: %v", d)
+	}
+	m.SetDateWithLocation(now, nil)
+	if d := m.GetGenHeader(HeaderDate); len(d) != 1 || d[0] != "Tue, 14 Mar 2023 15:09:26 +0000" {
+		t.Errorf("SetDateWithLocation with nil location failed. Got: %v", d)
+	}
+	m = NewMsg(WithLocation(loc), WithNowFunc(func() time.Time { return now }))
+	m.SetDate()
+	if d := m.GetGenHeader(HeaderDate); len(d) != 1 || d[0] != "Tue, 14 Mar 2023 10:09:26 -0500" {
+		t.Errorf("SetDate with WithLocation failed. Got: %v", d)
+	}
+	m = NewMsg(WithLocation(loc), WithFixedDate(now))
+	m.addDefaultHeader()
+	if d := m.GetGenHeader(HeaderDate); len(d) != 1 || d[0] != "Tue, 14 Mar 2023 10:09:26 -0500" {
+		t.Errorf("WithFixedDate with WithLocation failed. Got: %v", d)
+	}
+}
+
+// TestMsg_deterministicOutput tests that WithFixedBoundary, WithFixedDate and
+// WithNoDefaultUserAgent produce byte-identical output for identical messages
+func TestMsg_deterministicOutput(t *testing.T) {
+	d := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	render := func() string {
+		m := NewMsg(WithFixedBoundary(), WithFixedDate(d), WithNoDefaultUserAgent())
+		if err := m.From("sender@example.com"); err != nil {
+			t.Fatalf("failed to set FROM address: %s", err)
+		}
+		if err := m.To("rcpt@example.com"); err != nil {
+			t.Fatalf("failed to set TO address: %s", err)
+		}
+		m.SetMessageIDWithValue("fixed@example.com")
+		m.SetGenHeaderPreformatted("X-Preformatted-1", "one")
+		m.SetGenHeaderPreformatted("X-Preformatted-2", "two")
+		m.SetBodyString(TypeTextPlain, "plain body")
+		m.AddAlternativeString(TypeTextHTML, "<p>html body</p>")
+		m.AttachReader("file.txt", strings.NewReader("attachment"))
+		m.EmbedReader("image.png", strings.NewReader("embed"))
+		buf := bytes.Buffer{}
+		if _, err := m.WriteTo(&buf); err != nil {
+			t.Fatalf("failed to write message: %s", err)
+		}
+		return buf.String()
+	}
+
+	o := render()
+	for i := 0; i < 5; i++ {
+		if r := render(); r != o {
+			t.Fatalf("deterministic output mode failed. Renders differ:\n%s\n---\n%s", o, r)
+		}
+	}
+	for _, s := range []string{
+		"Date: Mon, 02 Jan 2023 03:04:05 +0000\r\n", "boundary=" + FixedBoundary + "\r\n",
+		"boundary=" + FixedBoundary + "_1\r\n", "boundary=" + FixedBoundary + "_2\r\n",
+	} {
+		if !strings.Contains(o, s) {
+			t.Errorf("deterministic output mode failed. Expected %q in output: %s", s, o)
+		}
+	}
+	if strings.Contains(o, string(HeaderUserAgent)) || strings.Contains(o, string(HeaderXMailer)) {
+		t.Errorf("WithNoDefaultUserAgent failed. Output contains default user agent: %s", o)
+	}
+}
+
+// TestMsg_RemoveHeader tests the Msg.RemoveHeader and Msg.RemoveAddrHeader methods
+func TestMsg_RemoveHeader(t *testing.T) {
+	m := NewMsg()
+	m.Subject("test subject")
+	m.SetGenHeaderPreformatted(HeaderContentLang, "de-DE")
+	if err := m.To("rcpt@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	if err := m.Cc("cc@example.com"); err != nil {
+		t.Fatalf("failed to set CC address: %s", err)
+	}
+
+	m.RemoveHeader(HeaderSubject)
+	m.RemoveHeader(HeaderContentLang)
+	m.RemoveAddrHeader(HeaderCc)
+	if len(m.GetGenHeader(HeaderSubject)) != 0 {
+		t.Errorf("RemoveHeader failed. Subject header is still set")
+	}
+	if _, ok := m.GetGenHeaderPreformatted(HeaderContentLang); ok {
+		t.Errorf("RemoveHeader failed. Preformatted Content-Language header is still set")
+	}
+	if len(m.GetCc()) != 0 {
+		t.Errorf("RemoveAddrHeader failed. Cc header is still set")
+	}
+	if len(m.GetTo()) != 1 {
+		t.Errorf("RemoveAddrHeader failed. To header was not expected to be removed")
+	}
+}
+
+// TestMsg_Reset tests that Msg.Reset does not leak headers, recipients or errors into the
+// next iteration of a mail-merge loop
+func TestMsg_Reset(t *testing.T) {
+	m := NewMsg(WithCharset(CharsetISO88591))
+	for i, r := range []string{"rcpt1@example.com", "rcpt2@example.com"} {
+		m.Reset()
+		if err := m.To(r); err != nil {
+			t.Fatalf("failed to set TO address: %s", err)
+		}
+		if i == 0 {
+			if err := m.Bcc("bcc@example.com"); err != nil {
+				t.Fatalf("failed to set BCC address: %s", err)
+			}
+			m.SetGenHeaderPreformatted(HeaderContentLang, "de-DE")
+			m.AttachReader("file.txt", strings.NewReader("attachment"))
+			m.sendError = &SendError{Reason: ErrSMTPData}
+		}
+		m.SetBodyString(TypeTextPlain, "body")
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		t.Fatalf("failed to get recipients: %s", err)
+	}
+	if len(rl) != 1 || rl[0] != "rcpt2@example.com" {
+		t.Errorf("Reset failed. Expected only the recipient of the current iteration, got: %v", rl)
+	}
+	if _, ok := m.GetGenHeaderPreformatted(HeaderContentLang); ok {
+		t.Errorf("Reset failed. Preformatted header leaked into next iteration")
+	}
+	if len(m.GetAttachments()) != 0 || m.HasSendError() {
+		t.Errorf("Reset failed. Attachments or send error leaked into next iteration")
+	}
+	if m.Charset() != string(CharsetISO88591) {
+		t.Errorf("Reset failed. Charset was not expected to be reset")
+	}
+}
+
+// TestMsg_Clone tests that Msg.Clone returns an independent deep copy of the Msg
+func TestMsg_Clone(t *testing.T) {
+	m := NewMsg(WithFixedBoundary())
+	if err := m.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set FROM address: %s", err)
+	}
+	if err := m.To("rcpt@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	m.Subject("base subject")
+	m.SetGenHeaderPreformatted(HeaderContentLang, "en")
+	m.SetBodyString(TypeTextPlain, "base body")
+	m.AttachReader("file.txt", strings.NewReader("attachment"))
+
+	c := m.Clone()
+	if err := c.To("clone@example.com"); err != nil {
+		t.Fatalf("failed to set TO address on clone: %s", err)
+	}
+	c.GetFrom()[0].Name = "changed"
+	c.Subject("clone subject")
+	c.SetGenHeaderPreformatted(HeaderContentLang, "de")
+	c.GetParts()[0].SetContent("clone body")
+	c.GetAttachments()[0].Name = "clone.txt"
+	c.GetAttachments()[0].setHeader(HeaderContentDescription, "clone")
+
+	if m.GetToString()[0] != "<rcpt@example.com>" || m.addrHeader[HeaderFrom][0].Name != "" {
+		t.Errorf("Clone failed. Address headers of the original were changed")
+	}
+	if m.GetGenHeader(HeaderSubject)[0] != "base subject" || m.preformHeader[HeaderContentLang] != "en" {
+		t.Errorf("Clone failed. Generic headers of the original were changed")
+	}
+	if b, _ := m.GetParts()[0].GetContent(); string(b) != "base body" {
+		t.Errorf("Clone failed. Body of the original was changed to: %s", b)
+	}
+	if f := m.GetAttachments()[0]; f.Name != "file.txt" || len(f.Header) != 0 {
+		t.Errorf("Clone failed. Attachment of the original was changed")
+	}
+
+	// Clones of the same Msg can be rendered concurrently
+	var wg sync.WaitGroup
+	ol := make([]string, 10)
+	for i := range ol {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			buf := bytes.Buffer{}
+			if _, err := m.Clone().WriteTo(&buf); err != nil {
+				t.Errorf("failed to write clone: %s", err)
+			}
+			ol[i] = buf.String()
+		}(i)
+	}
+	wg.Wait()
+	for _, o := range ol {
+		if !strings.Contains(o, "YXR0YWNobWVudA==") {
+			t.Errorf("Clone failed. Concurrently rendered clone is missing the attachment: %s", o)
+		}
+	}
+}
+
+// TestMsg_UnsetAllAttachmentsAndEmbeds tests the Msg.UnsetAllAttachments and Msg.UnsetAllEmbeds methods
+func TestMsg_UnsetAllAttachmentsAndEmbeds(t *testing.T) {
+	m := NewMsg()
+	m.AttachFile("README.md")
+	m.EmbedFile("doc.go")
+	m.UnsetAllAttachments()
+	if len(m.GetAttachments()) != 0 {
+		t.Errorf("UnsetAllAttachments() failed. Expected no attachments, got: %d", len(m.GetAttachments()))
+	}
+	if len(m.GetEmbeds()) != 1 {
+		t.Errorf("UnsetAllAttachments() failed. Expected embeds to be kept, got: %d", len(m.GetEmbeds()))
+	}
+	m.UnsetAllEmbeds()
+	if len(m.GetEmbeds()) != 0 {
+		t.Errorf("UnsetAllEmbeds() failed. Expected no embeds, got: %d", len(m.GetEmbeds()))
+	}
+}
+
+// TestMsg_Size tests the Msg.Size method
+func TestMsg_Size(t *testing.T) {
+	m := NewMsg(WithFixedBoundary(), WithFixedDate(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)))
+	if err := m.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set FROM address: %s", err)
+	}
+	if err := m.To("rcpt@example.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	m.SetMessageIDWithValue("size@example.com")
+	m.SetBodyString(TypeTextPlain, "This is a test body")
+	m.AttachReader("file.bin", bytes.NewReader(bytes.Repeat([]byte{0x00, 0xff}, 4096)))
+	s, err := m.Size()
+	if err != nil {
+		t.Fatalf("Size failed: %s", err)
+	}
+	if _, ok := m.genHeader[HeaderDate]; ok {
+		t.Errorf("Size failed. Default headers were expected not to be set on the Msg")
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if s != int64(buf.Len()) {
+		t.Errorf("Size failed. Expected: %d, got: %d", buf.Len(), s)
+	}
+	if s < 8192*4/3 {
+		t.Errorf("Size failed. Expected size to include the Base64 expansion, got: %d", s)
+	}
+}
+
+// TestMsg_Size_estimate tests that the computed size matches the rendered Msg for different
+// encodings, content lengths and file sources
+func TestMsg_Size_estimate(t *testing.T) {
+	tests := []struct {
+		name string
+		f    func(*Msg)
+	}{
+		{"QP body with long lines", func(m *Msg) {
+			m.SetBodyString(TypeTextPlain, strings.Repeat("Grüße aus Köln = ", 100))
+		}},
+		{"Base64 body", func(m *Msg) {
+			m.SetEncoding(EncodingB64)
+			m.SetBodyString(TypeTextPlain, strings.Repeat("a", 57))
+		}},
+		{"8bit body with alternative", func(m *Msg) {
+			m.SetEncoding(NoEncoding)
+			m.SetBodyString(TypeTextPlain, "Grüße")
+			m.AddAlternativeString(TypeTextHTML, "<p>Grüße</p>")
+		}},
+		{"attachments of different lengths", func(m *Msg) {
+			for _, n := range []int{0, 1, 2, 3, 56, 57, 58, 114, 4096} {
+				m.AttachReader(fmt.Sprintf("file%d.bin", n), bytes.NewReader(bytes.Repeat([]byte{0xff}, n)))
+			}
+		}},
+		{"file, io.ReadSeeker and embed", func(m *Msg) {
+			m.AttachFile("README.md")
+			m.AttachReadSeeker("seeker.bin", bytes.NewReader(bytes.Repeat([]byte{0x01}, 1000)))
+			m.EmbedReader("logo.png", bytes.NewReader(bytes.Repeat([]byte{0x02}, 300)))
+			m.AddAlternativeString(TypeTextHTML, `<img src="cid:logo.png">`)
+		}},
+		{"attachment without known size", func(m *Msg) {
+			if err := m.AttachTextTemplate("template.txt", ttpl.Must(ttpl.New("t").Parse("{{.}}")),
+				strings.Repeat("x", 200)); err != nil {
+				t.Fatalf("failed to attach template: %s", err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMsg(t)
+			WithFixedBoundary()(m)
+			m.SetDateWithLocation(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), time.UTC)
+			m.SetMessageIDWithValue("size@example.com")
+			tt.f(m)
+			s, err := m.Size()
+			if err != nil {
+				t.Fatalf("Size failed: %s", err)
+			}
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			if s != int64(buf.Len()) {
+				t.Errorf("Size failed. Expected: %d, got: %d", buf.Len(), s)
+			}
+		})
+	}
+}
+
+// TestMsg_Size_noRead tests that the content of files with a known length is not read
+func TestMsg_Size_noRead(t *testing.T) {
+	m := testMsg(t)
+	m.SetDateWithLocation(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), time.UTC)
+	m.SetMessageIDWithValue("size@example.com")
+	reads := 0
+	m.attachments = append(m.attachments, &File{
+		Name: "file.bin", ContentType: TypeAppOctetStream, Header: make(map[string][]string),
+		Writer: func(w io.Writer) (int64, error) {
+			reads++
+			n, err := w.Write(bytes.Repeat([]byte{0xff}, 1000))
+			return int64(n), err
+		},
+		size: func() (int64, error) { return 1000, nil },
+	})
+	s, err := m.Size()
+	if err != nil {
+		t.Fatalf("Size failed: %s", err)
+	}
+	if reads != 0 {
+		t.Errorf("Size was not expected to read the content of the file, got %d reads", reads)
+	}
+	n, err := m.WriteTo(io.Discard)
+	if err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if n != s {
+		t.Errorf("Size failed. Expected: %d, got: %d", n, s)
+	}
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MessageSizeError is returned if the Msg exceeds the size limit that was set via
+// WithMaxMessageSize. It matches ErrMessageTooLarge with errors.Is
+type MessageSizeError struct {
+	// Size is the size of the rendered Msg in bytes
+	Size int64
+	// Limit is the size limit of the Msg in bytes
+	Limit int64
+	// Attachments holds the names of the largest attachments and embeds, without which the
+	// Msg would not exceed the size limit
+	Attachments []string
+}
+
+// WithMaxMessageSize sets a size limit in bytes for the rendered Msg, including the headers
+// and the expansion by the transfer encoding. If the Msg exceeds the limit, WriteTo and the
+// Client fail with a MessageSizeError before any content is written or sent. The size is
+// computed with Msg.Size, which only renders the Msg if it uses middlewares, S/MIME, PGP/MIME
+// or DKIM
+func WithMaxMessageSize(l int64) MsgOption {
+	return func(m *Msg) {
+		m.maxSize = l
+	}
+}
+
+// Error implements the error interface for the MessageSizeError type
+func (e *MessageSizeError) Error() string {
+	em := fmt.Sprintf("message size of %d bytes exceeds the limit of %d bytes", e.Size, e.Limit)
+	if len(e.Attachments) > 0 {
+		em += ", largest attachments: " + strings.Join(e.Attachments, ", ")
+	}
+	return em
+}
+
+// Is implements the errors.Is functionality and matches the MessageSizeError against
+// ErrMessageTooLarge
+func (e *MessageSizeError) Is(et error) bool {
+	return et == ErrMessageTooLarge
+}
+
+// checkSize returns a MessageSizeError if the Msg exceeds its size limit
+func (m *Msg) checkSize() error {
+	if m.maxSize <= 0 || m.raw != nil {
+		return nil
+	}
+	n, err := m.Size()
+	if err != nil {
+		return err
+	}
+	if n <= m.maxSize {
+		return nil
+	}
+	return &MessageSizeError{Size: n, Limit: m.maxSize, Attachments: m.largestFiles(n - m.maxSize)}
+}
+
+// largestFiles returns the names of the largest attachments and embeds of the Msg, whose
+// Base64 encoded size adds up to at least the given number of bytes
+func (m *Msg) largestFiles(x int64) []string {
+	type fileSize struct {
+		name string
+		size int64
+	}
+	var fl []fileSize
+	for _, f := range append(append([]*File{}, m.attachments...), m.embeds...) {
+		if f.Writer == nil {
+			continue
+		}
+		var n int64
+		var err error
+		if f.size != nil {
+			n, err = f.size()
+		}
+		if f.size == nil || err != nil {
+			cw := &countWriter{w: io.Discard}
+			if _, err := f.Writer(cw); err != nil {
+				continue
+			}
+			n = cw.n
+		}
+		es, _ := bodySize(n, EncodingB64)
+		fl = append(fl, fileSize{name: f.Name, size: es})
+	}
+	sort.SliceStable(fl, func(i, j int) bool { return fl[i].size > fl[j].size })
+
+	var nl []string
+	for _, f := range fl {
+		if x <= 0 {
+			break
+		}
+		nl = append(nl, f.name)
+		x -= f.size
+	}
+	return nl
+}
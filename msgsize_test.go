@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestMsg_WithMaxMessageSize tests that the Msg fails to render if it exceeds its size limit
+func TestMsg_WithMaxMessageSize(t *testing.T) {
+	m := NewMsg(WithMaxMessageSize(4096))
+	m.SetBodyString(TypeTextPlain, "Test")
+	m.AttachReader("small.txt", strings.NewReader("small"))
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo within the size limit failed: %s", err)
+	}
+
+	m.AttachReader("large.bin", bytes.NewReader(bytes.Repeat([]byte{0xff}, 8192)))
+	m.AttachReader("medium.bin", bytes.NewReader(bytes.Repeat([]byte{0xff}, 2048)))
+	buf.Reset()
+	_, err := m.WriteTo(&buf)
+	var se *MessageSizeError
+	if !errors.As(err, &se) {
+		t.Fatalf("WriteTo was expected to fail with MessageSizeError, got: %v", err)
+	}
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("MessageSizeError was expected to match ErrMessageTooLarge")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("WriteTo was not expected to write content, got: %d bytes", buf.Len())
+	}
+	if se.Limit != 4096 || se.Size <= 4096 {
+		t.Errorf("unexpected MessageSizeError sizes. Size: %d, limit: %d", se.Size, se.Limit)
+	}
+	if len(se.Attachments) != 1 || se.Attachments[0] != "large.bin" {
+		t.Errorf("MessageSizeError was expected to list large.bin, got: %v", se.Attachments)
+	}
+	if n, err := m.Size(); err != nil || n != se.Size {
+		t.Errorf("Size was expected to ignore the size limit. Got: %d, %v", n, err)
+	}
+}
+
+// TestClient_Send_MaxMessageSize tests that the Client does not send a Msg that exceeds its
+// size limit
+func TestClient_Send_MaxMessageSize(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	m := testMsg(t)
+	WithMaxMessageSize(512)(m)
+	m.AttachReader("large.bin", bytes.NewReader(bytes.Repeat([]byte{0xff}, 1024)))
+	err := testClient(t, s).DialAndSendWithContext(context.Background(), m)
+	var se *SendError
+	if !errors.As(err, &se) || se.Reason != ErrSizeLimit || se.IsTemp() {
+		t.Fatalf("DialAndSend was expected to fail with ErrSizeLimit, got: %v", err)
+	}
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("SendError was expected to match ErrMessageTooLarge")
+	}
+	for _, c := range s.Commands() {
+		if strings.HasPrefix(c, "MAIL FROM") {
+			t.Errorf("Client was not expected to start the delivery, got: %s", c)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrEmptyBody is returned by Msg.Validate if the Msg has neither body content nor files
+	ErrEmptyBody = errors.New("message body is empty")
+
+	// ErrLineTooLong is returned by Msg.Validate if the rendered Msg contains lines that are
+	// longer than the 998 octets permitted by RFC 5322
+	ErrLineTooLong = errors.New("message contains lines longer than 998 octets")
+)
+
+// ValidationErrors is the list of errors that Msg.Validate found. The single errors can be
+// matched with errors.Is and errors.As (requires Go 1.20 or later)
+type ValidationErrors []error
+
+// Error implements the error interface for the ValidationErrors type
+func (e ValidationErrors) Error() string {
+	el := make([]string, len(e))
+	for i := range e {
+		el[i] = e[i].Error()
+	}
+	return "message validation failed: " + strings.Join(el, ", ")
+}
+
+// Unwrap returns the list of errors that Msg.Validate found
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// Validate performs a pre-flight check of the Msg, so that callers can fail fast before
+// connecting to the server. It checks for a missing FROM address, missing recipients, an empty
+// body, invalid header fields, lines that exceed the length limit of RFC 5322 and the size limit
+// of WithMaxMessageSize. All problems that are found are returned as ValidationErrors. Since
+// the line length is checked on the rendered Msg, the content of all parts and files is read
+func (m *Msg) Validate() error {
+	var el ValidationErrors
+	if _, err := m.GetSender(false); err != nil {
+		el = append(el, err)
+	}
+	if _, err := m.GetRecipients(); err != nil {
+		el = append(el, err)
+	}
+	if m.raw == nil && m.hasEmptyBody() {
+		el = append(el, ErrEmptyBody)
+	}
+	if m.headerErr != nil {
+		return append(el, m.headerErr)
+	}
+
+	c := m.Clone()
+	c.maxSize = 0
+	d := &encodingDetector{}
+	n, err := c.WriteTo(d)
+	switch {
+	case errors.Is(err, ErrLineTooLong):
+		el = append(el, err)
+	case err != nil:
+		el = append(el, fmt.Errorf("failed to render message: %w", err))
+	case d.long:
+		el = append(el, ErrLineTooLong)
+	}
+	if err == nil && m.maxSize > 0 && n > m.maxSize {
+		el = append(el, &MessageSizeError{Size: n, Limit: m.maxSize, Attachments: m.largestFiles(n - m.maxSize)})
+	}
+
+	if len(el) > 0 {
+		return el
+	}
+	return nil
+}
+
+// hasEmptyBody returns true if the Msg has neither body parts with content nor files
+func (m *Msg) hasEmptyBody() bool {
+	if len(m.attachments) > 0 || len(m.embeds) > 0 || len(m.digest) > 0 {
+		return false
+	}
+	for _, p := range m.parts {
+		if p.del || p.w == nil {
+			continue
+		}
+		if c, err := p.GetContent(); err != nil || len(c) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestMsg_Validate tests the Msg.Validate method
+func TestMsg_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		body string
+		msg  func(*Msg)
+		want []error
+	}{
+		{"valid", "sender@example.com", "rcpt@example.com", "Test", nil, nil},
+		{"no from", "", "rcpt@example.com", "Test", nil, []error{ErrNoFromAddress}},
+		{"no recipients", "sender@example.com", "", "Test", nil, []error{ErrNoRcptAddresses}},
+		{"empty body", "sender@example.com", "rcpt@example.com", "", nil, []error{ErrEmptyBody}},
+		{"attachment only", "sender@example.com", "rcpt@example.com", "", func(m *Msg) {
+			m.AttachReader("data.txt", strings.NewReader("data"))
+		}, nil},
+		{"long line", "sender@example.com", "rcpt@example.com", "", func(m *Msg) {
+			m.SetBodyString(TypeTextPlain, "Test")
+			m.SetGenHeaderPreformatted("X-Test", strings.Repeat("x", 1200))
+		}, []error{ErrLineTooLong}},
+		{"invalid header", "sender@example.com", "rcpt@example.com", "Test", func(m *Msg) {
+			m.SetGenHeaderPreformatted("X-Test", "foo\r\nBcc: injected@example.com")
+		}, []error{&HeaderError{}}},
+		{"folded preformatted header", "sender@example.com", "rcpt@example.com", "Test", func(m *Msg) {
+			m.SetGenHeaderPreformatted("X-Test", "foo\r\n\tbar")
+		}, nil},
+		{"size limit", "sender@example.com", "rcpt@example.com", "Test", func(m *Msg) {
+			WithMaxMessageSize(100)(m)
+		}, []error{ErrMessageTooLarge}},
+		{"everything missing", "", "", "", nil, []error{ErrNoFromAddress, ErrNoRcptAddresses, ErrEmptyBody}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if tt.from != "" {
+				if err := m.From(tt.from); err != nil {
+					t.Fatalf("failed to set FROM address: %s", err)
+				}
+			}
+			if tt.to != "" {
+				if err := m.To(tt.to); err != nil {
+					t.Fatalf("failed to set TO address: %s", err)
+				}
+			}
+			if tt.body != "" {
+				m.SetBodyString(TypeTextPlain, tt.body)
+			}
+			if tt.msg != nil {
+				tt.msg(m)
+			}
+			err := m.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("Validate failed: %s", err)
+				}
+				return
+			}
+			var ve ValidationErrors
+			if !errors.As(err, &ve) {
+				t.Fatalf("Validate was expected to return ValidationErrors, got: %v", err)
+			}
+			if len(ve) != len(tt.want) {
+				t.Fatalf("Validate failed. Expected %d errors, got: %s", len(tt.want), err)
+			}
+			for i, we := range tt.want {
+				var he *HeaderError
+				if errors.As(we, &he) {
+					if !errors.As(ve[i], &he) {
+						t.Errorf("Validate failed. Expected HeaderError, got: %s", ve[i])
+					}
+					continue
+				}
+				if !errors.Is(ve[i], we) {
+					t.Errorf("Validate failed. Expected %q, got: %q", we, ve[i])
+				}
+			}
+		})
+	}
+}
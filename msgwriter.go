@@ -13,9 +13,9 @@ import (
 	"mime/multipart"
 	"mime/quotedprintable"
 	"net/textproto"
-	"path/filepath"
 	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // MaxHeaderLength defines the maximum line length for a mail header
@@ -33,16 +33,34 @@ const SingleNewLine = "\r\n"
 // indicate a new segement of the mail
 const DoubleNewLine = "\r\n\r\n"
 
+// MaxParamLength defines the maximum length of a MIME parameter value before it is split
+// into RFC 2231 continuations
+const MaxParamLength = 60
+
+var (
+	// foldPrefix is the line break and whitespace that is used to fold a header field
+	foldPrefix = []byte(SingleNewLine + " ")
+
+	// trailingFold is a whitespace followed by a line break that is removed from header fields
+	trailingFold = []byte(" " + SingleNewLine)
+)
+
 // msgWriter handles the I/O to the io.WriteCloser of the SMTP client
 type msgWriter struct {
+	bcc bool
 	c   Charset
+	cw  countWriter
 	d   int8
 	en  mime.WordEncoder
 	err error
+	lb  Base64LineBreaker
 	mpw [3]*multipart.Writer
 	n   int64
 	pw  io.Writer
 	w   io.Writer
+	// size indicates that the msgWriter only computes the size of the Msg, so that the
+	// content is not encoded (see Msg.Size)
+	size bool
 }
 
 // Write implements the io.Writer interface for msgWriter
@@ -61,51 +79,36 @@ func (mw *msgWriter) Write(p []byte) (int, error) {
 func (mw *msgWriter) writeMsg(m *Msg) {
 	m.addDefaultHeader()
 	m.checkUserAgent()
-	mw.writeGenHeader(m)
-	mw.writePreformattedGenHeader(m)
-
-	// Set the FROM header (or envelope FROM if FROM is empty)
-	hf := true
-	f, ok := m.addrHeader[HeaderFrom]
-	if !ok || len(f) == 0 {
-		f, ok = m.addrHeader[HeaderEnvelopeFrom]
-		if !ok || len(f) == 0 {
-			hf = false
-		}
-	}
-	if hf {
-		mw.writeHeader(Header(HeaderFrom), f[0].String())
-	}
-
-	// Set the rest of the address headers
-	for _, t := range []AddrHeader{HeaderTo, HeaderCc} {
-		if al, ok := m.addrHeader[t]; ok {
-			var v []string
-			for _, a := range al {
-				v = append(v, a.String())
-			}
-			mw.writeHeader(Header(t), v...)
-		}
-	}
+	mw.writeHeaders(m)
 
+	b := m.safeBoundary()
 	if m.hasMixed() {
-		mw.startMP("mixed", m.boundary)
+		mt := MIMEMixed
+		if m.reportType != "" {
+			mt = MIMEType(fmt.Sprintf("report; report-type=%s", m.reportType))
+		}
+		mw.startMP(mt, b)
 		mw.writeString(DoubleNewLine)
 	}
 	if m.hasRelated() {
-		mw.startMP("related", m.boundary)
+		mt, err := m.relatedType()
+		if err != nil {
+			mw.err = err
+			return
+		}
+		mw.startMP(mt, b)
 		mw.writeString(DoubleNewLine)
 	}
 	if m.hasAlt() {
-		mw.startMP(MIMEAlternative, m.boundary)
+		mw.startMP(MIMEAlternative, b)
 		mw.writeString(DoubleNewLine)
 	}
 	if m.hasPGPType() {
 		switch m.pgptype {
 		case PGPEncrypt:
-			mw.startMP(`encrypted; protocol="application/pgp-encrypted"`, m.boundary)
+			mw.startMP(`encrypted; protocol="application/pgp-encrypted"`, b)
 		case PGPSignature:
-			mw.startMP(`signed; protocol="application/pgp-signature";`, m.boundary)
+			mw.startMP(`signed; protocol="application/pgp-signature";`, b)
 		}
 		mw.writeString(DoubleNewLine)
 	}
@@ -126,6 +129,10 @@ func (mw *msgWriter) writeMsg(m *Msg) {
 		mw.stopMP()
 	}
 
+	if m.hasDigest() {
+		mw.writeDigest(m, b)
+	}
+
 	// Add attachments
 	mw.addFiles(m.attachments, true)
 	if m.hasMixed() {
@@ -133,22 +140,72 @@ func (mw *msgWriter) writeMsg(m *Msg) {
 	}
 }
 
-// writeGenHeader writes out all generic headers to the msgWriter
-func (mw *msgWriter) writeGenHeader(m *Msg) {
-	gk := make([]string, 0, len(m.genHeader))
-	for k := range m.genHeader {
-		gk = append(gk, string(k))
+// headerField is a header field of the Msg that is written by the msgWriter
+type headerField struct {
+	name Header
+	vals []string
+	// pf indicates a preformatted header field that is written verbatim
+	pf bool
+}
+
+// writeHeaders writes out the generic, the preformatted and the address headers of the Msg in
+// a stable order, which is the canonical order unless overridden via WithHeaderOrder
+func (mw *msgWriter) writeHeaders(m *Msg) {
+	hl := make([]headerField, 0, len(m.genHeader)+len(m.preformHeader)+4)
+	for h, vl := range m.genHeader {
+		hl = append(hl, headerField{name: h, vals: vl})
 	}
-	sort.Strings(gk)
-	for _, k := range gk {
-		mw.writeHeader(Header(k), m.genHeader[Header(k)]...)
+	for h, v := range m.preformHeader {
+		hl = append(hl, headerField{name: h, vals: []string{v}, pf: true})
+	}
+
+	// Set the FROM header (or envelope FROM if FROM is empty)
+	f, ok := m.addrHeader[HeaderFrom]
+	if !ok || len(f) == 0 {
+		f = m.addrHeader[HeaderEnvelopeFrom]
+	}
+	if len(f) > 0 {
+		hl = append(hl, headerField{name: Header(HeaderFrom), vals: []string{f[0].String()}})
+	}
+
+	// Set the rest of the address headers. The Bcc header is only rendered on request
+	// (e.g. for sendmail -t, which removes it before delivery)
+	ahl := []AddrHeader{HeaderReplyTo, HeaderTo, HeaderCc}
+	if mw.bcc {
+		ahl = append(ahl, HeaderBcc)
+	}
+	for _, t := range ahl {
+		al, ok := m.addrHeader[t]
+		gl := m.addrGroups[t]
+		if (!ok || len(al) == 0) && len(gl) == 0 {
+			continue
+		}
+		var v []string
+		for _, a := range al {
+			v = append(v, a.String())
+		}
+		for _, g := range gl {
+			v = append(v, g.String())
+		}
+		hl = append(hl, headerField{name: Header(t), vals: v})
 	}
-}
 
-// writePreformatedHeader writes out all preformated generic headers to the msgWriter
-func (mw *msgWriter) writePreformattedGenHeader(m *Msg) {
-	for k, v := range m.preformHeader {
-		mw.writeString(fmt.Sprintf("%s: %s%s", k, v, SingleNewLine))
+	sort.SliceStable(hl, func(i, j int) bool {
+		ri, rj := headerRank(hl[i].name, m.headerOrder), headerRank(hl[j].name, m.headerOrder)
+		if ri != rj {
+			return ri < rj
+		}
+		if hl[i].name != hl[j].name {
+			return hl[i].name < hl[j].name
+		}
+		return !hl[i].pf && hl[j].pf
+	})
+	for _, h := range hl {
+		if h.pf {
+			mw.writeString(fmt.Sprintf("%s: %s%s", h.name, h.vals[0], SingleNewLine))
+			continue
+		}
+		mw.writeHeader(h.name, h.vals...)
 	}
 }
 
@@ -156,6 +213,10 @@ func (mw *msgWriter) writePreformattedGenHeader(m *Msg) {
 func (mw *msgWriter) startMP(mt MIMEType, b string) {
 	mp := multipart.NewWriter(mw)
 	if b != "" {
+		// Nested multiparts must not share the same boundary
+		if mw.d > 0 {
+			b = fmt.Sprintf("%s_%d", b, mw.d)
+		}
 		mw.err = mp.SetBoundary(b)
 	}
 
@@ -182,23 +243,30 @@ func (mw *msgWriter) stopMP() {
 // addFiles adds the attachments/embeds file content to the mail body
 func (mw *msgWriter) addFiles(fl []*File, a bool) {
 	for _, f := range fl {
+		if f.password != "" {
+			f = f.passwordProtected()
+		}
+		if err := f.setChecksumHeaders(); err != nil {
+			mw.err = err
+			return
+		}
 		e := EncodingB64
 		if _, ok := f.getHeader(HeaderContentType); !ok {
-			mt := mime.TypeByExtension(filepath.Ext(f.Name))
-			if mt == "" {
-				mt = "application/octet-stream"
-			}
-			if f.ContentType != "" {
-				mt = string(f.ContentType)
-			}
-			f.setHeader(HeaderContentType, fmt.Sprintf(`%s; name="%s"`, mt,
-				mw.en.Encode(mw.c.String(), f.Name)))
+			mt := f.mediaType()
+			f.setHeader(HeaderContentType, foldParam(HeaderContentType, mt, encodeParam("name", f.Name)))
 		}
 
+		if f.Enc != "" {
+			e = f.Enc
+		}
+		if e == EncodingAuto {
+			e = autoEncoding(f.Writer)
+		}
+		if (e == NoEncoding || e == Encoding7bit) && f.ContentType != TypeMessageRFC822 &&
+			exceedsLineLength(f.Writer) {
+			e = EncodingB64
+		}
 		if _, ok := f.getHeader(HeaderContentTransferEnc); !ok {
-			if f.Enc != "" {
-				e = f.Enc
-			}
 			f.setHeader(HeaderContentTransferEnc, string(e))
 		}
 
@@ -209,28 +277,47 @@ func (mw *msgWriter) addFiles(fl []*File, a bool) {
 		}
 
 		if _, ok := f.getHeader(HeaderContentDisposition); !ok {
-			d := "inline"
+			d := DispositionInline
 			if a {
-				d = "attachment"
+				d = DispositionAttachment
+			}
+			if f.Disposition != "" {
+				d = f.Disposition
 			}
-			f.setHeader(HeaderContentDisposition, fmt.Sprintf(`%s; filename="%s"`, d,
-				mw.en.Encode(mw.c.String(), f.Name)))
+			f.setHeader(HeaderContentDisposition, foldParam(HeaderContentDisposition, string(d),
+				encodeParam("filename", f.Name)))
 		}
 
-		if !a {
-			if _, ok := f.getHeader(HeaderContentID); !ok {
+		if _, ok := f.getHeader(HeaderContentID); !ok {
+			switch {
+			case f.ContentID != "":
+				f.setHeader(HeaderContentID, fmt.Sprintf("<%s>", f.ContentID))
+			case !a:
 				f.setHeader(HeaderContentID, fmt.Sprintf("<%s>", f.Name))
 			}
 		}
 		if mw.d == 0 {
-			for h, v := range f.Header {
-				mw.writeHeader(Header(h), v...)
+			hk := make([]string, 0, len(f.Header))
+			for h := range f.Header {
+				hk = append(hk, h)
+			}
+			sort.Strings(hk)
+			for _, h := range hk {
+				mw.writeHeader(Header(h), f.Header[h]...)
 			}
 			mw.writeString(SingleNewLine)
 		}
 		if mw.d > 0 {
 			mw.newPart(f.Header)
 		}
+		if mw.size && f.size != nil {
+			if n, err := f.size(); err == nil {
+				if s, ok := bodySize(n, e); ok {
+					mw.n += s
+					continue
+				}
+			}
+		}
 		mw.writeBody(f.Writer, e)
 	}
 }
@@ -242,8 +329,26 @@ func (mw *msgWriter) newPart(h map[string][]string) {
 
 // writePart writes the corresponding part to the Msg body
 func (mw *msgWriter) writePart(p *Part, cs Charset) {
+	if p.charset != "" {
+		cs = p.charset
+	}
 	ct := fmt.Sprintf("%s; charset=%s", p.ctype, cs)
-	cte := p.enc.String()
+	wf := p.w
+	if p.flowed && p.ctype == TypeTextPlain {
+		ct += "; format=flowed; delsp=yes"
+		wf = flowedWriteFunc(p.w)
+	}
+	if p.css && p.ctype == TypeTextHTML {
+		wf = inlineCSSWriteFunc(p.w)
+	}
+	e := p.enc
+	if e == EncodingAuto {
+		e = autoEncoding(wf)
+	}
+	if (e == NoEncoding || e == Encoding7bit) && exceedsLineLength(wf) {
+		e = EncodingQP
+	}
+	cte := e.String()
 	if mw.d == 0 {
 		mw.writeHeader(HeaderContentType, ct)
 		mw.writeHeader(HeaderContentTransferEnc, cte)
@@ -258,7 +363,7 @@ func (mw *msgWriter) writePart(p *Part, cs Charset) {
 		mh.Add(string(HeaderContentTransferEnc), cte)
 		mw.newPart(mh)
 	}
-	mw.writeBody(p.w, p.enc)
+	mw.writeBody(wf, e)
 }
 
 // writeString writes a string into the msgWriter's io.Writer interface
@@ -273,7 +378,8 @@ func (mw *msgWriter) writeString(s string) {
 
 // writeHeader writes a header into the msgWriter's io.Writer
 func (mw *msgWriter) writeHeader(k Header, vl ...string) {
-	wbuf := bytes.Buffer{}
+	wbuf := getBuffer()
+	defer putBuffer(wbuf)
 	cl := MaxHeaderLength - 2
 	wbuf.WriteString(string(k))
 	cl -= len(k)
@@ -287,8 +393,32 @@ func (mw *msgWriter) writeHeader(k Header, vl ...string) {
 	fs := strings.Join(vl, ", ")
 	sfs := strings.Split(fs, " ")
 	for i, v := range sfs {
-		if cl-len(v) <= 1 {
-			wbuf.WriteString(fmt.Sprintf("%s ", SingleNewLine))
+		// Words that cannot be folded into a line of the permitted length are split into
+		// encoded-words. Encoded-words are only permitted in unstructured header fields, since
+		// they would corrupt e.g. message IDs, URLs or addresses of structured fields
+		if len(k)+len(v)+2 > maxLineLength7bit {
+			if k != HeaderSubject && k != HeaderComments {
+				mw.err = fmt.Errorf("failed to write %s header: %w", k, ErrLineTooLong)
+				return
+			}
+			cs := mw.c
+			if cs == "" {
+				cs = CharsetUTF8
+			}
+			v = qEncodeWords(cs, v)
+			if !bytes.HasSuffix(wbuf.Bytes(), []byte(": ")) && !bytes.HasSuffix(wbuf.Bytes(), foldPrefix) {
+				wbuf.Write(foldPrefix)
+			}
+			wbuf.WriteString(v)
+			if i < len(sfs)-1 {
+				wbuf.WriteString(" ")
+			}
+			el := strings.Split(v, SingleNewLine+" ")
+			cl = MaxHeaderLength - 3 - len(el[len(el)-1])
+			continue
+		}
+		if cl-len(v) <= 1 && !bytes.HasSuffix(wbuf.Bytes(), foldPrefix) {
+			wbuf.Write(foldPrefix)
 			cl = MaxHeaderLength - 3
 		}
 		wbuf.WriteString(v)
@@ -297,19 +427,31 @@ func (mw *msgWriter) writeHeader(k Header, vl ...string) {
 			cl -= 1
 		}
 		cl -= len(v)
+
+		// Values may already be folded (e.g. by RFC 2231 continuations)
+		if strings.HasSuffix(v, SingleNewLine) {
+			cl = MaxHeaderLength - 3
+		}
 	}
 
-	bufs := wbuf.String()
-	bufs = strings.ReplaceAll(bufs, fmt.Sprintf(" %s", SingleNewLine), SingleNewLine)
-	mw.writeString(bufs)
-	mw.writeString("\r\n")
+	wbuf.WriteString(SingleNewLine)
+	b := wbuf.Bytes()
+	if bytes.Contains(b, trailingFold) {
+		b = bytes.ReplaceAll(b, trailingFold, nl)
+	}
+	_, _ = mw.Write(b)
 }
 
-// writeBody writes an io.Reader into an io.Writer using provided Encoding
+// writeBody writes an io.Reader into an io.Writer using provided Encoding. The content is
+// streamed through the encoder straight to the destination writer, so that large attachments
+// are never held in memory as a whole
 func (mw *msgWriter) writeBody(f func(io.Writer) (int64, error), e Encoding) {
+	if mw.size {
+		mw.sizeBody(f, e)
+		return
+	}
 	var w io.Writer
 	var ew io.WriteCloser
-	var n int64
 	var err error
 	if mw.d == 0 {
 		w = mw.w
@@ -317,30 +459,20 @@ func (mw *msgWriter) writeBody(f func(io.Writer) (int64, error), e Encoding) {
 	if mw.d > 0 {
 		w = mw.pw
 	}
-	wbuf := bytes.Buffer{}
-	lb := Base64LineBreaker{}
-	lb.out = &wbuf
+	mw.cw = countWriter{w: w}
+	cw := &mw.cw
+	mw.lb = Base64LineBreaker{out: cw}
+	lb := &mw.lb
 
 	switch e {
 	case EncodingQP:
-		ew = quotedprintable.NewWriter(&wbuf)
+		ew = quotedprintable.NewWriter(cw)
 	case EncodingB64:
-		ew = base64.NewEncoder(base64.StdEncoding, &lb)
-	case NoEncoding:
-		_, err = f(&wbuf)
-		if err != nil {
-			mw.err = fmt.Errorf("bodyWriter function: %w", err)
-		}
-		n, err = io.Copy(w, &wbuf)
-		if err != nil && mw.err == nil {
-			mw.err = fmt.Errorf("bodyWriter io.Copy: %w", err)
-		}
-		if mw.d == 0 {
-			mw.n += n
-		}
-		return
+		ew = base64.NewEncoder(base64.StdEncoding, lb)
+	case NoEncoding, Encoding7bit:
+		ew = nopWriteCloser{cw}
 	default:
-		ew = quotedprintable.NewWriter(w)
+		ew = quotedprintable.NewWriter(cw)
 	}
 
 	_, err = f(ew)
@@ -355,14 +487,202 @@ func (mw *msgWriter) writeBody(f func(io.Writer) (int64, error), e Encoding) {
 	if err != nil && mw.err == nil {
 		mw.err = fmt.Errorf("bodyWriter close linebreaker: %w", err)
 	}
-	n, err = io.Copy(w, &wbuf)
-	if err != nil && mw.err == nil {
-		mw.err = fmt.Errorf("bodyWriter io.Copy: %w", err)
-	}
 
-	// Since the part writer uses the WriteTo() method, we don't need to add the
+	// Since the part writer writes through the msgWriter, we don't need to add the
 	// bytes twice
 	if mw.d == 0 {
-		mw.n += n
+		mw.n += cw.n
+	}
+}
+
+// sizeBody adds the size of the content of the given writer function with the Encoding e
+// to the byte count of the msgWriter. Only the Quoted-Printable encoding is performed, since
+// its expansion depends on the content
+func (mw *msgWriter) sizeBody(f func(io.Writer) (int64, error), e Encoding) {
+	if mw.err != nil {
+		return
+	}
+	cw := &countWriter{w: io.Discard}
+	var err error
+	switch e {
+	case EncodingB64, NoEncoding, Encoding7bit:
+		_, err = f(cw)
+	default:
+		qw := quotedprintable.NewWriter(cw)
+		if _, err = f(qw); err == nil {
+			err = qw.Close()
+		}
+	}
+	if err != nil {
+		mw.err = fmt.Errorf("bodyWriter function: %w", err)
+		return
+	}
+	if s, ok := bodySize(cw.n, e); ok {
+		mw.n += s
+		return
+	}
+	mw.n += cw.n
+}
+
+// bodySize returns the size of content of the given length with the Encoding e, including
+// the line breaks of the Base64 encoding. It returns false for encodings whose expansion
+// depends on the content
+func bodySize(n int64, e Encoding) (int64, bool) {
+	switch e {
+	case EncodingB64:
+		l := (n + 2) / 3 * 4
+		return l + (l+MaxBodyLength-1)/MaxBodyLength*2, true
+	case NoEncoding, Encoding7bit:
+		return n, true
+	}
+	return 0, false
+}
+
+// countWriter is an io.Writer that counts the bytes written to the underlying io.Writer
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write satisfies the io.Writer interface for the countWriter
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// nopWriteCloser wraps an io.Writer with a no-op Close method
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close satisfies the io.Closer interface for the nopWriteCloser
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// exceedsLineLength returns true if the content of the given writer function contains lines
+// that are longer than the 998 octets permitted by RFC 5321
+func exceedsLineLength(f func(io.Writer) (int64, error)) bool {
+	if f == nil {
+		return false
+	}
+	d := &encodingDetector{}
+	if _, err := f(d); err != nil {
+		return false
+	}
+	return d.long
+}
+
+// qEncodeWords encodes the given string as RFC 2047 "Q" encoded-words of at most 75 characters
+// that are folded into separate lines. Since the whitespace between adjacent encoded-words is
+// ignored when decoding, the folding does not alter the header value
+func qEncodeWords(cs Charset, s string) string {
+	pre := fmt.Sprintf("=?%s?q?", cs)
+	ml := 75 - len(pre) - 2
+	var wl []string
+	wbuf := strings.Builder{}
+	for i := 0; i < len(s); {
+		_, rl := utf8.DecodeRuneInString(s[i:])
+		es := strings.Builder{}
+		for _, b := range []byte(s[i : i+rl]) {
+			switch {
+			case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9',
+				b == '!', b == '*', b == '+', b == '-', b == '/':
+				es.WriteByte(b)
+			default:
+				es.WriteString(fmt.Sprintf("=%02X", b))
+			}
+		}
+		if wbuf.Len()+es.Len() > ml {
+			wl = append(wl, pre+wbuf.String()+"?=")
+			wbuf.Reset()
+		}
+		wbuf.WriteString(es.String())
+		i += rl
+	}
+	wl = append(wl, pre+wbuf.String()+"?=")
+	return strings.Join(wl, SingleNewLine+" ")
+}
+
+// foldParam appends the given MIME parameter to the header value v. If the first line of the
+// header field would exceed the MaxHeaderLength, the parameter is folded into a new line
+func foldParam(h Header, v, p string) string {
+	fl := p
+	if i := strings.Index(p, SingleNewLine); i >= 0 {
+		fl = p[:i]
+	}
+	if len(h)+len(v)+len(fl)+4 > MaxHeaderLength {
+		return fmt.Sprintf("%s;%s %s", v, SingleNewLine, p)
 	}
+	return fmt.Sprintf("%s; %s", v, p)
+}
+
+// encodeParam returns the MIME parameter with the given key and value. Values that are not
+// printable ASCII are percent-encoded as UTF-8 and values that exceed MaxParamLength are split
+// into folded continuations as described in RFC 2231
+func encodeParam(k, v string) string {
+	ap := true
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			ap = false
+			break
+		}
+	}
+	if ap {
+		if len(v) <= MaxParamLength {
+			return fmt.Sprintf(`%s="%s"`, k, quoteParam(v))
+		}
+		var pl []string
+		for i := 0; len(v) > 0; i++ {
+			l := MaxParamLength
+			if len(v) < l {
+				l = len(v)
+			}
+			pl = append(pl, fmt.Sprintf(`%s*%d="%s"`, k, i, quoteParam(v[:l])))
+			v = v[l:]
+		}
+		return strings.Join(pl, ";"+SingleNewLine+" ")
+	}
+
+	// Percent-encode the value rune by rune, so that no continuation splits a character
+	var cl []string
+	cb := strings.Builder{}
+	cb.WriteString("UTF-8''")
+	for _, r := range v {
+		er := encodeParamRune(r)
+		if cb.Len()+len(er) > MaxParamLength {
+			cl = append(cl, cb.String())
+			cb.Reset()
+		}
+		cb.WriteString(er)
+	}
+	cl = append(cl, cb.String())
+	if len(cl) == 1 {
+		return fmt.Sprintf("%s*=%s", k, cl[0])
+	}
+	pl := make([]string, len(cl))
+	for i, c := range cl {
+		pl[i] = fmt.Sprintf("%s*%d*=%s", k, i, c)
+	}
+	return strings.Join(pl, ";"+SingleNewLine+" ")
+}
+
+// encodeParamRune returns the RFC 2231 percent-encoded representation of the given rune.
+// Characters that are allowed as attribute-char are returned as is
+func encodeParamRune(r rune) string {
+	if r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("!#$&+-.^_`|~", r)) {
+		return string(r)
+	}
+	sb := strings.Builder{}
+	for _, b := range []byte(string(r)) {
+		sb.WriteString(fmt.Sprintf("%%%02X", b))
+	}
+	return sb.String()
+}
+
+// quoteParam escapes the backslash and quote characters of a quoted MIME parameter value
+func quoteParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v)
 }
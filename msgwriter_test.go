@@ -6,6 +6,7 @@ package mail
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -133,3 +134,267 @@ func TestMsgWriter_writeMsg_PGP(t *testing.T) {
 		t.Errorf("writeMsg failed. Expected PGP encoding header but didn't find it in message output")
 	}
 }
+
+// TestEncodeParam tests the RFC 2231 encoding of MIME parameters
+func TestEncodeParam(t *testing.T) {
+	tests := []struct {
+		name string
+		v    string
+		want string
+	}{
+		{"ASCII", "test.txt", `filename="test.txt"`},
+		{"ASCII with quotes", `te"st\.txt`, `filename="te\"st\\.txt"`},
+		{"Umlauts", "Übersicht für Jürgen.pdf", "filename*=UTF-8''%C3%9Cbersicht%20f%C3%BCr%20J%C3%BCrgen.pdf"},
+		{
+			"Long ASCII", strings.Repeat("a", 70) + ".txt",
+			`filename*0="` + strings.Repeat("a", 60) + "\";\r\n filename*1=\"" + strings.Repeat("a", 10) + `.txt"`,
+		},
+		{
+			"Long CJK", strings.Repeat("日本", 6) + ".txt",
+			"filename*0*=UTF-8''%E6%97%A5%E6%9C%AC%E6%97%A5%E6%9C%AC%E6%97%A5;\r\n " +
+				"filename*1*=%E6%9C%AC%E6%97%A5%E6%9C%AC%E6%97%A5%E6%9C%AC%E6%97%A5;\r\n " +
+				"filename*2*=%E6%9C%AC.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := encodeParam("filename", tt.v)
+			if p != tt.want {
+				t.Errorf("encodeParam() failed. Expected: %q, got: %q", tt.want, p)
+			}
+			_, pm, err := mime.ParseMediaType("attachment; " + strings.ReplaceAll(p, "\r\n", ""))
+			if err != nil {
+				t.Fatalf("failed to parse encoded parameter: %s", err)
+			}
+			if pm["filename"] != tt.v {
+				t.Errorf("decoded parameter mismatch. Expected: %q, got: %q", tt.v, pm["filename"])
+			}
+		})
+	}
+}
+
+// TestMsgWriter_addFiles_RFC2231 tests that non-ASCII and long file names are RFC 2231
+// encoded in the Content-Disposition header of the rendered Msg
+func TestMsgWriter_addFiles_RFC2231(t *testing.T) {
+	fn := "Jahresübersicht über alle Abteilungen und Standorte 2023 (endgültig).pdf"
+	tests := []struct {
+		name string
+		body bool
+	}{
+		{"Multipart", true},
+		{"Single part", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if tt.body {
+				m.SetBodyString(TypeTextPlain, "This is a test")
+			}
+			m.AttachReader(fn, strings.NewReader("This is a test"))
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			for _, l := range strings.Split(buf.String(), "\r\n") {
+				if len(l) > 78 {
+					t.Errorf("rendered message line exceeds 78 characters: %q", l)
+				}
+			}
+			for _, h := range []Header{HeaderContentDisposition, HeaderContentType} {
+				pm := testParseParams(t, buf.String(), h)
+				p := "filename"
+				if h == HeaderContentType {
+					p = "name"
+				}
+				if pm[p] != fn {
+					t.Errorf("%s %s mismatch. Expected: %q, got: %q", h, p, fn, pm[p])
+				}
+			}
+		})
+	}
+}
+
+// testParseParams returns the MIME parameters of the last occurrence of the given header
+// field in the rendered message
+func testParseParams(t *testing.T, msg string, h Header) map[string]string {
+	t.Helper()
+	i := strings.LastIndex(msg, string(h)+": ")
+	if i < 0 {
+		t.Fatalf("rendered message has no %s header", h)
+	}
+	v := msg[i+len(h)+2:]
+	v = v[:strings.Index(v, "\r\n\r\n")]
+	if j := strings.Index(v, "\r\n"+string(HeaderContentTransferEnc)); j >= 0 {
+		v = v[:j]
+	}
+	if j := strings.Index(v, "\r\n"+string(HeaderContentType)); j >= 0 {
+		v = v[:j]
+	}
+	_, pm, err := mime.ParseMediaType(strings.ReplaceAll(v, "\r\n", ""))
+	if err != nil {
+		t.Fatalf("failed to parse %s %q: %s", h, v, err)
+	}
+	return pm
+}
+
+// TestMsgWriter_lineLength tests that no rendered line exceeds the 998 octets permitted by
+// RFC 5321, even for long header words and long unencoded body lines
+func TestMsgWriter_lineLength(t *testing.T) {
+	lw := strings.Repeat("äbc", 400)
+	lb := strings.Repeat("This is a long line. ", 80)
+	m := NewMsg(WithEncoding(NoEncoding))
+	m.SetGenHeader(HeaderComments, "prefix", lw, "suffix")
+	m.SetBodyString(TypeTextPlain, lb)
+	m.AttachReader("long.txt", strings.NewReader(lb), WithFileEncoding(NoEncoding))
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	for _, l := range strings.Split(buf.String(), "\r\n") {
+		if len(l) > maxLineLength7bit {
+			t.Errorf("rendered message line exceeds %d octets: %d", maxLineLength7bit, len(l))
+		}
+	}
+
+	i := strings.Index(buf.String(), string(HeaderComments)+": ")
+	hv := buf.String()[i+len(HeaderComments)+2:]
+	var hl []string
+	for _, l := range strings.Split(hv, "\r\n") {
+		if len(hl) > 0 && !strings.HasPrefix(l, " ") {
+			break
+		}
+		hl = append(hl, l)
+	}
+	hv = strings.Join(hl, "")
+	d, err := (&mime.WordDecoder{}).DecodeHeader(hv)
+	if err != nil {
+		t.Fatalf("failed to decode folded header: %s", err)
+	}
+	if ev := "prefix, " + lw + ", suffix"; d != ev {
+		t.Errorf("folded header value mismatch. Expected: %q, got: %q", ev, d)
+	}
+	if !strings.Contains(buf.String(), "Content-Transfer-Encoding: quoted-printable\r\n") {
+		t.Errorf("body with long lines was expected to be quoted-printable encoded")
+	}
+	if v, _ := m.GetAttachments()[0].getHeader(HeaderContentTransferEnc); v != string(EncodingB64) {
+		t.Errorf("attachment with long lines was expected to be Base64 encoded, got: %s", v)
+	}
+}
+
+// TestMsgWriter_lineLength_structured tests that words of structured header fields that
+// exceed the line length limit are not split into encoded-words
+func TestMsgWriter_lineLength_structured(t *testing.T) {
+	for _, h := range []Header{HeaderReferences, HeaderInReplyTo, HeaderListUnsubscribe} {
+		t.Run(string(h), func(t *testing.T) {
+			m := NewMsg()
+			m.SetGenHeader(h, "<https://example.com/"+strings.Repeat("a", 1000)+">")
+			if _, err := m.WriteTo(io.Discard); !errors.Is(err, ErrLineTooLong) {
+				t.Errorf("WriteTo was expected to fail with ErrLineTooLong, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestMsgWriter_writeBody_streaming tests that the content of a File is streamed through the
+// encoder to the destination writer instead of being buffered as a whole
+func TestMsgWriter_writeBody_streaming(t *testing.T) {
+	for _, e := range []Encoding{EncodingB64, EncodingQP, NoEncoding} {
+		t.Run(e.String(), func(t *testing.T) {
+			buf := bytes.Buffer{}
+			m := NewMsg()
+			m.SetBodyString(TypeTextPlain, "This is a test")
+			m.SetAttachments([]*File{{
+				Name:   "stream.txt",
+				Enc:    e,
+				Header: make(map[string][]string),
+				Writer: func(w io.Writer) (int64, error) {
+					n, err := w.Write(bytes.Repeat([]byte("streaming test\r\n"), 4096))
+					if err != nil {
+						return int64(n), err
+					}
+					if !strings.Contains(buf.String(), "stream.txt") {
+						return int64(n), nil
+					}
+					if l := buf.Len(); l < 4096 {
+						return int64(n), fmt.Errorf("content was not streamed to the destination: %d bytes", l)
+					}
+					return int64(n), nil
+				},
+			}})
+			n, err := m.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("failed to write message: %s", err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo returned wrong number of bytes. Expected: %d, got: %d", buf.Len(), n)
+			}
+		})
+	}
+}
+
+// TestMsgWriter_writeHeaders tests the canonical and the custom order of the header fields
+func TestMsgWriter_writeHeaders(t *testing.T) {
+	newMsg := func(o ...MsgOption) *Msg {
+		m := NewMsg(o...)
+		_ = m.From("from@example.com")
+		_ = m.To("to@example.com")
+		_ = m.ReplyTo("reply@example.com")
+		m.Subject("Header order")
+		m.SetDateWithValue(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+		m.SetMessageIDWithValue("id@example.com")
+		m.SetGenHeader("X-Custom", "custom")
+		m.SetGenHeader(HeaderContentLang, "de")
+		m.SetGenHeaderPreformatted("DKIM-Signature", "v=1")
+		m.SetBodyString(TypeTextPlain, "Test")
+		return m
+	}
+	tests := []struct {
+		name string
+		opts []MsgOption
+		want []string
+	}{
+		{
+			"canonical order", nil,
+			[]string{
+				"DKIM-Signature", "Date", "From", "Reply-To", "To", "Message-ID", "Subject", "User-Agent",
+				"X-Custom", "X-Mailer", "MIME-Version", "Content-Language", "Content-Type",
+				"Content-Transfer-Encoding",
+			},
+		},
+		{
+			"custom order", []MsgOption{WithHeaderOrder("x-custom", HeaderSubject, Header(HeaderFrom))},
+			[]string{
+				"X-Custom", "Subject", "From", "DKIM-Signature", "Date", "Reply-To", "To", "Message-ID",
+				"User-Agent", "X-Mailer", "MIME-Version", "Content-Language", "Content-Type",
+				"Content-Transfer-Encoding",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first string
+			for i := 0; i < 5; i++ {
+				buf := bytes.Buffer{}
+				if _, err := newMsg(tt.opts...).WriteTo(&buf); err != nil {
+					t.Fatalf("WriteTo failed: %s", err)
+				}
+				hdr := strings.SplitN(buf.String(), "\r\n\r\n", 2)[0]
+				if i == 0 {
+					first = hdr
+				}
+				if hdr != first {
+					t.Fatalf("header output is not stable:\n%s\n---\n%s", first, hdr)
+				}
+			}
+			var got []string
+			for _, l := range strings.Split(first, "\r\n") {
+				if i := strings.Index(l, ":"); i > 0 && !strings.HasPrefix(l, " ") {
+					got = append(got, l[:i])
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("wrong header order. Expected: %v, got: %v", tt.want, got)
+			}
+		})
+	}
+}
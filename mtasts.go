@@ -0,0 +1,377 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// List of MTA-STS policy modes as described in RFC 8461
+const (
+	// MTASTSModeEnforce requires that messages are only delivered to MX that match the
+	// policy and present a valid certificate via STARTTLS
+	MTASTSModeEnforce MTASTSMode = "enforce"
+
+	// MTASTSModeTesting delivers messages regardless of policy violations, which are
+	// reported instead
+	MTASTSModeTesting MTASTSMode = "testing"
+
+	// MTASTSModeNone indicates that the domain has no active MTA-STS policy
+	MTASTSModeNone MTASTSMode = "none"
+)
+
+const (
+	// mtastsMaxAge is the maximum lifetime of a cached MTA-STS policy as described in RFC 8461
+	mtastsMaxAge = time.Second * 31557600
+
+	// mtastsMaxPolicySize is the maximum size of an MTA-STS policy file
+	mtastsMaxPolicySize = 64 * 1024
+)
+
+// ErrMTASTSViolation is returned if an MX does not meet the MTA-STS policy of the recipient
+// domain in enforce mode
+var ErrMTASTSViolation = errors.New("MTA-STS policy violation")
+
+// MTASTSMode represents the mode of an MTA-STS policy
+type MTASTSMode string
+
+// MTASTSPolicy represents the MTA-STS policy of a domain as described in RFC 8461
+type MTASTSPolicy struct {
+	// ID is the policy ID published in the _mta-sts TXT record of the domain
+	ID string
+	// Mode is the mode of the policy
+	Mode MTASTSMode
+	// MX is the list of MX host patterns of the policy
+	MX []string
+	// MaxAge is the maximum lifetime of the policy in the cache
+	MaxAge time.Duration
+
+	// expires is the time the cached policy expires
+	expires time.Time
+}
+
+// MTASTSReportFunc is a callback that is called with the recipient domain, the MX host and
+// the error, if an MX violates the MTA-STS policy of the domain in testing mode
+type MTASTSReportFunc func(d, mx string, err error)
+
+// MTASTS fetches, caches and applies the MTA-STS policies of recipient domains as described
+// in RFC 8461. It is used by the MXSender via WithMXMTASTS and is safe for concurrent use
+type MTASTS struct {
+	// hc is the http.Client that fetches the policies
+	hc *http.Client
+
+	// txt is the function that looks up the _mta-sts TXT records
+	txt func(ctx context.Context, n string) ([]string, error)
+
+	// report is called for policy violations in testing mode
+	report MTASTSReportFunc
+
+	// roots is the certificate pool that validates the MX certificates (nil for the
+	// system roots)
+	roots *x509.CertPool
+
+	// now returns the current time for the cache expiry
+	now func() time.Time
+
+	// mu protects the cache
+	mu sync.Mutex
+
+	// cache holds the policies by domain
+	cache map[string]*MTASTSPolicy
+}
+
+// MTASTSOption returns a function that can be used for grouping MTASTS options
+type MTASTSOption func(*MTASTS) error
+
+// NewMTASTS returns a new MTASTS with an empty policy cache
+func NewMTASTS(o ...MTASTSOption) (*MTASTS, error) {
+	p := &MTASTS{
+		hc:    &http.Client{Timeout: DefaultTimeout},
+		txt:   net.DefaultResolver.LookupTXT,
+		now:   time.Now,
+		cache: make(map[string]*MTASTSPolicy),
+	}
+
+	// Override defaults with optionally provided MTASTSOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(p); err != nil {
+			return p, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	// Policies must not be fetched via redirects
+	hc := *p.hc
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	p.hc = &hc
+	return p, nil
+}
+
+// WithMTASTSHTTPClient overrides the default http.Client that fetches the policies
+func WithMTASTSHTTPClient(hc *http.Client) MTASTSOption {
+	return func(p *MTASTS) error {
+		if hc == nil {
+			return fmt.Errorf("http.Client must not be nil")
+		}
+		p.hc = hc
+		return nil
+	}
+}
+
+// WithMTASTSLookupTXTFunc overrides the function that looks up the _mta-sts TXT records. It
+// has the same signature as net.Resolver.LookupTXT
+func WithMTASTSLookupTXTFunc(f func(ctx context.Context, n string) ([]string, error)) MTASTSOption {
+	return func(p *MTASTS) error {
+		if f == nil {
+			return fmt.Errorf("TXT lookup function must not be nil")
+		}
+		p.txt = f
+		return nil
+	}
+}
+
+// WithMTASTSReportFunc sets the callback that is called for policy violations in testing mode
+func WithMTASTSReportFunc(f MTASTSReportFunc) MTASTSOption {
+	return func(p *MTASTS) error {
+		p.report = f
+		return nil
+	}
+}
+
+// WithMTASTSRootCAs overrides the system roots that validate the certificates of the MX
+func WithMTASTSRootCAs(cp *x509.CertPool) MTASTSOption {
+	return func(p *MTASTS) error {
+		p.roots = cp
+		return nil
+	}
+}
+
+// Match returns true if the given MX host matches one of the MX patterns of the policy.
+// Wildcard patterns like "*.example.com" match exactly one additional label
+func (sp *MTASTSPolicy) Match(h string) bool {
+	h = strings.ToLower(strings.TrimSuffix(h, "."))
+	for _, mx := range sp.MX {
+		mx = strings.ToLower(mx)
+		if strings.HasPrefix(mx, "*.") {
+			if i := strings.Index(h, "."); i > 0 && h[i+1:] == mx[2:] {
+				return true
+			}
+			continue
+		}
+		if h == mx {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the MTA-STS policy of the given domain. Policies are cached until their
+// max_age expires or the policy ID in the _mta-sts TXT record of the domain changes. It
+// returns nil if the domain has no valid policy. As described in RFC 8461, a cached policy
+// is used if the policy cannot be fetched
+func (p *MTASTS) Policy(ctx context.Context, d string) *MTASTSPolicy {
+	d = strings.ToLower(d)
+	p.mu.Lock()
+	cp := p.cache[d]
+	if cp != nil && !p.now().Before(cp.expires) {
+		delete(p.cache, d)
+		cp = nil
+	}
+	p.mu.Unlock()
+
+	id, err := p.lookupID(ctx, d)
+	if err != nil || id == "" || (cp != nil && cp.ID == id) {
+		return cp
+	}
+	sp, err := p.fetch(ctx, d)
+	if err != nil {
+		return cp
+	}
+	sp.ID = id
+	sp.expires = p.now().Add(sp.MaxAge)
+	p.mu.Lock()
+	p.cache[d] = sp
+	p.mu.Unlock()
+	return sp
+}
+
+// lookupID returns the policy ID of the _mta-sts TXT record of the given domain
+func (p *MTASTS) lookupID(ctx context.Context, d string) (string, error) {
+	tl, err := p.txt(ctx, "_mta-sts."+d)
+	if err != nil {
+		return "", err
+	}
+	var id string
+	n := 0
+	for _, t := range tl {
+		if !strings.HasPrefix(t, "v=STSv1") {
+			continue
+		}
+		n++
+		for _, f := range strings.Split(t, ";") {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "id=") {
+				id = f[3:]
+			}
+		}
+	}
+	if n != 1 {
+		return "", nil
+	}
+	return id, nil
+}
+
+// fetch retrieves and parses the policy of the given domain via HTTPS
+func (p *MTASTS) fetch(ctx context.Context, d string) (*MTASTSPolicy, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://mta-sts."+d+"/.well-known/mta-sts.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch MTA-STS policy: %s", res.Status)
+	}
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		return nil, fmt.Errorf("invalid MTA-STS policy content type: %s", ct)
+	}
+	return parseMTASTSPolicy(io.LimitReader(res.Body, mtastsMaxPolicySize))
+}
+
+// parseMTASTSPolicy parses the given MTA-STS policy file
+func parseMTASTSPolicy(r io.Reader) (*MTASTSPolicy, error) {
+	sp := &MTASTSPolicy{}
+	var v string
+	ma := -1
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		i := strings.IndexByte(l, ':')
+		if i < 0 {
+			continue
+		}
+		val := strings.TrimSpace(l[i+1:])
+		switch strings.TrimSpace(l[:i]) {
+		case "version":
+			v = val
+		case "mode":
+			sp.Mode = MTASTSMode(val)
+		case "mx":
+			sp.MX = append(sp.MX, val)
+		case "max_age":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid MTA-STS max_age: %s", val)
+			}
+			ma = n
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read MTA-STS policy: %w", err)
+	}
+	if v != "STSv1" {
+		return nil, fmt.Errorf("unsupported MTA-STS policy version: %q", v)
+	}
+	switch sp.Mode {
+	case MTASTSModeEnforce, MTASTSModeTesting:
+		if len(sp.MX) == 0 {
+			return nil, fmt.Errorf("MTA-STS policy has no mx patterns")
+		}
+	case MTASTSModeNone:
+	default:
+		return nil, fmt.Errorf("invalid MTA-STS policy mode: %q", sp.Mode)
+	}
+	if ma < 0 {
+		return nil, fmt.Errorf("MTA-STS policy has no max_age")
+	}
+	sp.MaxAge = time.Duration(ma) * time.Second
+	if sp.MaxAge > mtastsMaxAge {
+		sp.MaxAge = mtastsMaxAge
+	}
+	return sp, nil
+}
+
+// options returns the Client options that apply the given policy to the given MX host. In
+// enforce mode, an error is returned if the MX does not match the policy. In testing mode,
+// violations are reported via the report callback
+func (p *MTASTS) options(sp *MTASTSPolicy, d, h string) ([]Option, error) {
+	if sp == nil || sp.Mode == MTASTSModeNone {
+		return nil, nil
+	}
+	if !sp.Match(h) {
+		err := fmt.Errorf("%w: MX %s does not match the policy of %s", ErrMTASTSViolation, h, d)
+		if sp.Mode == MTASTSModeEnforce {
+			return nil, err
+		}
+		p.reportViolation(d, h, err)
+	}
+	if sp.Mode == MTASTSModeEnforce {
+		return []Option{
+			WithTLSPolicy(TLSMandatory),
+			WithTLSConfig(&tls.Config{ServerName: h, RootCAs: p.roots, MinVersion: DefaultTLSMinVersion}),
+		}, nil
+	}
+	return []Option{WithTLSConfig(&tls.Config{
+		ServerName: h,
+		MinVersion: DefaultTLSMinVersion,
+		// The certificate is verified by VerifyPeerCertificate, which only reports failures
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rc [][]byte, _ [][]*x509.Certificate) error {
+			if err := p.verify(h, rc); err != nil {
+				p.reportViolation(d, h, fmt.Errorf("%w: %s", ErrMTASTSViolation, err))
+			}
+			return nil
+		},
+	})}, nil
+}
+
+// verify validates the given raw certificate chain for the given host
+func (p *MTASTS) verify(h string, rc [][]byte) error {
+	if len(rc) == 0 {
+		return fmt.Errorf("server did not present a certificate")
+	}
+	ip := x509.NewCertPool()
+	var leaf *x509.Certificate
+	for i, r := range rc {
+		c, err := x509.ParseCertificate(r)
+		if err != nil {
+			return fmt.Errorf("failed to parse server certificate: %w", err)
+		}
+		if i == 0 {
+			leaf = c
+			continue
+		}
+		ip.AddCert(c)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: h, Roots: p.roots, Intermediates: ip})
+	return err
+}
+
+// reportViolation calls the report callback, if it is set
+func (p *MTASTS) reportViolation(d, h string, err error) {
+	if p.report != nil {
+		p.report(d, h, err)
+	}
+}
@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestParseMTASTSPolicy tests the parsing of MTA-STS policy files
+func TestParseMTASTSPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		mode MTASTSMode
+		mx   int
+		ma   time.Duration
+		sf   bool
+	}{
+		{
+			"Enforce policy", "version: STSv1\r\nmode: enforce\r\nmx: mx.example.com\r\nmx: *.example.net\r\nmax_age: 86400\r\n",
+			MTASTSModeEnforce, 2, time.Hour * 24, false,
+		},
+		{"Testing policy with LF", "version: STSv1\nmode: testing\nmx: mx.example.com\nmax_age: 600\n", MTASTSModeTesting, 1, time.Minute * 10, false},
+		{"None policy without mx", "version: STSv1\nmode: none\nmax_age: 600\n", MTASTSModeNone, 0, time.Minute * 10, false},
+		{"Capped max_age", "version: STSv1\nmode: none\nmax_age: 99999999\n", MTASTSModeNone, 0, mtastsMaxAge, false},
+		{"Wrong version", "version: STSv2\nmode: none\nmax_age: 600\n", "", 0, 0, true},
+		{"Invalid mode", "version: STSv1\nmode: strict\nmx: mx.example.com\nmax_age: 600\n", "", 0, 0, true},
+		{"Enforce without mx", "version: STSv1\nmode: enforce\nmax_age: 600\n", "", 0, 0, true},
+		{"Missing max_age", "version: STSv1\nmode: none\n", "", 0, 0, true},
+		{"Invalid max_age", "version: STSv1\nmode: none\nmax_age: soon\n", "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, err := parseMTASTSPolicy(strings.NewReader(tt.p))
+			if err != nil && !tt.sf {
+				t.Fatalf("parseMTASTSPolicy failed: %s", err)
+			}
+			if tt.sf {
+				if err == nil {
+					t.Errorf("parseMTASTSPolicy was expected to fail")
+				}
+				return
+			}
+			if sp.Mode != tt.mode || len(sp.MX) != tt.mx || sp.MaxAge != tt.ma {
+				t.Errorf("parseMTASTSPolicy failed. Unexpected policy: %+v", sp)
+			}
+		})
+	}
+}
+
+// TestMTASTSPolicy_Match tests the matching of MX hosts against the MX patterns of a policy
+func TestMTASTSPolicy_Match(t *testing.T) {
+	sp := &MTASTSPolicy{MX: []string{"mx.example.com", "*.example.net"}}
+	tests := []struct {
+		h    string
+		want bool
+	}{
+		{"mx.example.com", true},
+		{"MX.Example.COM.", true},
+		{"mx2.example.com", false},
+		{"mx1.example.net", true},
+		{"example.net", false},
+		{"a.mx1.example.net", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.h, func(t *testing.T) {
+			if m := sp.Match(tt.h); m != tt.want {
+				t.Errorf("Match failed. Expected: %t, got: %t", tt.want, m)
+			}
+		})
+	}
+}
+
+// TestMTASTS_Policy tests the fetching and caching of MTA-STS policies
+func TestMTASTS_Policy(t *testing.T) {
+	var mu sync.Mutex
+	fetches := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		if r.Host != "mta-sts.example.com" || r.URL.Path != "/.well-known/mta-sts.txt" {
+			http.Redirect(w, r, "https://mta-sts.example.com/.well-known/mta-sts.txt", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprint(w, "version: STSv1\r\nmode: enforce\r\nmx: mx.example.com\r\nmax_age: 3600\r\n")
+	}))
+	defer srv.Close()
+	hc := srv.Client()
+	hc.Transport.(*http.Transport).DialContext = func(ctx context.Context, n, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, n, srv.Listener.Addr().String())
+	}
+
+	id := "20230101"
+	now := time.Now()
+	p, err := NewMTASTS(WithMTASTSHTTPClient(hc),
+		WithMTASTSLookupTXTFunc(func(_ context.Context, n string) ([]string, error) {
+			if n != "_mta-sts.example.com" {
+				return nil, &net.DNSError{Err: "no such host", Name: n, IsNotFound: true}
+			}
+			return []string{"unrelated", "v=STSv1; id=" + id}, nil
+		}))
+	if err != nil {
+		t.Fatalf("NewMTASTS failed: %s", err)
+	}
+	p.now = func() time.Time { return now }
+
+	sp := p.Policy(context.Background(), "EXAMPLE.com")
+	if sp == nil || sp.Mode != MTASTSModeEnforce || sp.ID != id || !sp.Match("mx.example.com") {
+		t.Fatalf("Policy failed. Unexpected policy: %+v", sp)
+	}
+	if p.Policy(context.Background(), "example.com") != sp || fetches != 1 {
+		t.Errorf("Policy failed. Expected cached policy, got %d fetches", fetches)
+	}
+	id = "20230102"
+	if sp2 := p.Policy(context.Background(), "example.com"); sp2 == nil || sp2.ID != id || fetches != 2 {
+		t.Errorf("Policy failed. Expected policy to be refetched after ID change, got %d fetches", fetches)
+	}
+	now = now.Add(time.Hour * 2)
+	if sp2 := p.Policy(context.Background(), "example.com"); sp2 == nil || fetches != 3 {
+		t.Errorf("Policy failed. Expected policy to be refetched after expiry, got %d fetches", fetches)
+	}
+	if sp := p.Policy(context.Background(), "example.org"); sp != nil {
+		t.Errorf("Policy failed. Expected no policy for domain without TXT record, got: %+v", sp)
+	}
+}
+
+// TestMXSender_MTASTS tests the MTA-STS enforcement of the MX connections
+func TestMXSender_MTASTS(t *testing.T) {
+	ca, leaf := testDANECerts(t)
+	cp := x509.NewCertPool()
+	cp.AddCert(ca)
+	tlssrv := smtptest.NewTestServer(t, smtptest.WithSTARTTLS(&tls.Config{
+		Certificates: []tls.Certificate{leaf}, MinVersion: tls.VersionTLS12,
+	}))
+	plainsrv := smtptest.NewTestServer(t)
+	tests := []struct {
+		name string
+		srv  *smtptest.Server
+		mode MTASTSMode
+		mx   string
+		fail string
+		rep  bool
+		tn   bool
+	}{
+		{"Enforce with valid MX", tlssrv, MTASTSModeEnforce, "mx.example.com", "", false, false},
+		{"Enforce with non-matching MX", tlssrv, MTASTSModeEnforce, "mx.example.org", ErrMTASTSViolation.Error(), false, false},
+		{"Enforce without STARTTLS", plainsrv, MTASTSModeEnforce, "mx.example.com", "STARTTLS", false, false},
+		{"Enforce with certificate mismatch", tlssrv, MTASTSModeEnforce, "mx.example.net", "certificate", false, false},
+		{"Testing with non-matching MX", plainsrv, MTASTSModeTesting, "mx.example.org", "", true, false},
+		{"Testing with certificate mismatch", tlssrv, MTASTSModeTesting, "mx.example.net", "", true, false},
+		{"None", plainsrv, MTASTSModeNone, "mx.example.org", "", false, false},
+		{"Enforce with TLS-Required: No", plainsrv, MTASTSModeEnforce, "mx.example.org", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []string
+			p, err := NewMTASTS(WithMTASTSRootCAs(cp),
+				WithMTASTSLookupTXTFunc(func(context.Context, string) ([]string, error) {
+					return []string{"v=STSv1; id=1"}, nil
+				}),
+				WithMTASTSReportFunc(func(d, mx string, err error) {
+					reports = append(reports, fmt.Sprintf("%s %s %s", d, mx, err))
+				}))
+			if err != nil {
+				t.Fatalf("NewMTASTS failed: %s", err)
+			}
+			p.cache["example.com"] = &MTASTSPolicy{
+				ID: "1", Mode: tt.mode, MX: []string{"mx.example.com", "*.example.net"}, expires: time.Now().Add(time.Hour),
+			}
+			s, err := NewMXSender(WithMXMTASTS(p),
+				WithMXLookupFunc(func(context.Context, string) ([]*net.MX, error) {
+					return []*net.MX{{Host: tt.mx, Pref: 10}}, nil
+				}),
+				WithMXClientOptions(WithDialContextFunc(func(ctx context.Context, n, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, n, tt.srv.Addr())
+				})))
+			if err != nil {
+				t.Fatalf("NewMXSender failed: %s", err)
+			}
+			m := testMsg(t)
+			if tt.tn {
+				m.SetTLSRequiredNo()
+			}
+			err = s.Send(m)
+			if tt.fail == "" && err != nil {
+				t.Errorf("Send failed: %s", err)
+			}
+			if tt.fail != "" && (err == nil || !strings.Contains(err.Error(), tt.fail)) {
+				t.Errorf("Send was expected to fail with %q, got: %v", tt.fail, err)
+			}
+			if tt.rep != (len(reports) > 0) {
+				t.Errorf("Unexpected MTA-STS reports: %v", reports)
+			}
+		})
+	}
+}
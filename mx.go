@@ -0,0 +1,313 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// DefaultMXPort is the default port of the SMTP servers of the recipient domains
+const DefaultMXPort = 25
+
+// ErrNullMX is returned if a recipient domain publishes a null MX record as described in
+// RFC 7505 and therefore does not accept any mail
+var ErrNullMX = errors.New("domain does not accept mail (null MX)")
+
+// MXLookupFunc is a function that looks up the MX records of the given domain. It has the
+// same signature as net.Resolver.LookupMX
+type MXLookupFunc func(ctx context.Context, d string) ([]*net.MX, error)
+
+// MXSender delivers messages directly to the mail exchangers (MX) of the recipient domains,
+// for applications that act as their own MTA. For every recipient domain, the MX records
+// are resolved and the message is delivered to the MX with the highest preference. If an
+// MX cannot be reached or rejects the message temporarily, the next MX of the list is
+// tried. Domains without MX records are delivered to the domain itself (implicit MX)
+type MXSender struct {
+	// port is the port of the SMTP servers of the recipient domains
+	port int
+
+	// lookup is the function that resolves the MX records of a domain
+	lookup MXLookupFunc
+
+	// opts is the list of Option that is applied to the Client of every MX
+	opts []Option
+
+	// tlsa is the function that looks up the TLSA records of an MX for DANE
+	tlsa TLSALookupFunc
+
+	// sts fetches and applies the MTA-STS policies of the recipient domains
+	sts *MTASTS
+}
+
+// MXOption returns a function that can be used for grouping MXSender options
+type MXOption func(*MXSender) error
+
+// NewMXSender returns a new MXSender. By default, the Client of every MX uses opportunistic
+// STARTTLS, which can be overridden via WithMXClientOptions
+func NewMXSender(o ...MXOption) (*MXSender, error) {
+	s := &MXSender{
+		port:   DefaultMXPort,
+		lookup: net.DefaultResolver.LookupMX,
+	}
+
+	// Override defaults with optionally provided MXOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(s); err != nil {
+			return s, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// WithMXPort overrides the default port of the SMTP servers of the recipient domains
+func WithMXPort(p int) MXOption {
+	return func(s *MXSender) error {
+		if p < 1 || p > 65535 {
+			return ErrInvalidPort
+		}
+		s.port = p
+		return nil
+	}
+}
+
+// WithMXLookupFunc overrides the function that resolves the MX records of a domain (e.g.
+// to use a custom DNS resolver)
+func WithMXLookupFunc(f MXLookupFunc) MXOption {
+	return func(s *MXSender) error {
+		if f == nil {
+			return fmt.Errorf("MX lookup function must not be nil")
+		}
+		s.lookup = f
+		return nil
+	}
+}
+
+// WithMXClientOptions sets the list of Option that is applied to the Client of every MX
+// (e.g. the HELO hostname, the TLS policy or timeouts)
+func WithMXClientOptions(o ...Option) MXOption {
+	return func(s *MXSender) error {
+		s.opts = append(s.opts, o...)
+		return nil
+	}
+}
+
+// WithMXDANE enables DANE for the MX connections as described in RFC 7672. Before connecting
+// to an MX, its TLSA records are looked up with the given function, which must perform
+// DNSSEC validation. If usable TLSA records are published, STARTTLS is required and the
+// server certificate is authenticated against the DANE-EE and DANE-TA records. The
+// delivery to an MX fails closed, if the TLSA lookup fails or the published policy cannot
+// be met, so that the next MX is tried. MX without TLSA records and messages with the
+// "TLS-Required: No" header are not affected
+func WithMXDANE(f TLSALookupFunc) MXOption {
+	return func(s *MXSender) error {
+		if f == nil {
+			return fmt.Errorf("TLSA lookup function must not be nil")
+		}
+		s.tlsa = f
+		return nil
+	}
+}
+
+// WithMXMTASTS enables MTA-STS for the recipient domains as described in RFC 8461. The
+// policies are fetched and cached by the given MTASTS. In enforce mode, messages are only
+// delivered to MX that match the policy and present a valid certificate via STARTTLS. In
+// testing mode, violations are reported via the report callback of the MTASTS instead.
+// If DANE is enabled as well, MX with usable TLSA records are authenticated via DANE.
+// Messages with the "TLS-Required: No" header are delivered without MTA-STS
+func WithMXMTASTS(p *MTASTS) MXOption {
+	return func(s *MXSender) error {
+		if p == nil {
+			return fmt.Errorf("MTASTS must not be nil")
+		}
+		s.sts = p
+		return nil
+	}
+}
+
+// Send delivers the given messages to the MX of their recipient domains. See
+// SendWithContext for details
+func (s *MXSender) Send(ml ...*Msg) error {
+	return s.SendWithContext(context.Background(), ml...)
+}
+
+// SendWithContext delivers the given messages to the MX of their recipient domains and
+// satisfies the Sender interface. The result of each message is available via
+// Msg.HasSendError and Msg.SendError and the returned error aggregates the errors of all
+// failed deliveries
+func (s *MXSender) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	var errs []*SendError
+	for _, m := range ml {
+		m.sendError = nil
+		if se := s.send(ctx, m); se != nil {
+			m.sendError = se
+			errs = append(errs, se)
+		}
+	}
+	return joinSendErrors(errs)
+}
+
+// send delivers the given Msg to the MX of all its recipient domains
+func (s *MXSender) send(ctx context.Context, m *Msg) *SendError {
+	f, err := m.GetSender(false)
+	if err != nil {
+		return &SendError{Reason: ErrGetSender, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		return &SendError{Reason: ErrGetRcpts, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		return &SendError{Reason: ErrWriteContent, errlist: []error{err}, isTemp: isTempError(err)}
+	}
+
+	var dl []string
+	dr := make(map[string][]string)
+	for _, r := range rl {
+		d := strings.ToLower(r[strings.LastIndex(r, "@")+1:])
+		if _, ok := dr[d]; !ok {
+			dl = append(dl, d)
+		}
+		dr[d] = append(dr[d], r)
+	}
+	tn := m.tlsRequiredNo()
+	m.serverResponses = nil
+	m.suppressed = nil
+	var errs []*SendError
+	for _, d := range dl {
+		if se := s.deliver(ctx, m, buf.Bytes(), f, d, dr[d], tn); se != nil {
+			errs = append(errs, se)
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	se := &SendError{Reason: ErrAmbiguous, isTemp: errs[len(errs)-1].isTemp}
+	for _, e := range errs {
+		se.errlist = append(se.errlist, e.errlist...)
+		se.rcpt = append(se.rcpt, e.rcpt...)
+		se.rcptErrs = append(se.rcptErrs, e.rcptErrs...)
+	}
+	return se
+}
+
+// deliver delivers the given rendered message of the given Msg to the MX of the given
+// domain and records the server response in the Msg. The MX are
+// tried in the order of their preference until one of them accepts or permanently rejects
+// the message. If tn is true, the message has the "TLS-Required: No" header and the DANE and
+// MTA-STS policies of the domain are ignored as described in RFC 8689
+func (s *MXSender) deliver(ctx context.Context, m *Msg, raw []byte, f, d string, rl []string, tn bool) *SendError {
+	hl, err := s.hosts(ctx, d)
+	if err != nil {
+		return &SendError{Reason: ErrConnCheck, errlist: []error{err}, rcpt: rl, isTemp: !errors.Is(err, ErrNullMX)}
+	}
+	rm, err := newRawMsg(raw, f, rl)
+	if err != nil {
+		return &SendError{Reason: ErrGetRcpts, errlist: []error{err}, rcpt: rl, isTemp: false}
+	}
+
+	var sp *MTASTSPolicy
+	if s.sts != nil && !tn {
+		sp = s.sts.Policy(ctx, d)
+	}
+
+	var le *SendError
+	for _, h := range hl {
+		if err := ctx.Err(); err != nil {
+			return &SendError{Reason: ErrConnCheck, errlist: []error{err}, rcpt: rl, isTemp: true}
+		}
+		o := append([]Option{WithPort(s.port), WithTLSPolicy(TLSOpportunistic)}, s.opts...)
+		dane := false
+		if s.tlsa != nil && !tn {
+			tl, err := s.tlsa(ctx, fmt.Sprintf("_%d._tcp.%s", s.port, h))
+			if err != nil {
+				le = &SendError{
+					Reason: ErrConnCheck, errlist: []error{fmt.Errorf("TLSA lookup for MX %s failed: %w", h, err)},
+					rcpt: rl, isTemp: true,
+				}
+				continue
+			}
+			if ul := usableTLSA(tl); len(ul) > 0 {
+				o = append(o, WithTLSPolicy(TLSMandatory), WithTLSConfig(daneTLSConfig(h, ul)))
+				dane = true
+			}
+		}
+		if s.sts != nil && !dane && !tn {
+			so, err := s.sts.options(sp, d, h)
+			if err != nil {
+				le = &SendError{Reason: ErrConnCheck, errlist: []error{err}, rcpt: rl, isTemp: true}
+				continue
+			}
+			o = append(o, so...)
+		}
+		c, err := NewClient(h, o...)
+		if err != nil {
+			return &SendError{Reason: ErrConnCheck, errlist: []error{err}, rcpt: rl, isTemp: false}
+		}
+		err = c.SendWithContext(ctx, rm)
+		if err == nil {
+			m.serverResponses = append(m.serverResponses, rm.serverResponses...)
+			m.suppressed = append(m.suppressed, rm.suppressed...)
+			return nil
+		}
+		var se *SendError
+		if errors.As(err, &se) && !se.IsTemp() && se.Reason != ErrConnCheck {
+			m.suppressed = append(m.suppressed, rm.suppressed...)
+			return se
+		}
+		le = &SendError{
+			Reason: ErrConnCheck, errlist: []error{fmt.Errorf("delivery to MX %s failed: %w", h, err)},
+			rcpt: rl, isTemp: true,
+		}
+		if se != nil {
+			le.Reason = se.Reason
+		}
+	}
+	return le
+}
+
+// hosts returns the list of MX hosts of the given domain in the order of their preference.
+// If the domain has no MX records, the domain itself is returned as implicit MX
+func (s *MXSender) hosts(ctx context.Context, d string) ([]string, error) {
+	return mxHosts(ctx, s.lookup, d)
+}
+
+// mxHosts returns the list of MX hosts of the given domain looked up with the given
+// MXLookupFunc in the order of their preference. If the domain has no MX records, the
+// domain itself is returned as implicit MX
+func mxHosts(ctx context.Context, lookup MXLookupFunc, d string) ([]string, error) {
+	mxl, err := lookup(ctx, d)
+	if err != nil {
+		var de *net.DNSError
+		if !errors.As(err, &de) || !de.IsNotFound {
+			return nil, fmt.Errorf("failed to look up MX records of %s: %w", d, err)
+		}
+		mxl = nil
+	}
+	if len(mxl) == 0 {
+		return []string{d}, nil
+	}
+	if len(mxl) == 1 && (mxl[0].Host == "." || mxl[0].Host == "") {
+		return nil, fmt.Errorf("%s: %w", d, ErrNullMX)
+	}
+	sort.SliceStable(mxl, func(i, j int) bool { return mxl[i].Pref < mxl[j].Pref })
+	hl := make([]string, 0, len(mxl))
+	for _, mx := range mxl {
+		hl = append(hl, strings.TrimSuffix(mx.Host, "."))
+	}
+	return hl, nil
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestNewMXSender tests the options of NewMXSender
+func TestNewMXSender(t *testing.T) {
+	s, err := NewMXSender()
+	if err != nil {
+		t.Fatalf("NewMXSender failed: %s", err)
+	}
+	if s.port != DefaultMXPort {
+		t.Errorf("NewMXSender failed. Expected port: %d, got: %d", DefaultMXPort, s.port)
+	}
+	if _, err := NewMXSender(WithMXPort(0)); !errors.Is(err, ErrInvalidPort) {
+		t.Errorf("WithMXPort with invalid port was expected to fail, got: %v", err)
+	}
+	if _, err := NewMXSender(WithMXLookupFunc(nil)); err == nil {
+		t.Errorf("WithMXLookupFunc with nil function was expected to fail")
+	}
+}
+
+// TestMXSender_hosts tests the MX host resolution of the MXSender
+func TestMXSender_hosts(t *testing.T) {
+	tests := []struct {
+		name string
+		mxl  []*net.MX
+		err  error
+		want string
+		werr error
+	}{
+		{"MX records", []*net.MX{{Host: "mx2.example.com.", Pref: 20}, {Host: "mx1.example.com.", Pref: 10}},
+			nil, "mx1.example.com,mx2.example.com", nil},
+		{"No MX records", nil, nil, "example.com", nil},
+		{"Domain not found", nil, &net.DNSError{Err: "no such host", IsNotFound: true}, "example.com", nil},
+		{"Null MX", []*net.MX{{Host: ".", Pref: 0}}, nil, "", ErrNullMX},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewMXSender(WithMXLookupFunc(func(context.Context, string) ([]*net.MX, error) {
+				return tt.mxl, tt.err
+			}))
+			if err != nil {
+				t.Fatalf("NewMXSender failed: %s", err)
+			}
+			hl, err := s.hosts(context.Background(), "example.com")
+			if !errors.Is(err, tt.werr) {
+				t.Errorf("hosts returned unexpected error: %v", err)
+			}
+			if h := strings.Join(hl, ","); h != tt.want {
+				t.Errorf("hosts failed. Expected: %s, got: %s", tt.want, h)
+			}
+		})
+	}
+}
+
+// TestMXSender_Send tests the direct delivery to the MX with fallback over the MX list
+func TestMXSender_Send(t *testing.T) {
+	ts := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	var lookups []string
+	s, err := NewMXSender(WithMXPort(ts.Port()),
+		WithMXLookupFunc(func(_ context.Context, d string) ([]*net.MX, error) {
+			lookups = append(lookups, d)
+			if d == "nullmx.example" {
+				return []*net.MX{{Host: ".", Pref: 0}}, nil
+			}
+			// The MX with the highest preference is not reachable
+			return []*net.MX{{Host: "127.0.0.1.", Pref: 20}, {Host: "127.0.0.2.", Pref: 10}}, nil
+		}),
+		WithMXClientOptions(WithHELO("mx.example.org")))
+	if err != nil {
+		t.Fatalf("NewMXSender failed: %s", err)
+	}
+
+	m := testMsg(t)
+	if err := m.AddTo("other@EXAMPLE.com"); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	if err := s.Send(m); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	if r := m.ServerResponse(); !strings.Contains(r, "Ok: queued") {
+		t.Errorf("Send failed. Expected server response to be recorded, got: %q", r)
+	}
+	if strings.Join(lookups, ",") != "example.com" {
+		t.Errorf("Send failed. Expected a single MX lookup for example.com, got: %v", lookups)
+	}
+	if ml := testMessages(ts); len(ml) != 1 || !strings.Contains(ml[0], "This is a test body") {
+		t.Errorf("Send failed. Unexpected delivered messages: %v", ml)
+	}
+	if !hasCommand(ts, "RCPT TO:<rcpt@example.com>") || !hasCommand(ts, "RCPT TO:<other@EXAMPLE.com>") {
+		t.Errorf("Send failed. Expected both recipients in the envelope, got: %v", ts.Commands())
+	}
+	if !hasCommand(ts, "EHLO mx.example.org") {
+		t.Errorf("Send failed. Expected Client options to be applied, got: %v", ts.Commands())
+	}
+
+	m = testMsg(t)
+	if err := m.Cc("null@nullmx.example"); err != nil {
+		t.Fatalf("failed to set CC address: %s", err)
+	}
+	err = s.Send(m)
+	if err == nil || !strings.Contains(err.Error(), ErrNullMX.Error()) {
+		t.Errorf("Send to null MX domain was expected to fail, got: %v", err)
+	}
+	var se *SendError
+	if !errors.As(m.SendError(), &se) || se.IsTemp() || strings.Join(se.Rcpts(), ",") != "null@nullmx.example" {
+		t.Errorf("Send to null MX domain was expected to fail permanently for its recipient, got: %v", se)
+	}
+	if ml := testMessages(ts); len(ml) != 2 {
+		t.Errorf("Send failed. Expected message to be delivered to the other domain, got: %d messages", len(ml))
+	}
+}
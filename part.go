@@ -14,11 +14,14 @@ type PartOption func(*Part)
 
 // Part is a part of the Msg
 type Part struct {
-	ctype ContentType
-	desc  string
-	enc   Encoding
-	del   bool
-	w     func(io.Writer) (int64, error)
+	charset Charset
+	ctype   ContentType
+	desc    string
+	enc     Encoding
+	flowed  bool
+	css     bool
+	del     bool
+	w       func(io.Writer) (int64, error)
 }
 
 // GetContent executes the WriteFunc of the Part and returns the content as byte slice
@@ -30,6 +33,12 @@ func (p *Part) GetContent() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// GetCharset returns the currently set Charset of the Part. If no Charset is set for the
+// Part, the Charset of the Msg is used
+func (p *Part) GetCharset() Charset {
+	return p.charset
+}
+
 // GetContentType returns the currently set ContentType of the Part
 func (p *Part) GetContentType() ContentType {
 	return p.ctype
@@ -56,6 +65,11 @@ func (p *Part) SetContent(c string) {
 	p.w = writeFuncFromBuffer(buf)
 }
 
+// SetCharset overrides the Charset of the Msg for the Part
+func (p *Part) SetCharset(c Charset) {
+	p.charset = c
+}
+
 // SetContentType overrides the ContentType of the Part
 func (p *Part) SetContentType(c ContentType) {
 	p.ctype = c
@@ -89,6 +103,32 @@ func WithPartEncoding(e Encoding) PartOption {
 	}
 }
 
+// WithPartCharset overrides the default Part charset, which is the Charset of the Msg
+func WithPartCharset(c Charset) PartOption {
+	return func(p *Part) {
+		p.charset = c
+	}
+}
+
+// WithPartFlowed renders a text/plain Part as format=flowed text with DelSp=yes as described
+// in RFC 3676. Long lines are wrapped at FlowedLineLength characters with soft breaks, so that
+// clients that support format=flowed (e.g. on mobile devices) can reflow the paragraphs
+func WithPartFlowed() PartOption {
+	return func(p *Part) {
+		p.flowed = true
+	}
+}
+
+// WithPartCSSInlining inlines the rules of the style elements of a text/html Part into the
+// style attributes of the matching elements when the Msg is written, since many webmail
+// clients remove style elements. Only rules with type, class and ID selectors are inlined,
+// other rules (e.g. media queries) are kept in the style elements
+func WithPartCSSInlining() PartOption {
+	return func(p *Part) {
+		p.css = true
+	}
+}
+
 // WithPartContentDescription overrides the default Part Content-Description
 func WithPartContentDescription(d string) PartOption {
 	return func(p *Part) {
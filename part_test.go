@@ -45,6 +45,33 @@ func TestPartEncoding(t *testing.T) {
 	}
 }
 
+// TestPart_WithPartCharset tests the WithPartCharset and Part.SetCharset methods
+func TestPart_WithPartCharset(t *testing.T) {
+	m := NewMsg(WithCharset(CharsetUTF8))
+	m.SetBodyString(TypeTextPlain, "plain", WithPartEncoding(EncodingQP), WithPartCharset(CharsetISO88591))
+	m.AddAlternativeString(TypeTextHTML, "<p>html</p>", WithPartEncoding(EncodingB64))
+	pl := m.GetParts()
+	if pl[0].GetCharset() != CharsetISO88591 {
+		t.Errorf("WithPartCharset() failed. Expected: %s, got: %s", CharsetISO88591, pl[0].GetCharset())
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	for _, w := range []string{
+		"Content-Transfer-Encoding: quoted-printable\r\nContent-Type: text/plain; charset=ISO-8859-1",
+		"Content-Transfer-Encoding: base64\r\nContent-Type: text/html; charset=UTF-8",
+	} {
+		if !strings.Contains(buf.String(), w) {
+			t.Errorf("WithPartCharset() failed. Expected %q in message, got: %s", w, buf.String())
+		}
+	}
+	pl[0].SetCharset(CharsetUTF8)
+	if pl[0].GetCharset() != CharsetUTF8 {
+		t.Errorf("SetCharset() failed. Expected: %s, got: %s", CharsetUTF8, pl[0].GetCharset())
+	}
+}
+
 // TestPart_WithPartContentDescription tests the WithPartContentDescription method
 func TestPart_WithPartContentDescription(t *testing.T) {
 	tests := []struct {
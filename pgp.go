@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+)
+
+// ErrPGPMalformedMessage should be used if the message to be signed or encrypted has no
+// header section
+var ErrPGPMalformedMessage = errors.New("message to be PGP signed or encrypted is malformed")
+
+// PGPSigner is an interface to plug an OpenPGP implementation into the PGP/MIME signing
+// of a Msg as described in RFC 3156, section 5
+type PGPSigner interface {
+	// Sign returns the ASCII-armored, detached OpenPGP signature of the given data
+	Sign([]byte) ([]byte, error)
+	// MICAlg returns the hash algorithm used for the signature in the form required
+	// for the micalg parameter (e.g. "pgp-sha256")
+	MICAlg() string
+}
+
+// PGPEncrypter is an interface to plug an OpenPGP implementation into the PGP/MIME
+// encryption of a Msg as described in RFC 3156, section 4
+type PGPEncrypter interface {
+	// Encrypt returns the ASCII-armored OpenPGP message of the given data, encrypted for
+	// the recipients of the Msg
+	Encrypt([]byte) ([]byte, error)
+}
+
+// signPGP wraps the content of the given rendered message into a multipart/signed
+// entity, using the given PGPSigner for the signature, and returns the resulting message
+func signPGP(msg []byte, s PGPSigner) ([]byte, error) {
+	oh, e, err := splitEntity(msg)
+	if err != nil {
+		return nil, ErrPGPMalformedMessage
+	}
+	sig, err := s.Sign(e)
+	if err != nil {
+		return nil, fmt.Errorf("PGPSigner: %w", err)
+	}
+
+	b := multipart.NewWriter(nil).Boundary()
+	buf := bytes.Buffer{}
+	buf.Write(oh)
+	buf.WriteString(fmt.Sprintf("%s: multipart/signed;\r\n protocol=\"%s\";\r\n micalg=%s;\r\n"+
+		" boundary=\"%s\"%s", HeaderContentType, TypePGPSignature, s.MICAlg(), b, DoubleNewLine))
+	buf.WriteString(fmt.Sprintf("--%s%s", b, SingleNewLine))
+	buf.Write(e)
+	buf.WriteString(fmt.Sprintf("%s--%s%s", SingleNewLine, b, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s; name=\"signature.asc\"%s", HeaderContentType, TypePGPSignature,
+		SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: attachment; filename=\"signature.asc\"%s", HeaderContentDisposition,
+		DoubleNewLine))
+	buf.Write(toCRLF(sig))
+	buf.WriteString(fmt.Sprintf("--%s--%s", b, SingleNewLine))
+	return buf.Bytes(), nil
+}
+
+// encryptPGP replaces the content of the given rendered message with a multipart/encrypted
+// entity, using the given PGPEncrypter for the encryption, and returns the resulting message
+func encryptPGP(msg []byte, en PGPEncrypter) ([]byte, error) {
+	oh, e, err := splitEntity(msg)
+	if err != nil {
+		return nil, ErrPGPMalformedMessage
+	}
+	ed, err := en.Encrypt(e)
+	if err != nil {
+		return nil, fmt.Errorf("PGPEncrypter: %w", err)
+	}
+
+	b := multipart.NewWriter(nil).Boundary()
+	buf := bytes.Buffer{}
+	buf.Write(oh)
+	buf.WriteString(fmt.Sprintf("%s: multipart/encrypted;\r\n protocol=\"%s\";\r\n boundary=\"%s\"%s",
+		HeaderContentType, TypePGPEncrypted, b, DoubleNewLine))
+	buf.WriteString(fmt.Sprintf("--%s%s", b, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s%s", HeaderContentType, TypePGPEncrypted, DoubleNewLine))
+	buf.WriteString(fmt.Sprintf("Version: 1%s", SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s--%s%s", SingleNewLine, b, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s; name=\"encrypted.asc\"%s", HeaderContentType, TypeAppOctetStream,
+		SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: inline; filename=\"encrypted.asc\"%s", HeaderContentDisposition,
+		DoubleNewLine))
+	buf.Write(toCRLF(ed))
+	buf.WriteString(fmt.Sprintf("--%s--%s", b, SingleNewLine))
+	return buf.Bytes(), nil
+}
+
+// toCRLF normalizes the line breaks of the given data to CRLF and makes sure the data ends
+// with a line break
+func toCRLF(d []byte) []byte {
+	d = bytes.ReplaceAll(d, []byte(SingleNewLine), []byte("\n"))
+	d = bytes.ReplaceAll(d, []byte("\n"), []byte(SingleNewLine))
+	if !bytes.HasSuffix(d, []byte(SingleNewLine)) {
+		d = append(d, SingleNewLine...)
+	}
+	return d
+}
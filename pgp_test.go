@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// testPGP is a fake PGPSigner and PGPEncrypter that records the data it is given
+type testPGP struct {
+	data []byte
+	fail bool
+}
+
+// Sign satisfies the PGPSigner interface for testPGP
+func (tp *testPGP) Sign(d []byte) ([]byte, error) {
+	if tp.fail {
+		return nil, errors.New("signing failed")
+	}
+	tp.data = d
+	h := sha256.Sum256(d)
+	return []byte(fmt.Sprintf("-----BEGIN PGP SIGNATURE-----\n\n%x\n-----END PGP SIGNATURE-----\n",
+		h)), nil
+}
+
+// MICAlg satisfies the PGPSigner interface for testPGP
+func (tp *testPGP) MICAlg() string {
+	return "pgp-sha256"
+}
+
+// Encrypt satisfies the PGPEncrypter interface for testPGP
+func (tp *testPGP) Encrypt(d []byte) ([]byte, error) {
+	if tp.fail {
+		return nil, errors.New("encryption failed")
+	}
+	tp.data = d
+	return []byte(fmt.Sprintf("-----BEGIN PGP MESSAGE-----\n\n%s\n-----END PGP MESSAGE-----",
+		base64.StdEncoding.EncodeToString(d))), nil
+}
+
+// TestMsg_WriteTo_PGPSigner tests the rendering of a PGP/MIME signed Msg
+func TestMsg_WriteTo_PGPSigner(t *testing.T) {
+	tp := &testPGP{}
+	m := NewMsg(WithPGPSigner(tp))
+	_ = m.From("tester@example.com")
+	_ = m.To("alice@example.com")
+	m.Subject("PGP test")
+	m.SetBodyString(TypeTextPlain, "This is a test body")
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write PGP signed message: %s", err)
+	}
+
+	pl := testPGPParts(t, buf.Bytes(), "multipart/signed", "application/pgp-signature")
+	if len(pl) != 2 {
+		t.Fatalf("expected 2 parts, got: %d", len(pl))
+	}
+	if !strings.HasPrefix(string(tp.data), "Content-Type: text/plain; charset=UTF-8\r\n") {
+		t.Errorf("signed entity does not start with the Content-* headers: %q", tp.data)
+	}
+	if !bytes.Contains(buf.Bytes(), append(append([]byte(SingleNewLine), tp.data...), SingleNewLine+"--"...)) {
+		t.Errorf("signed entity is not included unaltered in the message")
+	}
+	if ct := pl[1].Header.Get("Content-Type"); !strings.HasPrefix(ct, string(TypePGPSignature)) {
+		t.Errorf("signature part has wrong Content-Type: %s", ct)
+	}
+	h := sha256.Sum256(tp.data)
+	if !strings.Contains(string(pl[1].body), fmt.Sprintf("\r\n%x\r\n", h)) {
+		t.Errorf("signature part does not contain the CRLF normalized signature: %q", pl[1].body)
+	}
+
+	m.SetPGPSigner(&testPGP{fail: true})
+	if _, err := m.WriteTo(io.Discard); err == nil {
+		t.Errorf("WriteTo with failing PGPSigner was expected to fail")
+	}
+}
+
+// TestMsg_WriteTo_PGPEncrypter tests the rendering of a PGP/MIME encrypted Msg
+func TestMsg_WriteTo_PGPEncrypter(t *testing.T) {
+	tests := []struct {
+		name string
+		sign bool
+		ct   string
+	}{
+		{"Encrypted", false, "Content-Type: text/plain; charset=UTF-8\r\n"},
+		{"Signed and encrypted", true, "Content-Type: multipart/signed;\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := &testPGP{}
+			m := NewMsg(WithPGPEncrypter(te))
+			if tt.sign {
+				m.SetPGPSigner(&testPGP{})
+			}
+			_ = m.From("tester@example.com")
+			_ = m.To("alice@example.com")
+			m.Subject("PGP test")
+			m.SetBodyString(TypeTextPlain, "This is a test body")
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write PGP encrypted message: %s", err)
+			}
+			if strings.Contains(buf.String(), "This is a test body") {
+				t.Errorf("PGP encrypted message contains the plain text body")
+			}
+			if !strings.HasPrefix(string(te.data), tt.ct) {
+				t.Errorf("encrypted entity does not start with %q: %q", tt.ct, te.data)
+			}
+
+			pl := testPGPParts(t, buf.Bytes(), "multipart/encrypted", "application/pgp-encrypted")
+			if len(pl) != 2 {
+				t.Fatalf("expected 2 parts, got: %d", len(pl))
+			}
+			if ct := pl[0].Header.Get("Content-Type"); ct != string(TypePGPEncrypted) {
+				t.Errorf("control part has wrong Content-Type: %s", ct)
+			}
+			if string(pl[0].body) != "Version: 1\r\n" {
+				t.Errorf("control part has wrong content: %q", pl[0].body)
+			}
+			if !strings.HasSuffix(string(pl[1].body), "-----END PGP MESSAGE-----") {
+				t.Errorf("encrypted part has wrong content: %q", pl[1].body)
+			}
+			if !strings.Contains(buf.String(), "-----END PGP MESSAGE-----\r\n--") {
+				t.Errorf("encrypted part is not CRLF terminated")
+			}
+		})
+	}
+}
+
+// testPGPPart is a part of a PGP/MIME message
+type testPGPPart struct {
+	Header mail.Header
+	body   []byte
+}
+
+// testPGPParts parses the given PGP/MIME message, checks its Content-Type and returns its parts
+func testPGPParts(t *testing.T, msg []byte, mt, p string) []testPGPPart {
+	t.Helper()
+	pm, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatalf("failed to parse message: %s", err)
+	}
+	if pm.Header.Get("Subject") != "PGP test" {
+		t.Errorf("message is missing the Subject header")
+	}
+	ct, pa, err := mime.ParseMediaType(pm.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("failed to parse Content-Type: %s", err)
+	}
+	if ct != mt || pa["protocol"] != p {
+		t.Errorf("wrong Content-Type. Expected: %s (%s), got: %s (%s)", mt, p, ct, pa["protocol"])
+	}
+	var pl []testPGPPart
+	mr := multipart.NewReader(pm.Body, pa["boundary"])
+	for {
+		pt, err := mr.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %s", err)
+		}
+		b, err := io.ReadAll(pt)
+		if err != nil {
+			t.Fatalf("failed to read part: %s", err)
+		}
+		pl = append(pl, testPGPPart{Header: mail.Header(pt.Header), body: b})
+	}
+	return pl
+}
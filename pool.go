@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"sync"
+)
+
+// maxPooledBufferSize is the maximum capacity of a buffer that is returned to the bufPool.
+// Larger buffers (e.g. of messages with big attachments) are left to the garbage collector,
+// so that the pool does not pin large amounts of memory
+const maxPooledBufferSize = 1 << 20
+
+// bufPool is a pool of bytes.Buffer that are used for rendering messages
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// mwPool is a pool of msgWriter that are used for rendering messages
+var mwPool = sync.Pool{
+	New: func() interface{} {
+		return &msgWriter{}
+	},
+}
+
+// getBuffer returns an empty bytes.Buffer from the bufPool
+func getBuffer() *bytes.Buffer {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns the given bytes.Buffer to the bufPool. The buffer must not be used
+// afterwards
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufPool.Put(buf)
+}
+
+// getMsgWriter returns a msgWriter from the mwPool for the given io.Writer
+func getMsgWriter(w io.Writer, c Charset, en mime.WordEncoder, bcc bool) *msgWriter {
+	mw := mwPool.Get().(*msgWriter)
+	*mw = msgWriter{w: w, c: c, en: en, bcc: bcc}
+	return mw
+}
+
+// putMsgWriter resets the given msgWriter and returns it to the mwPool. The msgWriter must
+// not be used afterwards
+func putMsgWriter(mw *msgWriter) {
+	*mw = msgWriter{}
+	mwPool.Put(mw)
+}
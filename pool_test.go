@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestBufferPool tests that pooled buffers are reset and large buffers are not pooled
+func TestBufferPool(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("test")
+	putBuffer(buf)
+	if buf = getBuffer(); buf.Len() != 0 {
+		t.Errorf("getBuffer returned a non-empty buffer: %q", buf.String())
+	}
+	putBuffer(buf)
+
+	lb := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	putBuffer(lb)
+	for i := 0; i < 10; i++ {
+		if buf = getBuffer(); buf == lb {
+			t.Errorf("putBuffer pooled a buffer that exceeds the maximum size")
+		}
+	}
+}
+
+// BenchmarkMsg_WriteTo benchmarks the rendering of a typical multipart message with an
+// alternative HTML part and a small attachment
+func BenchmarkMsg_WriteTo(b *testing.B) {
+	m := benchMsg(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.WriteTo(io.Discard); err != nil {
+			b.Fatalf("failed to write message: %s", err)
+		}
+	}
+}
+
+// BenchmarkMsg_WriteTo_parallel benchmarks the concurrent rendering of clones of a typical
+// message, as done by high-volume senders
+func BenchmarkMsg_WriteTo_parallel(b *testing.B) {
+	m := benchMsg(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		c := m.Clone()
+		for pb.Next() {
+			if _, err := c.WriteTo(io.Discard); err != nil {
+				b.Errorf("failed to write message: %s", err)
+			}
+		}
+	})
+}
+
+// benchMsg returns a typical multipart message for the benchmarks
+func benchMsg(b *testing.B) *Msg {
+	b.Helper()
+	m := NewMsg()
+	if err := m.From("Toni Tester <sender@example.com>"); err != nil {
+		b.Fatalf("failed to set FROM address: %s", err)
+	}
+	if err := m.To("rcpt1@example.com", "rcpt2@example.com", "rcpt3@example.com"); err != nil {
+		b.Fatalf("failed to set TO address: %s", err)
+	}
+	m.Subject("This is a subject line that is long enough to be folded into multiple header lines")
+	m.SetBodyString(TypeTextPlain, strings.Repeat("This is a test body. ", 100))
+	m.AddAlternativeString(TypeTextHTML, "<p>"+strings.Repeat("This is a test body. ", 100)+"</p>")
+	m.AttachReader("file.bin", bytes.NewReader(bytes.Repeat([]byte{0x00, 0xff}, 4096)))
+	return m
+}
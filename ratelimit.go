@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter limits the number of events to n within any period of time p. It is safe
+// for concurrent use
+type rateLimiter struct {
+	mu sync.Mutex
+	p  time.Duration
+	// tl is a ring buffer that holds the times of the last n events
+	tl []time.Time
+	i  int
+}
+
+// newRateLimiter returns a new rateLimiter that allows n events per period p
+func newRateLimiter(n int, p time.Duration) *rateLimiter {
+	return &rateLimiter{p: p, tl: make([]time.Time, n)}
+}
+
+// wait blocks until another event is allowed and records it
+func (r *rateLimiter) wait() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d := time.Until(r.tl[r.i].Add(r.p)); d > 0 {
+		time.Sleep(d)
+	}
+	r.tl[r.i] = time.Now()
+	r.i = (r.i + 1) % len(r.tl)
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter_wait tests that the rateLimiter never allows more than n events within
+// the period, also when used from concurrent goroutines
+func TestRateLimiter_wait(t *testing.T) {
+	n, p := 3, time.Millisecond*50
+	r := newRateLimiter(n, p)
+	var mu sync.Mutex
+	var tl []time.Time
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				r.wait()
+				mu.Lock()
+				tl = append(tl, time.Now())
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(tl) != 12 {
+		t.Fatalf("expected 12 events, got: %d", len(tl))
+	}
+	for i := n; i < len(tl); i++ {
+		if d := tl[i].Sub(tl[i-n]); d < p {
+			t.Errorf("event %d happened %s after event %d, expected at least %s", i, d, i-n, p)
+		}
+	}
+	if d := tl[len(tl)-1].Sub(tl[0]); d < p*3 {
+		t.Errorf("12 events with a limit of 3 per %s took only %s", p, d)
+	}
+}
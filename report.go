@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+)
+
+// errNoReport is returned by readReport if a message is not a multipart/report message of
+// the requested report type
+var errNoReport = errors.New("message is not a report of the requested type")
+
+// newReportFile returns a File for a part of a multipart/report Msg with the given content
+// type and content
+func newReportFile(ct ContentType, e Encoding, b []byte) *File {
+	buf := bytes.NewBuffer(b)
+	return &File{
+		ContentType: ct,
+		Enc:         e,
+		Header: map[string][]string{
+			HeaderContentType.String():        {string(ct)},
+			HeaderContentDisposition.String(): {string(DispositionInline)},
+		},
+		Writer: writeFuncFromBuffer(buf),
+	}
+}
+
+// readReport reads the given multipart/report message (RFC 6522) of the given report type and
+// calls the given function with the media type and the decoded body of every part. If the
+// message is not a report of the given type, errNoReport is returned
+func readReport(r io.Reader, rt string, fn func(mt string, pr io.Reader) error) error {
+	rm, err := mail.ReadMessage(r)
+	if err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	mt, mp, err := mime.ParseMediaType(rm.Header.Get(HeaderContentType.String()))
+	if err != nil || mt != "multipart/report" || !strings.EqualFold(mp["report-type"], rt) ||
+		mp["boundary"] == "" {
+		return errNoReport
+	}
+	mr := multipart.NewReader(rm.Body, mp["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read report part: %w", err)
+		}
+		pt, _, _ := mime.ParseMediaType(p.Header.Get(HeaderContentType.String()))
+		var pr io.Reader = p
+		if strings.EqualFold(p.Header.Get(HeaderContentTransferEnc.String()), EncodingB64.String()) {
+			pr = base64.NewDecoder(base64.StdEncoding, p)
+		}
+		if err := fn(pt, pr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import "context"
+
+// Sender is the interface for transports that deliver messages. It is implemented by the
+// SMTP Client, the MXSender, the Sendmail, the SES, the Mbox and the Maildir transport, so that applications can
+// swap the transport (e.g. for HTTP APIs or test doubles) without changing the code that
+// sends the messages.
+//
+// The method is named SendWithContext instead of Send, since Client.Send already exists
+// with a different signature
+type Sender interface {
+	SendWithContext(ctx context.Context, ml ...*Msg) error
+}
+
+// Compile-time checks that the transports of this package satisfy the Sender interface
+var (
+	_ Sender = (*Client)(nil)
+	_ Sender = (*MXSender)(nil)
+	_ Sender = (*Sendmail)(nil)
+	_ Sender = (*SES)(nil)
+	_ Sender = (*Mbox)(nil)
+	_ Sender = (*Maildir)(nil)
+)
@@ -6,6 +6,11 @@ package mail
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"regexp"
 	"strings"
 )
 
@@ -46,28 +51,71 @@ const (
 	ErrConnCheck
 
 	// ErrNoUnencoded is returned if the Msg delivery failed when the Msg is configured for
-	// unencoded delivery but the server does not support this
+	// unencoded delivery but the server does not support this and the 8bit downgrade is
+	// disabled via Without8BitDowngrade
 	ErrNoUnencoded
 
 	// ErrAmbiguous is a generalized delivery error for the SendError type that is
 	// returned if the exact reason for the delivery failure is ambiguous
 	ErrAmbiguous
+
+	// ErrNoSMTPUTF8 is returned if the Msg delivery failed because the envelope addresses
+	// require SMTPUTF8 but the server does not support this
+	ErrNoSMTPUTF8
+
+	// ErrNoRequireTLS is returned if the Msg delivery failed because REQUIRETLS was requested,
+	// but the server does not support REQUIRETLS or the connection is not encrypted
+	ErrNoRequireTLS
+
+	// ErrSuppressionCheck is returned if the Msg delivery failed because the SuppressionChecker
+	// of the Client failed to check a recipient address
+	ErrSuppressionCheck
+
+	// ErrAllRcptsSuppressed is returned if the Msg was not delivered because all its recipient
+	// addresses are suppressed by the SuppressionChecker of the Client
+	ErrAllRcptsSuppressed
+
+	// ErrSizeLimit is returned if the Msg was not delivered because it exceeds the size limit
+	// that was set via WithMaxMessageSize
+	ErrSizeLimit
+
+	// ErrReconnect is returned if the Msg delivery failed because the connection to the SMTP
+	// server could not be re-established before a retry of the delivery
+	ErrReconnect
 )
 
 // SendError is an error wrapper for delivery errors of the Msg
 type SendError struct {
-	Reason  SendErrReason
-	isTemp  bool
-	errlist []error
-	rcpt    []string
+	Reason   SendErrReason
+	isTemp   bool
+	errlist  []error
+	rcpt     []string
+	rcptErrs []*RcptError
+}
+
+// RcptError holds the details of a recipient address that was rejected by the server
+// during the RCPT TO command
+type RcptError struct {
+	// Rcpt is the rejected recipient address
+	Rcpt string
+	// Code is the SMTP reply code of the server
+	Code int
+	// EnhancedCode is the enhanced status code (RFC 3463) of the reply, if the server
+	// provided one (e.g. "5.1.1")
+	EnhancedCode string
+	// Msg is the reply text of the server without the enhanced status code
+	Msg string
 }
 
+// enhancedCodeRegexp matches the enhanced status code at the start of an SMTP reply
+var enhancedCodeRegexp = regexp.MustCompile(`^([245]\.\d{1,3}\.\d{1,3})\s*`)
+
 // SendErrReason represents a comparable reason on why the delivery failed
 type SendErrReason int
 
 // Error implements the error interface for the SendError type
 func (e *SendError) Error() string {
-	if e.Reason > 10 {
+	if e.Reason > ErrReconnect {
 		return "unknown reason"
 	}
 
@@ -104,11 +152,81 @@ func (e *SendError) Is(et error) bool {
 	return false
 }
 
+// Unwrap returns the list of errors that caused the delivery error, so that errors.Is and
+// errors.As can match the SMTPError replies of the server (requires Go 1.20 or later)
+func (e *SendError) Unwrap() []error {
+	return e.errlist
+}
+
 // IsTemp returns true if the delivery error is of temporary nature and can be retried
 func (e *SendError) IsTemp() bool {
 	return e.isTemp
 }
 
+// retryable returns true if the delivery of the Msg can be retried, because the error is
+// of temporary nature or was caused by a broken connection. Errors that occur after the
+// server accepted the message are not retryable, since a retry would deliver it twice
+func (e *SendError) retryable() bool {
+	if e.Reason == ErrSMTPReset || e.Reason == ErrConnCheck {
+		return false
+	}
+	if e.isTemp || e.Reason == ErrReconnect {
+		return true
+	}
+	for _, err := range e.errlist {
+		var ne net.Error
+		if errors.As(err, &ne) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, ErrNoActiveConnection) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rcpts returns the list of recipient addresses that are affected by the delivery error
+func (e *SendError) Rcpts() []string {
+	return e.rcpt
+}
+
+// RcptErrors returns the details of all recipient addresses that were rejected by the
+// server. Each Msg of a Send operation has its own SendError (see Msg.SendError)
+func (e *SendError) RcptErrors() []*RcptError {
+	return e.rcptErrs
+}
+
+// Error implements the error interface for the RcptError type
+func (e *RcptError) Error() string {
+	switch {
+	case e.Code == 0:
+		return fmt.Sprintf("recipient %s rejected: %s", e.Rcpt, e.Msg)
+	case e.EnhancedCode != "":
+		return fmt.Sprintf("recipient %s rejected: %d %s %s", e.Rcpt, e.Code, e.EnhancedCode, e.Msg)
+	default:
+		return fmt.Sprintf("recipient %s rejected: %d %s", e.Rcpt, e.Code, e.Msg)
+	}
+}
+
+// IsTemp returns true if the recipient was rejected temporarily and can be retried
+func (e *RcptError) IsTemp() bool {
+	return e.Code >= 400 && e.Code < 500
+}
+
+// newRcptError returns the RcptError for the given recipient address and error of the
+// RCPT TO command
+func newRcptError(r string, err error) *RcptError {
+	re := &RcptError{Rcpt: r, Msg: err.Error()}
+	var te *textproto.Error
+	if errors.As(err, &te) {
+		err = newSMTPError(te)
+	}
+	var se *SMTPError
+	if !errors.As(err, &se) {
+		return re
+	}
+	re.Code, re.EnhancedCode, re.Msg = se.Code, se.EnhancedCode, se.Message
+	return re
+}
+
 // String implements the Stringer interface for the SendErrReason
 func (r SendErrReason) String() string {
 	switch r {
@@ -134,6 +252,18 @@ func (r SendErrReason) String() string {
 		return ErrServerNoUnencoded.Error()
 	case ErrAmbiguous:
 		return "ambiguous reason, check Msg.SendError for message specific reasons"
+	case ErrNoSMTPUTF8:
+		return ErrServerNoSMTPUTF8.Error()
+	case ErrNoRequireTLS:
+		return ErrServerNoRequireTLS.Error()
+	case ErrSuppressionCheck:
+		return "checking recipient suppression"
+	case ErrAllRcptsSuppressed:
+		return "all recipients are suppressed"
+	case ErrSizeLimit:
+		return "message exceeds the size limit"
+	case ErrReconnect:
+		return "reconnecting to SMTP server"
 	}
 	return "unknown reason"
 }
@@ -7,6 +7,7 @@ package mail
 import (
 	"errors"
 	"fmt"
+	"net/textproto"
 	"strings"
 	"testing"
 )
@@ -40,6 +41,13 @@ func TestSendError_Error(t *testing.T) {
 		{"ErrNoUnencoded/perm", ErrNoUnencoded, false},
 		{"ErrAmbiguous/temp", ErrAmbiguous, true},
 		{"ErrAmbiguous/perm", ErrAmbiguous, false},
+		{"ErrNoSMTPUTF8/temp", ErrNoSMTPUTF8, true},
+		{"ErrNoSMTPUTF8/perm", ErrNoSMTPUTF8, false},
+		{"ErrSuppressionCheck/temp", ErrSuppressionCheck, true},
+		{"ErrAllRcptsSuppressed/perm", ErrAllRcptsSuppressed, false},
+		{"ErrSizeLimit/perm", ErrSizeLimit, false},
+		{"ErrReconnect/temp", ErrReconnect, true},
+		{"ErrReconnect/perm", ErrReconnect, false},
 		{"Unknown/temp", 9999, true},
 		{"Unknown/perm", 9999, false},
 	}
@@ -87,3 +95,40 @@ func TestSendError_IsTemp(t *testing.T) {
 func returnSendError(r SendErrReason, t bool) error {
 	return &SendError{Reason: r, isTemp: t}
 }
+
+// TestNewRcptError tests the parsing of RCPT TO errors into a RcptError
+func TestNewRcptError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RcptError
+		es   string
+	}{
+		{
+			"with enhanced code", &textproto.Error{Code: 550, Msg: "5.1.1 No such user"},
+			RcptError{Rcpt: "a@example.com", Code: 550, EnhancedCode: "5.1.1", Msg: "No such user"},
+			"recipient a@example.com rejected: 550 5.1.1 No such user",
+		},
+		{
+			"without enhanced code", &textproto.Error{Code: 451, Msg: "Try again later"},
+			RcptError{Rcpt: "a@example.com", Code: 451, Msg: "Try again later"},
+			"recipient a@example.com rejected: 451 Try again later",
+		},
+		{
+			"no SMTP error", errors.New("connection reset"),
+			RcptError{Rcpt: "a@example.com", Msg: "connection reset"},
+			"recipient a@example.com rejected: connection reset",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := newRcptError("a@example.com", tt.err)
+			if *re != tt.want {
+				t.Errorf("newRcptError failed. Expected: %+v, got: %+v", tt.want, *re)
+			}
+			if re.Error() != tt.es {
+				t.Errorf("RcptError.Error failed. Expected: %q, got: %q", tt.es, re.Error())
+			}
+		})
+	}
+}
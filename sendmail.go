@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultSendmailTimeout is the default timeout for the delivery of a single message via
+// the sendmail binary
+const DefaultSendmailTimeout = time.Second * 5
+
+// ErrInvalidSendmailPath should be used if an empty sendmail path is provided
+var ErrInvalidSendmailPath = errors.New("sendmail path cannot be empty")
+
+// Sendmail delivers messages by piping them into a local sendmail compatible binary (like
+// the ones of sendmail, Postfix or Exim). It can be used as an alternative to the Client
+// in environments without a reachable SMTP relay
+type Sendmail struct {
+	// path is the system path to the sendmail binary
+	path string
+
+	// args are additional arguments for the sendmail binary
+	args []string
+
+	// timeout is the timeout for the delivery of a single message
+	timeout time.Duration
+}
+
+// SendmailOption returns a function that can be used for grouping Sendmail options
+type SendmailOption func(*Sendmail) error
+
+// NewSendmail returns a new Sendmail transport that uses the sendmail binary at SendmailPath,
+// unless overridden with WithSendmailPath
+func NewSendmail(o ...SendmailOption) (*Sendmail, error) {
+	s := &Sendmail{
+		path:    SendmailPath,
+		timeout: DefaultSendmailTimeout,
+	}
+
+	// Override defaults with optionally provided SendmailOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(s); err != nil {
+			return s, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// WithSendmailPath overrides the default system path to the sendmail binary
+func WithSendmailPath(p string) SendmailOption {
+	return func(s *Sendmail) error {
+		if p == "" {
+			return ErrInvalidSendmailPath
+		}
+		s.path = p
+		return nil
+	}
+}
+
+// WithSendmailArgs sets additional arguments that are passed to the sendmail binary
+func WithSendmailArgs(a ...string) SendmailOption {
+	return func(s *Sendmail) error {
+		s.args = a
+		return nil
+	}
+}
+
+// WithSendmailTimeout overrides the default timeout for the delivery of a single message
+func WithSendmailTimeout(t time.Duration) SendmailOption {
+	return func(s *Sendmail) error {
+		if t <= 0 {
+			return ErrInvalidTimeout
+		}
+		s.timeout = t
+		return nil
+	}
+}
+
+// Path returns the system path to the sendmail binary of the Sendmail transport
+func (s *Sendmail) Path() string {
+	return s.path
+}
+
+// Send delivers the given messages via the sendmail binary. See SendWithContext for details
+func (s *Sendmail) Send(ml ...*Msg) error {
+	return s.SendWithContext(context.Background(), ml...)
+}
+
+// SendWithContext delivers the given messages one by one via the sendmail binary, which is
+// called with the -t flag to read the recipients from the message headers. The envelope
+// sender of the message (see Msg.GetSender) is passed with the -f flag. Delivery stops at
+// the first message that cannot be delivered
+func (s *Sendmail) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	for _, m := range ml {
+		if err := s.send(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send delivers a single message via the sendmail binary
+func (s *Sendmail) send(ctx context.Context, m *Msg) error {
+	f, err := m.GetSender(false)
+	if err != nil {
+		return fmt.Errorf("failed to get envelope sender: %w", err)
+	}
+	a := append([]string{"-f", f}, s.args...)
+	tctx, tcfn := context.WithTimeout(ctx, s.timeout)
+	defer tcfn()
+	if err := m.WriteToSendmailWithContext(tctx, s.path, a...); err != nil {
+		return fmt.Errorf("failed to deliver message via sendmail: %w", err)
+	}
+	return nil
+}
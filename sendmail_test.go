@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !windows
+// +build !windows
+
+package mail
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNewSendmail tests the options of NewSendmail
+func TestNewSendmail(t *testing.T) {
+	s, err := NewSendmail()
+	if err != nil {
+		t.Fatalf("NewSendmail failed: %s", err)
+	}
+	if s.Path() != SendmailPath {
+		t.Errorf("NewSendmail failed. Expected path: %s, got: %s", SendmailPath, s.Path())
+	}
+	if _, err := NewSendmail(WithSendmailPath("")); !errors.Is(err, ErrInvalidSendmailPath) {
+		t.Errorf("WithSendmailPath with empty path was expected to fail, got: %v", err)
+	}
+	if _, err := NewSendmail(WithSendmailTimeout(0)); !errors.Is(err, ErrInvalidTimeout) {
+		t.Errorf("WithSendmailTimeout with zero timeout was expected to fail, got: %v", err)
+	}
+}
+
+// TestSendmail_Send tests the delivery via a fake sendmail binary that records its arguments
+// and the piped message
+func TestSendmail_Send(t *testing.T) {
+	if os.Getenv("TEST_SKIP_SENDMAIL") != "" {
+		t.Skipf("TEST_SKIP_SENDMAIL variable is set. Skipping sendmail test")
+	}
+	td := t.TempDir()
+	sp := filepath.Join(td, "sendmail")
+	sc := "#!/bin/sh\necho \"$@\" > " + filepath.Join(td, "args") + "\ncat > " + filepath.Join(td, "msg") + "\n"
+	if err := os.WriteFile(sp, []byte(sc), 0o700); err != nil {
+		t.Fatalf("failed to write fake sendmail binary: %s", err)
+	}
+	s, err := NewSendmail(WithSendmailPath(sp), WithSendmailArgs("-v"))
+	if err != nil {
+		t.Fatalf("NewSendmail failed: %s", err)
+	}
+
+	m := testMsg(t)
+	if err := m.EnvelopeFrom("bounce@example.com"); err != nil {
+		t.Fatalf("failed to set envelope from: %s", err)
+	}
+	if err := m.Bcc("hidden@example.com"); err != nil {
+		t.Fatalf("failed to set BCC address: %s", err)
+	}
+	if err := s.Send(m); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	a, err := os.ReadFile(filepath.Join(td, "args"))
+	if err != nil {
+		t.Fatalf("failed to read sendmail arguments: %s", err)
+	}
+	if ea := "-oi -t -f bounce@example.com -v\n"; string(a) != ea {
+		t.Errorf("Send failed. Expected sendmail arguments: %q, got: %q", ea, string(a))
+	}
+	mb, err := os.ReadFile(filepath.Join(td, "msg"))
+	if err != nil {
+		t.Fatalf("failed to read piped message: %s", err)
+	}
+	if !strings.Contains(string(mb), "Bcc: <hidden@example.com>\r\n") {
+		t.Errorf("Send failed. Expected Bcc header in piped message, got: %s", mb)
+	}
+	if !strings.Contains(string(mb), "This is a test body") {
+		t.Errorf("Send failed. Expected message body in piped message, got: %s", mb)
+	}
+
+	if err := s.Send(NewMsg()); !errors.Is(err, ErrNoFromAddress) {
+		t.Errorf("Send without sender was expected to fail with %q, got: %v", ErrNoFromAddress, err)
+	}
+}
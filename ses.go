@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sesAPIVersion is the version of the SES Query API that is used for SendRawEmail
+	sesAPIVersion = "2010-12-01"
+
+	// sesService is the service name of SES for the AWS Signature Version 4
+	sesService = "ses"
+
+	// sesAmzDateFormat is the time format of the X-Amz-Date header
+	sesAmzDateFormat = "20060102T150405Z"
+)
+
+var (
+	// ErrSESNoRegion should be used if an SES transport is created without an AWS region
+	ErrSESNoRegion = errors.New("AWS region for SES cannot be empty")
+
+	// ErrSESNoCredentials should be used if an SES transport is created without AWS credentials
+	ErrSESNoCredentials = errors.New("AWS access key ID and secret access key for SES cannot be empty")
+)
+
+// SES delivers messages via the SendRawEmail API of Amazon SES. The messages are rendered
+// by go-mail and submitted as raw RFC 5322 message, so all MIME features of go-mail can be
+// used. The API requests are signed with the AWS Signature Version 4
+type SES struct {
+	// region is the AWS region of the SES endpoint
+	region string
+
+	// akid is the AWS access key ID
+	akid string
+
+	// secret is the AWS secret access key
+	secret string
+
+	// token is the optional AWS session token of temporary credentials
+	token string
+
+	// endpoint is the URL of the SES API
+	endpoint string
+
+	// confset is the optional name of the SES configuration set
+	confset string
+
+	// hc is the http.Client that is used for the API requests
+	hc *http.Client
+
+	// now returns the current time for the request signature
+	now func() time.Time
+}
+
+// SESOption returns a function that can be used for grouping SES options
+type SESOption func(*SES) error
+
+// SESError is returned if the SES API rejected a request
+type SESError struct {
+	// StatusCode is the HTTP status code of the API response
+	StatusCode int
+	// Type is the error type reported by SES (usually "Sender" or "Receiver")
+	Type string `xml:"Error>Type"`
+	// Code is the error code reported by SES (e.g. "MessageRejected")
+	Code string `xml:"Error>Code"`
+	// Message is the error message reported by SES
+	Message string `xml:"Error>Message"`
+}
+
+// sesSendRawEmailResponse is the XML response of the SendRawEmail API
+type sesSendRawEmailResponse struct {
+	MessageID string `xml:"SendRawEmailResult>MessageId"`
+}
+
+// NewSES returns a new SES transport for the given AWS region and credentials
+func NewSES(r, akid, secret string, o ...SESOption) (*SES, error) {
+	if r == "" {
+		return nil, ErrSESNoRegion
+	}
+	if akid == "" || secret == "" {
+		return nil, ErrSESNoCredentials
+	}
+	s := &SES{
+		region:   r,
+		akid:     akid,
+		secret:   secret,
+		endpoint: fmt.Sprintf("https://email.%s.amazonaws.com/", r),
+		hc:       &http.Client{Timeout: DefaultTimeout},
+		now:      time.Now,
+	}
+
+	// Override defaults with optionally provided SESOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(s); err != nil {
+			return s, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// WithSESSessionToken sets the AWS session token, which is required for temporary credentials
+func WithSESSessionToken(t string) SESOption {
+	return func(s *SES) error {
+		s.token = t
+		return nil
+	}
+}
+
+// WithSESEndpoint overrides the default SES API endpoint of the region (e.g. for VPC endpoints)
+func WithSESEndpoint(e string) SESOption {
+	return func(s *SES) error {
+		u, err := url.Parse(e)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid SES endpoint %q", e)
+		}
+		s.endpoint = e
+		return nil
+	}
+}
+
+// WithSESConfigurationSet sets the name of the SES configuration set that is used for sending
+func WithSESConfigurationSet(c string) SESOption {
+	return func(s *SES) error {
+		s.confset = c
+		return nil
+	}
+}
+
+// WithSESHTTPClient overrides the default http.Client that is used for the API requests
+func WithSESHTTPClient(hc *http.Client) SESOption {
+	return func(s *SES) error {
+		if hc == nil {
+			return fmt.Errorf("http.Client must not be nil")
+		}
+		s.hc = hc
+		return nil
+	}
+}
+
+// Error implements the error interface for the SESError type
+func (e *SESError) Error() string {
+	return fmt.Sprintf("SES API request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Send delivers the given messages via the SES API. See SendWithContext for details
+func (s *SES) Send(ml ...*Msg) error {
+	return s.SendWithContext(context.Background(), ml...)
+}
+
+// SendWithContext delivers the given messages one by one via the SES SendRawEmail API and
+// satisfies the Sender interface. The envelope sender of the message (see Msg.GetSender)
+// is used as Source and all To, Cc and Bcc addresses as Destinations. Delivery stops at
+// the first message that cannot be delivered
+func (s *SES) SendWithContext(ctx context.Context, ml ...*Msg) error {
+	for _, m := range ml {
+		if _, err := s.send(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send delivers a single message via the SES API and returns the SES message ID
+func (s *SES) send(ctx context.Context, m *Msg) (string, error) {
+	f, err := m.GetSender(false)
+	if err != nil {
+		return "", fmt.Errorf("failed to get envelope sender: %w", err)
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		return "", fmt.Errorf("failed to get recipients: %w", err)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		return "", fmt.Errorf("failed to render message: %w", err)
+	}
+
+	p := url.Values{}
+	p.Set("Action", "SendRawEmail")
+	p.Set("Version", sesAPIVersion)
+	p.Set("Source", f)
+	for i, r := range rl {
+		p.Set("Destinations.member."+strconv.Itoa(i+1), r)
+	}
+	p.Set("RawMessage.Data", base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if s.confset != "" {
+		p.Set("ConfigurationSetName", s.confset)
+	}
+	b := p.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(b))
+	if err != nil {
+		return "", fmt.Errorf("failed to create SES API request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	s.sign(req, []byte(b))
+
+	res, err := s.hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("SES API request failed: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	rb, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SES API response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		se := &SESError{StatusCode: res.StatusCode}
+		if err := xml.Unmarshal(rb, se); err != nil || se.Code == "" {
+			se.Code = http.StatusText(res.StatusCode)
+			se.Message = strings.TrimSpace(string(rb))
+		}
+		return "", se
+	}
+	var sr sesSendRawEmailResponse
+	if err := xml.Unmarshal(rb, &sr); err != nil {
+		return "", fmt.Errorf("failed to parse SES API response: %w", err)
+	}
+	return sr.MessageID, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the given SES API request
+func (s *SES) sign(req *http.Request, b []byte) {
+	t := s.now().UTC()
+	ad := t.Format(sesAmzDateFormat)
+	req.Header.Set("X-Amz-Date", ad)
+	if s.token != "" {
+		req.Header.Set("X-Amz-Security-Token", s.token)
+	}
+
+	hl := []string{"content-type", "host", "x-amz-date"}
+	if s.token != "" {
+		hl = append(hl, "x-amz-security-token")
+	}
+	ch := strings.Builder{}
+	for _, h := range hl {
+		v := req.Header.Get(h)
+		if h == "host" {
+			v = req.URL.Host
+		}
+		ch.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	sh := strings.Join(hl, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	cr := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, ch.String(), sh, sha256Hex(b),
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format("20060102"), s.region, sesService, "aws4_request"}, "/")
+	sts := strings.Join([]string{"AWS4-HMAC-SHA256", ad, scope, sha256Hex([]byte(cr))}, "\n")
+	k := sigV4Key(s.secret, t.Format("20060102"), s.region, sesService)
+	sig := hex.EncodeToString(hmacSHA256(k, []byte(sts)))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.akid, scope, sh, sig))
+}
+
+// sigV4Key derives the AWS Signature Version 4 signing key for the given secret access key,
+// date (in the format YYYYMMDD), region and service
+func sigV4Key(secret, d, r, svc string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), []byte(d))
+	k = hmacSHA256(k, []byte(r))
+	k = hmacSHA256(k, []byte(svc))
+	return hmacSHA256(k, []byte("aws4_request"))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the given data with the given key
+func hmacSHA256(k, d []byte) []byte {
+	h := hmac.New(sha256.New, k)
+	_, _ = h.Write(d)
+	return h.Sum(nil)
+}
+
+// sha256Hex returns the hex encoded SHA256 hash of the given data
+func sha256Hex(d []byte) string {
+	h := sha256.Sum256(d)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewSES tests the validation of NewSES
+func TestNewSES(t *testing.T) {
+	if _, err := NewSES("", "akid", "secret"); !errors.Is(err, ErrSESNoRegion) {
+		t.Errorf("NewSES without region was expected to fail with %q, got: %v", ErrSESNoRegion, err)
+	}
+	if _, err := NewSES("eu-central-1", "", "secret"); !errors.Is(err, ErrSESNoCredentials) {
+		t.Errorf("NewSES without credentials was expected to fail with %q, got: %v", ErrSESNoCredentials, err)
+	}
+	s, err := NewSES("eu-central-1", "akid", "secret")
+	if err != nil {
+		t.Fatalf("NewSES failed: %s", err)
+	}
+	if s.endpoint != "https://email.eu-central-1.amazonaws.com/" {
+		t.Errorf("NewSES failed. Unexpected endpoint: %s", s.endpoint)
+	}
+	if _, err := NewSES("eu-central-1", "akid", "secret", WithSESEndpoint("invalid")); err == nil {
+		t.Errorf("WithSESEndpoint with invalid endpoint was expected to fail")
+	}
+}
+
+// TestSigV4Key tests the signing key derivation with the example of the AWS documentation
+func TestSigV4Key(t *testing.T) {
+	k := sigV4Key("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	if e := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"; hex.EncodeToString(k) != e {
+		t.Errorf("sigV4Key failed. Expected: %s, got: %x", e, k)
+	}
+}
+
+// TestSES_Send tests the SendRawEmail request against a fake SES API
+func TestSES_Send(t *testing.T) {
+	var req *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse request form: %s", err)
+		}
+		req = r
+		if r.PostForm.Get("Source") == "rejected@example.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>MessageRejected</Code>` +
+				`<Message>Email address is not verified.</Message></Error></ErrorResponse>`))
+			return
+		}
+		_, _ = w.Write([]byte(`<SendRawEmailResponse><SendRawEmailResult><MessageId>0102-test</MessageId>` +
+			`</SendRawEmailResult></SendRawEmailResponse>`))
+	}))
+	defer ts.Close()
+
+	s, err := NewSES("eu-central-1", "AKIDEXAMPLE", "secret", WithSESEndpoint(ts.URL),
+		WithSESSessionToken("token"), WithSESConfigurationSet("confset"))
+	if err != nil {
+		t.Fatalf("NewSES failed: %s", err)
+	}
+	s.now = func() time.Time { return time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC) }
+	m := testMsg(t)
+	if err := m.Bcc("hidden@example.com"); err != nil {
+		t.Fatalf("failed to set BCC address: %s", err)
+	}
+	var sd Sender = s
+	if err := sd.SendWithContext(context.Background(), m); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+
+	f := req.PostForm
+	for k, v := range map[string]string{
+		"Action": "SendRawEmail", "Version": sesAPIVersion, "Source": "sender@example.com",
+		"Destinations.member.1": "rcpt@example.com", "Destinations.member.2": "hidden@example.com",
+		"ConfigurationSetName": "confset",
+	} {
+		if f.Get(k) != v {
+			t.Errorf("Send failed. Expected parameter %s to be %q, got: %q", k, v, f.Get(k))
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(f.Get("RawMessage.Data"))
+	if err != nil {
+		t.Fatalf("failed to decode raw message: %s", err)
+	}
+	if !strings.Contains(string(raw), "This is a test body") || strings.Contains(string(raw), "Bcc:") {
+		t.Errorf("Send failed. Unexpected raw message: %s", raw)
+	}
+	if req.Header.Get("X-Amz-Date") != "20230102T030405Z" || req.Header.Get("X-Amz-Security-Token") != "token" {
+		t.Errorf("Send failed. Unexpected AWS headers: %v", req.Header)
+	}
+	ah := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230102/eu-central-1/ses/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token, Signature="
+	if a := req.Header.Get("Authorization"); !strings.HasPrefix(a, ah) || len(a) != len(ah)+64 {
+		t.Errorf("Send failed. Unexpected Authorization header: %s", a)
+	}
+
+	if err := m.From("rejected@example.com"); err != nil {
+		t.Fatalf("failed to set FROM address: %s", err)
+	}
+	err = s.Send(m)
+	var se *SESError
+	if !errors.As(err, &se) || se.Code != "MessageRejected" || se.StatusCode != http.StatusBadRequest {
+		t.Errorf("Send was expected to fail with SESError MessageRejected, got: %v", err)
+	}
+}
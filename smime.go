@@ -0,0 +1,475 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TypeSMIMESignature is the content type of a detached S/MIME signature
+const TypeSMIMESignature ContentType = "application/pkcs7-signature"
+
+// TypeSMIMEMime is the content type of a S/MIME enveloped (encrypted) message
+const TypeSMIMEMime ContentType = "application/pkcs7-mime"
+
+// List of ASN.1 object identifiers used for the PKCS#7/CMS structures (RFC 5652)
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidEnvelopedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidAES256CBC         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var (
+	// ErrSMIMEInvalidKey should be used if the provided key is not a supported crypto.Signer
+	ErrSMIMEInvalidKey = errors.New("S/MIME key must be a RSA or ECDSA private key")
+
+	// ErrSMIMENoCertificate should be used if no signing certificate is provided
+	ErrSMIMENoCertificate = errors.New("S/MIME signing certificate must not be empty")
+
+	// ErrSMIMEKeyMismatch should be used if the private key does not match the public key
+	// of the signing certificate
+	ErrSMIMEKeyMismatch = errors.New("S/MIME private key does not match the certificate")
+
+	// ErrSMIMEMalformedMessage should be used if the message to be signed or encrypted has no
+	// header section
+	ErrSMIMEMalformedMessage = errors.New("message to be S/MIME signed or encrypted is malformed")
+
+	// ErrSMIMENoRecipients should be used if no recipient certificate is provided for the
+	// S/MIME encryption
+	ErrSMIMENoRecipients = errors.New("S/MIME encryption requires at least one recipient certificate")
+
+	// ErrSMIMEUnsupportedCert should be used if a recipient certificate does not hold a RSA
+	// public key
+	ErrSMIMEUnsupportedCert = errors.New("S/MIME encryption only supports RSA recipient certificates")
+)
+
+// SMIMESigner signs rendered messages with a detached S/MIME signature as described in
+// RFC 8551. The Msg body is wrapped into a multipart/signed entity with an
+// application/pkcs7-signature part. RSA and ECDSA keys are supported.
+//
+// Since the signed content must not be altered in transit, the Msg should use a 7bit
+// safe encoding (i. e. quoted-printable or base64)
+type SMIMESigner struct {
+	// key is the private key used for the signature
+	key crypto.Signer
+
+	// cert is the signing certificate
+	cert *x509.Certificate
+
+	// chain is the optional list of intermediate certificates that are included
+	// in the signature
+	chain []*x509.Certificate
+}
+
+// pkcs7ContentInfo represents the CMS ContentInfo type (RFC 5652, section 3)
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+// pkcs7EncapContentInfo represents the CMS EncapsulatedContentInfo type of a detached
+// signature, which does not include the eContent
+type pkcs7EncapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+}
+
+// pkcs7SignedData represents the CMS SignedData type (RFC 5652, section 5.1)
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo pkcs7EncapContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []pkcs7SignerInfo `asn1:"set"`
+}
+
+// pkcs7IssuerAndSerial represents the CMS IssuerAndSerialNumber type
+type pkcs7IssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// pkcs7SignerInfo represents the CMS SignerInfo type (RFC 5652, section 5.3)
+type pkcs7SignerInfo struct {
+	Version               int
+	IssuerAndSerialNumber pkcs7IssuerAndSerial
+	DigestAlgorithm       pkix.AlgorithmIdentifier
+	SignedAttrs           asn1.RawValue
+	SignatureAlgorithm    pkix.AlgorithmIdentifier
+	Signature             []byte
+}
+
+// pkcs7EnvelopedData represents the CMS EnvelopedData type (RFC 5652, section 6.1)
+type pkcs7EnvelopedData struct {
+	Version              int
+	RecipientInfos       asn1.RawValue
+	EncryptedContentInfo pkcs7EncryptedContentInfo
+}
+
+// pkcs7KeyTransRecipientInfo represents the CMS KeyTransRecipientInfo type (RFC 5652,
+// section 6.2.1)
+type pkcs7KeyTransRecipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  pkcs7IssuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// pkcs7EncryptedContentInfo represents the CMS EncryptedContentInfo type
+type pkcs7EncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue
+}
+
+// pkcs7Attribute represents the CMS Attribute type
+type pkcs7Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// NewSMIMESigner returns a new SMIMESigner for the given private key and signing certificate.
+// Optionally, intermediate certificates can be provided, which will be included in the
+// signature
+func NewSMIMESigner(k crypto.Signer, c *x509.Certificate, ic ...*x509.Certificate) (*SMIMESigner, error) {
+	if c == nil {
+		return nil, ErrSMIMENoCertificate
+	}
+	switch k.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, ErrSMIMEInvalidKey
+	}
+	pk, ok := k.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pk.Equal(c.PublicKey) {
+		return nil, ErrSMIMEKeyMismatch
+	}
+	return &SMIMESigner{key: k, cert: c, chain: ic}, nil
+}
+
+// NewSMIMESignerFromKeyPair returns a new SMIMESigner for the given tls.Certificate, as
+// returned by tls.LoadX509KeyPair or tls.X509KeyPair. Any additional certificate of the
+// key pair is treated as intermediate certificate
+func NewSMIMESignerFromKeyPair(kp tls.Certificate) (*SMIMESigner, error) {
+	if len(kp.Certificate) == 0 {
+		return nil, ErrSMIMENoCertificate
+	}
+	k, ok := kp.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, ErrSMIMEInvalidKey
+	}
+	var cl []*x509.Certificate
+	for _, cb := range kp.Certificate {
+		c, err := x509.ParseCertificate(cb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse S/MIME certificate: %w", err)
+		}
+		cl = append(cl, c)
+	}
+	return NewSMIMESigner(k, cl[0], cl[1:]...)
+}
+
+// Sign returns the DER encoded, detached PKCS#7 signature for the given content
+func (s *SMIMESigner) Sign(c []byte) ([]byte, error) {
+	return s.signAt(c, time.Now())
+}
+
+// signAt returns the DER encoded, detached PKCS#7 signature for the given content and
+// signing time
+func (s *SMIMESigner) signAt(c []byte, t time.Time) ([]byte, error) {
+	md := sha256.Sum256(c)
+	sa, err := marshalAttributes(
+		[]asn1.ObjectIdentifier{oidAttrContentType, oidAttrSigningTime, oidAttrMessageDigest},
+		[]interface{}{oidData, t.UTC(), md[:]},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature is calculated over the DER encoding of the signed attributes with
+	// an explicit SET OF tag (RFC 5652, section 5.4)
+	ss, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: sa})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal S/MIME signed attributes: %w", err)
+	}
+	h := sha256.Sum256(ss)
+	sig, err := s.key.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S/MIME signature: %w", err)
+	}
+	sai := pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+	if _, ok := s.key.(*ecdsa.PrivateKey); ok {
+		sai = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	}
+
+	cb := bytes.Buffer{}
+	for _, c := range append([]*x509.Certificate{s.cert}, s.chain...) {
+		cb.Write(c.Raw)
+	}
+	dai := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	sd := pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{dai},
+		EncapContentInfo: pkcs7EncapContentInfo{EContentType: oidData},
+		Certificates: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+			Bytes: cb.Bytes(),
+		},
+		SignerInfos: []pkcs7SignerInfo{{
+			Version: 1,
+			IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+				Issuer:       asn1.RawValue{FullBytes: s.cert.RawIssuer},
+				SerialNumber: s.cert.SerialNumber,
+			},
+			DigestAlgorithm: dai,
+			SignedAttrs: asn1.RawValue{
+				Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+				Bytes: sa,
+			},
+			SignatureAlgorithm: sai,
+			Signature:          sig,
+		}},
+	}
+	sdb, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal S/MIME signed data: %w", err)
+	}
+	ci := pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+			Bytes: sdb,
+		},
+	}
+	return asn1.Marshal(ci)
+}
+
+// signMsg wraps the content of the given rendered message into a multipart/signed entity
+// and returns the resulting message
+func (s *SMIMESigner) signMsg(msg []byte) ([]byte, error) {
+	oh, e, err := splitEntity(msg)
+	if err != nil {
+		return nil, ErrSMIMEMalformedMessage
+	}
+	sig, err := s.Sign(e)
+	if err != nil {
+		return nil, err
+	}
+
+	b := multipart.NewWriter(nil).Boundary()
+	buf := bytes.Buffer{}
+	buf.Write(oh)
+	buf.WriteString(fmt.Sprintf("%s: multipart/signed;\r\n protocol=\"%s\";\r\n micalg=sha-256;\r\n"+
+		" boundary=\"%s\"%s", HeaderContentType, TypeSMIMESignature, b, DoubleNewLine))
+	buf.WriteString(fmt.Sprintf("--%s%s", b, SingleNewLine))
+	buf.Write(e)
+	buf.WriteString(fmt.Sprintf("%s--%s%s", SingleNewLine, b, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s; name=\"smime.p7s\"%s", HeaderContentType, TypeSMIMESignature,
+		SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s%s", HeaderContentTransferEnc, EncodingB64, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: attachment; filename=\"smime.p7s\"%s", HeaderContentDisposition,
+		DoubleNewLine))
+	lb := Base64LineBreaker{out: &buf}
+	ew := base64.NewEncoder(base64.StdEncoding, &lb)
+	if _, err := ew.Write(sig); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	if err := lb.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString(fmt.Sprintf("--%s--%s", b, SingleNewLine))
+	return buf.Bytes(), nil
+}
+
+// encryptSMIME replaces the content of the given rendered message with a S/MIME enveloped
+// entity that is encrypted for the given recipient certificates and returns the resulting
+// message
+func encryptSMIME(msg []byte, cl []*x509.Certificate) ([]byte, error) {
+	oh, e, err := splitEntity(msg)
+	if err != nil {
+		return nil, ErrSMIMEMalformedMessage
+	}
+	ed, err := envelopeSMIME(e, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	buf.Write(oh)
+	buf.WriteString(fmt.Sprintf("%s: %s; smime-type=enveloped-data;\r\n name=\"smime.p7m\"%s",
+		HeaderContentType, TypeSMIMEMime, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: %s%s", HeaderContentTransferEnc, EncodingB64, SingleNewLine))
+	buf.WriteString(fmt.Sprintf("%s: attachment; filename=\"smime.p7m\"%s", HeaderContentDisposition,
+		DoubleNewLine))
+	lb := Base64LineBreaker{out: &buf}
+	ew := base64.NewEncoder(base64.StdEncoding, &lb)
+	if _, err := ew.Write(ed); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	if err := lb.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// envelopeSMIME returns the DER encoded PKCS#7 enveloped data of the given content. The
+// content is encrypted with AES-256-CBC and the content encryption key is encrypted with
+// RSAES-PKCS1-v1_5 for each of the recipient certificates
+func envelopeSMIME(c []byte, cl []*x509.Certificate) ([]byte, error) {
+	if len(cl) == 0 {
+		return nil, ErrSMIMENoRecipients
+	}
+	k := make([]byte, 32)
+	if _, err := rand.Read(k); err != nil {
+		return nil, fmt.Errorf("failed to generate S/MIME content encryption key: %w", err)
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("failed to generate S/MIME initialization vector: %w", err)
+	}
+	bc, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	// The content is padded as described in RFC 5652, section 6.3
+	pl := aes.BlockSize - len(c)%aes.BlockSize
+	ec := make([]byte, len(c)+pl)
+	copy(ec, c)
+	copy(ec[len(c):], bytes.Repeat([]byte{byte(pl)}, pl))
+	cipher.NewCBCEncrypter(bc, iv).CryptBlocks(ec, ec)
+
+	ril := make([][]byte, 0, len(cl))
+	for _, rc := range cl {
+		pk, ok := rc.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrSMIMEUnsupportedCert
+		}
+		ek, err := rsa.EncryptPKCS1v15(rand.Reader, pk, k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt S/MIME content encryption key: %w", err)
+		}
+		ri, err := asn1.Marshal(pkcs7KeyTransRecipientInfo{
+			Version: 0,
+			IssuerAndSerialNumber: pkcs7IssuerAndSerial{
+				Issuer:       asn1.RawValue{FullBytes: rc.RawIssuer},
+				SerialNumber: rc.SerialNumber,
+			},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue,
+			},
+			EncryptedKey: ek,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal S/MIME recipient info: %w", err)
+		}
+		ril = append(ril, ri)
+	}
+	sort.Slice(ril, func(i, j int) bool {
+		return bytes.Compare(ril[i], ril[j]) < 0
+	})
+
+	ivb, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal S/MIME initialization vector: %w", err)
+	}
+	ed, err := asn1.Marshal(pkcs7EnvelopedData{
+		Version:        0,
+		RecipientInfos: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(ril, nil)},
+		EncryptedContentInfo: pkcs7EncryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivb},
+			},
+			EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ec},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal S/MIME enveloped data: %w", err)
+	}
+	return asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidEnvelopedData,
+		Content: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+			Bytes: ed,
+		},
+	})
+}
+
+// marshalAttributes returns the DER encoded list of CMS attributes with the given types and
+// values. The attributes are sorted as required for a DER encoded SET OF
+func marshalAttributes(tl []asn1.ObjectIdentifier, vl []interface{}) ([]byte, error) {
+	al := make([][]byte, 0, len(tl))
+	for i := range tl {
+		v, err := asn1.Marshal(vl[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal S/MIME attribute value: %w", err)
+		}
+		a, err := asn1.Marshal(pkcs7Attribute{
+			Type:   tl[i],
+			Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: v},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal S/MIME attribute: %w", err)
+		}
+		al = append(al, a)
+	}
+	sort.Slice(al, func(i, j int) bool {
+		return bytes.Compare(al[i], al[j]) < 0
+	})
+	return bytes.Join(al, nil), nil
+}
+
+// splitEntity splits the given rendered message into the message header fields and the
+// MIME entity, consisting of the Content-* header fields and the body
+func splitEntity(msg []byte) ([]byte, []byte, error) {
+	hb, bb, err := splitMsg(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	oh := bytes.Buffer{}
+	e := bytes.Buffer{}
+	for _, h := range parseHeaders(hb) {
+		if strings.HasPrefix(strings.ToLower(h), "content-") {
+			e.WriteString(h)
+			continue
+		}
+		oh.WriteString(h)
+	}
+	e.WriteString(SingleNewLine)
+	e.Write(bb)
+	return oh.Bytes(), e.Bytes(), nil
+}
@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewSMIMESigner tests the NewSMIMESigner and NewSMIMESignerFromKeyPair methods
+func TestNewSMIMESigner(t *testing.T) {
+	k, c := testSMIMECert(t, testRSAKey(t))
+	ok, _ := testSMIMECert(t, testRSAKey(t))
+	_, ek, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tests := []struct {
+		name string
+		k    crypto.Signer
+		c    *x509.Certificate
+		err  error
+	}{
+		{"Valid", k, c, nil},
+		{"No certificate", k, nil, ErrSMIMENoCertificate},
+		{"Ed25519 key", ek, c, ErrSMIMEInvalidKey},
+		{"Key mismatch", ok, c, ErrSMIMEKeyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSMIMESigner(tt.k, tt.c)
+			if tt.err == nil && err != nil {
+				t.Errorf("NewSMIMESigner failed: %s", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("NewSMIMESigner was expected to fail with %q, got: %s", tt.err, err)
+			}
+		})
+	}
+
+	kd, err := x509.MarshalPKCS8PrivateKey(k)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %s", err)
+	}
+	kp, err := tls.X509KeyPair(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: kd}))
+	if err != nil {
+		t.Fatalf("failed to create key pair: %s", err)
+	}
+	if _, err := NewSMIMESignerFromKeyPair(kp); err != nil {
+		t.Errorf("NewSMIMESignerFromKeyPair failed: %s", err)
+	}
+	if _, err := NewSMIMESignerFromKeyPair(tls.Certificate{}); !errors.Is(err, ErrSMIMENoCertificate) {
+		t.Errorf("NewSMIMESignerFromKeyPair with empty key pair was expected to fail, got: %s", err)
+	}
+}
+
+// TestMsg_WriteTo_SMIME tests the rendering of a S/MIME signed Msg with RSA and ECDSA keys
+func TestMsg_WriteTo_SMIME(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %s", err)
+	}
+	tests := []struct {
+		name string
+		k    crypto.Signer
+		a    x509.SignatureAlgorithm
+	}{
+		{"RSA", testRSAKey(t), x509.SHA256WithRSA},
+		{"ECDSA", ec, x509.ECDSAWithSHA256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, c := testSMIMECert(t, tt.k)
+			s, err := NewSMIMESigner(k, c)
+			if err != nil {
+				t.Fatalf("failed to create S/MIME signer: %s", err)
+			}
+			m := NewMsg(WithSMIMESigner(s))
+			_ = m.From("tester@example.com")
+			_ = m.To("alice@example.com")
+			m.Subject("S/MIME test")
+			m.SetBodyString(TypeTextPlain, "This is a test body")
+			m.AttachReader("test.txt", strings.NewReader("This is a test attachment"))
+			buf := bytes.Buffer{}
+			n, err := m.WriteTo(&buf)
+			if err != nil {
+				t.Fatalf("failed to write S/MIME signed message: %s", err)
+			}
+			if n != int64(buf.Len()) {
+				t.Errorf("WriteTo returned wrong byte count. Expected: %d, got: %d", buf.Len(), n)
+			}
+			ms := buf.String()
+			if !strings.Contains(ms, "Content-Type: multipart/signed;\r\n protocol=\"application/pkcs7-signature\"") {
+				t.Errorf("S/MIME signed message is missing the multipart/signed Content-Type: %s", ms)
+			}
+			if !strings.Contains(ms, "Subject: S/MIME test\r\n") {
+				t.Errorf("S/MIME signed message is missing the Subject header: %s", ms)
+			}
+
+			e, sig, err := testSplitSMIME(buf.Bytes())
+			if err != nil {
+				t.Fatalf("failed to split S/MIME message: %s", err)
+			}
+			if !bytes.HasPrefix(e, []byte("Content-Type: multipart/mixed;")) {
+				t.Errorf("signed entity does not start with the Content-Type header: %s", e)
+			}
+			if err := testVerifySMIME(e, sig, c, tt.a); err != nil {
+				t.Errorf("S/MIME signature verification failed: %s", err)
+			}
+			if err := testVerifySMIME(append(e, 'X'), sig, c, tt.a); err == nil {
+				t.Errorf("S/MIME signature verification of tampered content was expected to fail")
+			}
+		})
+	}
+}
+
+// TestMsg_EncryptSMIME tests the rendering of a S/MIME encrypted Msg
+func TestMsg_EncryptSMIME(t *testing.T) {
+	k1, c1 := testSMIMECert(t, testRSAKey(t))
+	k2, c2 := testSMIMECert(t, testRSAKey(t))
+	ek, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ECDSA key: %s", err)
+	}
+	_, ec := testSMIMECert(t, ek)
+	s, err := NewSMIMESigner(k1, c1)
+	if err != nil {
+		t.Fatalf("failed to create S/MIME signer: %s", err)
+	}
+
+	m := NewMsg()
+	if err := m.EncryptSMIME(); !errors.Is(err, ErrSMIMENoRecipients) {
+		t.Errorf("EncryptSMIME without certificates was expected to fail, got: %s", err)
+	}
+	if err := m.EncryptSMIME(c1, ec); !errors.Is(err, ErrSMIMEUnsupportedCert) {
+		t.Errorf("EncryptSMIME with ECDSA certificate was expected to fail, got: %s", err)
+	}
+
+	tests := []struct {
+		name string
+		sign bool
+	}{
+		{"Encrypted", false},
+		{"Signed and encrypted", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMsg()
+			if tt.sign {
+				m.SetSMIMESigner(s)
+			}
+			_ = m.From("tester@example.com")
+			_ = m.To("alice@example.com", "bob@example.com")
+			m.Subject("S/MIME test")
+			m.SetBodyString(TypeTextPlain, "This is a test body")
+			if err := m.EncryptSMIME(c1, c2); err != nil {
+				t.Fatalf("EncryptSMIME failed: %s", err)
+			}
+			buf := bytes.Buffer{}
+			if _, err := m.WriteTo(&buf); err != nil {
+				t.Fatalf("failed to write S/MIME encrypted message: %s", err)
+			}
+			ms := buf.String()
+			if !strings.Contains(ms, "Content-Type: application/pkcs7-mime; smime-type=enveloped-data;") {
+				t.Errorf("S/MIME encrypted message is missing the application/pkcs7-mime Content-Type: %s", ms)
+			}
+			if strings.Contains(ms, "This is a test body") {
+				t.Errorf("S/MIME encrypted message contains the plain text body")
+			}
+			for _, k := range []*rsa.PrivateKey{k1.(*rsa.PrivateKey), k2.(*rsa.PrivateKey)} {
+				e, err := testDecryptSMIME(buf.Bytes(), k)
+				if err != nil {
+					t.Fatalf("failed to decrypt S/MIME message: %s", err)
+				}
+				ct := "Content-Type: text/plain; charset=UTF-8"
+				if tt.sign {
+					ct = "Content-Type: multipart/signed;"
+				}
+				if !bytes.HasPrefix(e, []byte(ct)) {
+					t.Errorf("decrypted entity does not start with %q: %s", ct, e)
+				}
+			}
+		})
+	}
+}
+
+// testDecryptSMIME decrypts the S/MIME enveloped entity of the given rendered message
+func testDecryptSMIME(msg []byte, k *rsa.PrivateKey) ([]byte, error) {
+	i := bytes.Index(msg, []byte(DoubleNewLine))
+	d, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(msg[i+4:]), "\r\n", ""))
+	if err != nil {
+		return nil, err
+	}
+	var ci pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(d, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("unexpected content type: %s", ci.ContentType)
+	}
+	var ed pkcs7EnvelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, err
+	}
+	var ck []byte
+	rest := ed.RecipientInfos.Bytes
+	for len(rest) > 0 && ck == nil {
+		var ri pkcs7KeyTransRecipientInfo
+		if rest, err = asn1.Unmarshal(rest, &ri); err != nil {
+			return nil, err
+		}
+		ck, _ = rsa.DecryptPKCS1v15(rand.Reader, k, ri.EncryptedKey)
+	}
+	if ck == nil {
+		return nil, fmt.Errorf("no recipient info for the given key")
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(ed.EncryptedContentInfo.ContentEncryptionAlgorithm.Parameters.FullBytes,
+		&iv); err != nil {
+		return nil, err
+	}
+	bc, err := aes.NewCipher(ck)
+	if err != nil {
+		return nil, err
+	}
+	c := ed.EncryptedContentInfo.EncryptedContent.Bytes
+	cipher.NewCBCDecrypter(bc, iv).CryptBlocks(c, c)
+	return c[:len(c)-int(c[len(c)-1])], nil
+}
+
+// testRSAKey returns a new RSA key for testing
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %s", err)
+	}
+	return k
+}
+
+// testSMIMECert returns the given key and a self-signed certificate for it
+func testSMIMECert(t *testing.T, k crypto.Signer) (crypto.Signer, *x509.Certificate) {
+	t.Helper()
+	tpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(1337),
+		Subject:        pkix.Name{CommonName: "tester@example.com"},
+		EmailAddresses: []string{"tester@example.com"},
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+	}
+	d, err := x509.CreateCertificate(rand.Reader, tpl, tpl, k.Public(), k)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %s", err)
+	}
+	c, err := x509.ParseCertificate(d)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %s", err)
+	}
+	return k, c
+}
+
+// testSplitSMIME returns the signed entity and the DER encoded signature of a rendered
+// S/MIME signed message
+func testSplitSMIME(msg []byte) ([]byte, []byte, error) {
+	ms := string(msg)
+	bi := strings.Index(ms, "boundary=\"")
+	if bi < 0 {
+		return nil, nil, fmt.Errorf("no boundary found")
+	}
+	b := ms[bi+10:]
+	b = "--" + b[:strings.Index(b, "\"")]
+	pl := strings.Split(ms, b)
+	if len(pl) != 4 {
+		return nil, nil, fmt.Errorf("expected 2 parts, got: %d", len(pl)-2)
+	}
+	e := strings.TrimSuffix(strings.TrimPrefix(pl[1], "\r\n"), "\r\n")
+	sp := pl[2][strings.Index(pl[2], DoubleNewLine)+4:]
+	sig, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(sp, "\r\n", ""))
+	return []byte(e), sig, err
+}
+
+// testVerifySMIME verifies the detached PKCS#7 signature of the given content
+func testVerifySMIME(e, sig []byte, c *x509.Certificate, a x509.SignatureAlgorithm) error {
+	var ci pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(sig, &ci); err != nil {
+		return err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return fmt.Errorf("unexpected content type: %s", ci.ContentType)
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return err
+	}
+	if len(sd.SignerInfos) != 1 {
+		return fmt.Errorf("expected 1 signer info, got: %d", len(sd.SignerInfos))
+	}
+	if !bytes.Equal(sd.Certificates.Bytes, c.Raw) {
+		return fmt.Errorf("signature does not include the signing certificate")
+	}
+	si := sd.SignerInfos[0]
+	if si.IssuerAndSerialNumber.SerialNumber.Cmp(c.SerialNumber) != 0 {
+		return fmt.Errorf("serial number mismatch")
+	}
+
+	// Find the message digest in the signed attributes
+	var md []byte
+	rest := si.SignedAttrs.Bytes
+	for len(rest) > 0 {
+		var at pkcs7Attribute
+		var err error
+		rest, err = asn1.Unmarshal(rest, &at)
+		if err != nil {
+			return err
+		}
+		if at.Type.Equal(oidAttrMessageDigest) {
+			if _, err := asn1.Unmarshal(at.Values.Bytes, &md); err != nil {
+				return err
+			}
+		}
+	}
+	h := sha256.Sum256(e)
+	if !bytes.Equal(md, h[:]) {
+		return fmt.Errorf("message digest mismatch")
+	}
+
+	sa, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: si.SignedAttrs.Bytes})
+	if err != nil {
+		return err
+	}
+	return c.CheckSignature(a, sa, si.Signature)
+}
@@ -18,6 +18,9 @@
 //	AUTH      RFC 2554
 //	STARTTLS  RFC 3207
 //	DSN       RFC 1891
+//	PIPELINING RFC 2920
+//	CHUNKING  RFC 3030
+//	SMTPUTF8  RFC 6531
 package smtp
 
 import (
@@ -30,6 +33,7 @@ import (
 	"net/textproto"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/wneessen/go-mail/log"
 )
@@ -55,9 +59,15 @@ type Client struct {
 	// debug logging
 	debug  bool       // debug logging is enabled
 	logger log.Logger // logger will be used for debug logging
+	isAuth bool       // AUTH exchange in progress, credentials are redacted in the debug log
 	// DSN support
-	dsnmrtype string // dsnmrtype defines the mail return option in case DSN is enabled
-	dsnrntype string // dsnrntype defines the recipient notify option in case DSN is enabled
+	dsnmrtype string            // dsnmrtype defines the mail return option in case DSN is enabled
+	dsnrntype string            // dsnrntype defines the recipient notify option in case DSN is enabled
+	dsnrcpt   map[string]string // dsnrcpt overrides the recipient notify option for single recipients
+	// REQUIRETLS support
+	requireTLS bool // requireTLS defines if the REQUIRETLS parameter is added to the MAIL command
+	// cmdTimeout is the time the server has to respond to a command
+	cmdTimeout time.Duration
 }
 
 // logDirection is a type wrapper for the direction a debug log message goes
@@ -131,6 +141,7 @@ func (c *Client) Hello(localName string) error {
 
 // cmd is a convenience function that sends a command and returns the response
 func (c *Client) cmd(expectCode int, format string, args ...interface{}) (int, string, error) {
+	c.extendDeadline()
 	c.debugLog(logOut, format, args...)
 	id, err := c.Text.Cmd(format, args...)
 	if err != nil {
@@ -193,6 +204,23 @@ func (c *Client) Verify(addr string) error {
 	return err
 }
 
+// Expand asks the server to expand the given mailing list and returns the lines of the
+// reply, which usually contain one mailbox each. Many servers will not expand mailing
+// lists for security reasons.
+func (c *Client) Expand(list string) ([]string, error) {
+	if err := validateLine(list); err != nil {
+		return nil, err
+	}
+	if err := c.hello(); err != nil {
+		return nil, err
+	}
+	_, msg, err := c.cmd(250, "EXPN %s", list)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(msg, "\n"), nil
+}
+
 // Auth authenticates a client using the provided authentication mechanism.
 // A failed authentication closes the connection.
 // Only servers that advertise the AUTH extension support this function.
@@ -210,6 +238,8 @@ func (c *Client) Auth(a Auth) error {
 	}
 	resp64 := make([]byte, encoding.EncodedLen(len(resp)))
 	encoding.Encode(resp64, resp)
+	c.isAuth = true
+	defer func() { c.isAuth = false }()
 	code, msg64, err := c.cmd(0, strings.TrimSpace(fmt.Sprintf("AUTH %s %s", mech, resp64)))
 	for err == nil {
 		var msg []byte
@@ -228,6 +258,7 @@ func (c *Client) Auth(a Auth) error {
 		if err != nil {
 			// abort the AUTH
 			_, _, _ = c.cmd(501, "*")
+			c.isAuth = false
 			_ = c.Quit()
 			break
 		}
@@ -244,7 +275,8 @@ func (c *Client) Auth(a Auth) error {
 // Mail issues a MAIL command to the server using the provided email address.
 // If the server supports the 8BITMIME extension, Mail adds the BODY=8BITMIME
 // parameter. If the server supports the SMTPUTF8 extension, Mail adds the
-// SMTPUTF8 parameter.
+// SMTPUTF8 parameter. If REQUIRETLS was requested via SetRequireTLS and the server
+// supports it on the TLS connection, Mail adds the REQUIRETLS parameter.
 // This initiates a mail transaction and is followed by one or more Rcpt calls.
 func (c *Client) Mail(from string) error {
 	if err := validateLine(from); err != nil {
@@ -253,6 +285,13 @@ func (c *Client) Mail(from string) error {
 	if err := c.hello(); err != nil {
 		return err
 	}
+	_, _, err := c.cmd(250, c.mailCmd(), from)
+	return err
+}
+
+// mailCmd returns the format string for the MAIL command including all parameters that
+// are supported by the server
+func (c *Client) mailCmd() string {
 	cmdStr := "MAIL FROM:<%s>"
 	if c.ext != nil {
 		if _, ok := c.ext["8BITMIME"]; ok {
@@ -265,9 +304,11 @@ func (c *Client) Mail(from string) error {
 		if ok && c.dsnmrtype != "" {
 			cmdStr += fmt.Sprintf(" RET=%s", c.dsnmrtype)
 		}
+		if _, ok := c.ext["REQUIRETLS"]; ok && c.requireTLS && c.tls {
+			cmdStr += " REQUIRETLS"
+		}
 	}
-	_, _, err := c.cmd(250, cmdStr, from)
-	return err
+	return cmdStr
 }
 
 // Rcpt issues a RCPT command to the server using the provided email address.
@@ -277,36 +318,291 @@ func (c *Client) Rcpt(to string) error {
 	if err := validateLine(to); err != nil {
 		return err
 	}
-	_, ok := c.ext["DSN"]
-	if ok && c.dsnrntype != "" {
-		_, _, err := c.cmd(25, "RCPT TO:<%s> NOTIFY=%s", to, c.dsnrntype)
-		return err
-	}
-	_, _, err := c.cmd(25, "RCPT TO:<%s>", to)
+	_, _, err := c.cmd(25, c.rcptCmd(to), to)
 	return err
 }
 
+// rcptCmd returns the format string for the RCPT command to the given recipient including
+// all parameters that are supported by the server
+func (c *Client) rcptCmd(to string) string {
+	if _, ok := c.ext["DSN"]; !ok {
+		return "RCPT TO:<%s>"
+	}
+	n := c.dsnrntype
+	if rn, ok := c.dsnrcpt[to]; ok {
+		n = rn
+	}
+	if n != "" {
+		return fmt.Sprintf("RCPT TO:<%%s> NOTIFY=%s", n)
+	}
+	return "RCPT TO:<%s>"
+}
+
+// MailRcpt issues a MAIL command for the provided from address, followed by a RCPT
+// command for each of the provided recipient addresses. If the server supports the
+// PIPELINING extension, all commands are sent as one batch and the replies are read
+// afterwards, saving a round trip per recipient. Otherwise the commands are issued
+// one after another.
+//
+// The returned error is only non-nil if the MAIL command itself failed. The errors
+// of the RCPT commands are returned as slice that matches the order of the provided
+// recipient addresses. An entry is nil if the recipient was accepted by the server.
+func (c *Client) MailRcpt(from string, to []string) ([]error, error) {
+	if err := validateLine(from); err != nil {
+		return nil, err
+	}
+	for _, r := range to {
+		if err := validateLine(r); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.hello(); err != nil {
+		return nil, err
+	}
+	if _, ok := c.ext["PIPELINING"]; !ok {
+		if _, _, err := c.cmd(250, c.mailCmd(), from); err != nil {
+			return nil, err
+		}
+		rerrs := make([]error, len(to))
+		for i, r := range to {
+			_, _, rerrs[i] = c.cmd(25, c.rcptCmd(r), r)
+		}
+		return rerrs, nil
+	}
+
+	errs, err := c.pipeline(c.mailRcptCmds(from, to))
+	if err != nil {
+		return nil, err
+	}
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	return errs[1:], nil
+}
+
+// MailRcptData issues a MAIL command for the provided from address, a RCPT command for
+// each of the provided recipient addresses and a DATA command as one batch, as described
+// in RFC 2920. It saves the round trip of the DATA command compared to MailRcpt followed
+// by Data. Only servers that advertise the PIPELINING extension support this function.
+//
+// The returned err is only non-nil if the MAIL command itself failed. The errors of the
+// RCPT commands are returned as rerrs, which matches the order of the provided recipient
+// addresses. If the server accepted the DATA command, the returned writer w can be used to
+// write the mail headers and body like the writer of Data, otherwise derr holds the error
+// of the DATA command. Since the server replies to DATA before the client knows the
+// replies to the RCPT commands, w may be returned although recipients were rejected. In
+// that case the mail data is sent to the accepted recipients, unless the caller aborts the
+// transaction by closing the connection without closing w.
+func (c *Client) MailRcptData(from string, to []string) (rerrs []error, w io.WriteCloser, derr, err error) {
+	if err := validateLine(from); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, r := range to {
+		if err := validateLine(r); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if err := c.hello(); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, ok := c.ext["PIPELINING"]; !ok {
+		return nil, nil, nil, errors.New("smtp: server does not support PIPELINING")
+	}
+
+	cmds := append(c.mailRcptCmds(from, to), pipelinedCmd{code: 354, format: "DATA"})
+	errs, err := c.pipeline(cmds)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	derr = errs[len(errs)-1]
+	if derr == nil {
+		w = &dataCloser{c: c, WriteCloser: c.Text.DotWriter()}
+	}
+	return errs[1 : len(errs)-1], w, derr, errs[0]
+}
+
+// pipelinedCmd is a command that is sent to the server as part of a pipelined batch
+type pipelinedCmd struct {
+	code   int
+	format string
+	args   []interface{}
+}
+
+// mailRcptCmds returns the MAIL command for the provided from address followed by a RCPT
+// command for each of the provided recipient addresses
+func (c *Client) mailRcptCmds(from string, to []string) []pipelinedCmd {
+	cmds := make([]pipelinedCmd, 0, len(to)+2)
+	cmds = append(cmds, pipelinedCmd{code: 250, format: c.mailCmd(), args: []interface{}{from}})
+	for _, r := range to {
+		cmds = append(cmds, pipelinedCmd{code: 25, format: c.rcptCmd(r), args: []interface{}{r}})
+	}
+	return cmds
+}
+
+// pipeline sends the given commands to the server as one batch and reads the replies
+// afterwards. It returns the errors of the replies in the order of the commands. If a
+// command cannot be sent, the replies of the already sent commands are skipped, so that
+// later commands do not block on them, and the connection is closed, since its state is
+// unknown
+func (c *Client) pipeline(cmds []pipelinedCmd) ([]error, error) {
+	ids := make([]uint, 0, len(cmds))
+	for _, cmd := range cmds {
+		id, err := c.pipelineCmd(cmd.format, cmd.args...)
+		ids = append(ids, id)
+		if err != nil {
+			for _, id := range ids {
+				c.Text.StartResponse(id)
+				c.Text.EndResponse(id)
+			}
+			_ = c.Text.Close()
+			return nil, err
+		}
+	}
+	errs := make([]error, len(cmds))
+	for i, id := range ids {
+		_, _, errs[i] = c.pipelineResponse(id, cmds[i].code)
+	}
+	return errs, nil
+}
+
+// pipelineCmd sends a command to the server without waiting for the response. The
+// returned id has to be passed to pipelineResponse to read the corresponding reply. The
+// id is returned even if the command could not be sent, so that its reply can be skipped
+func (c *Client) pipelineCmd(format string, args ...interface{}) (uint, error) {
+	c.extendDeadline()
+	c.debugLog(logOut, format, args...)
+	id := c.Text.Next()
+	c.Text.StartRequest(id)
+	err := c.Text.PrintfLine(format, args...)
+	c.Text.EndRequest(id)
+	return id, err
+}
+
+// pipelineResponse reads the response for a command that was sent via pipelineCmd
+func (c *Client) pipelineResponse(id uint, expectCode int) (int, string, error) {
+	c.Text.StartResponse(id)
+	defer c.Text.EndResponse(id)
+	code, msg, err := c.Text.ReadResponse(expectCode)
+	c.debugLog(logIn, "%d %s", code, msg)
+	return code, msg, err
+}
+
 type dataCloser struct {
 	c *Client
 	io.WriteCloser
+	resp string
 }
 
 func (d *dataCloser) Close() error {
 	_ = d.WriteCloser.Close()
-	_, _, err := d.c.Text.ReadResponse(250)
+	var err error
+	_, d.resp, err = d.c.Text.ReadResponse(250)
 	return err
 }
 
+// ServerResponse returns the reply text of the server to the end of the mail data, which
+// typically contains the queue ID of the message. It is empty until the writer is closed
+func (d *dataCloser) ServerResponse() string {
+	return d.resp
+}
+
 // Data issues a DATA command to the server and returns a writer that
 // can be used to write the mail headers and body. The caller should
 // close the writer before calling any more methods on c. A call to
-// Data must be preceded by one or more calls to Rcpt.
+// Data must be preceded by one or more calls to Rcpt. After the writer is
+// closed, the reply text of the server is available via its ServerResponse
+// method.
 func (c *Client) Data() (io.WriteCloser, error) {
 	_, _, err := c.cmd(354, "DATA")
 	if err != nil {
 		return nil, err
 	}
-	return &dataCloser{c, c.Text.DotWriter()}, nil
+	return &dataCloser{c: c, WriteCloser: c.Text.DotWriter()}, nil
+}
+
+// DefaultChunkSize is the default size of a single BDAT chunk in bytes
+const DefaultChunkSize = 1024 * 1024
+
+// chunkWriter is an io.WriteCloser that transfers the written data to the server in
+// BDAT chunks as described in RFC 3030. Bare LF line endings are converted to CRLF
+type chunkWriter struct {
+	c    *Client
+	buf  []byte
+	cs   int
+	cr   bool
+	resp string
+}
+
+// Write implements the io.Writer interface for the chunkWriter
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' && !w.cr {
+			w.buf = append(w.buf, '\r')
+		}
+		w.cr = b == '\r'
+		w.buf = append(w.buf, b)
+		if len(w.buf) >= w.cs {
+			if _, err := w.c.bdat(w.buf, false); err != nil {
+				return 0, err
+			}
+			w.buf = w.buf[:0]
+		}
+	}
+	return len(p), nil
+}
+
+// Close sends the remaining data as last BDAT chunk to the server
+func (w *chunkWriter) Close() error {
+	var err error
+	w.resp, err = w.c.bdat(w.buf, true)
+	return err
+}
+
+// ServerResponse returns the reply text of the server to the last BDAT chunk, which
+// typically contains the queue ID of the message. It is empty until the writer is closed
+func (w *chunkWriter) ServerResponse() string {
+	return w.resp
+}
+
+// Bdat issues the CHUNKING extension's BDAT command to the server and returns
+// a writer that can be used to write the mail headers and body. The data is sent to
+// the server in chunks of the given size. Other than with Data, the content is not
+// dot-stuffed. The caller should close the writer before calling any more methods
+// on c. A call to Bdat must be preceded by one or more calls to Rcpt. After the
+// writer is closed, the reply text of the server is available via its ServerResponse
+// method.
+// Only servers that advertise the CHUNKING extension support this function.
+func (c *Client) Bdat(size int) (io.WriteCloser, error) {
+	if _, ok := c.ext["CHUNKING"]; !ok {
+		return nil, errors.New("smtp: server does not support CHUNKING")
+	}
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	return &chunkWriter{c: c, cs: size, buf: make([]byte, 0, size)}, nil
+}
+
+// bdat sends a single BDAT chunk to the server and returns the reply text of its response
+func (c *Client) bdat(p []byte, last bool) (string, error) {
+	cmd := fmt.Sprintf("BDAT %d", len(p))
+	if last {
+		cmd += " LAST"
+	}
+	c.debugLog(logOut, "%s", cmd)
+	id := c.Text.Next()
+	c.Text.StartRequest(id)
+	err := c.Text.PrintfLine("%s", cmd)
+	if err == nil {
+		if _, err = c.Text.W.Write(p); err == nil {
+			err = c.Text.W.Flush()
+		}
+	}
+	c.Text.EndRequest(id)
+	if err != nil {
+		return "", err
+	}
+	_, msg, err := c.pipelineResponse(id, 250)
+	return msg, err
 }
 
 var testHookStartTLS func(*tls.Config) // nil, except for tests
@@ -469,6 +765,33 @@ func (c *Client) SetDSNRcptNotifyOption(d string) {
 	c.dsnrntype = d
 }
 
+// SetDSNRcptNotifyOptions sets DSN recipient notify options for single recipients, that
+// take precedence over the option of SetDSNRcptNotifyOption. An empty value requests no
+// NOTIFY parameter for the recipient. A nil map clears all recipient specific options
+func (c *Client) SetDSNRcptNotifyOptions(o map[string]string) {
+	c.dsnrcpt = o
+}
+
+// SetCommandTimeout sets the time the server has to respond to a single command. The
+// deadline of the connection is extended by the timeout before every command. A zero
+// timeout leaves the deadline of the connection unchanged
+func (c *Client) SetCommandTimeout(t time.Duration) {
+	c.cmdTimeout = t
+}
+
+// extendDeadline extends the deadline of the connection by the command timeout, if set
+func (c *Client) extendDeadline() {
+	if c.cmdTimeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.cmdTimeout))
+	}
+}
+
+// SetRequireTLS sets whether the Mail method adds the REQUIRETLS parameter as described in
+// RFC 8689. The parameter is only added on TLS connections to servers that support it
+func (c *Client) SetRequireTLS(v bool) {
+	c.requireTLS = v
+}
+
 // debugLog checks if the debug flag is set and if so logs the provided message to StdErr
 func (c *Client) debugLog(d logDirection, f string, a ...interface{}) {
 	if c.debug {
@@ -476,11 +799,30 @@ func (c *Client) debugLog(d logDirection, f string, a ...interface{}) {
 		if d == logOut {
 			p = "C --> S:"
 		}
+		if d == logOut && c.isAuth {
+			c.logger.Debugf("%s %s", p, redactAuth(fmt.Sprintf(f, a...)))
+			return
+		}
 		fs := fmt.Sprintf("%s %s", p, f)
 		c.logger.Debugf(fs, a...)
 	}
 }
 
+// redactAuth replaces the credentials in an outgoing line of the AUTH exchange with a
+// placeholder, so that they do not end up in the debug log
+func redactAuth(l string) string {
+	if l == "*" {
+		return l
+	}
+	if strings.HasPrefix(strings.ToUpper(l), "AUTH ") {
+		if f := strings.Fields(l); len(f) > 2 {
+			return fmt.Sprintf("%s %s <redacted>", f[0], f[1])
+		}
+		return l
+	}
+	return "<redacted>"
+}
+
 // validateLine checks to see if a line has CR or LF as per RFC 5321.
 func validateLine(line string) error {
 	if strings.ContainsAny(line, "\n\r") {
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -599,6 +600,331 @@ QUIT
 	})
 }
 
+// TestClient_MailRcpt tests the MailRcpt method with and without the PIPELINING extension
+func TestClient_MailRcpt(t *testing.T) {
+	tests := []struct {
+		name   string
+		server string
+		client string
+	}{
+		{
+			"without PIPELINING", `250-mx.google.com at your service
+250 SIZE 35651584
+250 Sender OK
+250 Receiver OK
+550 No such user
+221 Goodbye
+`, `EHLO localhost
+MAIL FROM:<user@gmail.com>
+RCPT TO:<valid@gmail.com>
+RCPT TO:<invalid@gmail.com>
+QUIT
+`,
+		},
+		{
+			"with PIPELINING", `250-mx.google.com at your service
+250 PIPELINING
+250 Sender OK
+250 Receiver OK
+550 No such user
+221 Goodbye
+`, `EHLO localhost
+MAIL FROM:<user@gmail.com>
+RCPT TO:<valid@gmail.com>
+RCPT TO:<invalid@gmail.com>
+QUIT
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := strings.Join(strings.Split(tt.server, "\n"), "\r\n")
+			var cmdbuf strings.Builder
+			bcmdbuf := bufio.NewWriter(&cmdbuf)
+			var fake faker
+			fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+			c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+
+			rerrs, err := c.MailRcpt("user@gmail.com", []string{"valid@gmail.com", "invalid@gmail.com"})
+			if err != nil {
+				t.Fatalf("MailRcpt failed: %s", err)
+			}
+			if len(rerrs) != 2 {
+				t.Fatalf("MailRcpt failed. Expected 2 RCPT results, got: %d", len(rerrs))
+			}
+			if rerrs[0] != nil {
+				t.Errorf("MailRcpt failed. Expected first recipient to be accepted, got: %s", rerrs[0])
+			}
+			if rerrs[1] == nil {
+				t.Errorf("MailRcpt failed. Expected second recipient to be rejected")
+			}
+			if err := c.Quit(); err != nil {
+				t.Fatalf("QUIT failed: %s", err)
+			}
+
+			if err := bcmdbuf.Flush(); err != nil {
+				t.Errorf("flush failed: %s", err)
+			}
+			actualcmds := cmdbuf.String()
+			client := strings.Join(strings.Split(tt.client, "\n"), "\r\n")
+			if client != actualcmds {
+				t.Fatalf("Got:\n%s\nExpected:\n%s", actualcmds, client)
+			}
+		})
+	}
+}
+
+// TestClient_MailRcpt_dsnRcpt tests the MailRcpt method with recipient specific DSN notify options
+func TestClient_MailRcpt_dsnRcpt(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 DSN
+250 Sender OK
+250 Receiver OK
+250 Receiver OK
+250 Receiver OK
+`, "\n"), "\r\n")
+	client := strings.Join(strings.Split(`EHLO localhost
+MAIL FROM:<user@gmail.com> RET=HDRS
+RCPT TO:<one@gmail.com> NOTIFY=FAILURE
+RCPT TO:<two@gmail.com> NOTIFY=SUCCESS,DELAY
+RCPT TO:<three@gmail.com>
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	bcmdbuf := bufio.NewWriter(&cmdbuf)
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	c.SetDSNMailReturnOption("HDRS")
+	c.SetDSNRcptNotifyOption("FAILURE")
+	c.SetDSNRcptNotifyOptions(map[string]string{"two@gmail.com": "SUCCESS,DELAY", "three@gmail.com": ""})
+
+	rerrs, err := c.MailRcpt("user@gmail.com", []string{"one@gmail.com", "two@gmail.com", "three@gmail.com"})
+	if err != nil {
+		t.Fatalf("MailRcpt failed: %s", err)
+	}
+	for i, rerr := range rerrs {
+		if rerr != nil {
+			t.Errorf("MailRcpt failed. Expected recipient %d to be accepted, got: %s", i, rerr)
+		}
+	}
+	if err := bcmdbuf.Flush(); err != nil {
+		t.Errorf("flush failed: %s", err)
+	}
+	if actualcmds := cmdbuf.String(); client != actualcmds {
+		t.Fatalf("Got:\n%s\nExpected:\n%s", actualcmds, client)
+	}
+}
+
+// TestClient_MailRcpt_mailFailed tests the MailRcpt method with a failing MAIL command
+func TestClient_MailRcpt_mailFailed(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 PIPELINING
+550 Sender rejected
+503 Need MAIL first
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	bcmdbuf := bufio.NewWriter(&cmdbuf)
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	if _, err := c.MailRcpt("user@gmail.com", []string{"valid@gmail.com"}); err == nil {
+		t.Errorf("MailRcpt with rejected sender was supposed to fail but didn't")
+	}
+	if _, err := c.MailRcpt("user@gmail.com", []string{"invalid\r\n@gmail.com"}); err == nil {
+		t.Errorf("MailRcpt with invalid recipient was supposed to fail but didn't")
+	}
+}
+
+// TestClient_MailRcptData tests the MailRcptData method with a pipelined DATA command
+func TestClient_MailRcptData(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 PIPELINING
+250 Sender OK
+250 Receiver OK
+550 No such user
+354 Go ahead
+250 2.0.0 Ok: queued
+221 Goodbye
+`, "\n"), "\r\n")
+	client := strings.Join(strings.Split(`EHLO localhost
+MAIL FROM:<user@gmail.com>
+RCPT TO:<valid@gmail.com>
+RCPT TO:<invalid@gmail.com>
+DATA
+test
+.
+QUIT
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	bcmdbuf := bufio.NewWriter(&cmdbuf)
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+
+	rerrs, w, derr, err := c.MailRcptData("user@gmail.com", []string{"valid@gmail.com", "invalid@gmail.com"})
+	if err != nil {
+		t.Fatalf("MailRcptData failed: %s", err)
+	}
+	if derr != nil || w == nil {
+		t.Fatalf("MailRcptData failed. Expected DATA to be accepted, got: %v", derr)
+	}
+	if len(rerrs) != 2 || rerrs[0] != nil || rerrs[1] == nil {
+		t.Fatalf("MailRcptData failed. Unexpected RCPT results: %v", rerrs)
+	}
+	if _, err := w.Write([]byte("test\r\n")); err != nil {
+		t.Fatalf("failed to write mail data: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close mail data: %s", err)
+	}
+	if err := c.Quit(); err != nil {
+		t.Fatalf("QUIT failed: %s", err)
+	}
+	if err := bcmdbuf.Flush(); err != nil {
+		t.Errorf("flush failed: %s", err)
+	}
+	if cmdbuf.String() != client {
+		t.Fatalf("Got:\n%s\nExpected:\n%s", cmdbuf.String(), client)
+	}
+}
+
+// TestClient_MailRcptData_dataFailed tests the MailRcptData method with a rejected DATA command
+// and a server without PIPELINING support
+func TestClient_MailRcptData_dataFailed(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 PIPELINING
+250 Sender OK
+550 No such user
+554 No valid recipients
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bufio.NewWriter(&cmdbuf))
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	rerrs, w, derr, err := c.MailRcptData("user@gmail.com", []string{"invalid@gmail.com"})
+	if err != nil {
+		t.Fatalf("MailRcptData failed: %s", err)
+	}
+	if w != nil || derr == nil || len(rerrs) != 1 || rerrs[0] == nil {
+		t.Errorf("MailRcptData failed. Expected rejected RCPT and DATA, got: %v, %v", rerrs, derr)
+	}
+
+	server = strings.Join(strings.Split(`250-mx.google.com at your service
+250 SIZE 35651584
+`, "\n"), "\r\n")
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bufio.NewWriter(&cmdbuf))
+	c = &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	if err := c.hello(); err != nil {
+		t.Fatalf("EHLO failed: %s", err)
+	}
+	if _, _, _, err := c.MailRcptData("user@gmail.com", []string{"valid@gmail.com"}); err == nil {
+		t.Errorf("MailRcptData without PIPELINING was supposed to fail but didn't")
+	}
+}
+
+// failingWriter is an io.Writer that fails on the write with the given number
+type failingWriter struct {
+	w    io.Writer
+	n    int
+	fail int
+}
+
+// Write implements the io.Writer interface for the failingWriter
+func (f *failingWriter) Write(p []byte) (int, error) {
+	f.n++
+	if f.n == f.fail {
+		return 0, errors.New("write failed")
+	}
+	return f.w.Write(p)
+}
+
+// TestClient_MailRcpt_writeFailed tests that a failed write of a pipelined command does not
+// block the replies of subsequent commands
+func TestClient_MailRcpt_writeFailed(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 PIPELINING
+250 OK
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	var fake faker
+	fake.ReadWriter = struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(server), &failingWriter{w: &cmdbuf, fail: 3}}
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	if _, err := c.MailRcpt("user@gmail.com", []string{"a@gmail.com", "b@gmail.com"}); err == nil {
+		t.Fatalf("MailRcpt with failing connection was supposed to fail but didn't")
+	}
+	done := make(chan struct{})
+	go func() {
+		id := c.Text.Next()
+		c.Text.StartResponse(id)
+		c.Text.EndResponse(id)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("reply of command after failed pipeline is blocked")
+	}
+}
+
+// TestClient_Bdat tests the Bdat method of the Client
+func TestClient_Bdat(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 CHUNKING
+250 2.0.0 10 octets received
+250 2.0.0 OK queued
+221 Goodbye
+`, "\n"), "\r\n")
+	client := "EHLO localhost\r\nBDAT 10\r\nSubject: 1BDAT 8 LAST\r\n2\r\n\r\n.\r\nQUIT\r\n"
+	var cmdbuf strings.Builder
+	bcmdbuf := bufio.NewWriter(&cmdbuf)
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	if err := c.Hello("localhost"); err != nil {
+		t.Fatalf("EHLO failed: %s", err)
+	}
+	w, err := c.Bdat(10)
+	if err != nil {
+		t.Fatalf("BDAT failed: %s", err)
+	}
+	if _, err := w.Write([]byte("Subject: 12\n\n.\n")); err != nil {
+		t.Fatalf("failed to write BDAT data: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close BDAT writer: %s", err)
+	}
+	if err := c.Quit(); err != nil {
+		t.Fatalf("QUIT failed: %s", err)
+	}
+	if err := bcmdbuf.Flush(); err != nil {
+		t.Errorf("flush failed: %s", err)
+	}
+	if actualcmds := cmdbuf.String(); client != actualcmds {
+		t.Fatalf("Got:\n%q\nExpected:\n%q", actualcmds, client)
+	}
+}
+
+// TestClient_Bdat_unsupported tests the Bdat method of the Client with a server that does
+// not support CHUNKING
+func TestClient_Bdat_unsupported(t *testing.T) {
+	server := strings.Join(strings.Split(`250-mx.google.com at your service
+250 SIZE 35651584
+`, "\n"), "\r\n")
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)),
+		bufio.NewWriter(&strings.Builder{}))
+	c := &Client{Text: textproto.NewConn(fake), localName: "localhost"}
+	if err := c.Hello("localhost"); err != nil {
+		t.Fatalf("EHLO failed: %s", err)
+	}
+	if _, err := c.Bdat(10); err == nil {
+		t.Errorf("BDAT was supposed to fail on a server without CHUNKING, but didn't")
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	server := strings.Join(strings.Split(newClientServer, "\n"), "\r\n")
 	client := strings.Join(strings.Split(newClientClient, "\n"), "\r\n")
@@ -695,6 +1021,75 @@ func TestClient_SetLogger(t *testing.T) {
 	c.logger.Debugf("test")
 }
 
+// TestClient_Auth_redactDebugLog tests that the credentials of the AUTH exchange are
+// redacted in the debug log
+func TestClient_Auth_redactDebugLog(t *testing.T) {
+	server := strings.Join(strings.Split(`220 hello world
+250-mx.google.com at your service
+250 AUTH LOGIN PLAIN
+334 VXNlcm5hbWU6
+334 UGFzc3dvcmQ6
+235 Accepted
+250 Ok
+`, "\n"), "\r\n")
+	var cmdbuf strings.Builder
+	bcmdbuf := bufio.NewWriter(&cmdbuf)
+	var fake faker
+	fake.ReadWriter = bufio.NewReadWriter(bufio.NewReader(strings.NewReader(server)), bcmdbuf)
+	c, err := NewClient(fake, "fake.host")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	var lb bytes.Buffer
+	c.SetLogger(log.New(&lb, log.LevelDebug))
+	c.SetDebugLog(true)
+	c.tls = true
+	c.serverName = "smtp.google.com"
+	if err := c.Auth(LoginAuth("user", "secret", "smtp.google.com")); err != nil {
+		t.Fatalf("Auth failed: %s", err)
+	}
+	if err := c.Noop(); err != nil {
+		t.Fatalf("Noop failed: %s", err)
+	}
+
+	ls := lb.String()
+	for _, s := range []string{
+		"C --> S: AUTH LOGIN\n", "C <-- S: 334 VXNlcm5hbWU6\n", "C --> S: <redacted>\n",
+		"C <-- S: 235 Accepted\n", "C --> S: NOOP\n",
+	} {
+		if !strings.Contains(ls, s) {
+			t.Errorf("debug log does not contain %q: %s", s, ls)
+		}
+	}
+	for _, s := range []string{"dXNlcg==", "c2VjcmV0"} {
+		if strings.Contains(ls, s) {
+			t.Errorf("debug log contains unredacted credentials %q: %s", s, ls)
+		}
+	}
+	if strings.Count(ls, "<redacted>") != 2 {
+		t.Errorf("expected 2 redacted lines in debug log: %s", ls)
+	}
+}
+
+// TestRedactAuth tests the redaction of the AUTH exchange
+func TestRedactAuth(t *testing.T) {
+	tests := []struct {
+		l string
+		e string
+	}{
+		{"AUTH PLAIN AHVzZXIAcGFzcw==", "AUTH PLAIN <redacted>"},
+		{"auth plain AHVzZXIAcGFzcw==", "auth plain <redacted>"},
+		{"AUTH LOGIN", "AUTH LOGIN"},
+		{"c2VjcmV0", "<redacted>"},
+		{"*", "*"},
+	}
+	for _, tt := range tests {
+		if r := redactAuth(tt.l); r != tt.e {
+			t.Errorf("redactAuth(%q) failed. Expected: %q, got: %q", tt.l, tt.e, r)
+		}
+	}
+}
+
 var newClientServer = `220 hello world
 250-mx.google.com at your service
 250-SIZE 35651584
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+// List of sentinel errors for common SMTP error replies. They can be compared with errors.Is
+// against an SMTPError or a SendError that contains an SMTPError
+var (
+	// ErrMailboxUnavailable matches replies that indicate that the mailbox does not exist or
+	// is not available (e.g. 550 5.1.1)
+	ErrMailboxUnavailable = errors.New("mailbox unavailable")
+
+	// ErrMailboxFull matches replies that indicate that the mailbox is full (e.g. 552 5.2.2)
+	ErrMailboxFull = errors.New("mailbox full")
+
+	// ErrMessageTooLarge matches replies that indicate that the message exceeds the size
+	// limit of the server or the mailbox (e.g. 552 5.3.4)
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrTooManyRecipients matches replies that indicate that the message has too many
+	// recipients (e.g. 452 4.5.3)
+	ErrTooManyRecipients = errors.New("too many recipients")
+
+	// ErrAuthRequired matches replies that indicate that authentication is required (e.g. 530)
+	ErrAuthRequired = errors.New("authentication required")
+
+	// ErrAuthFailed matches replies that indicate that the authentication failed (e.g. 535)
+	ErrAuthFailed = errors.New("authentication failed")
+
+	// ErrPolicyRejection matches replies that indicate that the message was rejected for
+	// security or policy reasons, like spam filters or sender restrictions (e.g. 550 5.7.1)
+	ErrPolicyRejection = errors.New("rejected by policy")
+
+	// ErrServiceUnavailable matches replies that indicate that the server is not available
+	// and closes the connection (421)
+	ErrServiceUnavailable = errors.New("service unavailable")
+)
+
+// SMTPError represents an error reply of the SMTP server
+type SMTPError struct {
+	// Code is the 3-digit SMTP reply code
+	Code int
+	// EnhancedCode is the enhanced status code (RFC 3463) of the reply, if the server
+	// provided one (e.g. "5.7.1")
+	EnhancedCode string
+	// Message is the reply text of the server without the enhanced status code
+	Message string
+}
+
+// Error implements the error interface for the SMTPError type
+func (e *SMTPError) Error() string {
+	if e.EnhancedCode != "" {
+		return fmt.Sprintf("%03d %s %s", e.Code, e.EnhancedCode, e.Message)
+	}
+	return fmt.Sprintf("%03d %s", e.Code, e.Message)
+}
+
+// IsTemp returns true if the reply is a transient negative completion reply (4xx)
+func (e *SMTPError) IsTemp() bool {
+	return e.Code >= 400 && e.Code < 500
+}
+
+// IsPermanent returns true if the reply is a permanent negative completion reply (5xx)
+func (e *SMTPError) IsPermanent() bool {
+	return e.Code >= 500 && e.Code < 600
+}
+
+// Is implements the errors.Is functionality and matches the SMTPError against the sentinel
+// errors for common SMTP error replies, based on the enhanced status code or, if the server
+// did not provide one, the reply code
+func (e *SMTPError) Is(et error) bool {
+	// The subject and detail of the enhanced status code, e.g. "1.1" for "5.1.1"
+	sd := ""
+	if i := strings.IndexByte(e.EnhancedCode, '.'); i > 0 {
+		sd = e.EnhancedCode[i+1:]
+	}
+	switch et {
+	case ErrMailboxUnavailable:
+		return sd == "1.1" || sd == "2.1" || (sd == "" && (e.Code == 550 || e.Code == 551 || e.Code == 553))
+	case ErrMailboxFull:
+		return sd == "2.2"
+	case ErrMessageTooLarge:
+		return sd == "3.4" || sd == "2.3"
+	case ErrTooManyRecipients:
+		return sd == "5.3"
+	case ErrAuthRequired:
+		return e.Code == 530 || (sd == "7.0" && e.Code != 535)
+	case ErrAuthFailed:
+		return e.Code == 535 || sd == "7.8"
+	case ErrPolicyRejection:
+		return sd == "7.1"
+	case ErrServiceUnavailable:
+		return e.Code == 421
+	}
+	return false
+}
+
+// IsPermanent returns true if the given error is or contains a permanent SMTP error reply
+// (5xx). For a SendError without an SMTP reply, its temporary state is considered
+func IsPermanent(err error) bool {
+	var se *SMTPError
+	if errors.As(err, &se) {
+		return se.IsPermanent()
+	}
+	var sde *SendError
+	if errors.As(err, &sde) {
+		return !sde.IsTemp()
+	}
+	return false
+}
+
+// IsTemporary returns true if the given error is or contains a temporary SMTP error reply
+// (4xx). For a SendError without an SMTP reply, its temporary state is considered
+func IsTemporary(err error) bool {
+	var se *SMTPError
+	if errors.As(err, &se) {
+		return se.IsTemp()
+	}
+	var sde *SendError
+	if errors.As(err, &sde) {
+		return sde.IsTemp()
+	}
+	return false
+}
+
+// newSMTPError returns the SMTPError for the given error, if it is an SMTP reply of the
+// smtp.Client. Other errors are returned unchanged
+func newSMTPError(err error) error {
+	te, ok := err.(*textproto.Error)
+	if !ok {
+		return err
+	}
+	se := &SMTPError{Code: te.Code, Message: te.Msg}
+	if m := enhancedCodeRegexp.FindStringSubmatch(te.Msg); m != nil {
+		se.EnhancedCode = m[1]
+		se.Message = te.Msg[len(m[0]):]
+	}
+	return se
+}
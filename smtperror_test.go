@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestSMTPError_Is tests the matching of SMTPError replies against the sentinel errors
+func TestSMTPError_Is(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  int
+		msg   string
+		want  error
+		enh   string
+		perm  bool
+		other error
+	}{
+		{"Unknown user", 550, "5.1.1 User unknown", ErrMailboxUnavailable, "5.1.1", true, ErrMailboxFull},
+		{"Unknown user without enhanced code", 550, "User unknown", ErrMailboxUnavailable, "", true, ErrPolicyRejection},
+		{"Mailbox full", 452, "4.2.2 Mailbox full", ErrMailboxFull, "4.2.2", false, ErrMailboxUnavailable},
+		{"Message too large", 552, "5.3.4 Message too big", ErrMessageTooLarge, "5.3.4", true, ErrMailboxFull},
+		{"Too many recipients", 452, "4.5.3 Too many recipients", ErrTooManyRecipients, "4.5.3", false, ErrMailboxFull},
+		{"Authentication required", 530, "5.7.0 Authentication required", ErrAuthRequired, "5.7.0", true, ErrAuthFailed},
+		{"Authentication failed", 535, "5.7.8 Authentication credentials invalid", ErrAuthFailed, "5.7.8", true, ErrAuthRequired},
+		{"Spam rejection", 550, "5.7.1 Message rejected as spam", ErrPolicyRejection, "5.7.1", true, ErrMailboxUnavailable},
+		{"Service unavailable", 421, "4.3.2 Service shutting down", ErrServiceUnavailable, "4.3.2", false, ErrAuthFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newSMTPError(&textproto.Error{Code: tt.code, Msg: tt.msg})
+			var se *SMTPError
+			if !errors.As(err, &se) {
+				t.Fatalf("newSMTPError failed. Expected *SMTPError, got: %T", err)
+			}
+			if se.Code != tt.code || se.EnhancedCode != tt.enh {
+				t.Errorf("newSMTPError failed. Unexpected codes: %d %s", se.Code, se.EnhancedCode)
+			}
+			if es := fmt.Sprintf("%03d %s", tt.code, tt.msg); se.Error() != es {
+				t.Errorf("Error failed. Expected: %s, got: %s", es, se.Error())
+			}
+			if !errors.Is(err, tt.want) || errors.Is(err, tt.other) {
+				t.Errorf("errors.Is failed. Expected match with %q only", tt.want)
+			}
+			if IsPermanent(err) != tt.perm || IsTemporary(err) == tt.perm {
+				t.Errorf("IsPermanent failed. Expected: %t", tt.perm)
+			}
+		})
+	}
+}
+
+// TestSMTPError_SendError tests that SMTPError replies can be matched through a SendError
+func TestSMTPError_SendError(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	s.RejectRcpt("rcpt@example.com", "550 5.1.1 User unknown")
+	err := testClient(t, s).DialAndSend(testMsg(t))
+	if !errors.Is(err, ErrMailboxUnavailable) || errors.Is(err, ErrPolicyRejection) {
+		t.Errorf("DialAndSend error was expected to match ErrMailboxUnavailable, got: %v", err)
+	}
+	var se *SMTPError
+	if !errors.As(err, &se) || se.Code != 550 || se.Message != "User unknown" {
+		t.Errorf("DialAndSend error was expected to contain the SMTPError, got: %v", err)
+	}
+	if !IsPermanent(err) {
+		t.Errorf("IsPermanent failed. Expected permanent error for %v", err)
+	}
+	if IsPermanent(errors.New("some error")) || IsTemporary(errors.New("some error")) {
+		t.Errorf("IsPermanent/IsTemporary failed. Expected false for non-SMTP errors")
+	}
+}
@@ -0,0 +1,735 @@
+// SPDX-FileCopyrightText: 2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+// Package smtptest provides a lightweight, in-process SMTP server that can be used to test
+// the sending of mails without the need of a real mail relay. The server records all
+// received messages and offers some assertion helpers for the use in tests.
+package smtptest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a mail message that has been received by the Server
+type Message struct {
+	// From is the envelope sender address of the MAIL FROM command
+	From string
+	// To is the list of envelope recipient addresses of the RCPT TO commands
+	To []string
+	// Data is the raw message content as received with the DATA or BDAT commands
+	Data []byte
+}
+
+// Option returns a function that can be used for grouping Server options
+type Option func(*Server) error
+
+// TestingT is the part of testing.TB that is used by the assertion helpers of the Server.
+// It keeps the testing package out of the binaries that import smtptest
+type TestingT interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+}
+
+// Server is an in-process SMTP server that records the received messages. It listens on a
+// random port of the loopback interface
+type Server struct {
+	l   net.Listener
+	ext []string
+	// tlsconfig is the tls.Config for the STARTTLS extension. If nil, STARTTLS is not offered
+	tlsconfig *tls.Config
+	// cert is the self-signed certificate that is generated by WithSTARTTLS
+	cert *x509.Certificate
+	// user and pass are the credentials for SMTP AUTH. If user is empty, AUTH is not offered
+	user string
+	pass string
+	// mbox holds the mailboxes that are confirmed by VRFY. If nil, VRFY is answered with 252
+	mbox map[string]bool
+	// lists holds the members of the mailing lists that are expanded by EXPN. If nil, EXPN is
+	// not implemented
+	lists map[string][]string
+
+	mu sync.Mutex
+	// rej holds a map of RCPT addresses and the reply that is sent instead of accepting them
+	rej map[string]string
+	// grey holds a map of RCPT addresses and the number of times they are temporarily
+	// rejected, like by a greylisting server
+	grey  map[string]int
+	cmds  []string
+	msgs  []Message
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// ErrNoTLSConfig should be used if STARTTLS is requested with a tls.Config without certificate
+var ErrNoTLSConfig = errors.New("tls.Config must contain at least one certificate")
+
+// NewServer starts a new Server on a random port of the loopback interface. The Server
+// should be stopped with Close when it is no longer needed
+func NewServer(o ...Option) (*Server, error) {
+	s := &Server{
+		ext:   []string{"8BITMIME", "PIPELINING", "SMTPUTF8"},
+		rej:   make(map[string]string),
+		grey:  make(map[string]int),
+		conns: make(map[net.Conn]struct{}),
+	}
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(s); err != nil {
+			return nil, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on loopback interface: %w", err)
+	}
+	s.l = l
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// NewTestServer starts a new Server like NewServer and registers its Close with the
+// Cleanup function of the given test. It fails the test if the Server cannot be started
+func NewTestServer(t interface {
+	TestingT
+	Cleanup(func())
+}, o ...Option,
+) *Server {
+	t.Helper()
+	s, err := NewServer(o...)
+	if err != nil {
+		t.Fatalf("failed to start test SMTP server: %s", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// WithExtensions overrides the list of ESMTP extensions that the Server advertises in its
+// EHLO response. STARTTLS and AUTH are added automatically, if they are enabled
+func WithExtensions(ext ...string) Option {
+	return func(s *Server) error {
+		s.ext = ext
+		return nil
+	}
+}
+
+// WithSTARTTLS enables the STARTTLS extension of the Server with the given tls.Config. If
+// the tls.Config is nil, a self-signed certificate for 127.0.0.1 is generated. Clients can
+// trust it with the tls.Config returned by ClientTLSConfig
+func WithSTARTTLS(c *tls.Config) Option {
+	return func(s *Server) error {
+		if c != nil {
+			if len(c.Certificates) == 0 && c.GetCertificate == nil {
+				return ErrNoTLSConfig
+			}
+			s.tlsconfig = c
+			return nil
+		}
+		tc, cert, err := selfSignedCert()
+		if err != nil {
+			return fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		s.tlsconfig = &tls.Config{Certificates: []tls.Certificate{tc}, MinVersion: tls.VersionTLS12}
+		s.cert = cert
+		return nil
+	}
+}
+
+// WithAuth enables the AUTH extension of the Server with the PLAIN and LOGIN mechanisms.
+// Only the given credentials are accepted and mail transactions require a successful
+// authentication
+func WithAuth(u, p string) Option {
+	return func(s *Server) error {
+		s.user = u
+		s.pass = p
+		return nil
+	}
+}
+
+// WithRejectRcpt tells the Server to reply to the RCPT TO command for the given address
+// with the given reply line (e.g. "550 5.1.1 User unknown") instead of accepting it
+func WithRejectRcpt(a, r string) Option {
+	return func(s *Server) error {
+		s.rej[strings.ToLower(a)] = r
+		return nil
+	}
+}
+
+// WithMailboxes sets the mailboxes that the Server confirms with the VRFY command. Other
+// addresses are rejected with 550. Without this option, VRFY is answered with 252
+func WithMailboxes(al ...string) Option {
+	return func(s *Server) error {
+		if s.mbox == nil {
+			s.mbox = make(map[string]bool)
+		}
+		for _, a := range al {
+			s.mbox[strings.ToLower(a)] = true
+		}
+		return nil
+	}
+}
+
+// WithMailingList adds a mailing list with the given members (e.g. "Alice <alice@example.com>")
+// that the Server expands with the EXPN command. Other lists are rejected with 550. Without
+// this option, EXPN is not implemented
+func WithMailingList(n string, ml ...string) Option {
+	return func(s *Server) error {
+		if s.lists == nil {
+			s.lists = make(map[string][]string)
+		}
+		s.lists[strings.ToLower(n)] = ml
+		return nil
+	}
+}
+
+// RejectRcpt tells the Server to reply to the RCPT TO command for the given address with
+// the given reply line, like WithRejectRcpt. An empty reply accepts the address again
+func (s *Server) RejectRcpt(a, r string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r == "" {
+		delete(s.rej, strings.ToLower(a))
+		return
+	}
+	s.rej[strings.ToLower(a)] = r
+}
+
+// GreylistRcpt tells the Server to temporarily reject the given RCPT address with 451 for
+// the next n RCPT TO commands, like a greylisting server
+func (s *Server) GreylistRcpt(a string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grey[strings.ToLower(a)] = n
+}
+
+// DropConnections closes all open client connections of the Server, like a server that
+// times out idle connections. The Server keeps accepting new connections
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for co := range s.conns {
+		_ = co.Close()
+	}
+}
+
+// Addr returns the address of the Server in the form host:port
+func (s *Server) Addr() string {
+	return s.l.Addr().String()
+}
+
+// Host returns the host address the Server is listening on
+func (s *Server) Host() string {
+	return s.l.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the Server is listening on
+func (s *Server) Port() int {
+	return s.l.Addr().(*net.TCPAddr).Port
+}
+
+// ClientTLSConfig returns a tls.Config for clients that trusts the self-signed certificate
+// generated by WithSTARTTLS. It returns nil if no certificate has been generated
+func (s *Server) ClientTLSConfig() *tls.Config {
+	if s.cert == nil {
+		return nil
+	}
+	cp := x509.NewCertPool()
+	cp.AddCert(s.cert)
+	return &tls.Config{RootCAs: cp, ServerName: s.Host(), MinVersion: tls.VersionTLS12}
+}
+
+// Close stops the Server, closes all open client connections and waits for the sessions
+// to finish
+func (s *Server) Close() error {
+	err := s.l.Close()
+	s.mu.Lock()
+	for co := range s.conns {
+		_ = co.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// Messages returns a copy of all messages the Server received so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ml := make([]Message, len(s.msgs))
+	for i, m := range s.msgs {
+		ml[i] = Message{
+			From: m.From,
+			To:   append([]string{}, m.To...),
+			Data: append([]byte{}, m.Data...),
+		}
+	}
+	return ml
+}
+
+// Commands returns a copy of all commands the Server received so far. The arguments of
+// the AUTH exchange are not recorded
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.cmds...)
+}
+
+// Reset removes all recorded messages and commands from the Server
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cmds = nil
+	s.msgs = nil
+}
+
+// AssertMessageCount fails the test if the Server did not receive exactly n messages
+func (s *Server) AssertMessageCount(t TestingT, n int) {
+	t.Helper()
+	if l := len(s.Messages()); l != n {
+		t.Fatalf("expected %d received messages, got: %d", n, l)
+	}
+}
+
+// AssertReceivedBy fails the test if the Server did not receive a message for the given
+// envelope recipient address
+func (s *Server) AssertReceivedBy(t TestingT, a string) {
+	t.Helper()
+	for _, m := range s.Messages() {
+		for _, r := range m.To {
+			if strings.EqualFold(r, a) {
+				return
+			}
+		}
+	}
+	t.Fatalf("expected a message for recipient %q, but none was received", a)
+}
+
+// AssertMessageContains fails the test if none of the received messages contains the
+// given string
+func (s *Server) AssertMessageContains(t TestingT, c string) {
+	t.Helper()
+	for _, m := range s.Messages() {
+		if bytes.Contains(m.Data, []byte(c)) {
+			return
+		}
+	}
+	t.Fatalf("expected a received message to contain %q, but none does", c)
+}
+
+// AssertCommand fails the test if the Server did not receive the given command. The
+// command is compared case-insensitively
+func (s *Server) AssertCommand(t TestingT, c string) {
+	t.Helper()
+	for _, cmd := range s.Commands() {
+		if strings.EqualFold(cmd, c) {
+			return
+		}
+	}
+	t.Fatalf("expected command %q, but it was not received", c)
+}
+
+// serve accepts new client connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		co, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[co] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(co)
+		}()
+	}
+}
+
+// session holds the state of a single SMTP session
+type session struct {
+	s    *Server
+	co   net.Conn
+	r    *bufio.Reader
+	tls  bool
+	auth bool
+	mail bool
+	from string
+	to   []string
+	bdat bytes.Buffer
+}
+
+// handle processes a single SMTP session
+func (s *Server) handle(co net.Conn) {
+	ss := &session{s: s, co: co, r: bufio.NewReader(co)}
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, ss.co)
+		s.mu.Unlock()
+		_ = ss.co.Close()
+	}()
+	ss.reply("220 %s ESMTP go-mail smtptest server", s.Host())
+	for {
+		l, err := ss.readLine()
+		if err != nil {
+			return
+		}
+		uc := strings.ToUpper(l)
+		if !strings.HasPrefix(uc, "AUTH ") {
+			s.mu.Lock()
+			s.cmds = append(s.cmds, l)
+			s.mu.Unlock()
+		}
+		if !ss.command(l, uc) {
+			return
+		}
+	}
+}
+
+// command processes a single SMTP command. It returns false if the session has ended
+func (ss *session) command(l, uc string) bool {
+	switch {
+	case strings.HasPrefix(uc, "EHLO"):
+		ss.reset()
+		el := append([]string{ss.s.Host()}, ss.s.ext...)
+		if ss.s.tlsconfig != nil && !ss.tls {
+			el = append(el, "STARTTLS")
+		}
+		if ss.s.user != "" {
+			el = append(el, "AUTH PLAIN LOGIN")
+		}
+		for i, e := range el {
+			if i == len(el)-1 {
+				ss.reply("250 %s", e)
+				continue
+			}
+			ss.reply("250-%s", e)
+		}
+	case strings.HasPrefix(uc, "HELO"):
+		ss.reset()
+		ss.reply("250 %s", ss.s.Host())
+	case uc == "STARTTLS":
+		if ss.s.tlsconfig == nil || ss.tls {
+			ss.reply("502 5.5.1 STARTTLS not available")
+			return true
+		}
+		ss.reply("220 2.0.0 Ready to start TLS")
+		tc := tls.Server(ss.co, ss.s.tlsconfig)
+		if err := tc.Handshake(); err != nil {
+			return false
+		}
+		ss.s.mu.Lock()
+		delete(ss.s.conns, ss.co)
+		ss.s.conns[tc] = struct{}{}
+		ss.s.mu.Unlock()
+		ss.co = tc
+		ss.r = bufio.NewReader(tc)
+		ss.tls = true
+		ss.auth = false
+		ss.reset()
+	case strings.HasPrefix(uc, "AUTH "):
+		return ss.authenticate(l)
+	case strings.HasPrefix(uc, "MAIL FROM:"):
+		if ss.s.user != "" && !ss.auth {
+			ss.reply("530 5.7.0 Authentication required")
+			return true
+		}
+		ss.reset()
+		ss.from = pathAddr(l[len("MAIL FROM:"):])
+		ss.mail = true
+		ss.reply("250 2.1.0 Ok")
+	case strings.HasPrefix(uc, "RCPT TO:"):
+		if !ss.mail {
+			ss.reply("503 5.5.1 Error: need MAIL command")
+			return true
+		}
+		a := pathAddr(l[len("RCPT TO:"):])
+		if r, ok := ss.s.rcptReply(a); ok {
+			ss.reply("%s", r)
+			return true
+		}
+		ss.to = append(ss.to, a)
+		ss.reply("250 2.1.5 Ok")
+	case uc == "DATA":
+		if len(ss.to) == 0 {
+			ss.reply("503 5.5.1 Error: need RCPT command")
+			return true
+		}
+		ss.reply("354 End data with <CR><LF>.<CR><LF>")
+		var d bytes.Buffer
+		for {
+			dl, err := ss.r.ReadString('\n')
+			if err != nil {
+				return false
+			}
+			if dl == ".\r\n" {
+				break
+			}
+			if strings.HasPrefix(dl, ".") {
+				dl = dl[1:]
+			}
+			d.WriteString(dl)
+		}
+		ss.deliver(d.Bytes())
+	case strings.HasPrefix(uc, "BDAT "):
+		f := strings.Fields(l)
+		n, err := strconv.Atoi(f[1])
+		if err != nil || n < 0 {
+			ss.reply("501 5.5.4 Syntax error")
+			return true
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(ss.r, buf); err != nil {
+			return false
+		}
+		if len(ss.to) == 0 {
+			ss.reply("503 5.5.1 Error: need RCPT command")
+			return true
+		}
+		ss.bdat.Write(buf)
+		if len(f) > 2 && strings.EqualFold(f[2], "LAST") {
+			ss.deliver(ss.bdat.Bytes())
+			return true
+		}
+		ss.reply("250 2.0.0 Ok: %d octets received", n)
+	case uc == "RSET":
+		ss.reset()
+		ss.reply("250 2.0.0 Ok")
+	case uc == "NOOP":
+		ss.reply("250 2.0.0 Ok")
+	case strings.HasPrefix(uc, "VRFY"):
+		ss.verify(l)
+	case strings.HasPrefix(uc, "EXPN"):
+		ss.expand(l)
+	case uc == "QUIT":
+		ss.reply("221 2.0.0 Bye")
+		return false
+	default:
+		ss.reply("502 5.5.2 Error: command not recognized")
+	}
+	return true
+}
+
+// rcptReply returns the reply line for a RCPT address that is rejected or greylisted
+func (s *Server) rcptReply(a string) (string, bool) {
+	a = strings.ToLower(a)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.rej[a]; ok {
+		return r, true
+	}
+	if s.grey[a] > 0 {
+		s.grey[a]--
+		return "451 4.7.1 Greylisted, please try again later", true
+	}
+	return "", false
+}
+
+// verify processes the VRFY command
+func (ss *session) verify(l string) {
+	if ss.s.mbox == nil {
+		ss.reply("252 2.0.0 Cannot VRFY user, but will accept message")
+		return
+	}
+	a := strings.TrimSpace(l[len("VRFY"):])
+	if !ss.s.mbox[strings.ToLower(pathAddr(a))] {
+		ss.reply("550 5.1.1 User unknown")
+		return
+	}
+	ss.reply("250 2.1.5 <%s>", pathAddr(a))
+}
+
+// expand processes the EXPN command
+func (ss *session) expand(l string) {
+	if ss.s.lists == nil {
+		ss.reply("502 5.5.2 Error: command not recognized")
+		return
+	}
+	ml, ok := ss.s.lists[strings.ToLower(strings.TrimSpace(l[len("EXPN"):]))]
+	if !ok || len(ml) == 0 {
+		ss.reply("550 5.1.1 Mailing list unknown")
+		return
+	}
+	for i, m := range ml {
+		if i == len(ml)-1 {
+			ss.reply("250 2.1.5 %s", m)
+			continue
+		}
+		ss.reply("250-2.1.5 %s", m)
+	}
+}
+
+// authenticate processes the AUTH command with the PLAIN or LOGIN mechanism. It returns
+// false if the session has ended
+func (ss *session) authenticate(l string) bool {
+	f := strings.Fields(l)
+	ss.s.mu.Lock()
+	ss.s.cmds = append(ss.s.cmds, strings.Join(f[:2], " "))
+	ss.s.mu.Unlock()
+	if ss.s.user == "" || ss.auth {
+		ss.reply("503 5.5.1 Error: authentication not available")
+		return true
+	}
+
+	var u, p string
+	switch strings.ToUpper(f[1]) {
+	case "PLAIN":
+		ir := ""
+		if len(f) > 2 {
+			ir = f[2]
+		} else {
+			ss.reply("334 ")
+			var err error
+			if ir, err = ss.readLine(); err != nil {
+				return false
+			}
+		}
+		c, err := base64.StdEncoding.DecodeString(ir)
+		if err != nil {
+			ss.reply("501 5.5.2 Cannot decode response")
+			return true
+		}
+		pl := strings.Split(string(c), "\x00")
+		if len(pl) != 3 {
+			ss.reply("501 5.5.2 Invalid response")
+			return true
+		}
+		u, p = pl[1], pl[2]
+	case "LOGIN":
+		var err error
+		if u, err = ss.challenge("Username:"); err != nil {
+			return false
+		}
+		if p, err = ss.challenge("Password:"); err != nil {
+			return false
+		}
+	default:
+		ss.reply("504 5.5.4 Unrecognized authentication type")
+		return true
+	}
+
+	if u != ss.s.user || p != ss.s.pass {
+		ss.reply("535 5.7.8 Error: authentication failed")
+		return true
+	}
+	ss.auth = true
+	ss.reply("235 2.7.0 Authentication successful")
+	return true
+}
+
+// challenge sends the given AUTH LOGIN challenge and returns the decoded response
+func (ss *session) challenge(c string) (string, error) {
+	ss.reply("334 %s", base64.StdEncoding.EncodeToString([]byte(c)))
+	l, err := ss.readLine()
+	if err != nil {
+		return "", err
+	}
+	r, err := base64.StdEncoding.DecodeString(l)
+	if err != nil {
+		return "", err
+	}
+	return string(r), nil
+}
+
+// deliver records the given message content as received Message and resets the session
+func (ss *session) deliver(d []byte) {
+	ss.s.mu.Lock()
+	ss.s.msgs = append(ss.s.msgs, Message{
+		From: ss.from,
+		To:   append([]string{}, ss.to...),
+		Data: append([]byte{}, d...),
+	})
+	ss.s.mu.Unlock()
+	ss.reset()
+	ss.reply("250 2.0.0 Ok: queued")
+}
+
+// reset resets the mail transaction of the session
+func (ss *session) reset() {
+	ss.mail = false
+	ss.from = ""
+	ss.to = nil
+	ss.bdat.Reset()
+}
+
+// readLine reads a single line from the client and strips the line ending
+func (ss *session) readLine() (string, error) {
+	l, err := ss.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(l, "\r\n"), nil
+}
+
+// reply sends the formatted reply line to the client
+func (ss *session) reply(f string, a ...interface{}) {
+	_, _ = fmt.Fprintf(ss.co, f+"\r\n", a...)
+}
+
+// pathAddr returns the address of the given reverse-path or forward-path argument of the
+// MAIL FROM and RCPT TO commands, without angle brackets and ESMTP parameters
+func pathAddr(p string) string {
+	p = strings.TrimSpace(p)
+	if i := strings.Index(p, ">"); i >= 0 {
+		return strings.TrimPrefix(p[:i], "<")
+	}
+	if i := strings.Index(p, " "); i >= 0 {
+		p = p[:i]
+	}
+	return p
+}
+
+// selfSignedCert generates a self-signed ECDSA certificate for 127.0.0.1 and localhost
+func selfSignedCert() (tls.Certificate, *x509.Certificate, error) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	sn, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	tpl := &x509.Certificate{
+		SerialNumber:          sn,
+		Subject:               pkix.Name{CommonName: "go-mail smtptest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour * 24),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:              []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &k.PublicKey, k)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: k, Leaf: cert}, cert, nil
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package smtptest_test
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wneessen/go-mail"
+	"github.com/wneessen/go-mail/smtp"
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// testMsg returns a simple Msg for the given recipients
+func testMsg(t *testing.T, rcpts ...string) *mail.Msg {
+	t.Helper()
+	m := mail.NewMsg()
+	if err := m.From("sender@example.com"); err != nil {
+		t.Fatalf("failed to set FROM address: %s", err)
+	}
+	if err := m.To(rcpts...); err != nil {
+		t.Fatalf("failed to set TO address: %s", err)
+	}
+	m.Subject("smtptest subject")
+	m.SetBodyString(mail.TypeTextPlain, "smtptest body")
+	return m
+}
+
+// TestServer tests the delivery of a message to the Server
+func TestServer(t *testing.T) {
+	s := smtptest.NewTestServer(t)
+	c, err := mail.NewClient(s.Host(), mail.WithPort(s.Port()), mail.WithTLSPolicy(mail.NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	if err := c.DialAndSend(testMsg(t, "rcpt1@example.com", "rcpt2@example.com")); err != nil {
+		t.Fatalf("failed to send mail: %s", err)
+	}
+
+	s.AssertMessageCount(t, 1)
+	s.AssertReceivedBy(t, "rcpt2@example.com")
+	s.AssertMessageContains(t, "Subject: smtptest subject")
+	s.AssertCommand(t, "QUIT")
+	ml := s.Messages()
+	if ml[0].From != "sender@example.com" {
+		t.Errorf("expected envelope sender %q, got: %q", "sender@example.com", ml[0].From)
+	}
+	if len(ml[0].To) != 2 {
+		t.Errorf("expected 2 envelope recipients, got: %d", len(ml[0].To))
+	}
+
+	s.Reset()
+	if len(s.Messages()) != 0 || len(s.Commands()) != 0 {
+		t.Errorf("Reset was expected to remove all recorded messages and commands")
+	}
+}
+
+// TestServer_STARTTLSAuth tests the STARTTLS and AUTH stubbing of the Server
+func TestServer_STARTTLSAuth(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithSTARTTLS(nil), smtptest.WithAuth("user", "secret"))
+	tests := []struct {
+		name string
+		at   mail.SMTPAuthType
+		pass string
+		sf   bool
+	}{
+		{"PLAIN", mail.SMTPAuthPlain, "secret", false},
+		{"LOGIN", mail.SMTPAuthLogin, "secret", false},
+		{"PLAIN wrong password", mail.SMTPAuthPlain, "wrong", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := mail.NewClient(s.Host(), mail.WithPort(s.Port()),
+				mail.WithTLSConfig(s.ClientTLSConfig()), mail.WithSMTPAuth(tt.at),
+				mail.WithUsername("user"), mail.WithPassword(tt.pass))
+			if err != nil {
+				t.Fatalf("failed to create new client: %s", err)
+			}
+			err = c.DialAndSend(testMsg(t, "rcpt@example.com"))
+			if err != nil && !tt.sf {
+				t.Errorf("failed to send mail: %s", err)
+			}
+			if err == nil && tt.sf {
+				t.Errorf("authentication was expected to fail")
+			}
+		})
+	}
+	s.AssertCommand(t, "STARTTLS")
+	s.AssertCommand(t, "AUTH LOGIN")
+	s.AssertMessageCount(t, 2)
+	for _, cmd := range s.Commands() {
+		if strings.Contains(cmd, "secret") {
+			t.Errorf("credentials were not expected to be recorded: %s", cmd)
+		}
+	}
+}
+
+// TestServer_authRequired tests that the Server rejects mail transactions without AUTH
+func TestServer_authRequired(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithAuth("user", "secret"))
+	c, err := smtp.Dial(s.Addr())
+	if err != nil {
+		t.Fatalf("failed to connect to server: %s", err)
+	}
+	defer func() { _ = c.Close() }()
+	if err := c.Mail("sender@example.com"); err == nil || !strings.HasPrefix(err.Error(), "530") {
+		t.Errorf("MAIL FROM without AUTH was expected to fail with 530, got: %v", err)
+	}
+}
+
+// TestServer_rejectRcpt tests the rejection of recipients
+func TestServer_rejectRcpt(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithRejectRcpt("unknown@example.com",
+		"550 5.1.1 User unknown"))
+	c, err := mail.NewClient(s.Host(), mail.WithPort(s.Port()), mail.WithTLSPolicy(mail.NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	err = c.DialAndSend(testMsg(t, "unknown@example.com"))
+	var se *mail.SendError
+	if !errors.As(err, &se) || se.Reason != mail.ErrSMTPRcptTo {
+		t.Errorf("expected SendError with reason %q, got: %v", mail.ErrSMTPRcptTo, err)
+	}
+	s.AssertMessageCount(t, 0)
+}
+
+// TestServer_pipelinedDataAbort tests that the Server discards the mail data if the client
+// aborts a transaction with pipelined DATA, which is used with the default extensions
+func TestServer_pipelinedDataAbort(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithRejectRcpt("unknown@example.com",
+		"550 5.1.1 User unknown"))
+	c, err := mail.NewClient(s.Host(), mail.WithPort(s.Port()), mail.WithTLSPolicy(mail.NoTLS))
+	if err != nil {
+		t.Fatalf("failed to create new client: %s", err)
+	}
+	m1 := testMsg(t, "rcpt@example.com", "unknown@example.com")
+	m2 := testMsg(t, "rcpt@example.com")
+	if err := c.DialAndSend(m1, m2); err == nil {
+		t.Fatalf("sending mail with rejected recipient was expected to fail")
+	}
+	if m2.HasSendError() {
+		t.Errorf("second message was not expected to fail: %s", m2.SendError())
+	}
+	s.AssertMessageCount(t, 1)
+	data := 0
+	for _, cmd := range s.Commands() {
+		if cmd == "DATA" {
+			data++
+		}
+	}
+	if data != 2 {
+		t.Errorf("expected 2 pipelined DATA commands, got: %d", data)
+	}
+}
+
+// TestWithSTARTTLS tests WithSTARTTLS with a tls.Config without certificates
+func TestWithSTARTTLS(t *testing.T) {
+	if _, err := smtptest.NewServer(smtptest.WithSTARTTLS(&tls.Config{})); !errors.Is(err,
+		smtptest.ErrNoTLSConfig) {
+		t.Errorf("expected error %q, got: %v", smtptest.ErrNoTLSConfig, err)
+	}
+}
@@ -0,0 +1,396 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSpoolMaxAttempts is the default number of delivery attempts before a spooled
+	// message is moved to the dead-letter directory
+	DefaultSpoolMaxAttempts = 5
+
+	// DefaultSpoolBackoff is the default delay before the first retry of a spooled message.
+	// It is doubled for every further retry, up to MaxSpoolBackoff
+	DefaultSpoolBackoff = time.Minute
+
+	// MaxSpoolBackoff is the maximum delay between two retries of a spooled message
+	MaxSpoolBackoff = time.Hour * 24
+
+	// DefaultSpoolInterval is the default interval in which Spool.Run processes the spool
+	DefaultSpoolInterval = time.Second * 10
+
+	// SpoolDeadDir is the name of the subdirectory of the spool directory that holds the
+	// messages that could not be delivered
+	SpoolDeadDir = "dead"
+)
+
+const (
+	// spoolMsgExt is the file extension of the rendered message of a spool entry
+	spoolMsgExt = ".eml"
+
+	// spoolMetaExt is the file extension of the envelope and state of a spool entry
+	spoolMetaExt = ".json"
+)
+
+var (
+	// ErrSpoolNoDir should be used if a Spool is created without a spool directory
+	ErrSpoolNoDir = errors.New("spool directory cannot be empty")
+
+	// ErrSpoolNoSender should be used if a Spool is created without a Sender
+	ErrSpoolNoSender = errors.New("spool requires a Sender for the delivery")
+)
+
+// Spool is a persistent queue for outgoing messages. Enqueued messages are rendered and
+// stored in a spool directory together with their envelope, so that they are not lost if
+// the application crashes. The messages are delivered by the Spool.Process and Spool.Run
+// methods via the given Sender. Failed deliveries are retried with an exponential backoff.
+// Messages that failed permanently or too often are moved to the dead-letter directory.
+//
+// Since the messages are stored as rendered messages, Bcc recipients are only part of the
+// envelope. They are therefore not delivered by the Sendmail transport, which reads the
+// recipients from the message headers
+type Spool struct {
+	// dir is the spool directory
+	dir string
+
+	// s is the Sender that is used for the delivery of the spooled messages
+	s Sender
+
+	// maxattempts is the maximum number of delivery attempts of a spooled message
+	maxattempts int
+
+	// backoff is the delay before the first retry of a spooled message
+	backoff time.Duration
+
+	// interval is the interval in which Run processes the spool
+	interval time.Duration
+
+	// now returns the current time for the scheduling of retries
+	now func() time.Time
+
+	// mu prevents concurrent processing of the spool
+	mu sync.Mutex
+}
+
+// SpoolOption returns a function that can be used for grouping Spool options
+type SpoolOption func(*Spool) error
+
+// SpoolEntry holds the envelope and delivery state of a spooled message
+type SpoolEntry struct {
+	// ID is the unique ID of the spooled message
+	ID string `json:"id"`
+	// From is the envelope sender address
+	From string `json:"from"`
+	// Rcpts is the list of envelope recipient addresses
+	Rcpts []string `json:"rcpts"`
+	// Attempts is the number of failed delivery attempts
+	Attempts int `json:"attempts"`
+	// Next is the time of the next delivery attempt
+	Next time.Time `json:"next"`
+	// LastError is the error of the last failed delivery attempt
+	LastError string `json:"last_error,omitempty"`
+}
+
+// NewSpool returns a new Spool that stores its messages in the given directory and delivers
+// them via the given Sender. The directory is created if it does not exist
+func NewSpool(dir string, s Sender, o ...SpoolOption) (*Spool, error) {
+	if dir == "" {
+		return nil, ErrSpoolNoDir
+	}
+	if s == nil {
+		return nil, ErrSpoolNoSender
+	}
+	sp := &Spool{
+		dir:         dir,
+		s:           s,
+		maxattempts: DefaultSpoolMaxAttempts,
+		backoff:     DefaultSpoolBackoff,
+		interval:    DefaultSpoolInterval,
+		now:         time.Now,
+	}
+
+	// Override defaults with optionally provided SpoolOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(sp); err != nil {
+			return sp, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, SpoolDeadDir), 0o700); err != nil {
+		return sp, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	return sp, nil
+}
+
+// WithSpoolMaxAttempts sets the number of delivery attempts before a spooled message is
+// moved to the dead-letter directory
+func WithSpoolMaxAttempts(n int) SpoolOption {
+	return func(sp *Spool) error {
+		if n < 1 {
+			return fmt.Errorf("max attempts must be at least 1")
+		}
+		sp.maxattempts = n
+		return nil
+	}
+}
+
+// WithSpoolBackoff sets the delay before the first retry of a spooled message. The delay
+// is doubled for every further retry, up to MaxSpoolBackoff or the given delay, whichever
+// is larger
+func WithSpoolBackoff(d time.Duration) SpoolOption {
+	return func(sp *Spool) error {
+		if d < 0 {
+			return fmt.Errorf("backoff must not be negative")
+		}
+		sp.backoff = d
+		return nil
+	}
+}
+
+// WithSpoolInterval sets the interval in which Spool.Run processes the spool
+func WithSpoolInterval(d time.Duration) SpoolOption {
+	return func(sp *Spool) error {
+		if d <= 0 {
+			return ErrInvalidTimeout
+		}
+		sp.interval = d
+		return nil
+	}
+}
+
+// Enqueue renders the given Msg and stores it with its envelope in the spool directory. It
+// returns the ID of the spool entry. The Msg is delivered by the next run of Spool.Process
+func (sp *Spool) Enqueue(m *Msg) (string, error) {
+	f, err := m.GetSender(false)
+	if err != nil {
+		return "", fmt.Errorf("failed to get envelope sender: %w", err)
+	}
+	rl, err := m.GetRecipients()
+	if err != nil {
+		return "", fmt.Errorf("failed to get recipients: %w", err)
+	}
+	rs, err := randomStringSecure(24)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate spool ID: %w", err)
+	}
+	e := &SpoolEntry{
+		ID:    fmt.Sprintf("%d-%s", sp.now().UnixNano(), rs),
+		From:  f,
+		Rcpts: rl,
+		Next:  sp.now(),
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := m.WriteTo(buf); err != nil {
+		return "", fmt.Errorf("failed to render message: %w", err)
+	}
+	if err := writeFileAtomic(sp.path(e.ID, spoolMsgExt), buf.Bytes()); err != nil {
+		return "", err
+	}
+	if err := sp.writeEntry(e); err != nil {
+		_ = os.Remove(sp.path(e.ID, spoolMsgExt))
+		return "", err
+	}
+	return e.ID, nil
+}
+
+// Entries returns the entries of the spool that are waiting for delivery
+func (sp *Spool) Entries() ([]*SpoolEntry, error) {
+	return sp.entries(sp.dir)
+}
+
+// DeadLetters returns the entries of the dead-letter directory of the spool
+func (sp *Spool) DeadLetters() ([]*SpoolEntry, error) {
+	return sp.entries(filepath.Join(sp.dir, SpoolDeadDir))
+}
+
+// Process attempts to deliver all spooled messages that are due. Delivered messages are
+// removed from the spool. Failed messages are rescheduled, or moved to the dead-letter
+// directory if the error is permanent or the maximum number of attempts is reached. Entries
+// whose message is missing or whose envelope is invalid are moved to the dead-letter
+// directory as well. The returned error only reports problems of the spool itself, not
+// delivery errors
+func (sp *Spool) Process(ctx context.Context) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	el, err := sp.Entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range el {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if e.Next.After(sp.now()) {
+			continue
+		}
+		if err := sp.deliver(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run processes the spool in the configured interval until the given context is cancelled.
+// It returns the error of the context or the first error of Process
+func (sp *Spool) Run(ctx context.Context) error {
+	t := time.NewTicker(sp.interval)
+	defer t.Stop()
+	for {
+		if err := sp.Process(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// deliver attempts to deliver the message of the given spool entry and updates the spool
+// according to the result
+func (sp *Spool) deliver(ctx context.Context, e *SpoolEntry) error {
+	raw, err := os.ReadFile(sp.path(e.ID, spoolMsgExt))
+	if err != nil {
+		e.LastError = fmt.Sprintf("failed to read spooled message: %s", err)
+		return sp.bury(e)
+	}
+	m, err := newRawMsg(raw, e.From, e.Rcpts)
+	if err != nil {
+		e.LastError = fmt.Sprintf("invalid envelope of spooled message: %s", err)
+		return sp.bury(e)
+	}
+
+	serr := sp.s.SendWithContext(ctx, m)
+	if serr == nil {
+		for _, ext := range []string{spoolMetaExt, spoolMsgExt} {
+			if err := os.Remove(sp.path(e.ID, ext)); err != nil {
+				return fmt.Errorf("failed to remove delivered message %s from spool: %w", e.ID, err)
+			}
+		}
+		return nil
+	}
+
+	e.Attempts++
+	e.LastError = serr.Error()
+	var se *SendError
+	if e.Attempts >= sp.maxattempts || (errors.As(serr, &se) && !se.IsTemp()) {
+		return sp.bury(e)
+	}
+	e.Next = sp.now().Add(sp.retryDelay(e.Attempts))
+	return sp.writeEntry(e)
+}
+
+// bury moves the given spool entry and its message, if present, to the dead-letter directory
+func (sp *Spool) bury(e *SpoolEntry) error {
+	if err := sp.writeEntry(e); err != nil {
+		return err
+	}
+	for _, ext := range []string{spoolMsgExt, spoolMetaExt} {
+		err := os.Rename(sp.path(e.ID, ext), filepath.Join(sp.dir, SpoolDeadDir, e.ID+ext))
+		if err != nil && !(ext == spoolMsgExt && errors.Is(err, os.ErrNotExist)) {
+			return fmt.Errorf("failed to move message %s to dead-letter directory: %w", e.ID, err)
+		}
+	}
+	return nil
+}
+
+// retryDelay returns the delay before the next delivery attempt after the given number of
+// failed attempts. The backoff is doubled for every attempt, up to MaxSpoolBackoff
+func (sp *Spool) retryDelay(a int) time.Duration {
+	d := sp.backoff
+	for i := 1; i < a && d < MaxSpoolBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxSpoolBackoff && sp.backoff <= MaxSpoolBackoff {
+		d = MaxSpoolBackoff
+	}
+	return d
+}
+
+// entries returns the spool entries of the given directory, sorted by their ID
+func (sp *Spool) entries(dir string) ([]*SpoolEntry, error) {
+	fl, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	var el []*SpoolEntry
+	for _, f := range fl {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), spoolMetaExt) {
+			continue
+		}
+		d, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spool entry: %w", err)
+		}
+		e := &SpoolEntry{}
+		if err := json.Unmarshal(d, e); err != nil {
+			return nil, fmt.Errorf("failed to parse spool entry %s: %w", f.Name(), err)
+		}
+		el = append(el, e)
+	}
+	sort.Slice(el, func(i, j int) bool { return el[i].ID < el[j].ID })
+	return el, nil
+}
+
+// writeEntry stores the given spool entry in the spool directory
+func (sp *Spool) writeEntry(e *SpoolEntry) error {
+	d, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to serialize spool entry: %w", err)
+	}
+	return writeFileAtomic(sp.path(e.ID, spoolMetaExt), d)
+}
+
+// path returns the path of the file with the given extension of the spool entry with the
+// given ID
+func (sp *Spool) path(id, ext string) string {
+	return filepath.Join(sp.dir, id+ext)
+}
+
+// writeFileAtomic writes the given data to a temporary file that is synced and renamed to
+// the given file name, so that incomplete files are never visible in the spool
+func writeFileAtomic(n string, d []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(n), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	tn := f.Name()
+	if _, err := f.Write(d); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tn)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tn)
+		return fmt.Errorf("failed to sync spool file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tn)
+		return fmt.Errorf("failed to close spool file: %w", err)
+	}
+	if err := os.Rename(tn, n); err != nil {
+		_ = os.Remove(tn)
+		return fmt.Errorf("failed to rename spool file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// testSender is a Sender that records the delivered messages and fails with the given errors
+type testSender struct {
+	errs []error
+	msgs []string
+	rcpt [][]string
+}
+
+// SendWithContext satisfies the Sender interface for the testSender
+func (s *testSender) SendWithContext(_ context.Context, ml ...*Msg) error {
+	for _, m := range ml {
+		if len(s.errs) > 0 {
+			err := s.errs[0]
+			s.errs = s.errs[1:]
+			return err
+		}
+		buf := bytes.Buffer{}
+		if _, err := m.WriteTo(&buf); err != nil {
+			return err
+		}
+		rl, err := m.GetRecipients()
+		if err != nil {
+			return err
+		}
+		s.msgs = append(s.msgs, buf.String())
+		s.rcpt = append(s.rcpt, rl)
+	}
+	return nil
+}
+
+// TestNewSpool tests the options of NewSpool
+func TestNewSpool(t *testing.T) {
+	if _, err := NewSpool("", &testSender{}); !errors.Is(err, ErrSpoolNoDir) {
+		t.Errorf("NewSpool without directory was expected to fail, got: %v", err)
+	}
+	if _, err := NewSpool(t.TempDir(), nil); !errors.Is(err, ErrSpoolNoSender) {
+		t.Errorf("NewSpool without Sender was expected to fail, got: %v", err)
+	}
+	if _, err := NewSpool(t.TempDir(), &testSender{}, WithSpoolMaxAttempts(0)); err == nil {
+		t.Errorf("WithSpoolMaxAttempts with zero attempts was expected to fail")
+	}
+	if _, err := NewSpool(t.TempDir(), &testSender{}, WithSpoolInterval(0)); !errors.Is(err, ErrInvalidTimeout) {
+		t.Errorf("WithSpoolInterval with zero interval was expected to fail, got: %v", err)
+	}
+}
+
+// TestSpool_Process tests the delivery, retry and dead-lettering of spooled messages
+func TestSpool_Process(t *testing.T) {
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	ts := &testSender{errs: []error{
+		&SendError{Reason: ErrSMTPRcptTo, isTemp: true},
+		&SendError{Reason: ErrSMTPRcptTo, isTemp: true},
+		&SendError{Reason: ErrSMTPRcptTo, isTemp: false},
+	}}
+	sp, err := NewSpool(t.TempDir(), ts, WithSpoolBackoff(time.Minute), WithSpoolMaxAttempts(3))
+	if err != nil {
+		t.Fatalf("NewSpool failed: %s", err)
+	}
+	sp.now = func() time.Time { return now }
+
+	m := testMsg(t)
+	if err := m.Bcc("hidden@example.com"); err != nil {
+		t.Fatalf("failed to set BCC address: %s", err)
+	}
+	id, err := sp.Enqueue(m)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+
+	// First attempt fails temporarily and is rescheduled
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	el, err := sp.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %s", err)
+	}
+	if len(el) != 1 || el[0].ID != id || el[0].Attempts != 1 || !el[0].Next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Process failed. Unexpected spool entries after temporary failure: %+v", el)
+	}
+
+	// Not due yet
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	if len(ts.errs) != 2 {
+		t.Errorf("Process failed. Message was delivered before it was due")
+	}
+
+	// Second attempt fails temporarily, the backoff is doubled
+	now = now.Add(time.Minute)
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	if el, _ = sp.Entries(); len(el) != 1 || !el[0].Next.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("Process failed. Unexpected spool entries after second temporary failure: %+v", el)
+	}
+
+	// Permanent failure moves the message to the dead-letter directory
+	now = now.Add(2 * time.Minute)
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	if el, _ = sp.Entries(); len(el) != 0 {
+		t.Errorf("Process failed. Expected empty spool, got: %+v", el)
+	}
+	dl, err := sp.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %s", err)
+	}
+	if len(dl) != 1 || dl[0].ID != id || dl[0].Attempts != 3 || dl[0].LastError == "" {
+		t.Errorf("Process failed. Unexpected dead letters: %+v", dl)
+	}
+
+	// Successful delivery removes the message from the spool
+	if _, err := sp.Enqueue(m); err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	if el, _ = sp.Entries(); len(el) != 0 {
+		t.Errorf("Process failed. Expected empty spool, got: %+v", el)
+	}
+	if len(ts.msgs) != 1 || !strings.Contains(ts.msgs[0], "This is a test body") ||
+		strings.Contains(ts.msgs[0], "hidden@example.com") {
+		t.Errorf("Process failed. Unexpected delivered message: %v", ts.msgs)
+	}
+	if len(ts.rcpt) != 1 || strings.Join(ts.rcpt[0], ",") != "rcpt@example.com,hidden@example.com" {
+		t.Errorf("Process failed. Unexpected envelope recipients: %v", ts.rcpt)
+	}
+}
+
+// TestSpool_Run tests the delivery of spooled messages via SMTP by the Spool runner
+func TestSpool_Run(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions("8BITMIME"))
+	sp, err := NewSpool(t.TempDir(), testClient(t, s), WithSpoolInterval(time.Millisecond*10))
+	if err != nil {
+		t.Fatalf("NewSpool failed: %s", err)
+	}
+	if _, err := sp.Enqueue(testMsg(t)); err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if err := sp.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run was expected to return the context error, got: %v", err)
+	}
+	ml := testMessages(s)
+	if len(ml) != 1 || !strings.Contains(ml[0], "This is a test subject") {
+		t.Errorf("Run failed. Unexpected delivered messages: %v", ml)
+	}
+}
+
+// TestSpool_Process_broken tests that broken spool entries are moved to the dead-letter
+// directory instead of blocking the spool
+func TestSpool_Process_broken(t *testing.T) {
+	ts := &testSender{}
+	dir := t.TempDir()
+	sp, err := NewSpool(dir, ts)
+	if err != nil {
+		t.Fatalf("NewSpool failed: %s", err)
+	}
+	missing, err := sp.Enqueue(testMsg(t))
+	if err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+	if err := os.Remove(sp.path(missing, spoolMsgExt)); err != nil {
+		t.Fatalf("failed to remove spooled message: %s", err)
+	}
+	invalid, err := sp.Enqueue(testMsg(t))
+	if err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+	if err := sp.writeEntry(&SpoolEntry{ID: invalid}); err != nil {
+		t.Fatalf("failed to overwrite spool entry: %s", err)
+	}
+	if _, err := sp.Enqueue(testMsg(t)); err != nil {
+		t.Fatalf("Enqueue failed: %s", err)
+	}
+
+	if err := sp.Process(context.Background()); err != nil {
+		t.Fatalf("Process failed: %s", err)
+	}
+	if el, _ := sp.Entries(); len(el) != 0 {
+		t.Errorf("Process failed. Expected empty spool, got: %+v", el)
+	}
+	if len(ts.msgs) != 1 {
+		t.Errorf("Process failed. Expected 1 delivered message, got: %d", len(ts.msgs))
+	}
+	dl, err := sp.DeadLetters()
+	if err != nil {
+		t.Fatalf("DeadLetters failed: %s", err)
+	}
+	if len(dl) != 2 || dl[0].ID != missing || dl[1].ID != invalid || dl[0].LastError == "" ||
+		dl[1].LastError == "" {
+		t.Errorf("Process failed. Unexpected dead letters: %+v", dl)
+	}
+}
+
+// TestSpool_retryDelay tests that the exponential backoff is capped
+func TestSpool_retryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff time.Duration
+		a       int
+		want    time.Duration
+	}{
+		{"first retry", time.Minute, 1, time.Minute},
+		{"third retry", time.Minute, 3, time.Minute * 4},
+		{"capped", time.Minute, 12, MaxSpoolBackoff},
+		{"overflow", time.Minute, 100, MaxSpoolBackoff},
+		{"backoff above cap", MaxSpoolBackoff * 2, 5, MaxSpoolBackoff * 2},
+		{"no backoff", 0, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := &Spool{backoff: tt.backoff}
+			if d := sp.retryDelay(tt.a); d != tt.want {
+				t.Errorf("retryDelay failed. Expected: %s, got: %s", tt.want, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+// SuppressionChecker decides whether mail to a recipient address is suppressed, e.g. because
+// the recipient unsubscribed or the address bounced. It is consulted by the Client before
+// each recipient is added to the envelope (see WithSuppressionChecker). If messages are sent
+// concurrently, the SuppressionChecker must be safe for concurrent use
+type SuppressionChecker interface {
+	// IsSuppressed returns true if no mail must be sent to the given recipient address
+	IsSuppressed(rcpt string) (bool, error)
+}
+
+// SuppressionFunc is an adapter to use an ordinary function as SuppressionChecker
+type SuppressionFunc func(rcpt string) (bool, error)
+
+// IsSuppressed calls the SuppressionFunc and satisfies the SuppressionChecker interface
+func (f SuppressionFunc) IsSuppressed(rcpt string) (bool, error) {
+	return f(rcpt)
+}
+
+// filterSuppressed returns the recipients of the given list that are not suppressed by the
+// given SuppressionChecker and the suppressed ones
+func filterSuppressed(sc SuppressionChecker, rl []string) ([]string, []string, error) {
+	var al, sl []string
+	for _, r := range rl {
+		s, err := sc.IsSuppressed(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		if s {
+			sl = append(sl, r)
+			continue
+		}
+		al = append(al, r)
+	}
+	return al, sl, nil
+}
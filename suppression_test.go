@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestClient_WithSuppressionChecker tests that suppressed recipients are dropped from the envelope
+func TestClient_WithSuppressionChecker(t *testing.T) {
+	sc := SuppressionFunc(func(r string) (bool, error) {
+		return r == "bounced@example.com", nil
+	})
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s, WithSuppressionChecker(sc))
+	m := testMsg(t)
+	if err := m.Cc("bounced@example.com"); err != nil {
+		t.Fatalf("failed to set Cc: %s", err)
+	}
+	if err := c.DialAndSend(m); err != nil {
+		t.Fatalf("Send failed: %s", err)
+	}
+	if hasCommand(s, "RCPT TO:<bounced@example.com>") {
+		t.Errorf("suppressed recipient was added to the envelope: %v", s.Commands())
+	}
+	if !hasCommand(s, "RCPT TO:<rcpt@example.com>") {
+		t.Errorf("recipient was not added to the envelope: %v", s.Commands())
+	}
+	if sl := m.SuppressedRecipients(); len(sl) != 1 || sl[0] != "bounced@example.com" {
+		t.Errorf("SuppressedRecipients failed. Expected: %v, got: %v", []string{"bounced@example.com"}, sl)
+	}
+
+	m = testMsg(t)
+	c = testClient(t, s, WithSuppressionChecker(SuppressionFunc(func(string) (bool, error) { return true, nil })))
+	err := c.DialAndSend(m)
+	var se *SendError
+	if !errors.As(err, &se) || se.Reason != ErrAllRcptsSuppressed || se.IsTemp() {
+		t.Errorf("Send with all recipients suppressed was expected to fail with ErrAllRcptsSuppressed, got: %v", err)
+	}
+	if rl := se.Rcpts(); len(rl) != 1 || rl[0] != "rcpt@example.com" {
+		t.Errorf("expected suppressed recipients in SendError, got: %v", rl)
+	}
+
+	ce := errors.New("lookup failed")
+	c = testClient(t, s, WithSuppressionChecker(SuppressionFunc(func(string) (bool, error) { return false, ce })))
+	err = c.DialAndSend(testMsg(t))
+	if !errors.As(err, &se) || se.Reason != ErrSuppressionCheck || !se.IsTemp() || !errors.Is(err, ce) {
+		t.Errorf("Send with failing SuppressionChecker was expected to fail with ErrSuppressionCheck, got: %v", err)
+	}
+
+	if _, err := NewClient("localhost", WithSuppressionChecker(nil)); err == nil {
+		t.Errorf("WithSuppressionChecker with nil checker was expected to fail")
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"fmt"
+	ht "html/template"
+	"io/fs"
+	"path"
+	tt "text/template"
+)
+
+// ErrTemplateNotFound is returned if a template is not part of a TemplateSet
+var ErrTemplateNotFound = errors.New("template not found in template set")
+
+// TemplateSet is a set of named templates and template functions, so that mail bodies can be
+// composed of layouts and partials (e.g. a header, a footer or button components). Every
+// template of the set can use the other templates via the "template" action and is parsed as
+// html/template for HTML bodies and as text/template for text bodies. A TemplateSet is safe
+// for concurrent use
+type TemplateSet struct {
+	// html is the set of templates for HTML bodies
+	html *ht.Template
+
+	// text is the set of templates for text bodies
+	text *tt.Template
+}
+
+// templateSource is a named template source of a TemplateSet
+type templateSource struct {
+	name string
+	src  string
+}
+
+// templateSetConfig holds the configuration of a TemplateSet while its options are applied
+type templateSetConfig struct {
+	funcs   map[string]interface{}
+	sources []templateSource
+}
+
+// TemplateOption returns a function that can be used for grouping TemplateSet options
+type TemplateOption func(*templateSetConfig) error
+
+// NewTemplateSet returns a new TemplateSet with the given templates and template functions.
+// The template functions are available in all templates, regardless of the order of the
+// options
+func NewTemplateSet(o ...TemplateOption) (*TemplateSet, error) {
+	c := &templateSetConfig{funcs: make(map[string]interface{})}
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(c); err != nil {
+			return nil, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	ts := &TemplateSet{
+		html: ht.New("").Funcs(c.funcs),
+		text: tt.New("").Funcs(c.funcs),
+	}
+	for _, s := range c.sources {
+		if _, err := ts.html.New(s.name).Parse(s.src); err != nil {
+			return nil, fmt.Errorf("failed to parse HTML template %q: %w", s.name, err)
+		}
+		if _, err := ts.text.New(s.name).Parse(s.src); err != nil {
+			return nil, fmt.Errorf("failed to parse text template %q: %w", s.name, err)
+		}
+	}
+	return ts, nil
+}
+
+// WithTemplateFuncs adds the given functions to the template functions of the TemplateSet
+func WithTemplateFuncs(fm map[string]interface{}) TemplateOption {
+	return func(c *templateSetConfig) error {
+		for n, f := range fm {
+			c.funcs[n] = f
+		}
+		return nil
+	}
+}
+
+// WithTemplate adds a template with the given name and source to the TemplateSet
+func WithTemplate(n, s string) TemplateOption {
+	return func(c *templateSetConfig) error {
+		if n == "" {
+			return fmt.Errorf("template name must not be empty")
+		}
+		c.sources = append(c.sources, templateSource{name: n, src: s})
+		return nil
+	}
+}
+
+// WithTemplateFS adds the files of the given fs.FS that match the given patterns to the
+// TemplateSet. The templates are named after the base names of the files
+func WithTemplateFS(fsys fs.FS, pl ...string) TemplateOption {
+	return func(c *templateSetConfig) error {
+		if fsys == nil {
+			return fmt.Errorf("file system must not be nil")
+		}
+		for _, p := range pl {
+			fl, err := fs.Glob(fsys, p)
+			if err != nil {
+				return fmt.Errorf("failed to match pattern %q: %w", p, err)
+			}
+			if len(fl) == 0 {
+				return fmt.Errorf("pattern %q matches no files", p)
+			}
+			for _, f := range fl {
+				b, err := fs.ReadFile(fsys, f)
+				if err != nil {
+					return fmt.Errorf("failed to read template %q: %w", f, err)
+				}
+				c.sources = append(c.sources, templateSource{name: path.Base(f), src: string(b)})
+			}
+		}
+		return nil
+	}
+}
+
+// HTML returns the html/template.Template with the given name of the TemplateSet
+func (ts *TemplateSet) HTML(n string) (*ht.Template, error) {
+	t := ts.html.Lookup(n)
+	if t == nil || n == "" {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, n)
+	}
+	return t, nil
+}
+
+// Text returns the text/template.Template with the given name of the TemplateSet
+func (ts *TemplateSet) Text(n string) (*tt.Template, error) {
+	t := ts.text.Lookup(n)
+	if t == nil || n == "" {
+		return nil, fmt.Errorf("%w: %q", ErrTemplateNotFound, n)
+	}
+	return t, nil
+}
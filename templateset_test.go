@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestTemplateSet tests the composition of mail bodies from layouts, partials and template
+// functions
+func TestTemplateSet(t *testing.T) {
+	fsys := fstest.MapFS{
+		"partials/footer.tpl": {Data: []byte(`<p>Regards, {{ .Team | upper }}</p>`)},
+	}
+	ts, err := NewTemplateSet(
+		WithTemplate("welcome", `{{ template "button" . }}{{ template "footer.tpl" . }}`),
+		WithTemplate("button", `<a href="{{ .URL }}">{{ .Label }}</a>`),
+		WithTemplateFS(fsys, "partials/*.tpl"),
+		WithTemplateFuncs(map[string]interface{}{"upper": strings.ToUpper}),
+	)
+	if err != nil {
+		t.Fatalf("NewTemplateSet failed: %s", err)
+	}
+	d := map[string]string{"URL": "https://example.com/?a=1&b=2", "Label": "Start <now>", "Team": "go-mail"}
+
+	m := NewMsg()
+	if err := m.SetBodyHTMLTemplateSet(ts, "welcome", d); err != nil {
+		t.Fatalf("SetBodyHTMLTemplateSet failed: %s", err)
+	}
+	if err := m.AddAlternativeTextTemplateSet(ts, "welcome", d); err != nil {
+		t.Fatalf("AddAlternativeTextTemplateSet failed: %s", err)
+	}
+	pl := m.GetParts()
+	if len(pl) != 2 {
+		t.Fatalf("expected 2 parts, got: %d", len(pl))
+	}
+	hb, _ := pl[0].GetContent()
+	if want := `<a href="https://example.com/?a=1&amp;b=2">Start &lt;now&gt;</a><p>Regards, GO-MAIL</p>`; string(hb) != want {
+		t.Errorf("SetBodyHTMLTemplateSet failed. Expected: %s, got: %s", want, hb)
+	}
+	tb, _ := pl[1].GetContent()
+	if want := `<a href="https://example.com/?a=1&b=2">Start <now></a><p>Regards, GO-MAIL</p>`; string(tb) != want {
+		t.Errorf("AddAlternativeTextTemplateSet failed. Expected: %s, got: %s", want, tb)
+	}
+
+	if err := m.SetBodyTextTemplateSet(ts, "unknown", d); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("SetBodyTextTemplateSet with unknown template was expected to fail, got: %v", err)
+	}
+	if _, err := NewTemplateSet(WithTemplate("broken", "{{ .X ")); err == nil {
+		t.Errorf("NewTemplateSet with invalid template was expected to fail")
+	}
+	if _, err := NewTemplateSet(WithTemplateFS(fsys, "*.html")); err == nil {
+		t.Errorf("NewTemplateSet with pattern without matches was expected to fail")
+	}
+}
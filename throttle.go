@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidDomainLimit should be used if a DomainLimit has no or negative limits
+var ErrInvalidDomainLimit = errors.New("invalid domain limit")
+
+// DomainLimit limits the deliveries to the recipients of a destination domain
+type DomainLimit struct {
+	// MaxConcurrent is the maximum number of concurrent deliveries to the domain. Zero means
+	// no limit
+	MaxConcurrent int
+	// Rate is the maximum number of deliveries to the domain within Period. Zero means no
+	// limit
+	Rate int
+	// Period is the period of time of the Rate
+	Period time.Duration
+}
+
+// DomainThrottle limits the concurrency and the rate of deliveries per destination domain
+// (e.g. at most 5 concurrent deliveries and 100 messages per minute to yahoo.com), so that
+// bulk sends do not exceed the limits of large mailbox providers and harm the reputation of
+// the sender. A DomainThrottle can be shared by multiple Client (see WithDomainThrottle) and
+// is safe for concurrent use. A Msg with recipients of multiple throttled domains counts
+// against the limits of all of them
+type DomainThrottle struct {
+	mu     sync.Mutex
+	limits map[string]*domainLimiter
+}
+
+// domainLimiter enforces a DomainLimit
+type domainLimiter struct {
+	// sem holds a token for every running delivery, if the concurrency is limited
+	sem chan struct{}
+	// rl limits the rate of deliveries, if the rate is limited
+	rl *rateLimiter
+}
+
+// NewDomainThrottle returns a new DomainThrottle without limits
+func NewDomainThrottle() *DomainThrottle {
+	return &DomainThrottle{limits: make(map[string]*domainLimiter)}
+}
+
+// SetLimit sets the DomainLimit for the given destination domain. The domain is matched
+// case-insensitively and does not include its subdomains
+func (t *DomainThrottle) SetLimit(d string, l DomainLimit) error {
+	if d == "" || l.MaxConcurrent < 0 || l.Rate < 0 || (l.Rate > 0 && l.Period <= 0) ||
+		(l.MaxConcurrent == 0 && l.Rate == 0) {
+		return ErrInvalidDomainLimit
+	}
+	dl := &domainLimiter{}
+	if l.MaxConcurrent > 0 {
+		dl.sem = make(chan struct{}, l.MaxConcurrent)
+	}
+	if l.Rate > 0 {
+		dl.rl = newRateLimiter(l.Rate, l.Period)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[strings.ToLower(d)] = dl
+	return nil
+}
+
+// acquire blocks until a delivery to the domains of the given recipients is allowed by all
+// limits and returns the function that releases the delivery again
+func (t *DomainThrottle) acquire(rl []string) func() {
+	dm := make(map[string]bool)
+	for _, r := range rl {
+		dm[strings.ToLower(r[strings.LastIndex(r, "@")+1:])] = true
+	}
+	// The limiters are acquired in a fixed order, so that concurrent deliveries to multiple
+	// domains cannot deadlock
+	dl := make([]string, 0, len(dm))
+	for d := range dm {
+		dl = append(dl, d)
+	}
+	sort.Strings(dl)
+
+	t.mu.Lock()
+	var ll []*domainLimiter
+	for _, d := range dl {
+		if l, ok := t.limits[d]; ok {
+			ll = append(ll, l)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, l := range ll {
+		if l.sem != nil {
+			l.sem <- struct{}{}
+		}
+		if l.rl != nil {
+			l.rl.wait()
+		}
+	}
+	return func() {
+		for _, l := range ll {
+			if l.sem != nil {
+				<-l.sem
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestDomainThrottle tests the per-domain concurrency and rate limits
+func TestDomainThrottle(t *testing.T) {
+	dt := NewDomainThrottle()
+	for _, l := range []DomainLimit{{}, {MaxConcurrent: -1}, {Rate: 1}, {Rate: -1, Period: time.Second}} {
+		if err := dt.SetLimit("example.com", l); !errors.Is(err, ErrInvalidDomainLimit) {
+			t.Errorf("SetLimit with %+v was expected to fail, got: %v", l, err)
+		}
+	}
+	if err := dt.SetLimit("Example.com", DomainLimit{MaxConcurrent: 1}); err != nil {
+		t.Fatalf("SetLimit failed: %s", err)
+	}
+
+	release := dt.acquire([]string{"a@example.com", "b@EXAMPLE.com"})
+	acquired := make(chan struct{})
+	go func() {
+		dt.acquire([]string{"c@example.com"})()
+		close(acquired)
+	}()
+	// Deliveries to other domains are not affected
+	dt.acquire([]string{"a@example.org"})()
+	select {
+	case <-acquired:
+		t.Fatal("acquire was expected to block while the concurrency limit is reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire was expected to succeed after the release")
+	}
+}
+
+// TestClient_WithDomainThrottle tests that the Client applies the rate limit of the recipient
+// domain
+func TestClient_WithDomainThrottle(t *testing.T) {
+	dt := NewDomainThrottle()
+	if err := dt.SetLimit("example.com", DomainLimit{Rate: 1, Period: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("SetLimit failed: %s", err)
+	}
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	c := testClient(t, s, WithDomainThrottle(dt))
+	st := time.Now()
+	if err := c.SendConcurrently(context.Background(), []*Msg{testMsg(t), testMsg(t), testMsg(t)}, 3); err != nil {
+		t.Fatalf("SendConcurrently failed: %s", err)
+	}
+	if d := time.Since(st); d < 100*time.Millisecond {
+		t.Errorf("WithDomainThrottle failed. Expected deliveries to take at least 100ms, took: %s", d)
+	}
+	if _, err := NewClient("localhost", WithDomainThrottle(nil)); err == nil {
+		t.Errorf("WithDomainThrottle with nil throttle was expected to fail")
+	}
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// ValidationLevel is the strictness of an address validation
+type ValidationLevel int
+
+const (
+	// ValidationSyntax only checks that the address is RFC 5322 compliant
+	ValidationSyntax ValidationLevel = iota
+
+	// ValidationMX additionally checks that the domain of the address accepts mail, i.e. that
+	// it has MX records or, as implicit MX, an address record and does not publish a null MX
+	ValidationMX
+
+	// ValidationCallout additionally asks the MX of the domain whether it accepts the address
+	// as recipient (SMTP callout). The callout ends before any mail data is sent. Note that
+	// many servers accept any recipient or block hosts that perform callouts
+	ValidationCallout
+)
+
+// ErrInvalidAddress is returned if an address is not RFC 5322 compliant
+var ErrInvalidAddress = errors.New("invalid mail address")
+
+// ErrNoMailDomain is returned if the domain of an address has neither MX nor address records
+var ErrNoMailDomain = errors.New("domain does not exist or does not accept mail")
+
+// ErrAddressRejected is returned if the MX of the domain permanently rejected an address
+// during an SMTP callout
+var ErrAddressRejected = errors.New("address rejected by mail exchanger")
+
+// HostLookupFunc is a function that looks up the addresses of the given host. It has the
+// same signature as net.Resolver.LookupHost
+type HostLookupFunc func(ctx context.Context, h string) ([]string, error)
+
+// AddressValidator validates mail addresses with a selectable ValidationLevel, e.g. for
+// signup forms or before sending a Msg. An AddressValidator is safe for concurrent use
+type AddressValidator struct {
+	// mxLookup is the function that resolves the MX records of a domain
+	mxLookup MXLookupFunc
+
+	// hostLookup is the function that resolves the address records of an implicit MX
+	hostLookup HostLookupFunc
+
+	// port is the port of the SMTP servers that are asked during a callout
+	port int
+
+	// from is the envelope sender address of a callout
+	from string
+
+	// opts is the list of Option that is applied to the Client of a callout
+	opts []Option
+}
+
+// ValidatorOption returns a function that can be used for grouping AddressValidator options
+type ValidatorOption func(*AddressValidator) error
+
+// ValidateAddress validates the given mail address with the given ValidationLevel using the
+// default resolver and a callout with the null sender. It returns nil if the address is valid
+func ValidateAddress(ctx context.Context, a string, l ValidationLevel) error {
+	v, err := NewAddressValidator()
+	if err != nil {
+		return err
+	}
+	return v.Validate(ctx, a, l)
+}
+
+// NewAddressValidator returns a new AddressValidator
+func NewAddressValidator(o ...ValidatorOption) (*AddressValidator, error) {
+	v := &AddressValidator{
+		mxLookup:   net.DefaultResolver.LookupMX,
+		hostLookup: net.DefaultResolver.LookupHost,
+		port:       DefaultMXPort,
+	}
+
+	// Override defaults with optionally provided ValidatorOption functions
+	for _, co := range o {
+		if co == nil {
+			continue
+		}
+		if err := co(v); err != nil {
+			return v, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// WithValidatorLookupFuncs overrides the functions that resolve the MX records of a domain
+// and the address records of an implicit MX (e.g. to use a custom DNS resolver)
+func WithValidatorLookupFuncs(mf MXLookupFunc, hf HostLookupFunc) ValidatorOption {
+	return func(v *AddressValidator) error {
+		if mf == nil || hf == nil {
+			return fmt.Errorf("lookup functions must not be nil")
+		}
+		v.mxLookup = mf
+		v.hostLookup = hf
+		return nil
+	}
+}
+
+// WithValidatorPort overrides the default port of the SMTP servers that are asked during a
+// callout
+func WithValidatorPort(p int) ValidatorOption {
+	return func(v *AddressValidator) error {
+		if p < 1 || p > 65535 {
+			return ErrInvalidPort
+		}
+		v.port = p
+		return nil
+	}
+}
+
+// WithValidatorCalloutSender overrides the envelope sender address of a callout, which is the
+// null sender by default. Some servers reject callouts with the null sender
+func WithValidatorCalloutSender(f string) ValidatorOption {
+	return func(v *AddressValidator) error {
+		a, err := mail.ParseAddress(f)
+		if err != nil {
+			return fmt.Errorf(errParseMailAddr, f, err)
+		}
+		v.from = a.Address
+		return nil
+	}
+}
+
+// WithValidatorClientOptions sets the list of Option that is applied to the Client of a
+// callout (e.g. the HELO hostname, the TLS policy or timeouts)
+func WithValidatorClientOptions(o ...Option) ValidatorOption {
+	return func(v *AddressValidator) error {
+		v.opts = append(v.opts, o...)
+		return nil
+	}
+}
+
+// Validate validates the given mail address with the given ValidationLevel. It returns nil
+// if the address is valid. Lookup and connection failures are returned as they are, since
+// they don't prove that the address is invalid
+func (v *AddressValidator) Validate(ctx context.Context, a string, l ValidationLevel) error {
+	pa, err := mail.ParseAddress(a)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, err)
+	}
+	i := strings.LastIndex(pa.Address, "@")
+	if i <= 0 || i == len(pa.Address)-1 {
+		return fmt.Errorf("%w: missing local part or domain", ErrInvalidAddress)
+	}
+	if l < ValidationMX {
+		return nil
+	}
+
+	d, err := idnaToASCII(strings.ToLower(pa.Address[i+1:]))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, err)
+	}
+	hl, err := mxHosts(ctx, v.mxLookup, d)
+	if err != nil {
+		return err
+	}
+	if len(hl) == 1 && hl[0] == d {
+		if _, err := v.hostLookup(ctx, d); err != nil {
+			var de *net.DNSError
+			if errors.As(err, &de) && de.IsNotFound {
+				return fmt.Errorf("%s: %w", d, ErrNoMailDomain)
+			}
+			return fmt.Errorf("failed to look up address records of %s: %w", d, err)
+		}
+	}
+	if l < ValidationCallout {
+		return nil
+	}
+	return v.callout(ctx, pa.Address, hl)
+}
+
+// callout asks the given MX hosts in the order of their preference whether they accept the
+// given address as recipient, until one of them accepts or permanently rejects it
+func (v *AddressValidator) callout(ctx context.Context, a string, hl []string) error {
+	var le error
+	for _, h := range hl {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		o := append([]Option{WithPort(v.port), WithTLSPolicy(TLSOpportunistic)}, v.opts...)
+		c, err := NewClient(h, o...)
+		if err != nil {
+			return err
+		}
+		if err := c.DialWithContext(ctx); err != nil {
+			le = fmt.Errorf("callout to MX %s failed: %w", h, err)
+			continue
+		}
+		if err := c.sc.Mail(v.from); err != nil {
+			_ = c.Close()
+			le = fmt.Errorf("callout to MX %s failed, sender not accepted: %w", h, err)
+			continue
+		}
+		err = c.sc.Rcpt(a)
+		_ = c.Close()
+		if err == nil {
+			return nil
+		}
+		var te *textproto.Error
+		if errors.As(err, &te) && te.Code >= 500 {
+			return fmt.Errorf("%w: %s", ErrAddressRejected, err)
+		}
+		le = fmt.Errorf("callout to MX %s failed: %w", h, err)
+	}
+	return le
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/wneessen/go-mail/smtptest"
+)
+
+// TestAddressValidator_Validate tests the address validation levels
+func TestAddressValidator_Validate(t *testing.T) {
+	s := smtptest.NewTestServer(t, smtptest.WithExtensions())
+	s.RejectRcpt("unknown@example.com", "550 5.1.1 User unknown")
+	s.RejectRcpt("busy@example.com", "450 4.2.1 Mailbox busy")
+	mx := func(_ context.Context, d string) ([]*net.MX, error) {
+		switch d {
+		case "example.com":
+			return []*net.MX{{Host: "127.0.0.1.", Pref: 10}}, nil
+		case "null.example.com":
+			return []*net.MX{{Host: ".", Pref: 0}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: d, IsNotFound: true}
+	}
+	host := func(_ context.Context, h string) ([]string, error) {
+		if h == "implicit.example.com" {
+			return []string{"127.0.0.1"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: h, IsNotFound: true}
+	}
+	v, err := NewAddressValidator(WithValidatorLookupFuncs(mx, host), WithValidatorPort(s.Port()),
+		WithValidatorClientOptions(WithTLSPolicy(NoTLS)))
+	if err != nil {
+		t.Fatalf("failed to create address validator: %s", err)
+	}
+	tests := []struct {
+		name  string
+		addr  string
+		level ValidationLevel
+		err   error
+		fail  bool
+	}{
+		{"valid syntax", "Toni <toni@nonexistent.example>", ValidationSyntax, nil, false},
+		{"invalid syntax", "toni@", ValidationSyntax, ErrInvalidAddress, true},
+		{"missing domain", "toni", ValidationSyntax, ErrInvalidAddress, true},
+		{"MX", "toni@example.com", ValidationMX, nil, false},
+		{"implicit MX", "toni@implicit.example.com", ValidationMX, nil, false},
+		{"no mail domain", "toni@nonexistent.example", ValidationMX, ErrNoMailDomain, true},
+		{"null MX", "toni@null.example.com", ValidationMX, ErrNullMX, true},
+		{"MX skips callout", "unknown@example.com", ValidationMX, nil, false},
+		{"callout accepted", "toni@example.com", ValidationCallout, nil, false},
+		{"callout rejected", "unknown@example.com", ValidationCallout, ErrAddressRejected, true},
+		{"callout temporary failure", "busy@example.com", ValidationCallout, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(context.Background(), tt.addr, tt.level)
+			if !tt.fail {
+				if err != nil {
+					t.Errorf("Validate(%q) failed: %s", tt.addr, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate(%q) was expected to fail", tt.addr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Validate(%q) was expected to fail with %q, got: %s", tt.addr, tt.err, err)
+			}
+			if tt.err == nil && errors.Is(err, ErrAddressRejected) {
+				t.Errorf("Validate(%q) was not expected to reject the address, got: %s", tt.addr, err)
+			}
+		})
+	}
+	if !hasCommand(s, "MAIL FROM:<>") {
+		t.Errorf("expected callout with null sender, got: %v", s.Commands())
+	}
+
+	if _, err := NewAddressValidator(WithValidatorLookupFuncs(nil, host)); err == nil {
+		t.Errorf("WithValidatorLookupFuncs with nil function was expected to fail")
+	}
+	if _, err := NewAddressValidator(WithValidatorPort(0)); !errors.Is(err, ErrInvalidPort) {
+		t.Errorf("WithValidatorPort with invalid port was expected to fail, got: %v", err)
+	}
+	if _, err := NewAddressValidator(WithValidatorCalloutSender("invalid")); err == nil {
+		t.Errorf("WithValidatorCalloutSender with invalid address was expected to fail")
+	}
+}
+
+// TestValidateAddress tests the syntax validation of the default AddressValidator
+func TestValidateAddress(t *testing.T) {
+	if err := ValidateAddress(context.Background(), "toni@example.com", ValidationSyntax); err != nil {
+		t.Errorf("ValidateAddress failed: %s", err)
+	}
+	if err := ValidateAddress(context.Background(), "invalid", ValidationSyntax); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("ValidateAddress was expected to fail with ErrInvalidAddress, got: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// vCardLineLength is the maximum length of a vCard content line in octets, excluding the line
+// break (RFC 6350, Section 3.2)
+const vCardLineLength = 75
+
+// ErrInvalidVCard is returned by AttachVCard if the given content is not a vCard
+var ErrInvalidVCard = errors.New("content is not a vCard")
+
+// VCard is a small builder for vCard 4.0 contact cards as described in RFC 6350, e.g. for
+// signature cards or contact sharing
+type VCard struct {
+	// FullName is the formatted name of the contact (required)
+	FullName string
+	// FamilyName is the family name of the contact
+	FamilyName string
+	// GivenName is the given name of the contact
+	GivenName string
+	// Organization is the organization of the contact
+	Organization string
+	// Title is the job title of the contact
+	Title string
+	// Emails are the mail addresses of the contact
+	Emails []string
+	// Phones are the telephone numbers of the contact
+	Phones []string
+	// URL is the website of the contact
+	URL string
+}
+
+// Bytes returns the vCard 4.0 representation of the VCard
+func (c *VCard) Bytes() []byte {
+	buf := bytes.Buffer{}
+	writeVCardLine(&buf, "BEGIN:VCARD")
+	writeVCardLine(&buf, "VERSION:4.0")
+	writeVCardLine(&buf, "FN:"+escapeVCard(c.FullName))
+	if c.FamilyName != "" || c.GivenName != "" {
+		writeVCardLine(&buf, fmt.Sprintf("N:%s;%s;;;", escapeVCard(c.FamilyName), escapeVCard(c.GivenName)))
+	}
+	if c.Organization != "" {
+		writeVCardLine(&buf, "ORG:"+escapeVCard(c.Organization))
+	}
+	if c.Title != "" {
+		writeVCardLine(&buf, "TITLE:"+escapeVCard(c.Title))
+	}
+	for _, e := range c.Emails {
+		writeVCardLine(&buf, "EMAIL:"+escapeVCard(e))
+	}
+	for _, p := range c.Phones {
+		writeVCardLine(&buf, "TEL;VALUE=uri:tel:"+strings.ReplaceAll(p, " ", "-"))
+	}
+	if c.URL != "" {
+		writeVCardLine(&buf, "URL:"+c.URL)
+	}
+	writeVCardLine(&buf, "END:VCARD")
+	return buf.Bytes()
+}
+
+// AttachVCard adds the vCard of the given io.Reader as text/vcard attachment File to the Msg.
+// The file name defaults to "contact.vcf" and can be overridden with WithFileName. See VCard
+// for a builder of contact cards
+func (m *Msg) AttachVCard(r io.Reader, o ...FileOption) error {
+	d, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read vCard: %w", err)
+	}
+	if !bytes.HasPrefix(bytes.ToUpper(bytes.TrimSpace(d)), []byte("BEGIN:VCARD")) {
+		return ErrInvalidVCard
+	}
+	f := fileFromReader("contact.vcf", bytes.NewReader(d))
+	f.ContentType = TypeTextVCard + "; charset=utf-8"
+	m.attachments = m.appendFile(m.attachments, f, o...)
+	return nil
+}
+
+// escapeVCard escapes the special characters of a vCard property value
+func escapeVCard(v string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(v)
+}
+
+// writeVCardLine writes the given content line to the buffer and folds it at vCardLineLength
+// octets without splitting multi-octet characters
+func writeVCardLine(buf *bytes.Buffer, l string) {
+	ll := vCardLineLength
+	for len(l) > ll {
+		i := ll
+		for i > 0 && !utf8.RuneStart(l[i]) {
+			i--
+		}
+		buf.WriteString(l[:i])
+		buf.WriteString(SingleNewLine + " ")
+		l = l[i:]
+		// The leading whitespace of a continuation line counts towards its length
+		ll = vCardLineLength - 1
+	}
+	buf.WriteString(l)
+	buf.WriteString(SingleNewLine)
+}
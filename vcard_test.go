@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestVCard_Bytes tests the vCard representation of the VCard
+func TestVCard_Bytes(t *testing.T) {
+	c := &VCard{
+		FullName:     "Toni Tester",
+		FamilyName:   "Tester",
+		GivenName:    "Toni",
+		Organization: "Example, Inc.",
+		Title:        "Head of QA; Testing",
+		Emails:       []string{"toni.tester@example.com"},
+		Phones:       []string{"+1 555 0100"},
+		URL:          "https://example.com",
+	}
+	want := "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Toni Tester\r\nN:Tester;Toni;;;\r\n" +
+		"ORG:Example\\, Inc.\r\nTITLE:Head of QA\\; Testing\r\nEMAIL:toni.tester@example.com\r\n" +
+		"TEL;VALUE=uri:tel:+1-555-0100\r\nURL:https://example.com\r\nEND:VCARD\r\n"
+	if v := string(c.Bytes()); v != want {
+		t.Errorf("VCard.Bytes failed. Expected:\n%q\ngot:\n%q", want, v)
+	}
+}
+
+// TestVCard_Bytes_fold tests the folding of long vCard content lines
+func TestVCard_Bytes_fold(t *testing.T) {
+	c := &VCard{FullName: strings.Repeat("ä", 60)}
+	for _, l := range strings.Split(strings.TrimSuffix(string(c.Bytes()), "\r\n"), "\r\n") {
+		if len(l) > vCardLineLength {
+			t.Errorf("vCard content line exceeds %d octets: %q", vCardLineLength, l)
+		}
+		if !strings.HasPrefix(l, " ") && !strings.Contains(l, ":") {
+			t.Errorf("unexpected vCard content line: %q", l)
+		}
+	}
+	uf := strings.ReplaceAll(string(c.Bytes()), "\r\n ", "")
+	if !strings.Contains(uf, "FN:"+strings.Repeat("ä", 60)+"\r\n") {
+		t.Errorf("folded vCard content line does not unfold to the original: %q", uf)
+	}
+}
+
+// TestMsg_AttachVCard tests the Msg.AttachVCard method
+func TestMsg_AttachVCard(t *testing.T) {
+	m := NewMsg()
+	c := &VCard{FullName: "Toni Tester"}
+	if err := m.AttachVCard(bytes.NewReader(c.Bytes())); err != nil {
+		t.Fatalf("AttachVCard failed: %s", err)
+	}
+	if err := m.AttachVCard(strings.NewReader("not a vcard")); !errors.Is(err, ErrInvalidVCard) {
+		t.Errorf("AttachVCard was expected to fail with ErrInvalidVCard, got: %v", err)
+	}
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), `Content-Type: text/vcard; charset=utf-8; name="contact.vcf"`) {
+		t.Errorf("AttachVCard failed. Expected text/vcard attachment, got: %s", buf.String())
+	}
+	if len(m.GetAttachments()) != 1 {
+		t.Errorf("AttachVCard failed. Expected 1 attachment, got: %d", len(m.GetAttachments()))
+	}
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ZipEntry is a file of a ZIP attachment that is read from an io.ReadSeeker (see
+// Msg.AttachZipReader)
+type ZipEntry struct {
+	// Name is the file name of the entry in the ZIP archive
+	Name string
+	// Reader provides the content of the entry. It is rewound after it has been read, so
+	// that the Msg can be written more than once
+	Reader io.ReadSeeker
+}
+
+// zipSource is a file of a ZIP attachment
+type zipSource struct {
+	name string
+	mod  time.Time
+	open func() (io.ReadCloser, error)
+}
+
+// AttachZip adds the given files of the system's file system as a single ZIP attachment
+// File with the given name to the Msg. The files are read and compressed when the Msg is
+// written, without creating a temporary file
+func (m *Msg) AttachZip(n string, fl ...string) error {
+	sl := make([]zipSource, 0, len(fl))
+	for _, p := range fl {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return fmt.Errorf("failed to attach ZIP file: %w", err)
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("failed to attach ZIP file: %q is a directory", p)
+		}
+		p := p
+		sl = append(sl, zipSource{
+			name: filepath.Base(p),
+			mod:  fi.ModTime(),
+			open: func() (io.ReadCloser, error) { return os.Open(p) },
+		})
+	}
+	m.attachments = m.appendFile(m.attachments, fileFromZip(n, sl))
+	return nil
+}
+
+// AttachZipReader adds the given entries as a single ZIP attachment File with the given name
+// to the Msg. The entries are read and compressed when the Msg is written
+func (m *Msg) AttachZipReader(n string, el ...ZipEntry) {
+	sl := make([]zipSource, 0, len(el))
+	now := time.Now()
+	for _, e := range el {
+		r := e.Reader
+		sl = append(sl, zipSource{
+			name: e.Name,
+			mod:  now,
+			open: func() (io.ReadCloser, error) {
+				if _, err := r.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return io.NopCloser(r), nil
+			},
+		})
+	}
+	m.attachments = m.appendFile(m.attachments, fileFromZip(n, sl))
+}
+
+// fileFromZip returns a File pointer that writes the given sources as ZIP archive
+func fileFromZip(n string, sl []zipSource) *File {
+	return &File{
+		Name:        n,
+		ContentType: TypeAppZip,
+		Header:      make(map[string][]string),
+		Writer: func(w io.Writer) (int64, error) {
+			cw := &countWriter{w: w}
+			err := writeZip(cw, sl)
+			return cw.n, err
+		},
+	}
+}
+
+// writeZip writes the given sources as ZIP archive to the io.Writer
+func writeZip(w io.Writer, sl []zipSource) error {
+	zw := zip.NewWriter(w)
+	for _, s := range sl {
+		zh := &zip.FileHeader{Name: s.name, Method: zip.Deflate, Modified: s.mod}
+		fw, err := zw.CreateHeader(zh)
+		if err != nil {
+			return fmt.Errorf("failed to create ZIP entry %q: %w", s.name, err)
+		}
+		r, err := s.open()
+		if err != nil {
+			return fmt.Errorf("failed to open ZIP entry %q: %w", s.name, err)
+		}
+		if _, err := io.Copy(fw, r); err != nil {
+			_ = r.Close()
+			return fmt.Errorf("failed to write ZIP entry %q: %w", s.name, err)
+		}
+		if err := r.Close(); err != nil {
+			return fmt.Errorf("failed to close ZIP entry %q: %w", s.name, err)
+		}
+	}
+	return zw.Close()
+}
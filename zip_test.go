@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readZipAttachment writes the given File and returns the content of its ZIP entries
+func readZipAttachment(t *testing.T, f *File) map[string]string {
+	t.Helper()
+	buf := bytes.Buffer{}
+	n, err := f.Writer(&buf)
+	if err != nil {
+		t.Fatalf("failed to write ZIP attachment: %s", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("ZIP attachment writer returned %d bytes, wrote: %d", n, buf.Len())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read ZIP attachment: %s", err)
+	}
+	c := make(map[string]string)
+	for _, zf := range zr.File {
+		r, err := zf.Open()
+		if err != nil {
+			t.Fatalf("failed to open ZIP entry %q: %s", zf.Name, err)
+		}
+		d, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("failed to read ZIP entry %q: %s", zf.Name, err)
+		}
+		_ = r.Close()
+		c[zf.Name] = string(d)
+	}
+	return c
+}
+
+// TestMsg_AttachZip tests the Msg.AttachZip method
+func TestMsg_AttachZip(t *testing.T) {
+	d := t.TempDir()
+	for _, n := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(d, n), []byte("content of "+n), 0o600); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+	m := NewMsg()
+	if err := m.AttachZip("bundle.zip", filepath.Join(d, "a.txt"), filepath.Join(d, "b.txt")); err != nil {
+		t.Fatalf("AttachZip failed: %s", err)
+	}
+	al := m.GetAttachments()
+	if len(al) != 1 || al[0].Name != "bundle.zip" || al[0].ContentType != TypeAppZip {
+		t.Fatalf("AttachZip failed. Expected a single bundle.zip attachment, got: %+v", al)
+	}
+	// The files are read at write time
+	if err := os.WriteFile(filepath.Join(d, "b.txt"), []byte("updated"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	c := readZipAttachment(t, al[0])
+	if len(c) != 2 || c["a.txt"] != "content of a.txt" || c["b.txt"] != "updated" {
+		t.Errorf("AttachZip failed. Unexpected ZIP content: %v", c)
+	}
+
+	if err := m.AttachZip("fail.zip", filepath.Join(d, "missing.txt")); err == nil {
+		t.Errorf("AttachZip with missing file was expected to fail")
+	}
+	if err := m.AttachZip("fail.zip", d); err == nil {
+		t.Errorf("AttachZip with directory was expected to fail")
+	}
+	if len(m.GetAttachments()) != 1 {
+		t.Errorf("AttachZip failures were not expected to add attachments")
+	}
+}
+
+// TestMsg_AttachZipReader tests the Msg.AttachZipReader method
+func TestMsg_AttachZipReader(t *testing.T) {
+	m := NewMsg()
+	m.AttachZipReader("bundle.zip",
+		ZipEntry{Name: "report.csv", Reader: strings.NewReader("a,b\n1,2\n")},
+		ZipEntry{Name: "docs/readme.txt", Reader: strings.NewReader("read me")},
+	)
+	al := m.GetAttachments()
+	if len(al) != 1 {
+		t.Fatalf("AttachZipReader failed. Expected 1 attachment, got: %d", len(al))
+	}
+	// The Msg can be written more than once
+	for i := 0; i < 2; i++ {
+		c := readZipAttachment(t, al[0])
+		if len(c) != 2 || c["report.csv"] != "a,b\n1,2\n" || c["docs/readme.txt"] != "read me" {
+			t.Errorf("AttachZipReader failed. Unexpected ZIP content: %v", c)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	// zipMethodAES is the compression method of WinZip AES encrypted ZIP entries
+	zipMethodAES = 99
+
+	// zipAESKeyLen is the key length of AES-256
+	zipAESKeyLen = 32
+
+	// zipAESSaltLen is the salt length for AES-256
+	zipAESSaltLen = 16
+
+	// zipAESIterations is the number of PBKDF2 iterations of the key derivation
+	zipAESIterations = 1000
+
+	// zipAESMACLen is the length of the authentication code of an entry
+	zipAESMACLen = 10
+)
+
+// WithFilePassword protects the File with the given password. The File is wrapped in an
+// AES-256 encrypted ZIP archive (WinZip AE-2) when the Msg is written, which is supported by
+// all common archive tools. The attachment name is the file name with the suffix ".zip".
+// Writing password protected files requires Go 1.17 or later
+func WithFilePassword(p string) FileOption {
+	return func(f *File) {
+		f.password = p
+	}
+}
+
+// passwordProtected returns a copy of the File that writes the File as AES encrypted ZIP
+// archive
+func (f *File) passwordProtected() *File {
+	cf := *f
+	cf.Name = f.Name + ".zip"
+	cf.ContentType = TypeAppZip
+	cf.password = ""
+	cf.Header = make(map[string][]string, len(f.Header))
+	for k, vl := range f.Header {
+		cf.Header[k] = vl
+	}
+	cf.Header.Del(string(HeaderContentType))
+	s := zipSource{
+		name: f.Name,
+		mod:  time.Now(),
+		open: func() (io.ReadCloser, error) {
+			buf := bytes.Buffer{}
+			if _, err := f.Writer(&buf); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(&buf), nil
+		},
+	}
+	// The archive is cached, since each encryption uses a new random salt
+	var zd []byte
+	cf.Writer = func(w io.Writer) (int64, error) {
+		if zd == nil {
+			buf := bytes.Buffer{}
+			if err := writeAESZip(&buf, f.password, []zipSource{s}); err != nil {
+				return 0, err
+			}
+			zd = buf.Bytes()
+		}
+		n, err := w.Write(zd)
+		return int64(n), err
+	}
+	return &cf
+}
+
+// encryptAESZipEntry returns the salt, the password verification value, the encrypted data
+// and the authentication code of a WinZip AES encrypted ZIP entry
+func encryptAESZipEntry(p string, d []byte) ([]byte, error) {
+	salt := make([]byte, zipAESSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	k := pbkdf2SHA1([]byte(p), salt, zipAESIterations, 2*zipAESKeyLen+2)
+	s, err := newAESZipStream(k[:zipAESKeyLen])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, zipAESSaltLen+2+len(d)+zipAESMACLen)
+	out = append(out, salt...)
+	out = append(out, k[2*zipAESKeyLen:]...)
+	ed := make([]byte, len(d))
+	s.XORKeyStream(ed, d)
+	out = append(out, ed...)
+	mac := hmac.New(sha1.New, k[zipAESKeyLen:2*zipAESKeyLen])
+	_, _ = mac.Write(ed)
+	return append(out, mac.Sum(nil)[:zipAESMACLen]...), nil
+}
+
+// aesZipStream is the AES-CTR variant of the WinZip AES specification, which uses a little
+// endian counter that starts at 1
+type aesZipStream struct {
+	b   cipher.Block
+	ctr [aes.BlockSize]byte
+	ks  [aes.BlockSize]byte
+	pos int
+}
+
+// newAESZipStream returns a new aesZipStream for the given key
+func newAESZipStream(k []byte) (*aesZipStream, error) {
+	b, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return &aesZipStream{b: b, pos: aes.BlockSize}, nil
+}
+
+// XORKeyStream satisfies the cipher.Stream interface for the aesZipStream
+func (s *aesZipStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.pos == aes.BlockSize {
+			for j := range s.ctr {
+				s.ctr[j]++
+				if s.ctr[j] != 0 {
+					break
+				}
+			}
+			s.b.Encrypt(s.ks[:], s.ctr[:])
+			s.pos = 0
+		}
+		dst[i] = src[i] ^ s.ks[s.pos]
+		s.pos++
+	}
+}
+
+// pbkdf2SHA1 derives a key of the given length from the password and salt as described in
+// RFC 8018 with HMAC-SHA1 as pseudorandom function
+func pbkdf2SHA1(p, salt []byte, iter, l int) []byte {
+	prf := hmac.New(sha1.New, p)
+	var dk []byte
+	u := make([]byte, 0, prf.Size())
+	t := make([]byte, prf.Size())
+	for bi := uint32(1); len(dk) < l; bi++ {
+		prf.Reset()
+		_, _ = prf.Write(salt)
+		_ = binary.Write(prf, binary.BigEndian, bi)
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iter; i++ {
+			prf.Reset()
+			_, _ = prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		dk = append(dk, t...)
+	}
+	return dk[:l]
+}
@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+package mail
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestPBKDF2SHA1 tests the key derivation with the test vectors of RFC 6070
+func TestPBKDF2SHA1(t *testing.T) {
+	tests := []struct {
+		iter int
+		want string
+	}{
+		{1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{4096, "4b007901b765489abead49d926f721d065a429c1"},
+	}
+	for _, tt := range tests {
+		if k := hex.EncodeToString(pbkdf2SHA1([]byte("password"), []byte("salt"), tt.iter, 20)); k != tt.want {
+			t.Errorf("pbkdf2SHA1 with %d iterations failed. Expected: %s, got: %s", tt.iter, tt.want, k)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.17
+// +build go1.17
+
+package mail
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// writeAESZip writes the given sources as AES-256 encrypted ZIP archive (WinZip AE-2) with
+// the given password to the io.Writer
+func writeAESZip(w io.Writer, p string, sl []zipSource) error {
+	zw := zip.NewWriter(w)
+	for _, s := range sl {
+		r, err := s.open()
+		if err != nil {
+			return fmt.Errorf("failed to open ZIP entry %q: %w", s.name, err)
+		}
+		buf := bytes.Buffer{}
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			_ = r.Close()
+			return err
+		}
+		n, err := io.Copy(fw, r)
+		if err != nil {
+			_ = r.Close()
+			return fmt.Errorf("failed to compress ZIP entry %q: %w", s.name, err)
+		}
+		if err := fw.Close(); err != nil {
+			_ = r.Close()
+			return fmt.Errorf("failed to compress ZIP entry %q: %w", s.name, err)
+		}
+		if err := r.Close(); err != nil {
+			return fmt.Errorf("failed to close ZIP entry %q: %w", s.name, err)
+		}
+		d, err := encryptAESZipEntry(p, buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to encrypt ZIP entry %q: %w", s.name, err)
+		}
+
+		// The extra field holds the AE-2 version, the vendor ID, the AES-256 strength and
+		// the actual compression method
+		ex := make([]byte, 11)
+		binary.LittleEndian.PutUint16(ex[0:], 0x9901)
+		binary.LittleEndian.PutUint16(ex[2:], 7)
+		binary.LittleEndian.PutUint16(ex[4:], 2)
+		copy(ex[6:], "AE")
+		ex[8] = 3
+		binary.LittleEndian.PutUint16(ex[9:], zip.Deflate)
+		fh := &zip.FileHeader{
+			Name:               s.name,
+			Method:             zipMethodAES,
+			Flags:              0x1,
+			ReaderVersion:      51,
+			CreatorVersion:     51,
+			Modified:           s.mod,
+			Extra:              ex,
+			CompressedSize64:   uint64(len(d)),
+			UncompressedSize64: uint64(n),
+		}
+		if !isASCII(s.name) {
+			fh.Flags |= 0x800
+		}
+		// CreateRaw does not convert the modification time to the MS-DOS format
+		fh.ModifiedDate, fh.ModifiedTime = msDosTime(s.mod)
+		ew, err := zw.CreateRaw(fh)
+		if err != nil {
+			return fmt.Errorf("failed to create ZIP entry %q: %w", s.name, err)
+		}
+		if _, err := ew.Write(d); err != nil {
+			return fmt.Errorf("failed to write ZIP entry %q: %w", s.name, err)
+		}
+	}
+	return zw.Close()
+}
+
+// msDosTime returns the MS-DOS date and time of the given time
+func msDosTime(t time.Time) (uint16, uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	d := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	tm := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return d, tm
+}
@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build !go1.17
+// +build !go1.17
+
+package mail
+
+import (
+	"errors"
+	"io"
+)
+
+// writeAESZip requires zip.Writer.CreateRaw, which is available since Go 1.17
+func writeAESZip(io.Writer, string, []zipSource) error {
+	return errors.New("password protected files require Go 1.17 or later")
+}
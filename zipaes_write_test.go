@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2022-2023 The go-mail Authors
+//
+// SPDX-License-Identifier: MIT
+
+//go:build go1.17
+// +build go1.17
+
+package mail
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/hmac"
+	"crypto/sha1"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestFile_WithFilePassword tests that a File with password is written as AES encrypted ZIP
+// archive that can be decrypted with the password
+func TestFile_WithFilePassword(t *testing.T) {
+	m := NewMsg()
+	m.AttachReader("secret.txt", strings.NewReader("top secret content"), WithFilePassword("s3cret"))
+	buf := bytes.Buffer{}
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("failed to write message: %s", err)
+	}
+	if !strings.Contains(buf.String(), `Content-Type: application/zip; name="secret.txt.zip"`) {
+		t.Errorf("WithFilePassword failed. Expected ZIP attachment, got: %s", buf.String())
+	}
+	if strings.Contains(buf.String(), "top secret content") {
+		t.Errorf("WithFilePassword failed. Plain content found in message")
+	}
+
+	f := m.GetAttachments()[0]
+	if f.Name != "secret.txt" {
+		t.Errorf("WithFilePassword was not expected to change the File. Got name: %s", f.Name)
+	}
+	zb := bytes.Buffer{}
+	if _, err := f.passwordProtected().Writer(&zb); err != nil {
+		t.Fatalf("failed to write encrypted ZIP: %s", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zb.Bytes()), int64(zb.Len()))
+	if err != nil {
+		t.Fatalf("failed to read encrypted ZIP: %s", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "secret.txt" || zr.File[0].Method != zipMethodAES {
+		t.Fatalf("unexpected encrypted ZIP entries: %+v", zr.File)
+	}
+	r, err := zr.File[0].OpenRaw()
+	if err != nil {
+		t.Fatalf("failed to open encrypted ZIP entry: %s", err)
+	}
+	d, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to read encrypted ZIP entry: %s", err)
+	}
+
+	salt, pv := d[:zipAESSaltLen], d[zipAESSaltLen:zipAESSaltLen+2]
+	ed, mc := d[zipAESSaltLen+2:len(d)-zipAESMACLen], d[len(d)-zipAESMACLen:]
+	k := pbkdf2SHA1([]byte("s3cret"), salt, zipAESIterations, 2*zipAESKeyLen+2)
+	if !bytes.Equal(pv, k[2*zipAESKeyLen:]) {
+		t.Fatalf("password verification value does not match")
+	}
+	mac := hmac.New(sha1.New, k[zipAESKeyLen:2*zipAESKeyLen])
+	_, _ = mac.Write(ed)
+	if !bytes.Equal(mc, mac.Sum(nil)[:zipAESMACLen]) {
+		t.Fatalf("authentication code does not match")
+	}
+	s, err := newAESZipStream(k[:zipAESKeyLen])
+	if err != nil {
+		t.Fatalf("failed to create AES stream: %s", err)
+	}
+	s.XORKeyStream(ed, ed)
+	c, err := io.ReadAll(flate.NewReader(bytes.NewReader(ed)))
+	if err != nil {
+		t.Fatalf("failed to decompress ZIP entry: %s", err)
+	}
+	if string(c) != "top secret content" {
+		t.Errorf("WithFilePassword failed. Expected decrypted content, got: %q", c)
+	}
+}